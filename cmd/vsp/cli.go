@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
@@ -24,6 +25,7 @@ func init() {
 
 	// Add CLI subcommands
 	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
 	rootCmd.AddCommand(searchCmd)
 	rootCmd.AddCommand(sourceCmd)
 	rootCmd.AddCommand(systemsCmd)
@@ -209,6 +211,96 @@ func runExport(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// --- import command ---
+
+var importCmd = &cobra.Command{
+	Use:   "import <zip>",
+	Short: "Import an abapGit ZIP into a package",
+	Long: `Import an abapGit ZIP (e.g. written by 'vsp export') into a package.
+
+Objects are created or updated in dependency order, written inactive and
+activated in one batch. Supports CLAS, INTF, PROG, DDLS, BDEF and SRVD;
+other object types are reported as skipped. Uses ADT only, ZADT_VSP is not
+required on the target system.
+
+Examples:
+  vsp -s sandbox import packages.zip --package '$ZPROTO'
+  vsp -s dev import export.zip -p ZPROTO -t DEVK900123
+  vsp -s sandbox import export.zip -p '$ZPROTO' --dry-run`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImport,
+}
+
+func init() {
+	importCmd.Flags().StringP("package", "p", "", "Target package for new objects (required)")
+	importCmd.Flags().StringP("transport", "t", "", "Transport request (for transportable packages)")
+	importCmd.Flags().Bool("dry-run", false, "List the objects in import order without changing anything")
+	importCmd.Flags().Bool("json", false, "Output JSON")
+	_ = importCmd.MarkFlagRequired("package")
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	packageName, _ := cmd.Flags().GetString("package")
+	transport, _ := cmd.Flags().GetString("transport")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	zipData, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read ZIP file: %w", err)
+	}
+
+	params, err := resolveSystemParams(cmd)
+	if err != nil {
+		return err
+	}
+	client, err := getClient(params)
+	if err != nil {
+		return err
+	}
+
+	if !asJSON {
+		fmt.Fprintf(os.Stderr, "Importing %s into %s...\n", args[0], strings.ToUpper(packageName))
+	}
+
+	result, err := client.GitImport(context.Background(), zipData, adt.GitImportOptions{
+		Package:   packageName,
+		Transport: transport,
+		DryRun:    dryRun,
+	})
+	if err != nil {
+		return fmt.Errorf("import failed: %w", err)
+	}
+
+	if asJSON {
+		output, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Println(string(output))
+	} else {
+		for _, w := range result.Warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+		}
+		for _, obj := range result.Objects {
+			status := obj.Status
+			if (status == "created" || status == "updated") && !obj.Activated {
+				status += ", inactive"
+			}
+			fmt.Printf("  %-4s %-30s %s\n", obj.Type, obj.Name, status)
+			if obj.Error != "" {
+				fmt.Printf("       %s\n", obj.Error)
+			}
+			for _, msg := range obj.Messages {
+				fmt.Printf("       [%s] %s\n", msg.Type, msg.ShortText)
+			}
+		}
+		fmt.Println(result.Message)
+	}
+
+	if !result.Success {
+		return fmt.Errorf("import finished with errors")
+	}
+	return nil
+}
+
 // --- search command ---
 
 var searchCmd = &cobra.Command{
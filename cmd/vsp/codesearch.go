@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+	"github.com/oisee/vibing-steampunk/pkg/cache"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(indexCmd)
+	rootCmd.AddCommand(grepCmd)
+}
+
+// openSourceStore opens (creating if needed) the SQLite source index at path.
+func openSourceStore(path string) (*cache.SQLiteCache, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	cfg := cache.DefaultConfig()
+	cfg.Type = "sqlite"
+	cfg.Path = path
+	return cache.NewSQLiteCache(cfg)
+}
+
+// --- index command ---
+
+var indexCmd = &cobra.Command{
+	Use:   "index <package>...",
+	Short: "Build or update the local full-text source index",
+	Long: `Download the sources of the given packages into the local source index.
+
+Only objects whose ADT change timestamp moved since the last run are downloaded,
+so running it again is cheap. Objects no longer in a package are dropped.
+
+Examples:
+  vsp -s a4h index '$ZORDERS'
+  vsp index ZFI ZCO --subpackages
+  vsp index '$TMP' --force`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runIndex,
+}
+
+func init() {
+	indexCmd.Flags().Bool("subpackages", false, "Also index subpackages (recursively)")
+	indexCmd.Flags().Bool("force", false, "Re-download every source, ignoring timestamps")
+}
+
+func runIndex(cmd *cobra.Command, args []string) error {
+	subpackages, _ := cmd.Flags().GetBool("subpackages")
+	force, _ := cmd.Flags().GetBool("force")
+
+	params, err := resolveSystemParams(cmd)
+	if err != nil {
+		return err
+	}
+	client, err := getClient(params)
+	if err != nil {
+		return err
+	}
+
+	store, err := openSourceStore(resolveCachePath(cmd))
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	result, err := cache.SyncSources(context.Background(), client, store, args, cache.SyncOptions{
+		IncludeSubpackages: subpackages,
+		Force:              force,
+		Progress: func(format string, a ...interface{}) {
+			fmt.Fprintf(os.Stderr, format+"\n", a...)
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Indexed %s: %d checked, %d updated, %d unchanged, %d removed (%s)\n",
+		strings.Join(result.Packages, ", "), result.Checked, result.Updated, result.Unchanged, result.Removed,
+		result.Duration.Round(time.Millisecond))
+	for _, f := range result.Failed {
+		fmt.Fprintf(os.Stderr, "  failed: %s\n", f)
+	}
+	return nil
+}
+
+// --- grep command ---
+
+var grepCmd = &cobra.Command{
+	Use:   "grep <query>",
+	Short: "Search ABAP sources using the local full-text index",
+	Long: `Search the local source index. Results are ranked by relevance.
+
+The query supports words, "exact phrases", prefix* terms and AND / OR / NOT.
+Packages given with --package are indexed first if they are not yet in the index;
+--refresh re-checks them for changed objects. --live skips the index and runs a
+regex over the sources in the SAP system instead (slow on large packages).
+
+Examples:
+  vsp grep zcl_order_api --package '$ZORDERS'
+  vsp grep '"read table" AND binary' --type PROG,INCL -C 2
+  vsp grep 'bapi_po*' --package ZMM --refresh
+  vsp grep 'CALL FUNCTION .BAPI_' --package ZMM --live`,
+	Args: cobra.ExactArgs(1),
+	RunE: runGrep,
+}
+
+func init() {
+	grepCmd.Flags().StringSliceP("package", "p", nil, "Packages to search")
+	grepCmd.Flags().Bool("subpackages", false, "Also search subpackages")
+	grepCmd.Flags().StringSliceP("type", "t", nil, "Object types to search (PROG,INCL,CLAS,INTF,FUNC,DDLS,BDEF,SRVD)")
+	grepCmd.Flags().IntP("context", "C", 0, "Lines of context around each match")
+	grepCmd.Flags().IntP("max", "m", 50, "Maximum number of objects")
+	grepCmd.Flags().Bool("refresh", false, "Update the index for --package before searching")
+	grepCmd.Flags().Bool("live", false, "Regex search in the SAP system instead of the index")
+	grepCmd.Flags().Bool("json", false, "Output JSON")
+}
+
+func runGrep(cmd *cobra.Command, args []string) error {
+	packages, _ := cmd.Flags().GetStringSlice("package")
+	subpackages, _ := cmd.Flags().GetBool("subpackages")
+	types, _ := cmd.Flags().GetStringSlice("type")
+	contextLines, _ := cmd.Flags().GetInt("context")
+	maxResults, _ := cmd.Flags().GetInt("max")
+	refresh, _ := cmd.Flags().GetBool("refresh")
+	live, _ := cmd.Flags().GetBool("live")
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	ctx := context.Background()
+	for i := range packages {
+		packages[i] = strings.ToUpper(packages[i])
+	}
+
+	if live {
+		return runLiveGrep(cmd, args[0], packages, subpackages, types, maxResults, asJSON)
+	}
+
+	store, err := openSourceStore(resolveCachePath(cmd))
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	var toSync []string
+	for _, pkg := range packages {
+		cached, err := store.ListSources(ctx, pkg)
+		if err != nil {
+			return err
+		}
+		if refresh || len(cached) == 0 {
+			toSync = append(toSync, pkg)
+		}
+	}
+	if len(toSync) > 0 {
+		params, err := resolveSystemParams(cmd)
+		if err != nil {
+			return err
+		}
+		client, err := getClient(params)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Updating index for %s...\n", strings.Join(toSync, ", "))
+		result, err := cache.SyncSources(ctx, client, store, toSync, cache.SyncOptions{IncludeSubpackages: subpackages})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "%d updated, %d unchanged, %d removed\n", result.Updated, result.Unchanged, result.Removed)
+	}
+	if subpackages && len(packages) > 0 {
+		packages = cache.ExpandPackages(ctx, store, packages)
+	}
+	if store.SourceIndexModule() == "fts4" {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", cache.FTS4Notice)
+	}
+
+	hits, err := store.SearchCode(ctx, cache.CodeSearchOptions{
+		Query:        args[0],
+		Types:        types,
+		Packages:     packages,
+		MaxResults:   maxResults,
+		ContextLines: contextLines,
+	})
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		output, _ := json.MarshalIndent(hits, "", "  ")
+		fmt.Println(string(output))
+		return nil
+	}
+
+	if len(hits) == 0 {
+		fmt.Println("No matches.")
+		return nil
+	}
+	for _, hit := range hits {
+		name := hit.ObjectName
+		if hit.Variant != "" {
+			name += " (" + hit.Variant + ")"
+		}
+		fmt.Printf("%s %s [%s] %d line(s)\n", hit.ObjectType, name, hit.Package, hit.MatchCount)
+		printGrepLines(hit.Matches)
+	}
+	return nil
+}
+
+// runLiveGrep runs the regex over the sources in the SAP system (no context lines).
+func runLiveGrep(cmd *cobra.Command, pattern string, packages []string, subpackages bool, types []string, maxResults int, asJSON bool) error {
+	if len(packages) == 0 {
+		return fmt.Errorf("--package is required with --live")
+	}
+	params, err := resolveSystemParams(cmd)
+	if err != nil {
+		return err
+	}
+	client, err := getClient(params)
+	if err != nil {
+		return err
+	}
+
+	listingTypes := make([]string, 0, len(types))
+	for _, t := range types {
+		listingTypes = append(listingTypes, cache.ListingType(t))
+	}
+
+	fmt.Fprintf(os.Stderr, "Searching %s in the SAP system...\n", strings.Join(packages, ", "))
+	result, err := client.GrepPackages(context.Background(), packages, subpackages, pattern, true, listingTypes, maxResults)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		output, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Println(string(output))
+		return nil
+	}
+
+	if len(result.Objects) == 0 {
+		fmt.Println("No matches.")
+		return nil
+	}
+	for _, obj := range result.Objects {
+		fmt.Printf("%s %s %d line(s)\n", obj.ObjectType, obj.ObjectName, obj.MatchCount)
+		printGrepLines(obj.Matches)
+	}
+	return nil
+}
+
+func printGrepLines(matches []adt.GrepMatch) {
+	for _, m := range matches {
+		for i, line := range m.ContextBefore {
+			fmt.Printf("  %5d- %s\n", m.LineNumber-len(m.ContextBefore)+i, line)
+		}
+		fmt.Printf("  %5d: %s\n", m.LineNumber, m.MatchedLine)
+		for i, line := range m.ContextAfter {
+			fmt.Printf("  %5d- %s\n", m.LineNumber+1+i, line)
+		}
+	}
+}
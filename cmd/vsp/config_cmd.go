@@ -770,6 +770,8 @@ func GetAllToolNames() []string {
 		"GetInactiveObjects", "CreatePackage", "CreateTable",
 		"CompareSource", "CreateClassWithTests", "CreateTestInclude",
 		"CreateAndActivateProgram", "UpdateClassInclude",
+		// Version history
+		"GetVersions", "GetVersionSource", "DiffVersions", "RestoreVersion",
 		// Code intelligence
 		"FindDefinition", "FindReferences", "CodeCompletion", "GetTypeHierarchy",
 		// Call graph / analysis
@@ -828,6 +830,8 @@ func GetFocusedToolNames() []string {
 		"Activate", "ActivatePackage", "PrettyPrint",
 		"GetInactiveObjects", "CreatePackage", "CreateTable",
 		"CompareSource", "CloneObject", "GetClassInfo",
+		// Version history
+		"GetVersions", "GetVersionSource", "DiffVersions",
 		// Lock/Unlock
 		"LockObject", "UnlockObject",
 		// File operations
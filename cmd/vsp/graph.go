@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+	"github.com/oisee/vibing-steampunk/pkg/cache"
+	"github.com/oisee/vibing-steampunk/pkg/graph"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(impactCmd)
+	rootCmd.AddCommand(graphCmd)
+}
+
+// openGraphCache opens the SQLite graph cache at path.
+func openGraphCache(path string) (cache.Cache, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("graph cache not found at %s", path)
+	}
+	cfg := cache.DefaultConfig()
+	cfg.Type = "sqlite"
+	cfg.Path = path
+	return cache.NewCache(cfg)
+}
+
+// --- impact command ---
+
+var impactCmd = &cobra.Command{
+	Use:   "impact <object>",
+	Short: "Show transitive dependents of an object from the graph cache",
+	Long: `Analyze the impact of changing an object or method using the cached object graph.
+
+Lists every object that transitively depends on the given object (up to --depth hops),
+grouped by package and object type. Unless --offline is set, dependents are annotated
+with their unit test classes and the modifiable transports they are locked in.
+
+Examples:
+  vsp -s a4h impact ZCL_UTIL
+  vsp impact ZCL_UTIL --type CLAS --depth 5 --transport A4HK900123
+  vsp impact 'ME.ZCL_UTIL\ME:FORMAT' --offline --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImpact,
+}
+
+func init() {
+	impactCmd.Flags().StringP("type", "t", "", "Object type (CLAS, PROG, FUNC, etc.)")
+	impactCmd.Flags().IntP("depth", "d", 3, "Maximum number of hops")
+	impactCmd.Flags().StringSlice("edges", nil, "Edge types to follow (e.g. CALLS,USES)")
+	impactCmd.Flags().String("transport", "", "Transport carrying the change (flags dependents locked elsewhere)")
+	impactCmd.Flags().Bool("offline", false, "Skip test and transport lookups in the SAP system")
+	impactCmd.Flags().Bool("json", false, "Output JSON")
+}
+
+func runImpact(cmd *cobra.Command, args []string) error {
+	objType, _ := cmd.Flags().GetString("type")
+	depth, _ := cmd.Flags().GetInt("depth")
+	edges, _ := cmd.Flags().GetStringSlice("edges")
+	transport, _ := cmd.Flags().GetString("transport")
+	offline, _ := cmd.Flags().GetBool("offline")
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	graphCache, err := openGraphCache(resolveCachePath(cmd))
+	if err != nil {
+		return err
+	}
+	defer graphCache.Close()
+
+	ctx := context.Background()
+	roots, err := cache.ResolveImpactRoots(ctx, graphCache, objType, args[0])
+	if err == cache.ErrNotFound {
+		return fmt.Errorf("%s not found in graph cache %s", args[0], resolveCachePath(cmd))
+	}
+	if err != nil {
+		return err
+	}
+
+	opts := cache.ImpactOptions{MaxDepth: depth}
+	for _, e := range edges {
+		opts.EdgeTypes = append(opts.EdgeTypes, strings.ToUpper(e))
+	}
+
+	result, err := cache.AnalyzeImpact(ctx, graphCache, roots, opts)
+	if err != nil {
+		return fmt.Errorf("impact analysis failed: %w", err)
+	}
+
+	if !offline {
+		params, err := resolveSystemParams(cmd)
+		if err != nil {
+			return err
+		}
+		client, err := getClient(params)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Checking tests and transports for %d dependents...\n", result.Total)
+		result.Annotate(ctx, client, strings.ToUpper(transport))
+	}
+
+	if asJSON {
+		output, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Println(string(output))
+		return nil
+	}
+
+	printImpactResult(result, !offline)
+	return nil
+}
+
+func printImpactResult(result *cache.ImpactResult, annotated bool) {
+	fmt.Printf("Impact of %s (max depth %d): %d dependents\n", strings.Join(result.Roots, ", "), result.MaxDepth, result.Total)
+
+	byKey := make(map[string]*cache.ImpactedObject)
+	for _, obj := range result.Dependents {
+		byKey[obj.ObjectType+"/"+obj.ObjectName] = obj
+	}
+
+	packages := make([]string, 0, len(result.ByPackage))
+	for pkg := range result.ByPackage {
+		packages = append(packages, pkg)
+	}
+	sort.Strings(packages)
+
+	for _, pkg := range packages {
+		fmt.Printf("\n%s\n", pkg)
+		types := make([]string, 0, len(result.ByPackage[pkg]))
+		for t := range result.ByPackage[pkg] {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+
+		for _, t := range types {
+			for _, name := range result.ByPackage[pkg][t] {
+				obj := byKey[t+"/"+name]
+				var flags []string
+				if annotated {
+					if obj.HasTests {
+						flags = append(flags, "tests")
+					} else if obj.ObjectType == "CLAS" {
+						flags = append(flags, "NO TESTS")
+					}
+					if obj.OtherTransport {
+						flags = append(flags, "in "+strings.Join(obj.Transports, ","))
+					}
+				}
+				fmt.Printf("  %-6s %-40s depth %d", t, name, obj.Depth)
+				if len(flags) > 0 {
+					fmt.Printf("  [%s]", strings.Join(flags, "; "))
+				}
+				fmt.Println()
+			}
+		}
+	}
+
+	for _, w := range result.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+}
+
+// --- graph command ---
+
+var graphCmd = &cobra.Command{
+	Use:   "graph <callgraph|cds|package> <target>",
+	Short: "Export object graphs as DOT, GraphML or Mermaid",
+	Long: `Export call graphs, CDS dependency trees or cached package graphs.
+
+Sources:
+  callgraph <object-uri>   ADT call hierarchy (use --direction callers|callees)
+  cds <ddls-name>          CDS dependency tree
+  package <packages>       Cached object graph of one or more packages (comma-separated)
+
+Formats: mermaid (default), dot (Graphviz), graphml (Gephi, yEd)
+
+Examples:
+  vsp -s a4h graph callgraph /sap/bc/adt/oo/classes/ZCL_ORDER --format dot -o order.dot
+  vsp graph cds ZI_SALESORDER --format mermaid
+  vsp graph package '$ZSD,$ZMM' --format graphml --collapse -o packages.graphml
+  vsp graph package '$ZSD' --depth 2 --types CLAS,INTF --packages 'Z*'`,
+	Args: cobra.ExactArgs(2),
+	RunE: runGraph,
+}
+
+func init() {
+	graphCmd.Flags().StringP("format", "f", graph.FormatMermaid, "Output format: mermaid, dot, graphml")
+	graphCmd.Flags().StringP("output", "o", "", "Output file (default: stdout)")
+	graphCmd.Flags().String("direction", "callees", "Call graph direction: callees or callers")
+	graphCmd.Flags().IntP("depth", "d", 3, "Maximum depth from the root or packages")
+	graphCmd.Flags().StringSlice("packages", nil, "Keep only nodes in these packages (wildcards allowed)")
+	graphCmd.Flags().StringSlice("types", nil, "Keep only these object types (e.g. CLAS,PROG)")
+	graphCmd.Flags().Bool("collapse", false, "Collapse nodes into one node per package")
+}
+
+func runGraph(cmd *cobra.Command, args []string) error {
+	source, target := strings.ToLower(args[0]), args[1]
+	format, _ := cmd.Flags().GetString("format")
+	output, _ := cmd.Flags().GetString("output")
+	direction, _ := cmd.Flags().GetString("direction")
+	depth, _ := cmd.Flags().GetInt("depth")
+	packages, _ := cmd.Flags().GetStringSlice("packages")
+	types, _ := cmd.Flags().GetStringSlice("types")
+	collapse, _ := cmd.Flags().GetBool("collapse")
+
+	ctx := context.Background()
+	var g *graph.Graph
+
+	switch source {
+	case "callgraph", "cds":
+		params, err := resolveSystemParams(cmd)
+		if err != nil {
+			return err
+		}
+		client, err := getClient(params)
+		if err != nil {
+			return err
+		}
+
+		if source == "callgraph" {
+			root, err := client.GetCallGraph(ctx, target, &adt.CallGraphOptions{
+				Direction:  direction,
+				MaxDepth:   depth,
+				MaxResults: 500,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to get call graph: %w", err)
+			}
+			g = graph.FromCallGraph(root, direction)
+		} else {
+			root, err := client.GetCDSDependencies(ctx, strings.ToUpper(target), adt.CDSDependencyOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get CDS dependencies: %w", err)
+			}
+			g = graph.FromCDSDependencies(root)
+		}
+
+	case "package":
+		graphCache, err := openGraphCache(resolveCachePath(cmd))
+		if err != nil {
+			return err
+		}
+		defer graphCache.Close()
+
+		g, err = graph.FromCache(ctx, graphCache, splitCommaSeparated(strings.ToUpper(target)), depth)
+		if err != nil {
+			return err
+		}
+
+	default:
+		return fmt.Errorf("unknown graph source: %s (expected callgraph, cds or package)", source)
+	}
+
+	g = g.Filter(graph.FilterOptions{
+		MaxDepth: depth,
+		Packages: packages,
+		Types:    types,
+		Collapse: collapse,
+	})
+
+	rendered, err := graph.Export(g, format)
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		fmt.Print(rendered)
+		return nil
+	}
+	if err := os.WriteFile(output, []byte(rendered), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %d nodes, %d edges to %s\n", len(g.Nodes), len(g.Edges), output)
+	return nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/oisee/vibing-steampunk/pkg/dsl"
+	"github.com/spf13/cobra"
+)
+
+var historyToGitCmd = &cobra.Command{
+	Use:   "history-to-git <package> <repo-dir>",
+	Short: "Replay a package's released transports as git commits",
+	Long: `Read the version history of a package's sources and replay it into a local
+git repository, one commit per released transport, oldest first.
+
+Each commit has the transport owner as author, the transport description as
+message and the date of the transport's newest version. Files are written in
+abapGit layout under <repo-dir>/src, with a .abapgit.xml in <repo-dir>. The
+repository is created with git init if needed; in an existing repository, each
+commit contains only the files written for its transport.
+
+Versions without a transport (local objects, $TMP) and transports that are not
+released yet are skipped. Only objects that still exist in the package are
+replayed, and the .xml metadata of an object is its current state.
+
+Status, owner and description are read from each transport, which requires
+--enable-transports (or --allow-transportable-edits). A transport that can't
+be read stops the replay.
+
+Examples:
+  vsp -s dev history-to-git '$ZORDERS' ./orders-history
+  vsp -s dev history-to-git ZORDERS ./orders-history -r --email-domain example.com
+  vsp -s dev history-to-git ZORDERS ./orders-history --dry-run`,
+	Args: cobra.ExactArgs(2),
+	RunE: runHistoryToGit,
+}
+
+func init() {
+	historyToGitCmd.Flags().BoolP("subpackages", "r", false, "Include subpackages")
+	historyToGitCmd.Flags().String("email-domain", "localhost", "Domain for author emails (<owner>@<domain>)")
+	historyToGitCmd.Flags().Bool("dry-run", false, "List the transports that would be committed")
+	historyToGitCmd.Flags().Bool("json", false, "Output JSON")
+	rootCmd.AddCommand(historyToGitCmd)
+}
+
+func runHistoryToGit(cmd *cobra.Command, args []string) error {
+	subpackages, _ := cmd.Flags().GetBool("subpackages")
+	emailDomain, _ := cmd.Flags().GetString("email-domain")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	asJSON, _ := cmd.Flags().GetBool("json")
+	dir := args[1]
+
+	params, err := resolveSystemParams(cmd)
+	if err != nil {
+		return err
+	}
+	client, err := getClient(params)
+	if err != nil {
+		return err
+	}
+
+	builder := dsl.History(client, args[0])
+	if subpackages {
+		builder.Subpackages()
+	}
+	if !asJSON {
+		builder.OnObject(func(typ, name string) {
+			fmt.Fprintf(os.Stderr, "Reading versions of %s %s\n", typ, name)
+		})
+	}
+
+	ctx := context.Background()
+	var result *dsl.HistoryResult
+	if dryRun {
+		result, err = builder.Collect(ctx)
+	} else {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); os.IsNotExist(err) {
+			if _, err := runGit(dir, nil, "init", "--quiet"); err != nil {
+				return err
+			}
+		}
+		commits := 0
+		result, err = builder.Replay(ctx, dir, func(t dsl.HistoryTransport, paths []string) error {
+			committed, err := commitTransport(dir, t, paths, emailDomain)
+			if committed {
+				commits++
+				if !asJSON {
+					fmt.Printf("  %s  %s  %-12s %s\n", t.Number, t.Date.Format("2006-01-02"), t.Owner, t.Description)
+				}
+			}
+			return err
+		})
+		if err == nil && !asJSON {
+			fmt.Printf("\n%d commit(s) from %d transport(s) in %s\n", commits, len(result.Transports), dir)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		output, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Println(string(output))
+		return nil
+	}
+	for _, w := range result.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+	if dryRun {
+		for _, t := range result.Transports {
+			fmt.Printf("  %s  %s  %-12s %s (%d files)\n", t.Number, t.Date.Format("2006-01-02"), t.Owner, t.Description, len(t.Files))
+		}
+		fmt.Printf("\n%d transport(s) for %d object(s)\n", len(result.Transports), result.Objects)
+	}
+	if result.Skipped > 0 {
+		fmt.Printf("%d version(s) skipped (no released transport)\n", result.Skipped)
+	}
+	return nil
+}
+
+// commitTransport commits the paths written for transport t. Other changes
+// in the repository, staged or not, are left out of the commit.
+// Returns false if the transport changed nothing.
+func commitTransport(dir string, t dsl.HistoryTransport, paths []string, emailDomain string) (bool, error) {
+	if len(paths) == 0 {
+		return false, nil
+	}
+	if _, err := runGit(dir, nil, append([]string{"add", "--"}, paths...)...); err != nil {
+		return false, err
+	}
+	if _, err := runGit(dir, nil, append([]string{"diff", "--cached", "--quiet", "--"}, paths...)...); err == nil {
+		return false, nil // Nothing changed
+	}
+
+	owner := t.Owner
+	if owner == "" {
+		owner = "UNKNOWN"
+	}
+	email := strings.ToLower(owner) + "@" + emailDomain
+	date := t.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	env := []string{
+		"GIT_AUTHOR_NAME=" + owner,
+		"GIT_AUTHOR_EMAIL=" + email,
+		"GIT_AUTHOR_DATE=" + date.Format(time.RFC3339),
+		"GIT_COMMITTER_NAME=" + owner,
+		"GIT_COMMITTER_EMAIL=" + email,
+		"GIT_COMMITTER_DATE=" + date.Format(time.RFC3339),
+	}
+
+	message := t.Description
+	if message == "" {
+		message = t.Number
+	}
+	message += "\n\nTransport: " + t.Number
+	if _, err := runGit(dir, env, append([]string{"commit", "--quiet", "-m", message, "--"}, paths...)...); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// runGit runs git in dir with extra environment variables.
+func runGit(dir string, env []string, args ...string) (string, error) {
+	c := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	c.Env = append(os.Environ(), env...)
+	out, err := c.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return string(out), nil
+}
@@ -97,6 +97,10 @@ func init() {
 	// Debugger configuration
 	rootCmd.Flags().StringVar(&cfg.TerminalID, "terminal-id", "", "SAP GUI terminal ID for cross-tool breakpoint sharing")
 
+	// Object graph cache (persistent: shared by impact, graph, index, grep and tests)
+	rootCmd.PersistentFlags().StringVar(&cfg.CachePath, "cache-path", "", "Path to the SQLite object graph cache (default .cache/graph.db; MCP reads are only cached locally when set)")
+	rootCmd.Flags().BoolVar(&cfg.Offline, "offline", false, "Serve reads from the local cache without contacting SAP (write tools disabled)")
+
 	// Output options
 	rootCmd.Flags().BoolVarP(&cfg.Verbose, "verbose", "v", false, "Enable verbose output to stderr")
 
@@ -132,6 +136,8 @@ func init() {
 
 	// Debugger configuration
 	viper.BindPFlag("terminal-id", rootCmd.Flags().Lookup("terminal-id"))
+	viper.BindPFlag("cache-path", rootCmd.PersistentFlags().Lookup("cache-path"))
+	viper.BindPFlag("offline", rootCmd.Flags().Lookup("offline"))
 
 	// Set up environment variable mapping
 	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
@@ -174,6 +180,9 @@ func runServer(cmd *cobra.Command, args []string) error {
 		}
 
 		// Safety status
+		if cfg.Offline {
+			fmt.Fprintf(os.Stderr, "[VERBOSE] Offline: reads served from %s, write tools disabled\n", resolveCachePath(cmd))
+		}
 		if cfg.ReadOnly {
 			fmt.Fprintf(os.Stderr, "[VERBOSE] Safety: READ-ONLY mode enabled\n")
 		}
@@ -367,10 +376,39 @@ func resolveConfig(cmd *cobra.Command) {
 			cfg.TerminalID = v
 		}
 	}
+
+	// Graph cache path: flag > SAP_CACHE_PATH env (empty: no write-through caching)
+	if !cmd.Flags().Changed("cache-path") {
+		if v := viper.GetString("CACHE_PATH"); v != "" {
+			cfg.CachePath = v
+		}
+	}
+
+	// Offline mode: flag > SAP_OFFLINE env
+	if !cmd.Flags().Changed("offline") {
+		cfg.Offline = viper.GetBool("OFFLINE")
+	}
+}
+
+// defaultCachePath is the SQLite cache used when no --cache-path is configured.
+const defaultCachePath = ".cache/graph.db"
+
+// resolveCachePath returns the SQLite cache path: --cache-path flag > SAP_CACHE_PATH env > default.
+// Subcommands use it for the graph cache, the source index and the test history.
+func resolveCachePath(cmd *cobra.Command) string {
+	if !cmd.Flags().Changed("cache-path") {
+		if v := viper.GetString("CACHE_PATH"); v != "" {
+			return v
+		}
+	}
+	if cfg.CachePath != "" {
+		return cfg.CachePath
+	}
+	return defaultCachePath
 }
 
 func validateConfig() error {
-	if cfg.BaseURL == "" {
+	if cfg.BaseURL == "" && !cfg.Offline {
 		return fmt.Errorf("SAP URL is required. Use --url flag or SAP_URL environment variable")
 	}
 
@@ -412,7 +450,7 @@ func processCookieAuth(cmd *cobra.Command) error {
 		return fmt.Errorf("only one authentication method can be used at a time (basic auth, cookie-file, or cookie-string)")
 	}
 
-	if authMethods == 0 {
+	if authMethods == 0 && !cfg.Offline {
 		return fmt.Errorf("authentication required. Use --user/--password, --cookie-file, or --cookie-string")
 	}
 
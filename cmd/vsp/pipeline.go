@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+	"github.com/oisee/vibing-steampunk/pkg/dsl"
+	"github.com/spf13/cobra"
+)
+
+var pipelineCmd = &cobra.Command{
+	Use:   "pipeline",
+	Short: "Run ready-made multi-stage pipelines",
+	Long: `Run one of the built-in pipelines. Stages run in dependency order and share
+saved variables; a failing stage skips the stages that depend on it.`,
+}
+
+var pipelineRunCmd = &cobra.Command{
+	Use:   "run <ci|test|deploy|rap|export>",
+	Short: "Run a built-in pipeline",
+	Long: `Run a built-in pipeline.
+
+Pipelines:
+  test    discover → syntax check → unit tests       (--package)
+  ci      like test, with progress messages          (--package)
+  deploy  import → activate → unit tests             (--source, --package)
+  rap     import → activate → publish → verify       (--source, --package, --binding)
+  export  discover → export to files                 (--package, --output)
+
+Use --dry-run to run the read-only steps and only report the changes.
+
+Examples:
+  vsp pipeline run ci --package 'Z*'
+  vsp pipeline run deploy --source ./src --package '$ZORDERS' --dry-run
+  vsp pipeline run export --package '$ZORDERS' --output ./backup --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPipeline,
+}
+
+var pipelineBuilders = map[string]func(client *adt.Client, pkg, source, output, binding string) (*dsl.Pipeline, error){
+	"test": func(client *adt.Client, pkg, _, _, _ string) (*dsl.Pipeline, error) {
+		if pkg == "" {
+			return nil, fmt.Errorf("--package is required")
+		}
+		return dsl.TestPipeline(client, pkg), nil
+	},
+	"ci": func(client *adt.Client, pkg, _, _, _ string) (*dsl.Pipeline, error) {
+		if pkg == "" {
+			return nil, fmt.Errorf("--package is required")
+		}
+		return dsl.CIPipeline(client, pkg), nil
+	},
+	"deploy": func(client *adt.Client, pkg, source, _, _ string) (*dsl.Pipeline, error) {
+		if pkg == "" || source == "" {
+			return nil, fmt.Errorf("--package and --source are required")
+		}
+		return dsl.DeployPipeline(client, source, pkg), nil
+	},
+	"rap": func(client *adt.Client, pkg, source, _, binding string) (*dsl.Pipeline, error) {
+		if pkg == "" || source == "" || binding == "" {
+			return nil, fmt.Errorf("--package, --source and --binding are required")
+		}
+		return dsl.RAPPipeline(client, source, pkg, binding), nil
+	},
+	"export": func(client *adt.Client, pkg, _, output, _ string) (*dsl.Pipeline, error) {
+		if pkg == "" || output == "" {
+			return nil, fmt.Errorf("--package and --output are required")
+		}
+		return dsl.ExportPipeline(client, pkg, output), nil
+	},
+}
+
+func init() {
+	pipelineRunCmd.Flags().StringP("package", "p", "", "Package (or pattern) the pipeline works on")
+	pipelineRunCmd.Flags().String("source", "", "Source directory to import (deploy, rap)")
+	pipelineRunCmd.Flags().String("output", "", "Output directory (export)")
+	pipelineRunCmd.Flags().String("binding", "", "Service binding to publish (rap)")
+	pipelineRunCmd.Flags().Bool("dry-run", false, "Preview changes without executing")
+	pipelineRunCmd.Flags().StringToString("var", nil, "Set pipeline variables (key=value)")
+	pipelineRunCmd.Flags().Bool("json", false, "Output the result as JSON")
+
+	pipelineCmd.AddCommand(pipelineRunCmd)
+	rootCmd.AddCommand(pipelineCmd)
+}
+
+func runPipeline(cmd *cobra.Command, args []string) error {
+	pkg, _ := cmd.Flags().GetString("package")
+	source, _ := cmd.Flags().GetString("source")
+	output, _ := cmd.Flags().GetString("output")
+	binding, _ := cmd.Flags().GetString("binding")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	vars, _ := cmd.Flags().GetStringToString("var")
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	build, ok := pipelineBuilders[strings.ToLower(args[0])]
+	if !ok {
+		names := make([]string, 0, len(pipelineBuilders))
+		for name := range pipelineBuilders {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown pipeline %q (available: %s)", args[0], strings.Join(names, ", "))
+	}
+
+	// Resolve configuration (same as MCP server)
+	resolveConfig(cmd.Parent().Parent())
+	if err := validateConfig(); err != nil {
+		return err
+	}
+	if err := processCookieAuth(cmd.Parent().Parent()); err != nil {
+		return err
+	}
+	client := createADTClient()
+
+	pipeline, err := build(client, pkg, source, output, binding)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Running pipeline: %s\n\n", pipeline.Name)
+
+	opts := []dsl.ExecuteOption{dsl.WithDryRun(dryRun), dsl.WithToolCaller(newWorkflowToolCaller(client))}
+	if len(vars) > 0 {
+		opts = append(opts, dsl.WithVariables(vars))
+	}
+
+	result, err := pipeline.Run(context.Background(), opts...)
+	if err != nil {
+		return fmt.Errorf("pipeline execution failed: %w", err)
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(result)
+	} else {
+		printPipelineResult(result)
+	}
+
+	if !result.Success {
+		return fmt.Errorf("pipeline failed: %s", result.Error)
+	}
+	return nil
+}
+
+func printPipelineResult(result *dsl.PipelineResult) {
+	fmt.Printf("Pipeline: %s\n", result.Name)
+	fmt.Printf("Status: %s\n", statusString(result.Success))
+	if result.DryRun {
+		fmt.Println("Mode: dry run")
+	}
+	fmt.Printf("Stages: %d\n\n", len(result.Stages))
+
+	for _, stage := range result.Stages {
+		status := "PASS"
+		switch stage.Status {
+		case dsl.StageFailed:
+			status = "FAIL"
+		case dsl.StageSkipped:
+			status = "SKIP"
+		}
+		fmt.Printf("[%s] %s\n", status, stage.Name)
+		if stage.SkipReason != "" {
+			fmt.Printf("       Reason: %s\n", stage.SkipReason)
+		}
+		for _, step := range stage.Steps {
+			status := "PASS"
+			if !step.Success {
+				status = "FAIL"
+			}
+			if step.Skipped {
+				status = "SKIP"
+			}
+			fmt.Printf("  [%s] %s (%s)\n", status, step.Name, step.Action)
+			if step.Error != "" {
+				fmt.Printf("         Error: %s\n", step.Error)
+			}
+		}
+	}
+
+	if result.Error != "" {
+		fmt.Printf("\nError: %s\n", result.Error)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/oisee/vibing-steampunk/pkg/dsl"
+	"github.com/spf13/cobra"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync <dir> <package>",
+	Short: "Two-way sync between a local abapGit folder and a package",
+	Long: `Compare a local folder in abapGit layout with a package and push or pull
+the differences.
+
+Each file is compared against its base, the content at the last sync, which is
+recorded in <dir>/.vsp/sync-state.json. This tells which side changed:
+
+  local-added / local-changed     pushed with --push
+  remote-added / remote-changed   pulled with --pull
+  remote-deleted                  local file removed with --pull --delete
+  local-deleted                   reported only (delete the object in SAP)
+  conflict                        changed on both sides; skipped unless --force
+
+Without --push or --pull the differences are only reported.
+
+Examples:
+  vsp -s dev sync ./src '$ZORDERS'
+  vsp -s dev sync ./src '$ZORDERS' --pull
+  vsp -s dev sync ./src '$ZORDERS' --push --only ZCL_ORDER,ZIF_ORDER
+  vsp -s dev sync ./src '$ZORDERS' --push --force --only ZCL_ORDER`,
+	Args: cobra.ExactArgs(2),
+	RunE: runSync,
+}
+
+func init() {
+	syncCmd.Flags().Bool("push", false, "Write local additions and changes to the system")
+	syncCmd.Flags().Bool("pull", false, "Write remote additions and changes to the folder")
+	syncCmd.Flags().StringSlice("only", nil, "Only push/pull these objects (NAME or TYPE:NAME)")
+	syncCmd.Flags().Bool("force", false, "Resolve conflicts in favor of the chosen direction")
+	syncCmd.Flags().Bool("delete", false, "With --pull: remove local files of objects deleted on the system")
+	syncCmd.Flags().BoolP("subpackages", "r", false, "Include subpackages")
+	syncCmd.Flags().StringP("transport", "t", "", "Transport request for pushed objects")
+	syncCmd.Flags().Bool("dry-run", false, "Show what would be pushed or pulled")
+	syncCmd.Flags().Bool("json", false, "Output JSON")
+	rootCmd.AddCommand(syncCmd)
+}
+
+func runSync(cmd *cobra.Command, args []string) error {
+	push, _ := cmd.Flags().GetBool("push")
+	pull, _ := cmd.Flags().GetBool("pull")
+	only, _ := cmd.Flags().GetStringSlice("only")
+	force, _ := cmd.Flags().GetBool("force")
+	deleteLocal, _ := cmd.Flags().GetBool("delete")
+	subpackages, _ := cmd.Flags().GetBool("subpackages")
+	transport, _ := cmd.Flags().GetString("transport")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	asJSON, _ := cmd.Flags().GetBool("json")
+
+	if info, err := os.Stat(args[0]); err != nil || !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", args[0])
+	}
+
+	params, err := resolveSystemParams(cmd)
+	if err != nil {
+		return err
+	}
+	client, err := getClient(params)
+	if err != nil {
+		return err
+	}
+
+	builder := dsl.Sync(client, args[0], args[1]).WithTransport(transport)
+	if push {
+		builder.Push()
+	}
+	if pull {
+		builder.Pull()
+	}
+	if force {
+		builder.Force()
+	}
+	if deleteLocal {
+		builder.DeleteLocal()
+	}
+	if subpackages {
+		builder.Subpackages()
+	}
+	if dryRun {
+		builder.DryRun()
+	}
+	if len(only) > 0 {
+		builder.Only(only...)
+	}
+
+	result, err := builder.Execute(context.Background())
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		output, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Println(string(output))
+	} else {
+		printSyncResult(result)
+	}
+
+	if result.Failed > 0 {
+		return fmt.Errorf("%d file(s) failed to sync", result.Failed)
+	}
+	return nil
+}
+
+func printSyncResult(result *dsl.SyncResult) {
+	for _, w := range result.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+
+	changed := 0
+	for _, e := range result.Entries {
+		if e.Status == dsl.SyncUnchanged {
+			continue
+		}
+		changed++
+		line := fmt.Sprintf("  %-15s %s", e.Status, e.Path)
+		if e.Action != "" {
+			line += "  -> " + e.Action
+		}
+		fmt.Println(line)
+		if e.Reason != "" {
+			fmt.Printf("                  %s\n", e.Reason)
+		}
+		if e.Error != "" {
+			fmt.Printf("                  error: %s\n", e.Error)
+		}
+	}
+	if changed == 0 {
+		fmt.Printf("%s is in sync with %s (%d files)\n", result.Dir, result.Package, len(result.Entries))
+		return
+	}
+
+	fmt.Printf("\n%d unchanged, %d conflict(s); pushed %d, pulled %d, deleted %d\n",
+		result.Count(dsl.SyncUnchanged), result.Count(dsl.SyncConflict), result.Pushed, result.Pulled, result.Deleted)
+}
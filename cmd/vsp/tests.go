@@ -0,0 +1,333 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oisee/vibing-steampunk/pkg/cache"
+	"github.com/oisee/vibing-steampunk/pkg/dsl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	testsDays            int
+	testsMinRuns         int
+	testChanged          bool
+	testObjects          []string
+	testChangedTransport string
+	testGitBase          string
+	testRepoDir          string
+	testDepth            int
+	testGraphOnly        bool
+	testDryRun           bool
+)
+
+var testCmd = &cobra.Command{
+	Use:   "test [package-pattern]",
+	Short: "Run unit tests for a package pattern or for changed objects",
+	Long: `Run ABAP Unit tests for all classes/programs matching a package pattern or,
+with --changed, only the tests affected by a change.
+
+Changed objects are taken from --objects and the objects of --changed-transport.
+If neither is given, they are the files in abapGit layout that differ from
+--base in the git repository --repo (committed, uncommitted and untracked
+changes). Their dependents are found in the graph cache (--cache-path), the
+callers and the where-used list; classes without test classes are skipped.
+
+Examples:
+  vsp test '$ZRAY*' --parallel 4
+  vsp test --changed
+  vsp test --changed --base origin/main --junit results.xml --base-dir src
+  vsp test --changed --changed-transport A4HK900123
+  vsp test --changed --objects CLAS:ZCL_UTIL,INTF:ZIF_UTIL --dry-run`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runTestCmd,
+}
+
+var testsCmd = &cobra.Command{
+	Use:   "tests",
+	Short: "Analyze the local unit test history",
+	Long: `Analyze the unit test results recorded by "vsp workflow test".
+
+Each run stores one row per test method with the system, transport and time in
+the local SQLite cache (--cache-path), with the tested object's change timestamp
+(or, with --hash-sources, a hash of its source).`,
+}
+
+var testsFlakyCmd = &cobra.Command{
+	Use:   "flaky",
+	Short: "List tests whose results flip between runs on unchanged source",
+	Long: `List test methods that both passed and failed while the tested source
+stayed the same. A test that fails after its class changed is a regression, not
+a flaky test, and is not listed. Runs are compared by the recorded change
+timestamp or source hash; runs without either are not considered.
+
+Examples:
+  vsp tests flaky
+  vsp -s dev tests flaky --days 7
+  vsp tests flaky --min-runs 5 --json`,
+	Args: cobra.NoArgs,
+	RunE: runTestsFlaky,
+}
+
+func init() {
+	testsFlakyCmd.Flags().IntVar(&testsDays, "days", 30, "Only consider runs of the last N days (0 = all)")
+	testsFlakyCmd.Flags().IntVar(&testsMinRuns, "min-runs", 2, "Minimum number of runs on the same source")
+	testsFlakyCmd.Flags().BoolVar(&outputJSON, "json", false, "Output as JSON")
+
+	testsCmd.AddCommand(testsFlakyCmd)
+	rootCmd.AddCommand(testsCmd)
+
+	addTestFlags(testCmd)
+	testCmd.Flags().BoolVar(&testChanged, "changed", false, "Only run the tests affected by changed objects")
+	testCmd.Flags().StringSliceVar(&testObjects, "objects", nil, "Changed objects as TYPE:NAME (e.g. CLAS:ZCL_UTIL)")
+	testCmd.Flags().StringVar(&testChangedTransport, "changed-transport", "", "Also take the changed objects from this transport")
+	testCmd.Flags().StringVar(&testGitBase, "base", "HEAD", "Git revision the changes are compared with")
+	testCmd.Flags().StringVar(&testRepoDir, "repo", ".", "Git repository in abapGit layout")
+	testCmd.Flags().IntVar(&testDepth, "depth", 3, "Maximum number of hops from a changed object")
+	testCmd.Flags().BoolVar(&testGraphOnly, "graph-only", false, "Skip the caller and where-used lookups in the SAP system")
+	testCmd.Flags().BoolVar(&testDryRun, "dry-run", false, "List the affected tests without running them")
+	rootCmd.AddCommand(testCmd)
+}
+
+func runTestCmd(cmd *cobra.Command, args []string) error {
+	if !testChanged {
+		if len(args) != 1 {
+			return fmt.Errorf("a package pattern or --changed is required")
+		}
+		client, err := testClient(cmd.Parent())
+		if err != nil {
+			return err
+		}
+		return runPackageTests(context.Background(), client, args[0], resolveCachePath(cmd))
+	}
+	if len(args) > 0 {
+		return fmt.Errorf("--changed does not take a package pattern")
+	}
+
+	client, err := testClient(cmd.Parent())
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	var changed []dsl.ObjectRef
+	for _, obj := range testObjects {
+		changed = append(changed, dsl.ParseObjectRef(obj))
+	}
+	if testChangedTransport != "" {
+		objs, err := dsl.TransportObjects(ctx, client, testChangedTransport)
+		if err != nil {
+			return fmt.Errorf("reading transport %s: %w", testChangedTransport, err)
+		}
+		changed = append(changed, objs...)
+	}
+	if len(testObjects) == 0 && testChangedTransport == "" {
+		files, err := gitChangedFiles(testRepoDir, testGitBase)
+		if err != nil {
+			return err
+		}
+		changed = dsl.ObjectsFromFiles(files...)
+	}
+	if len(changed) == 0 {
+		fmt.Println("No changed objects found")
+		return nil
+	}
+
+	finder := dsl.AffectedTests(client).Depth(testDepth)
+	if testGraphOnly {
+		finder.GraphOnly()
+	}
+	if graphCache, err := openGraphCache(resolveCachePath(cmd)); err == nil {
+		defer graphCache.Close()
+		finder.Graph(graphCache)
+	} else {
+		fmt.Fprintf(os.Stderr, "Warning: %v; using the SAP system only\n", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Finding tests affected by %d changed objects...\n", len(changed))
+	selection, err := finder.Find(ctx, changed)
+	if err != nil {
+		return err
+	}
+	for _, w := range selection.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+
+	if testDryRun {
+		if outputJSON {
+			output, _ := json.MarshalIndent(selection, "", "  ")
+			fmt.Println(string(output))
+			return nil
+		}
+		printAffectedTests(selection)
+		return nil
+	}
+	if len(selection.Tests) == 0 {
+		fmt.Println("No affected tests found")
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "Found %d affected objects to test\n\n", len(selection.Tests))
+	return runTests(ctx, client, "changed", selection.Objects(), resolveCachePath(cmd))
+}
+
+func printAffectedTests(selection *dsl.AffectedTestsResult) {
+	fmt.Printf("%d changed objects, %d affected objects with tests\n\n", len(selection.Changed), len(selection.Tests))
+	for _, t := range selection.Tests {
+		reason := t.Reason
+		if t.Via != "" {
+			reason = fmt.Sprintf("%s of %s, depth %d", t.Reason, t.Via, t.Depth)
+		}
+		fmt.Printf("  %-6s %-40s %s\n", t.Object.Type, t.Object.Name, reason)
+	}
+	if len(selection.Untested) > 0 {
+		fmt.Printf("\nAffected classes without tests:\n")
+		for _, obj := range selection.Untested {
+			fmt.Printf("  %s\n", obj.Name)
+		}
+	}
+}
+
+// gitChangedFiles returns the files of the repository in dir that differ
+// from the merge base of base and HEAD, including uncommitted and untracked
+// files.
+func gitChangedFiles(dir, base string) ([]string, error) {
+	git := func(args ...string) (string, error) {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+		if err != nil {
+			if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+				return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+			}
+			return "", fmt.Errorf("git %s: %w", args[0], err)
+		}
+		return string(out), nil
+	}
+
+	rev := base
+	if mergeBase, err := git("merge-base", base, "HEAD"); err == nil {
+		rev = strings.TrimSpace(mergeBase)
+	}
+	diff, err := git("diff", "--name-only", rev)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := git("ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(diff + "\n" + untracked), nil
+}
+
+func runTestsFlaky(cmd *cobra.Command, args []string) error {
+	historyPath := resolveCachePath(cmd)
+	if _, err := os.Stat(historyPath); err != nil {
+		return fmt.Errorf("test history not found at %s (run vsp workflow test first)", historyPath)
+	}
+	store, err := openSourceStore(historyPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	opts := cache.FlakyOptions{System: systemName, MinRuns: testsMinRuns}
+	if testsDays > 0 {
+		opts.Since = time.Now().AddDate(0, 0, -testsDays)
+	}
+	flaky, err := store.FlakyTests(context.Background(), opts)
+	if err != nil {
+		return err
+	}
+	if len(flaky) == 0 {
+		total, withSource, err := store.CountTestRecords(context.Background(), opts.System, opts.Since)
+		if err != nil {
+			return err
+		}
+		if total > 0 && withSource == 0 {
+			fmt.Fprintf(os.Stderr, "Warning: none of the %d recorded results has a source hash or change timestamp; flaky tests can't be told apart from regressions\n", total)
+		}
+	}
+
+	if outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if flaky == nil {
+			flaky = []*cache.FlakyTest{}
+		}
+		return enc.Encode(flaky)
+	}
+
+	if len(flaky) == 0 {
+		fmt.Println("No flaky tests found")
+		return nil
+	}
+	fmt.Printf("%d flaky test(s):\n\n", len(flaky))
+	for _, f := range flaky {
+		fmt.Printf("%s %s=>%s (%s)\n", f.ObjectName, f.ClassName, f.Method, f.System)
+		fmt.Printf("  %d runs: %d passed, %d failed, %d flips (%.0f%% failures), last run %s\n",
+			f.Runs, f.Passed, f.Failed, f.Flips, f.FailureRate()*100, f.LastRun.Format("2006-01-02 15:04"))
+		if f.LastFailure != "" {
+			fmt.Printf("  last failure: %s\n", f.LastFailure)
+		}
+	}
+	return nil
+}
+
+// recordTestHistory stores the results of a test run, including failed
+// attempts that were rerun, in the test history at path.
+func recordTestHistory(ctx context.Context, path string, summary *dsl.TestSummary) error {
+	store, err := openSourceStore(path)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	runAt := time.Now()
+	runID := strconv.FormatInt(runAt.UnixNano(), 36)
+	system := historySystem()
+
+	var records []*cache.TestRecord
+	for _, res := range summary.Results {
+		attempts := append(append([]dsl.TestResult(nil), res.Retries...), res)
+		for i, attempt := range attempts {
+			for _, class := range attempt.Classes {
+				for _, m := range class.Methods {
+					records = append(records, &cache.TestRecord{
+						RunID:      runID,
+						System:     system,
+						ObjectType: res.Object.Type,
+						ObjectName: res.Object.Name,
+						ClassName:  class.Name,
+						Method:     m.Name,
+						Transport:  testTransport,
+						RunAt:      runAt,
+						Attempt:    i + 1,
+						Passed:     m.Success,
+						Message:    m.Message,
+						SourceHash: res.SourceHash,
+						Duration:   m.ExecutionTime,
+					})
+				}
+			}
+		}
+	}
+	return store.RecordTests(ctx, records)
+}
+
+// historySystem returns the system name results are recorded under: the
+// --system name, or the host of the SAP URL.
+func historySystem() string {
+	if systemName != "" {
+		return systemName
+	}
+	if u, err := url.Parse(cfg.BaseURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return cfg.BaseURL
+}
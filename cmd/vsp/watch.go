@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/oisee/vibing-steampunk/pkg/dsl"
+	"github.com/spf13/cobra"
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch <dir>",
+	Short: "Write saved files to the system as you edit them",
+	Long: `Watch a local folder in abapGit layout and write each saved file to the
+system, then syntax check it. Errors are printed as file:line:column.
+
+Saves that happen close together (e.g. "save all") are handled as one batch,
+in dependency order: interfaces first, then classes, then class includes.
+Objects that don't exist yet are created in --package.
+
+With --activate, a batch without syntax errors is activated in one request.
+With --test, the unit tests of the saved classes and programs run after
+activation (implies --activate).
+
+Stop with Ctrl+C.
+
+Examples:
+  vsp -s dev watch ./src --package '$ZDEV'
+  vsp -s dev watch ./src --package '$ZDEV' --activate
+  vsp -s dev watch ./src --package ZORDERS --test -t DEVK900123`,
+	Args: cobra.ExactArgs(1),
+	RunE: runWatch,
+}
+
+func init() {
+	watchCmd.Flags().StringP("package", "p", "", "Package for new objects (required)")
+	watchCmd.Flags().StringP("transport", "t", "", "Transport request")
+	watchCmd.Flags().Bool("activate", false, "Activate after a clean syntax check")
+	watchCmd.Flags().Bool("test", false, "Run unit tests after activation (implies --activate)")
+	watchCmd.Flags().Duration("debounce", dsl.DefaultWatchDebounce, "Wait this long after the last save before processing")
+	_ = watchCmd.MarkFlagRequired("package")
+	rootCmd.AddCommand(watchCmd)
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	pkg, _ := cmd.Flags().GetString("package")
+	transport, _ := cmd.Flags().GetString("transport")
+	activate, _ := cmd.Flags().GetBool("activate")
+	runTests, _ := cmd.Flags().GetBool("test")
+	debounce, _ := cmd.Flags().GetDuration("debounce")
+
+	if info, err := os.Stat(args[0]); err != nil || !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", args[0])
+	}
+
+	params, err := resolveSystemParams(cmd)
+	if err != nil {
+		return err
+	}
+	client, err := getClient(params)
+	if err != nil {
+		return err
+	}
+
+	watcher := dsl.Watch(client, args[0], pkg).
+		WithTransport(transport).
+		Debounce(debounce).
+		OnChange(func(paths []string) {
+			fmt.Printf("[%s] %d file(s) changed\n", time.Now().Format("15:04:05"), len(paths))
+		}).
+		OnBatch(printWatchBatch).
+		OnError(func(err error) {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		})
+	if activate {
+		watcher.Activate()
+	}
+	if runTests {
+		watcher.RunTests()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("Watching %s (package %s). Press Ctrl+C to stop.\n", args[0], pkg)
+	return watcher.Run(ctx)
+}
+
+func printWatchBatch(batch *dsl.WatchBatch) {
+	for _, f := range batch.Files {
+		line := fmt.Sprintf("  %-8s %s", f.Action, f.Path)
+		if f.Error != "" {
+			line += "  " + f.Error
+		}
+		fmt.Println(line)
+	}
+	for _, m := range batch.Messages() {
+		fmt.Println(m.String())
+	}
+	for _, r := range batch.Tests {
+		status := "PASS"
+		if !r.Success {
+			status = "FAIL"
+		}
+		fmt.Printf("  %s %s %s: %d/%d passed\n", status, r.Object.Type, r.Object.Name, r.PassedTests, r.TotalTests)
+		for _, c := range r.Classes {
+			for _, m := range c.Methods {
+				if !m.Success {
+					fmt.Printf("    %s->%s: %s\n", c.Name, m.Name, m.Message)
+				}
+			}
+		}
+		if r.Error != "" {
+			fmt.Printf("    error: %s\n", r.Error)
+		}
+	}
+
+	switch {
+	case batch.Errors > 0:
+		fmt.Printf("%d error(s) in %s\n", batch.Errors, batch.Duration.Round(time.Millisecond))
+	case batch.Activated:
+		fmt.Printf("OK, activated in %s\n", batch.Duration.Round(time.Millisecond))
+	default:
+		fmt.Printf("OK in %s\n", batch.Duration.Round(time.Millisecond))
+	}
+}
@@ -5,10 +5,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/oisee/vibing-steampunk/internal/mcp"
 	"github.com/oisee/vibing-steampunk/pkg/adt"
+	"github.com/oisee/vibing-steampunk/pkg/config"
 	"github.com/oisee/vibing-steampunk/pkg/dsl"
+	"github.com/oisee/vibing-steampunk/pkg/report"
 	"github.com/spf13/cobra"
 )
 
@@ -41,6 +46,53 @@ var workflowRunCmd = &cobra.Command{
 	RunE:  runWorkflow,
 }
 
+var workflowResumeCmd = &cobra.Command{
+	Use:   "resume <state-file>",
+	Short: "Continue a failed workflow run from its state file",
+	Long: `Continue a workflow run from the state file written by 'vsp workflow run'.
+
+Steps that completed before the first failed (or not yet run) step are not run
+again; their saved outputs and the run's variables are restored from the state
+file. Use --from to restart at a specific step (id or name) instead.
+
+Examples:
+  vsp workflow resume deploy.state.json
+  vsp workflow resume deploy.state.json --from activate
+  vsp workflow resume deploy.state.json --var TRANSPORT=DEVK900124`,
+	Args: cobra.ExactArgs(1),
+	RunE: runWorkflowResume,
+}
+
+var workflowValidateCmd = &cobra.Command{
+	Use:   "validate <workflow.yaml>",
+	Short: "Check a workflow file without running it",
+	Long: `Check a workflow file without connecting to SAP: unknown fields and actions,
+missing or unknown parameters, ${VAR} references that no variable or earlier
+step defines, references to saved outputs, saveAs collisions, dependencies and
+condition syntax.
+
+Variables passed at run time with --var must be given here too.
+
+Examples:
+  vsp workflow validate deploy.yaml
+  vsp workflow validate deploy.yaml --var PACKAGE='$ZORDERS' --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runWorkflowValidate,
+}
+
+var workflowSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of workflow files",
+	Long: `Print a JSON Schema for workflow files, for validation and autocompletion
+in editors.
+
+Example (VS Code with the YAML extension, in settings.json):
+  vsp workflow schema > vsp-workflow.schema.json
+  "yaml.schemas": { "./vsp-workflow.schema.json": "workflows/*.yaml" }`,
+	Args: cobra.NoArgs,
+	RunE: runWorkflowSchema,
+}
+
 var workflowTestCmd = &cobra.Command{
 	Use:   "test <package-pattern>",
 	Short: "Run unit tests for a package pattern",
@@ -49,7 +101,15 @@ var workflowTestCmd = &cobra.Command{
 Examples:
   vsp workflow test "$TMP"
   vsp workflow test "$ZRAY*"
-  vsp workflow test "ZCL_*" --parallel 4`,
+  vsp workflow test "ZCL_*" --parallel 4
+  vsp workflow test "$ZRAY*" --junit results.xml
+  vsp workflow test "$ZRAY*" --tap results.tap --base-dir src
+  vsp workflow test "$ZRAY*" --lcov coverage.info --cobertura coverage.xml
+  vsp workflow test "$ZRAY*" --rerun-failures 2 --transport A4HK900123
+
+Every run is recorded in the local test history in the cache (--cache-path).
+With --hash-sources, a hash of each tested source is recorded too, which
+"vsp tests flaky" uses to find tests whose results flip on unchanged source.`,
 	Args: cobra.ExactArgs(1),
 	RunE: runTestWorkflow,
 }
@@ -58,10 +118,24 @@ var (
 	workflowDryRun  bool
 	workflowVerbose bool
 	workflowVars    map[string]string
+	workflowMaxPar  int
+	workflowState   string
+	workflowNoState bool
+	workflowFrom    string
+	workflowReport  string
 	testParallel    int
 	testDangerous   bool
 	testLong        bool
 	testStopOnFail  bool
+	testJUnit       string
+	testTAP         string
+	testLCOV        string
+	testCobertura   string
+	testBaseDir     string
+	testRerun       int
+	testHashSources bool
+	testNoHistory   bool
+	testTransport   string
 	outputJSON      bool
 )
 
@@ -70,15 +144,29 @@ func init() {
 	workflowRunCmd.Flags().BoolVar(&workflowDryRun, "dry-run", false, "Preview changes without executing")
 	workflowRunCmd.Flags().BoolVarP(&workflowVerbose, "verbose", "v", false, "Verbose output")
 	workflowRunCmd.Flags().StringToStringVar(&workflowVars, "var", nil, "Set workflow variables (key=value)")
+	workflowRunCmd.Flags().IntVar(&workflowMaxPar, "max-parallel", 0, "Maximum steps running at the same time (default: workflow maxParallel or 4)")
+	workflowRunCmd.Flags().StringVar(&workflowState, "state", "", "State file written after every step (default: <workflow>.state.json)")
+	workflowRunCmd.Flags().BoolVar(&workflowNoState, "no-state", false, "Do not write a state file")
+	workflowRunCmd.Flags().StringVar(&workflowReport, "report", "", "Write the combined matrix report as JSON to this file")
+
+	// Workflow resume flags
+	workflowResumeCmd.Flags().StringVar(&workflowFrom, "from", "", "Restart at this step (id or name)")
+	workflowResumeCmd.Flags().BoolVar(&workflowDryRun, "dry-run", false, "Preview changes without executing (default: as in the saved run)")
+	workflowResumeCmd.Flags().BoolVarP(&workflowVerbose, "verbose", "v", false, "Verbose output")
+	workflowResumeCmd.Flags().StringToStringVar(&workflowVars, "var", nil, "Override workflow variables (key=value)")
+	workflowResumeCmd.Flags().IntVar(&workflowMaxPar, "max-parallel", 0, "Maximum steps running at the same time (default: workflow maxParallel or 4)")
+
+	// Workflow validate flags
+	workflowValidateCmd.Flags().StringToStringVar(&workflowVars, "var", nil, "Variables the workflow is run with (key=value)")
+	workflowValidateCmd.Flags().BoolVar(&outputJSON, "json", false, "Output issues as JSON")
 
 	// Test workflow flags
-	workflowTestCmd.Flags().IntVar(&testParallel, "parallel", 1, "Number of parallel test executions")
-	workflowTestCmd.Flags().BoolVar(&testDangerous, "dangerous", false, "Include dangerous risk level tests")
-	workflowTestCmd.Flags().BoolVar(&testLong, "long", false, "Include long duration tests")
-	workflowTestCmd.Flags().BoolVar(&testStopOnFail, "stop-on-fail", false, "Stop on first failure")
-	workflowTestCmd.Flags().BoolVar(&outputJSON, "json", false, "Output results as JSON")
+	addTestFlags(workflowTestCmd)
 
 	workflowCmd.AddCommand(workflowRunCmd)
+	workflowCmd.AddCommand(workflowResumeCmd)
+	workflowCmd.AddCommand(workflowValidateCmd)
+	workflowCmd.AddCommand(workflowSchemaCmd)
 	workflowCmd.AddCommand(workflowTestCmd)
 	rootCmd.AddCommand(workflowCmd)
 }
@@ -89,6 +177,17 @@ func runWorkflow(cmd *cobra.Command, args []string) error {
 	// Resolve configuration (same as MCP server)
 	resolveConfig(cmd.Parent().Parent())
 
+	// Load workflow
+	workflow, err := dsl.NewWorkflowEngine(nil).LoadWorkflow(workflowFile)
+	if err != nil {
+		return fmt.Errorf("failed to load workflow: %w", err)
+	}
+
+	// A matrix over systems connects to each system from the systems config
+	if _, ok := workflow.Matrix[dsl.MatrixSystem]; ok {
+		return runWorkflowMatrix(workflow, dsl.NewWorkflowEngine(nil), nil)
+	}
+
 	// Validate we have auth
 	if err := validateConfig(); err != nil {
 		return err
@@ -104,11 +203,8 @@ func runWorkflow(cmd *cobra.Command, args []string) error {
 
 	// Create workflow engine
 	engine := dsl.NewWorkflowEngine(client)
-
-	// Load workflow
-	workflow, err := engine.LoadWorkflow(workflowFile)
-	if err != nil {
-		return fmt.Errorf("failed to load workflow: %w", err)
+	if len(workflow.Matrix) > 0 {
+		return runWorkflowMatrix(workflow, engine, client)
 	}
 
 	fmt.Fprintf(os.Stderr, "Running workflow: %s\n", workflow.Name)
@@ -121,11 +217,24 @@ func runWorkflow(cmd *cobra.Command, args []string) error {
 	opts := []dsl.ExecuteOption{
 		dsl.WithDryRun(workflowDryRun),
 		dsl.WithVerbose(workflowVerbose),
+		dsl.WithToolCaller(newWorkflowToolCaller(client)),
 	}
 
 	if len(workflowVars) > 0 {
 		opts = append(opts, dsl.WithVariables(workflowVars))
 	}
+	if workflowMaxPar > 0 {
+		opts = append(opts, dsl.WithMaxParallel(workflowMaxPar))
+	}
+
+	statePath := ""
+	if !workflowNoState {
+		statePath = workflowState
+		if statePath == "" {
+			statePath = strings.TrimSuffix(workflowFile, filepath.Ext(workflowFile)) + ".state.json"
+		}
+		opts = append(opts, dsl.WithStateFile(statePath))
+	}
 
 	// Execute
 	ctx := context.Background()
@@ -138,16 +247,158 @@ func runWorkflow(cmd *cobra.Command, args []string) error {
 	printWorkflowResult(result)
 
 	if !result.Success {
+		if statePath != "" {
+			fmt.Fprintf(os.Stderr, "\nResume with: vsp workflow resume %s\n", statePath)
+		}
 		return fmt.Errorf("workflow failed: %s", result.Error)
 	}
 
 	return nil
 }
 
-func runTestWorkflow(cmd *cobra.Command, args []string) error {
-	packagePattern := args[0]
+// runWorkflowMatrix runs a workflow once per matrix combination and prints
+// the combined report. Matrix runs do not write state files.
+func runWorkflowMatrix(workflow *dsl.Workflow, engine *dsl.WorkflowEngine, client *adt.Client) error {
+	combos := workflow.Combinations()
+	fmt.Fprintf(os.Stderr, "Running workflow: %s (%d matrix combinations)\n\n", workflow.Name, len(combos))
 
-	// Resolve configuration
+	opts := []dsl.ExecuteOption{
+		dsl.WithDryRun(workflowDryRun),
+		dsl.WithVerbose(workflowVerbose),
+	}
+	if len(workflowVars) > 0 {
+		opts = append(opts, dsl.WithVariables(workflowVars))
+	}
+	if workflowMaxPar > 0 {
+		opts = append(opts, dsl.WithMaxParallel(workflowMaxPar))
+	}
+
+	var systems dsl.SystemOptions
+	if _, ok := workflow.Matrix[dsl.MatrixSystem]; ok {
+		var err error
+		if systems, err = matrixSystemOptions(); err != nil {
+			return err
+		}
+	} else {
+		opts = append(opts, dsl.WithToolCaller(newWorkflowToolCaller(client)))
+	}
+
+	result, err := engine.ExecuteMatrix(context.Background(), workflow, systems, opts...)
+	if err != nil {
+		return fmt.Errorf("workflow execution failed: %w", err)
+	}
+
+	printMatrixResult(result)
+
+	if workflowReport != "" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(workflowReport, data, 0644); err != nil {
+			return fmt.Errorf("writing report: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "\nReport written to %s\n", workflowReport)
+	}
+
+	if !result.Success {
+		return fmt.Errorf("workflow failed for %d of %d combinations", result.Failed, result.Total)
+	}
+	return nil
+}
+
+// matrixSystemOptions connects matrix runs to the systems of the systems
+// config (.vsp.json), each with its own ADT client and tools and the safety
+// settings of the command line plus those of the system.
+func matrixSystemOptions() (dsl.SystemOptions, error) {
+	systemsCfg, _, err := config.LoadSystems()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load systems config: %w", err)
+	}
+	if systemsCfg == nil {
+		return nil, fmt.Errorf("no systems config found. Create .vsp.json or ~/.vsp.json\n\nExample:\n%s", config.ExampleConfig())
+	}
+
+	return func(name string) ([]dsl.ExecuteOption, error) {
+		sys, err := systemsCfg.GetSystem(name)
+		if err != nil {
+			return nil, err
+		}
+
+		serverCfg := *cfg
+		serverCfg.Mode = "expert"
+		serverCfg.ToolsConfig = systemsCfg.Tools
+		serverCfg.BaseURL = sys.URL
+		serverCfg.Username = sys.User
+		serverCfg.Password = sys.Password
+		serverCfg.Client = sys.Client
+		serverCfg.Language = sys.Language
+		serverCfg.InsecureSkipVerify = sys.Insecure
+		serverCfg.Cookies = nil
+		switch {
+		case sys.CookieFile != "":
+			if serverCfg.Cookies, err = adt.LoadCookiesFromFile(sys.CookieFile); err != nil {
+				return nil, fmt.Errorf("failed to load cookies from %s: %w", sys.CookieFile, err)
+			}
+		case sys.CookieString != "":
+			serverCfg.Cookies = adt.ParseCookieString(sys.CookieString)
+		case sys.Password == "":
+			return nil, fmt.Errorf("auth not found. Set VSP_%s_PASSWORD env var or use cookie_file/cookie_string", strings.ToUpper(name))
+		}
+		if len(serverCfg.Cookies) > 0 {
+			serverCfg.Username, serverCfg.Password = "", ""
+		}
+		if sys.ReadOnly {
+			serverCfg.ReadOnly = true
+		}
+		if len(sys.AllowedPackages) > 0 {
+			serverCfg.AllowedPackages = sys.AllowedPackages
+		}
+
+		server := mcp.NewServer(&serverCfg)
+		return []dsl.ExecuteOption{dsl.WithClient(server.ADTClient()), dsl.WithToolCaller(server.CallToolValue)}, nil
+	}, nil
+}
+
+func printMatrixResult(result *dsl.MatrixResult) {
+	fmt.Printf("Workflow: %s\n", result.Name)
+	fmt.Printf("Status: %s\n", statusString(result.Success))
+	fmt.Printf("Runs: %d (%d passed, %d failed) in %s\n\n", result.Total, result.Passed, result.Failed, result.TotalTime.Round(time.Millisecond))
+
+	for _, run := range result.Runs {
+		status := "PASS"
+		if !run.Success {
+			status = "FAIL"
+		}
+		fmt.Printf("[%s] %s (%s)\n", status, run.Label(), run.ExecutionTime.Round(time.Millisecond))
+		if run.Result == nil {
+			fmt.Printf("       Error: %s\n", run.Error)
+			continue
+		}
+		for _, step := range run.Result.StepResults {
+			if step.Success {
+				continue
+			}
+			fmt.Printf("       [FAIL] %s (%s): %s\n", step.Name, step.Action, step.Error)
+		}
+		if run.Error != "" && len(run.Result.StepResults) == 0 {
+			fmt.Printf("       Error: %s\n", run.Error)
+		}
+	}
+}
+
+func runWorkflowResume(cmd *cobra.Command, args []string) error {
+	statePath := args[0]
+
+	state, err := dsl.LoadWorkflowState(statePath)
+	if err != nil {
+		return err
+	}
+	if state.Workflow == "" {
+		return fmt.Errorf("state file does not name its workflow file")
+	}
+
+	// Resolve configuration (same as MCP server)
 	resolveConfig(cmd.Parent().Parent())
 
 	if err := validateConfig(); err != nil {
@@ -158,13 +409,132 @@ func runTestWorkflow(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Create ADT client
 	client := createADTClient()
+	engine := dsl.NewWorkflowEngine(client)
 
+	workflow, err := engine.LoadWorkflow(state.Workflow)
+	if err != nil {
+		return fmt.Errorf("failed to load workflow: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Resuming workflow: %s\n", workflow.Name)
+	if workflowFrom != "" {
+		fmt.Fprintf(os.Stderr, "Restarting at step: %s\n", workflowFrom)
+	}
+	fmt.Fprintf(os.Stderr, "\n")
+
+	dryRun := state.DryRun
+	if cmd.Flags().Changed("dry-run") {
+		dryRun = workflowDryRun
+	}
+
+	// Saved variables first, so --var overrides them
+	opts := []dsl.ExecuteOption{
+		dsl.WithResume(state, workflowFrom),
+		dsl.WithDryRun(dryRun),
+		dsl.WithVerbose(workflowVerbose),
+		dsl.WithStateFile(statePath),
+		dsl.WithToolCaller(newWorkflowToolCaller(client)),
+	}
+	if len(workflowVars) > 0 {
+		opts = append(opts, dsl.WithVariables(workflowVars))
+	}
+	if workflowMaxPar > 0 {
+		opts = append(opts, dsl.WithMaxParallel(workflowMaxPar))
+	}
+
+	result, err := engine.Execute(context.Background(), workflow, opts...)
+	if err != nil {
+		return fmt.Errorf("workflow execution failed: %w", err)
+	}
+
+	printWorkflowResult(result)
+
+	if !result.Success {
+		return fmt.Errorf("workflow failed: %s", result.Error)
+	}
+
+	return nil
+}
+
+func runWorkflowValidate(cmd *cobra.Command, args []string) error {
+	vars := make([]string, 0, len(workflowVars))
+	for k := range workflowVars {
+		vars = append(vars, k)
+	}
+
+	issues, err := dsl.NewWorkflowEngine(nil).CheckWorkflowFile(args[0], vars)
+	if err != nil {
+		return err
+	}
+
+	if outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(map[string]interface{}{
+			"workflow": args[0],
+			"valid":    !dsl.HasErrors(issues),
+			"issues":   issues,
+		})
+	} else {
+		errorCount := 0
+		for _, issue := range issues {
+			fmt.Println(issue)
+			if !issue.Warning {
+				errorCount++
+			}
+		}
+		if len(issues) == 0 {
+			fmt.Printf("%s: OK\n", args[0])
+		} else {
+			fmt.Printf("\n%s: %d errors, %d warnings\n", args[0], errorCount, len(issues)-errorCount)
+		}
+	}
+
+	if dsl.HasErrors(issues) {
+		return fmt.Errorf("workflow %s is invalid", args[0])
+	}
+	return nil
+}
+
+func runWorkflowSchema(cmd *cobra.Command, args []string) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(dsl.NewWorkflowEngine(nil).JSONSchema())
+}
+
+func runTestWorkflow(cmd *cobra.Command, args []string) error {
+	client, err := testClient(cmd.Parent().Parent())
+	if err != nil {
+		return err
+	}
+	return runPackageTests(context.Background(), client, args[0], resolveCachePath(cmd))
+}
+
+// testClient resolves the connection settings of a test command and creates
+// the ADT client.
+func testClient(root *cobra.Command) (*adt.Client, error) {
+	// Resolve configuration
+	resolveConfig(root)
+
+	if err := validateConfig(); err != nil {
+		return nil, err
+	}
+
+	if err := processCookieAuth(root); err != nil {
+		return nil, err
+	}
+
+	// Create ADT client
+	return createADTClient(), nil
+}
+
+// runPackageTests runs the unit tests of all classes and programs matching
+// a package pattern.
+func runPackageTests(ctx context.Context, client *adt.Client, packagePattern, historyPath string) error {
 	fmt.Fprintf(os.Stderr, "Discovering tests in: %s\n", packagePattern)
 
 	// Search for testable objects
-	ctx := context.Background()
 	objects, err := dsl.Search(client).
 		Query(packagePattern).
 		Types(dsl.TypeClass, dsl.TypeProgram).
@@ -182,6 +552,13 @@ func runTestWorkflow(cmd *cobra.Command, args []string) error {
 
 	fmt.Fprintf(os.Stderr, "Found %d objects to test\n\n", len(objects))
 
+	return runTests(ctx, client, packagePattern, objects, historyPath)
+}
+
+// runTests runs the unit tests of objects as configured by the test flags,
+// then writes the reports and records the results in the test history at
+// historyPath.
+func runTests(ctx context.Context, client *adt.Client, name string, objects []dsl.ObjectRef, historyPath string) error {
 	// Build test runner
 	runner := dsl.Test(client).
 		Objects(objects...).
@@ -196,6 +573,15 @@ func runTestWorkflow(cmd *cobra.Command, args []string) error {
 	if testStopOnFail {
 		runner.StopOnFirstFailure()
 	}
+	if testLCOV != "" || testCobertura != "" {
+		runner.WithCoverage()
+	}
+	if testRerun > 0 {
+		runner.RerunFailures(testRerun)
+	}
+	if testHashSources {
+		runner.HashSources()
+	}
 
 	// Add progress callbacks
 	runner.OnStart(func(obj dsl.ObjectRef) {
@@ -212,6 +598,12 @@ func runTestWorkflow(cmd *cobra.Command, args []string) error {
 			}
 			fmt.Fprintf(os.Stderr, "  %s: %s (%d tests, %v)\n",
 				status, obj.Name, result.TotalTests, result.ExecutionTime.Round(time.Millisecond))
+			if n := len(result.Retries); n > 0 {
+				fmt.Fprintf(os.Stderr, "        after %d rerun(s)\n", n)
+			}
+			if result.CoverageError != "" {
+				fmt.Fprintf(os.Stderr, "        no coverage: %s\n", result.CoverageError)
+			}
 		}
 	})
 
@@ -231,6 +623,16 @@ func runTestWorkflow(cmd *cobra.Command, args []string) error {
 		printTestSummary(summary)
 	}
 
+	if err := writeTestReports(name, summary); err != nil {
+		return err
+	}
+
+	if !testNoHistory {
+		if err := recordTestHistory(ctx, historyPath, summary); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: test history not recorded: %v\n", err)
+		}
+	}
+
 	if summary.FailedTests > 0 {
 		return fmt.Errorf("%d tests failed", summary.FailedTests)
 	}
@@ -238,6 +640,51 @@ func runTestWorkflow(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// addTestFlags registers the flags of a unit test run, shared by
+// "vsp workflow test" and "vsp test".
+func addTestFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&testParallel, "parallel", 1, "Number of parallel test executions")
+	cmd.Flags().BoolVar(&testDangerous, "dangerous", false, "Include dangerous risk level tests")
+	cmd.Flags().BoolVar(&testLong, "long", false, "Include long duration tests")
+	cmd.Flags().BoolVar(&testStopOnFail, "stop-on-fail", false, "Stop on first failure")
+	cmd.Flags().BoolVar(&outputJSON, "json", false, "Output results as JSON")
+	cmd.Flags().StringVar(&testJUnit, "junit", "", "Write results as JUnit XML to this file")
+	cmd.Flags().StringVar(&testTAP, "tap", "", "Write results as TAP to this file")
+	cmd.Flags().StringVar(&testLCOV, "lcov", "", "Measure coverage and write it as LCOV to this file")
+	cmd.Flags().StringVar(&testCobertura, "cobertura", "", "Measure coverage and write it as Cobertura XML to this file")
+	cmd.Flags().IntVar(&testRerun, "rerun-failures", 0, "Rerun the tests of failed objects up to N times")
+	cmd.Flags().BoolVar(&testHashSources, "hash-sources", false, "Record a hash of each tested source in the test history instead of its change timestamp (reads the sources)")
+	cmd.Flags().BoolVar(&testNoHistory, "no-history", false, "Do not record the results in the test history")
+	cmd.Flags().StringVar(&testTransport, "transport", "", "Transport the run belongs to (recorded in the test history)")
+	cmd.Flags().StringVar(&testBaseDir, "base-dir", "", "Directory prefix for source file paths in reports (e.g. src)")
+}
+
+// writeTestReports writes the --junit, --tap, --lcov and --cobertura report
+// files of a test run.
+func writeTestReports(name string, summary *dsl.TestSummary) error {
+	files := []struct{ path, format string }{
+		{testJUnit, report.FormatJUnit},
+		{testTAP, report.FormatTAP},
+		{testLCOV, report.FormatLCOV},
+		{testCobertura, report.FormatCobertura},
+	}
+	rep := report.New(name).WithBaseDir(testBaseDir).AddTestSummary(summary)
+	for _, f := range files {
+		if f.path == "" {
+			continue
+		}
+		out, err := rep.Render(f.format)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(f.path, []byte(out), 0644); err != nil {
+			return fmt.Errorf("writing report: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Report written to %s\n", f.path)
+	}
+	return nil
+}
+
 func createADTClient() *adt.Client {
 	opts := []adt.Option{
 		adt.WithClient(cfg.Client),
@@ -255,6 +702,17 @@ func createADTClient() *adt.Client {
 	return adt.NewClient(cfg.BaseURL, cfg.Username, cfg.Password, opts...)
 }
 
+// newWorkflowToolCaller returns the caller for the workflow "tool" action: an
+// in-process MCP server on the command's client, with the resolved mode, safety
+// flags, disabled groups and the tools section of .vsp.json.
+func newWorkflowToolCaller(client *adt.Client) dsl.ToolCaller {
+	serverCfg := *cfg
+	if systemsCfg, _, err := config.LoadSystems(); err == nil && systemsCfg != nil && systemsCfg.Tools != nil {
+		serverCfg.ToolsConfig = systemsCfg.Tools
+	}
+	return mcp.NewServerWithClient(&serverCfg, client).CallToolValue
+}
+
 func printWorkflowResult(result *dsl.WorkflowResult) {
 	fmt.Printf("Workflow: %s\n", result.Name)
 	fmt.Printf("Status: %s\n", statusString(result.Success))
@@ -268,8 +726,14 @@ func printWorkflowResult(result *dsl.WorkflowResult) {
 		if step.Skipped {
 			status = "SKIP"
 		}
+		if step.Resumed {
+			status = "DONE"
+		}
 
 		fmt.Printf("  [%s] %s (%s)\n", status, step.Name, step.Action)
+		if step.Attempts > 1 {
+			fmt.Printf("         Attempts: %d\n", step.Attempts)
+		}
 		if step.Error != "" {
 			fmt.Printf("         Error: %s\n", step.Error)
 		}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/oisee/vibing-steampunk/pkg/adt"
+	"github.com/oisee/vibing-steampunk/pkg/report"
 )
 
 // --- ATC Handlers ---
@@ -29,11 +30,20 @@ func (s *Server) handleRunATCCheck(ctx context.Context, request mcp.CallToolRequ
 		maxResults = int(mr)
 	}
 
+	format, errResult := reportFormat(request, report.FormatSARIF, report.FormatCheckstyle)
+	if errResult != nil {
+		return errResult, nil
+	}
+
 	result, err := s.adtClient.RunATCCheck(ctx, objectURL, variant, maxResults)
 	if err != nil {
 		return newToolResultError(fmt.Sprintf("ATC check failed: %v", err)), nil
 	}
 
+	if format != report.FormatJSON {
+		return renderReport(report.New(objectURL).AddATCWorklist(result), format)
+	}
+
 	// Format output with summary
 	type summary struct {
 		TotalObjects  int `json:"totalObjects"`
@@ -436,10 +436,18 @@ func (s *Server) handleGetSource(ctx context.Context, request mcp.CallToolReques
 		Method:  method,
 	}
 
+	if s.config.Offline {
+		return s.offlineGetSource(ctx, reasonOffline, objectType, name, opts), nil
+	}
+
 	source, err := s.adtClient.GetSource(ctx, objectType, name, opts)
+	if isConnectionError(err) {
+		return s.offlineGetSource(ctx, reasonUnreachable, objectType, name, opts), nil
+	}
 	if err != nil {
 		return newToolResultError(fmt.Sprintf("GetSource failed: %v", err)), nil
 	}
+	s.cacheSource(ctx, objectType, name, opts, source)
 
 	return mcp.NewToolResultText(source), nil
 }
@@ -543,10 +551,10 @@ func (s *Server) registerGrepPackages() {
 // registerImportFromFile registers the ImportFromFile tool (alias for DeployFromFile)
 func (s *Server) registerImportFromFile() {
 	s.mcpServer.AddTool(mcp.NewTool("ImportFromFile",
-		mcp.WithDescription("Import ABAP object from local file into SAP system. Auto-detects object type from file extension, creates or updates, activates. Supports: programs, classes (with includes), interfaces, function groups/modules, CDS views (DDLS), behavior definitions (BDEF), service definitions (SRVD). For class includes (.clas.testclasses.abap, .clas.locals_def.abap, etc.), the parent class must exist."),
+		mcp.WithDescription("Import ABAP object from local file into SAP system. Auto-detects object type from file extension, creates or updates, activates. Supports: programs, classes (with includes), interfaces, function groups/modules, CDS views (DDLS), behavior definitions (BDEF), service definitions (SRVD), and abapGit XML files of tables, structures, data elements, domains, table types and message classes. For class includes (.clas.testclasses.abap, .clas.locals_def.abap, etc.), the parent class must exist."),
 		mcp.WithString("file_path",
 			mcp.Required(),
-			mcp.Description("Absolute path to ABAP source file. Supported extensions: .prog.abap, .clas.abap, .clas.testclasses.abap, .clas.locals_def.abap, .clas.locals_imp.abap, .intf.abap, .fugr.abap, .func.abap, .ddls.asddls, .bdef.asbdef, .srvd.srvdsrv"),
+			mcp.Description("Absolute path to ABAP source file. Supported extensions: .prog.abap, .clas.abap, .clas.testclasses.abap, .clas.locals_def.abap, .clas.locals_imp.abap, .intf.abap, .fugr.abap, .func.abap, .ddls.asddls, .bdef.asbdef, .srvd.srvdsrv, .tabl.xml, .dtel.xml, .doma.xml, .ttyp.xml, .msag.xml"),
 		),
 		mcp.WithString("package_name",
 			mcp.Description("Target package name (required for new objects, not needed for class includes)"),
@@ -560,7 +568,7 @@ func (s *Server) registerImportFromFile() {
 // registerExportToFile registers the ExportToFile tool (alias for SaveToFile)
 func (s *Server) registerExportToFile() {
 	s.mcpServer.AddTool(mcp.NewTool("ExportToFile",
-		mcp.WithDescription("Export ABAP object from SAP system to local file. Saves source code with appropriate file extension. Supports: programs, classes (with includes), interfaces, function groups/modules, CDS views (DDLS), behavior definitions (BDEF), service definitions (SRVD). For classes, use 'include' parameter to export specific includes (testclasses, definitions, implementations, macros)."),
+		mcp.WithDescription("Export ABAP object from SAP system to local file. Saves source code with appropriate file extension; classes, interfaces and programs also get their abapGit .xml metadata file (e.g. zcl_foo.clas.xml) so the folder can be pulled with abapGit. Supports: programs, classes (with includes), interfaces, function groups/modules, CDS views (DDLS), behavior definitions (BDEF), service definitions (SRVD). For classes, use 'include' parameter to export specific includes (testclasses, definitions, implementations, macros)."),
 		mcp.WithString("object_type",
 			mcp.Required(),
 			mcp.Description("Object type: PROG, CLAS, INTF, FUGR, FUNC, DDLS, BDEF, SRVD"),
@@ -614,11 +622,24 @@ func (s *Server) handleGrepObjects(ctx context.Context, request mcp.CallToolRequ
 		contextLines = int(cl)
 	}
 
+	if _, err := adt.GrepSource("", pattern, caseInsensitive, 0); err != nil {
+		return newToolResultError(fmt.Sprintf("Invalid regex pattern: %v", err)), nil
+	}
+
+	if s.config.Offline {
+		return s.offlineGrepObjects(ctx, reasonOffline, objectURLs, pattern, caseInsensitive, contextLines), nil
+	}
+
 	result, err := s.adtClient.GrepObjects(ctx, objectURLs, pattern, caseInsensitive, contextLines)
 	if err != nil {
 		return newToolResultError(fmt.Sprintf("GrepObjects failed: %v", err)), nil
 	}
 
+	// The system could not be reached: answer from the local cache instead
+	if isConnectionError(result.ReadError) {
+		return s.offlineGrepObjects(ctx, reasonUnreachable, objectURLs, pattern, caseInsensitive, contextLines), nil
+	}
+
 	output, _ := json.MarshalIndent(result, "", "  ")
 	return mcp.NewToolResultText(string(output)), nil
 }
@@ -0,0 +1,129 @@
+// Package mcp provides the MCP server implementation for ABAP ADT tools.
+// handlers_codesearch.go contains the SearchCode handler backed by the local full-text source index.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oisee/vibing-steampunk/pkg/cache"
+)
+
+// codeSearchResult is the SearchCode answer for indexed searches
+type codeSearchResult struct {
+	Query     string            `json:"query"`
+	Index     string            `json:"index,omitempty"` // Full-text module: fts5, or fts4 (degraded ranking)
+	Packages  []string          `json:"packages,omitempty"`
+	Sync      *cache.SyncResult `json:"sync,omitempty"`
+	IndexedAt *time.Time        `json:"indexed_at,omitempty"` // Oldest hit's index time, when the index was not synced
+	TotalHits int               `json:"total_hits"`
+	Hits      []*cache.CodeHit  `json:"hits"`
+	Warnings  []string          `json:"warnings,omitempty"`
+}
+
+func (s *Server) handleSearchCode(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query, ok := request.Params.Arguments["query"].(string)
+	if !ok || query == "" {
+		return newToolResultError("query is required"), nil
+	}
+
+	opts := cache.CodeSearchOptions{Query: query, MaxResults: 50}
+	if pkgs, ok := request.Params.Arguments["packages"].(string); ok && pkgs != "" {
+		opts.Packages = splitList(pkgs)
+	}
+	if types, ok := request.Params.Arguments["object_types"].(string); ok && types != "" {
+		opts.Types = splitList(types)
+	}
+	if mr, ok := request.Params.Arguments["max_results"].(float64); ok && mr > 0 {
+		opts.MaxResults = int(mr)
+	}
+	if cl, ok := request.Params.Arguments["context_lines"].(float64); ok && cl > 0 {
+		opts.ContextLines = int(cl)
+	}
+	includeSubpackages, _ := request.Params.Arguments["include_subpackages"].(bool)
+	refresh, _ := request.Params.Arguments["refresh"].(bool)
+	live, _ := request.Params.Arguments["live"].(bool)
+
+	if live {
+		if s.config.Offline {
+			return newToolResultError("live search needs a connection to the SAP system (offline mode)"), nil
+		}
+		if len(opts.Packages) == 0 {
+			return newToolResultError("packages is required for live search"), nil
+		}
+		var listingTypes []string
+		for _, t := range opts.Types {
+			listingTypes = append(listingTypes, cache.ListingType(t))
+		}
+		result, err := s.adtClient.GrepPackages(ctx, opts.Packages, includeSubpackages, query, true, listingTypes, opts.MaxResults)
+		if err != nil {
+			return newToolResultError(fmt.Sprintf("Live search failed: %v", err)), nil
+		}
+		output, _ := json.MarshalIndent(result, "", "  ")
+		return mcp.NewToolResultText(string(output)), nil
+	}
+
+	store, err := s.getSourceStore()
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("Failed to open source index: %v", err)), nil
+	}
+
+	result := &codeSearchResult{Query: query, Packages: opts.Packages}
+	if idx, ok := store.(interface{ SourceIndexModule() string }); ok {
+		result.Index = idx.SourceIndexModule()
+		if result.Index == "fts4" {
+			result.Warnings = append(result.Warnings, cache.FTS4Notice)
+		}
+	}
+
+	// Bring the index up to date before searching, unless offline. The sync
+	// compares ADT change timestamps, so only changed objects are read again;
+	// refresh re-reads every source.
+	synced := false
+	if !s.config.Offline && len(opts.Packages) > 0 {
+		syncResult, err := cache.SyncSources(ctx, s.adtClient, store, opts.Packages,
+			cache.SyncOptions{IncludeSubpackages: includeSubpackages, Force: refresh})
+		if err != nil {
+			if !isConnectionError(err) {
+				return newToolResultError(fmt.Sprintf("Failed to index packages: %v", err)), nil
+			}
+			result.Warnings = append(result.Warnings, fmt.Sprintf("index not refreshed (%s): %v", reasonUnreachable, err))
+		} else {
+			synced = true
+		}
+		result.Sync = syncResult
+	}
+	if includeSubpackages && len(opts.Packages) > 0 {
+		opts.Packages = cache.ExpandPackages(ctx, store, opts.Packages)
+		result.Packages = opts.Packages
+	}
+
+	hits, err := store.SearchCode(ctx, opts)
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("SearchCode failed: %v", err)), nil
+	}
+	if hits == nil {
+		hits = []*cache.CodeHit{}
+	}
+	result.Hits = hits
+	result.TotalHits = len(hits)
+
+	var oldest time.Time
+	for _, hit := range hits {
+		if oldest.IsZero() || hit.CachedAt.Before(oldest) {
+			oldest = hit.CachedAt
+		}
+	}
+	if !synced && !oldest.IsZero() {
+		result.IndexedAt = &oldest
+	}
+
+	output, _ := json.MarshalIndent(result, "", "  ")
+	if s.config.Offline {
+		return mcp.NewToolResultText(offlineNotice(reasonOffline, oldest) + string(output)), nil
+	}
+	return mcp.NewToolResultText(string(output)), nil
+}
@@ -6,9 +6,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/oisee/vibing-steampunk/pkg/adt"
+	"github.com/oisee/vibing-steampunk/pkg/dsl"
+	"github.com/oisee/vibing-steampunk/pkg/report"
 )
 
 // --- Development Tool Handlers ---
@@ -24,11 +27,20 @@ func (s *Server) handleSyntaxCheck(ctx context.Context, request mcp.CallToolRequ
 		return newToolResultError("content is required"), nil
 	}
 
+	format, errResult := reportFormat(request, report.FormatSARIF, report.FormatCheckstyle)
+	if errResult != nil {
+		return errResult, nil
+	}
+
 	results, err := s.adtClient.SyntaxCheck(ctx, objectURL, content)
 	if err != nil {
 		return newToolResultError(fmt.Sprintf("Syntax check failed: %v", err)), nil
 	}
 
+	if format != report.FormatJSON {
+		return renderReport(report.New(objectURL).AddSyntaxCheck(objectURL, results), format)
+	}
+
 	output, _ := json.MarshalIndent(results, "", "  ")
 	return mcp.NewToolResultText(string(output)), nil
 }
@@ -90,11 +102,233 @@ func (s *Server) handleRunUnitTests(ctx context.Context, request mcp.CallToolReq
 		flags.Long = true
 	}
 
+	format, errResult := reportFormat(request, report.FormatJUnit, report.FormatTAP)
+	if errResult != nil {
+		return errResult, nil
+	}
+
 	result, err := s.adtClient.RunUnitTests(ctx, objectURL, &flags)
 	if err != nil {
 		return newToolResultError(fmt.Sprintf("Unit test run failed: %v", err)), nil
 	}
 
+	if format != report.FormatJSON {
+		return renderReport(report.New(objectURL).AddUnitTestResult(objectURL, result), format)
+	}
+
 	output, _ := json.MarshalIndent(result, "", "  ")
 	return mcp.NewToolResultText(string(output)), nil
 }
+
+func (s *Server) handleGetCoverage(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	objectURL, ok := request.Params.Arguments["object_url"].(string)
+	if !ok || objectURL == "" {
+		return newToolResultError("object_url is required"), nil
+	}
+
+	flags := adt.DefaultUnitTestFlags()
+	flags.Coverage = true
+	if includeDangerous, ok := request.Params.Arguments["include_dangerous"].(bool); ok && includeDangerous {
+		flags.Dangerous = true
+	}
+	if includeLong, ok := request.Params.Arguments["include_long"].(bool); ok && includeLong {
+		flags.Long = true
+	}
+
+	format, errResult := reportFormat(request, report.FormatLCOV, report.FormatCobertura)
+	if errResult != nil {
+		return errResult, nil
+	}
+
+	result, err := s.adtClient.RunUnitTests(ctx, objectURL, &flags)
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("Coverage run failed: %v", err)), nil
+	}
+	if result.Coverage == nil {
+		return newToolResultError(fmt.Sprintf("No coverage data returned: %s (the system may not support coverage measurement)", result.CoverageError)), nil
+	}
+
+	if format != report.FormatJSON {
+		return renderReport(report.New(report.ObjectName(objectURL)).AddCoverage(result.Coverage), format)
+	}
+
+	output, _ := json.MarshalIndent(coverageSummary(result), "", "  ")
+	return mcp.NewToolResultText(string(output)), nil
+}
+
+// coverageOutput is the JSON result of GetCoverage.
+type coverageOutput struct {
+	Tests   int                    `json:"tests"`
+	Failed  int                    `json:"failed"`
+	Objects []coverageObjectOutput `json:"objects"`
+}
+
+type coverageObjectOutput struct {
+	Name           string                 `json:"name"`
+	Type           string                 `json:"type"`
+	Statements     string                 `json:"statements"` // e.g. "75.0% (6/8)"
+	Branches       string                 `json:"branches"`
+	Procedures     string                 `json:"procedures"`
+	Methods        []coverageMethodOutput `json:"methods,omitempty"`
+	UncoveredLines map[string][]int       `json:"uncoveredLines,omitempty"` // File -> line numbers
+}
+
+type coverageMethodOutput struct {
+	Name       string `json:"name"`
+	File       string `json:"file,omitempty"`
+	Line       int    `json:"line,omitempty"`
+	Statements string `json:"statements"`
+	Branches   string `json:"branches,omitempty"`
+}
+
+// coverageSummary condenses a coverage run for GetCoverage.
+func coverageSummary(result *adt.UnitTestResult) coverageOutput {
+	counter := func(c adt.CoverageCounter) string {
+		return fmt.Sprintf("%.1f%% (%d/%d)", c.Percent(), c.Executed, c.Total)
+	}
+
+	out := coverageOutput{Objects: []coverageObjectOutput{}}
+	for _, class := range result.Classes {
+		for _, m := range class.TestMethods {
+			out.Tests++
+			if len(m.Alerts) > 0 {
+				out.Failed++
+			}
+		}
+	}
+	for _, obj := range result.Coverage.Objects {
+		o := coverageObjectOutput{
+			Name:       obj.Name,
+			Type:       obj.Type,
+			Statements: counter(obj.Statements),
+			Branches:   counter(obj.Branches),
+			Procedures: counter(obj.Procedures),
+		}
+		for _, m := range obj.Methods {
+			line, _ := report.Location(m.URI)
+			mo := coverageMethodOutput{
+				Name:       m.Name,
+				File:       report.FilePath(m.URI),
+				Line:       line,
+				Statements: counter(m.Statements),
+			}
+			if m.Branches.Total > 0 {
+				mo.Branches = counter(m.Branches)
+			}
+			o.Methods = append(o.Methods, mo)
+		}
+		for _, l := range obj.UncoveredLines() {
+			if o.UncoveredLines == nil {
+				o.UncoveredLines = make(map[string][]int)
+			}
+			file := report.FilePath(l.URI)
+			if file == "" {
+				file = l.URI
+			}
+			o.UncoveredLines[file] = append(o.UncoveredLines[file], l.Line)
+		}
+		out.Objects = append(out.Objects, o)
+	}
+	return out
+}
+
+// reportFormat returns the format argument of a tool that can render its
+// result with pkg/report: json (the default) or one of formats.
+func reportFormat(request mcp.CallToolRequest, formats ...string) (string, *mcp.CallToolResult) {
+	format, _ := request.Params.Arguments["format"].(string)
+	format = strings.ToLower(format)
+	if format == "" || format == report.FormatJSON {
+		return report.FormatJSON, nil
+	}
+	for _, f := range formats {
+		if format == f {
+			return format, nil
+		}
+	}
+	return "", newToolResultError(fmt.Sprintf("unsupported format '%s' (use json, %s)", format, strings.Join(formats, ", ")))
+}
+
+// renderReport returns a report rendered in format as the tool result.
+func renderReport(rep *report.Report, format string) (*mcp.CallToolResult, error) {
+	out, err := rep.Render(format)
+	if err != nil {
+		return newToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText(out), nil
+}
+
+// affectedTestsOutput is the JSON result of RunAffectedTests.
+type affectedTestsOutput struct {
+	Selection *dsl.AffectedTestsResult `json:"selection"`
+	Summary   *dsl.TestSummary         `json:"summary,omitempty"`
+}
+
+func (s *Server) handleRunAffectedTests(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	transport, _ := request.Params.Arguments["transport"].(string)
+	objects, _ := request.Params.Arguments["objects"].(string)
+	files, _ := request.Params.Arguments["files"].(string)
+
+	format, errResult := reportFormat(request, report.FormatJUnit, report.FormatTAP)
+	if errResult != nil {
+		return errResult, nil
+	}
+
+	var changed []dsl.ObjectRef
+	for _, obj := range splitLines(objects) {
+		changed = append(changed, dsl.ParseObjectRef(obj))
+	}
+	changed = append(changed, dsl.ObjectsFromFiles(splitLines(files)...)...)
+	if transport != "" {
+		objs, err := dsl.TransportObjects(ctx, s.adtClient, transport)
+		if err != nil {
+			return newToolResultError(fmt.Sprintf("Failed to read transport %s: %v", transport, err)), nil
+		}
+		changed = append(changed, objs...)
+	}
+	if len(changed) == 0 {
+		return newToolResultError("transport, objects or files is required"), nil
+	}
+
+	finder := dsl.AffectedTests(s.adtClient)
+	if depth, ok := request.Params.Arguments["max_depth"].(float64); ok && depth > 0 {
+		finder.Depth(int(depth))
+	}
+	if graph, err := s.getGraphCache(); err == nil {
+		finder.Graph(graph)
+	}
+	selection, err := finder.Find(ctx, changed)
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("Test selection failed: %v", err)), nil
+	}
+
+	out := affectedTestsOutput{Selection: selection}
+	if dryRun, _ := request.Params.Arguments["dry_run"].(bool); !dryRun && len(selection.Tests) > 0 {
+		runner := dsl.Test(s.adtClient).Objects(selection.Objects()...)
+		if includeDangerous, ok := request.Params.Arguments["include_dangerous"].(bool); ok && includeDangerous {
+			runner.IncludeDangerous()
+		}
+		if includeLong, ok := request.Params.Arguments["include_long"].(bool); ok && includeLong {
+			runner.IncludeLong()
+		}
+		if out.Summary, err = runner.Run(ctx); err != nil {
+			return newToolResultError(fmt.Sprintf("Unit test run failed: %v", err)), nil
+		}
+		if format != report.FormatJSON {
+			return renderReport(report.New("affected tests").AddTestSummary(out.Summary), format)
+		}
+	}
+
+	output, _ := json.MarshalIndent(out, "", "  ")
+	return mcp.NewToolResultText(string(output)), nil
+}
+
+// splitLines splits a list separated by commas or newlines.
+func splitLines(s string) []string {
+	var items []string
+	for _, item := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
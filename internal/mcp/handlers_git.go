@@ -1,5 +1,5 @@
 // Package mcp provides the MCP server implementation for ABAP ADT tools.
-// handlers_git.go contains handlers for Git/abapGit operations via ZADT_VSP.
+// handlers_git.go contains handlers for Git/abapGit operations (export via ZADT_VSP, import via ADT).
 package mcp
 
 import (
@@ -124,3 +124,41 @@ func (s *Server) handleGitExport(ctx context.Context, request mcp.CallToolReques
 
 	return mcp.NewToolResultText(sb.String()), nil
 }
+
+func (s *Server) handleGitImport(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	zipPath, _ := request.Params.Arguments["zip_path"].(string)
+	zipBase64, _ := request.Params.Arguments["zip_base64"].(string)
+	packageName, _ := request.Params.Arguments["package"].(string)
+	if packageName == "" {
+		return newToolResultError("package is required"), nil
+	}
+
+	var zipData []byte
+	var err error
+	switch {
+	case zipPath != "":
+		zipData, err = os.ReadFile(zipPath)
+		if err != nil {
+			return newToolResultError(fmt.Sprintf("Failed to read ZIP file: %v", err)), nil
+		}
+	case zipBase64 != "":
+		zipData, err = base64.StdEncoding.DecodeString(zipBase64)
+		if err != nil {
+			return newToolResultError(fmt.Sprintf("Failed to decode ZIP: %v", err)), nil
+		}
+	default:
+		return newToolResultError("Either zip_path or zip_base64 parameter is required"), nil
+	}
+
+	opts := adt.GitImportOptions{Package: packageName}
+	opts.Transport, _ = request.Params.Arguments["transport"].(string)
+	opts.DryRun, _ = request.Params.Arguments["dry_run"].(bool)
+
+	result, err := s.adtClient.GitImport(ctx, zipData, opts)
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("GitImport failed: %v", err)), nil
+	}
+
+	output, _ := json.MarshalIndent(result, "", "  ")
+	return mcp.NewToolResultText(string(output)), nil
+}
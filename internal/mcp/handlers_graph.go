@@ -0,0 +1,197 @@
+// Package mcp provides the MCP server implementation for ABAP ADT tools.
+// handlers_graph.go contains handlers for object graph tools (impact analysis, graph export).
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+	"github.com/oisee/vibing-steampunk/pkg/cache"
+	"github.com/oisee/vibing-steampunk/pkg/graph"
+)
+
+// getGraphCache opens the SQLite graph cache on first use.
+func (s *Server) getGraphCache() (cache.Cache, error) {
+	s.graphCacheMu.Lock()
+	defer s.graphCacheMu.Unlock()
+
+	if s.graphCache != nil {
+		return s.graphCache, nil
+	}
+
+	path := s.config.CachePath
+	if path == "" {
+		path = ".cache/graph.db"
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	cfg := cache.DefaultConfig()
+	cfg.Type = "sqlite"
+	cfg.Path = path
+	c, err := cache.NewCache(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	s.graphCache = c
+	return c, nil
+}
+
+// --- Impact Analysis ---
+
+func (s *Server) handleAnalyzeImpact(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	objectName, ok := request.Params.Arguments["object_name"].(string)
+	if !ok || objectName == "" {
+		return newToolResultError("object_name is required"), nil
+	}
+	objectType, _ := request.Params.Arguments["object_type"].(string)
+	transport, _ := request.Params.Arguments["transport"].(string)
+
+	opts := cache.ImpactOptions{MaxDepth: 3}
+	if depth, ok := request.Params.Arguments["max_depth"].(float64); ok && depth > 0 {
+		opts.MaxDepth = int(depth)
+	}
+	if edgeTypes, ok := request.Params.Arguments["edge_types"].(string); ok && edgeTypes != "" {
+		opts.EdgeTypes = splitList(edgeTypes)
+	}
+	annotate := true
+	if a, ok := request.Params.Arguments["annotate"].(bool); ok {
+		annotate = a
+	}
+
+	graph, err := s.getGraphCache()
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("Failed to open graph cache: %v", err)), nil
+	}
+
+	roots, err := cache.ResolveImpactRoots(ctx, graph, objectType, objectName)
+	if err == cache.ErrNotFound {
+		return newToolResultError(fmt.Sprintf("%s not found in graph cache. Populate the cache for its package first.", objectName)), nil
+	}
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("Failed to resolve %s: %v", objectName, err)), nil
+	}
+
+	result, err := cache.AnalyzeImpact(ctx, graph, roots, opts)
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("Impact analysis failed: %v", err)), nil
+	}
+
+	if s.config.Offline {
+		if annotate {
+			result.Warnings = append(result.Warnings, "offline mode: unit tests and transport locks were not checked")
+		}
+		output, _ := json.MarshalIndent(result, "", "  ")
+		return mcp.NewToolResultText(offlineNotice(reasonOffline, graphCachedAt(ctx, graph, roots)) + string(output)), nil
+	}
+
+	if annotate {
+		result.Annotate(ctx, s.adtClient, strings.ToUpper(transport))
+	}
+
+	output, _ := json.MarshalIndent(result, "", "  ")
+	return mcp.NewToolResultText(string(output)), nil
+}
+
+// --- Graph Export ---
+
+func (s *Server) handleExportGraph(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	source, _ := request.Params.Arguments["source"].(string)
+	target, ok := request.Params.Arguments["target"].(string)
+	if !ok || target == "" {
+		return newToolResultError("target is required"), nil
+	}
+
+	format := graph.FormatMermaid
+	if f, ok := request.Params.Arguments["format"].(string); ok && f != "" {
+		format = f
+	}
+	maxDepth := 3
+	if depth, ok := request.Params.Arguments["max_depth"].(float64); ok && depth > 0 {
+		maxDepth = int(depth)
+	}
+
+	if s.config.Offline && source != "package" {
+		return newToolResultError(fmt.Sprintf("offline mode: source '%s' needs a connection to the SAP system; use source 'package' to export from the local cache", source)), nil
+	}
+
+	var g *graph.Graph
+	var notice string
+	switch source {
+	case "callgraph":
+		direction := "callees"
+		if dir, ok := request.Params.Arguments["direction"].(string); ok && dir != "" {
+			direction = dir
+		}
+		root, err := s.adtClient.GetCallGraph(ctx, target, &adt.CallGraphOptions{
+			Direction:  direction,
+			MaxDepth:   maxDepth,
+			MaxResults: 500,
+		})
+		if err != nil {
+			return newToolResultError(fmt.Sprintf("Failed to get call graph: %v", err)), nil
+		}
+		g = graph.FromCallGraph(root, direction)
+
+	case "cds":
+		root, err := s.adtClient.GetCDSDependencies(ctx, strings.ToUpper(target), adt.CDSDependencyOptions{})
+		if err != nil {
+			return newToolResultError(fmt.Sprintf("Failed to get CDS dependencies: %v", err)), nil
+		}
+		g = graph.FromCDSDependencies(root)
+
+	case "package":
+		graphCache, err := s.getGraphCache()
+		if err != nil {
+			return newToolResultError(fmt.Sprintf("Failed to open graph cache: %v", err)), nil
+		}
+		g, err = graph.FromCache(ctx, graphCache, splitList(target), maxDepth)
+		if err != nil {
+			return newToolResultError(fmt.Sprintf("Failed to build package graph: %v", err)), nil
+		}
+		if s.config.Offline {
+			notice = offlineNotice(reasonOffline, packageCachedAt(ctx, graphCache, splitList(target)))
+		}
+
+	default:
+		return newToolResultError("source must be 'callgraph', 'cds' or 'package'"), nil
+	}
+
+	opts := graph.FilterOptions{MaxDepth: maxDepth}
+	if pkgs, ok := request.Params.Arguments["packages"].(string); ok && pkgs != "" {
+		opts.Packages = splitList(pkgs)
+	}
+	if types, ok := request.Params.Arguments["types"].(string); ok && types != "" {
+		opts.Types = splitList(types)
+	}
+	if collapse, ok := request.Params.Arguments["collapse"].(bool); ok {
+		opts.Collapse = collapse
+	}
+	g = g.Filter(opts)
+
+	output, err := graph.Export(g, format)
+	if err != nil {
+		return newToolResultError(err.Error()), nil
+	}
+
+	return mcp.NewToolResultText(notice + output), nil
+}
+
+// splitList splits a comma-separated argument into trimmed, uppercased entries.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.ToUpper(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
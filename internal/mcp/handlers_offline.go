@@ -0,0 +1,310 @@
+// Package mcp provides the MCP server implementation for ABAP ADT tools.
+// handlers_offline.go contains the local source cache used by offline mode and connection fallback.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+	"github.com/oisee/vibing-steampunk/pkg/cache"
+)
+
+const (
+	reasonOffline     = "offline mode"
+	reasonUnreachable = "SAP system unreachable"
+)
+
+// getSourceStore returns the source store of the local cache.
+func (s *Server) getSourceStore() (cache.SourceStore, error) {
+	c, err := s.getGraphCache()
+	if err != nil {
+		return nil, err
+	}
+	store, ok := c.(cache.SourceStore)
+	if !ok {
+		return nil, fmt.Errorf("cache backend does not store sources")
+	}
+	return store, nil
+}
+
+// isConnectionError reports whether err means the SAP system could not be reached
+// (as opposed to an error returned by the system itself).
+func isConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"connection refused", "no such host", "i/o timeout", "connection reset", "network is unreachable", "tls handshake timeout"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// offlineNotice is prepended to every answer served from the local cache.
+func offlineNotice(reason string, cachedAt time.Time) string {
+	if cachedAt.IsZero() {
+		return fmt.Sprintf("[%s: served from local cache, data age unknown]\n\n", reason)
+	}
+	return fmt.Sprintf("[%s: served from local cache, data is %s old (cached %s)]\n\n",
+		reason, formatAge(time.Since(cachedAt)), cachedAt.UTC().Format("2006-01-02 15:04 MST"))
+}
+
+// formatAge renders a duration as a short age like "45s", "12m" or "3h5m".
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return d.Round(time.Second).String()
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+// sourceVariant identifies which part of an object GetSource returned.
+func sourceVariant(opts *adt.GetSourceOptions) string {
+	switch {
+	case opts.Method != "":
+		return "method:" + strings.ToUpper(opts.Method)
+	case opts.Include != "" && opts.Include != "main":
+		return "include:" + strings.ToLower(opts.Include)
+	default:
+		return ""
+	}
+}
+
+// --- Write-through ---
+
+// writeThroughStore returns the source store for caching what is read from the
+// SAP system, or nil if no cache path is configured.
+func (s *Server) writeThroughStore() cache.SourceStore {
+	if s.config.CachePath == "" {
+		return nil
+	}
+	store, err := s.getSourceStore()
+	if err != nil {
+		return nil
+	}
+	return store
+}
+
+// cacheSource stores a source read from the SAP system. Failures are ignored:
+// the cache only serves offline reads. Nothing is stored without a configured cache path.
+func (s *Server) cacheSource(ctx context.Context, objectType, name string, opts *adt.GetSourceOptions, source string) {
+	store := s.writeThroughStore()
+	if store == nil {
+		return
+	}
+	objectType, name = strings.ToUpper(objectType), strings.ToUpper(name)
+	src := &cache.CachedSource{
+		ObjectType: objectType,
+		ObjectName: name,
+		Variant:    sourceVariant(opts),
+		Source:     source,
+	}
+	if src.Variant == "" {
+		src.URL = cache.SourceObjectURL(objectType, name, opts.Parent)
+	}
+	store.PutSource(ctx, src)
+}
+
+// cachePackage stores a package listing read from the SAP system.
+func (s *Server) cachePackage(ctx context.Context, pkg *adt.PackageContent) {
+	store := s.writeThroughStore()
+	if store == nil {
+		return
+	}
+	store.PutPackage(ctx, cache.NewCachedPackage(pkg))
+}
+
+// cacheSearchResults stores search hits in the object catalog.
+func (s *Server) cacheSearchResults(ctx context.Context, results []adt.SearchResult) {
+	store := s.writeThroughStore()
+	if store == nil || len(results) == 0 {
+		return
+	}
+	objects := make([]*cache.CachedObject, 0, len(results))
+	for _, r := range results {
+		objects = append(objects, &cache.CachedObject{
+			ObjectType:  r.Type,
+			ObjectName:  r.Name,
+			Package:     r.PackageName,
+			URI:         r.URI,
+			Description: r.Description,
+		})
+	}
+	store.PutObjects(ctx, objects)
+}
+
+// --- Offline reads ---
+
+func (s *Server) offlineGetSource(ctx context.Context, reason, objectType, name string, opts *adt.GetSourceOptions) *mcp.CallToolResult {
+	store, err := s.getSourceStore()
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("%s: failed to open local cache: %v", reason, err))
+	}
+	src, err := store.GetSource(ctx, strings.ToUpper(objectType), strings.ToUpper(name), sourceVariant(opts))
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("%s: %s %s is not in the local cache", reason, strings.ToUpper(objectType), strings.ToUpper(name)))
+	}
+	return mcp.NewToolResultText(offlineNotice(reason, src.CachedAt) + src.Source)
+}
+
+func (s *Server) offlineGrepObjects(ctx context.Context, reason string, objectURLs []string, pattern string, caseInsensitive bool, contextLines int) *mcp.CallToolResult {
+	store, err := s.getSourceStore()
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("%s: failed to open local cache: %v", reason, err))
+	}
+
+	result := &adt.GrepObjectsResult{Objects: []adt.GrepObjectResult{}}
+	var oldest time.Time
+	for _, objectURL := range objectURLs {
+		src, err := store.GetSourceByURL(ctx, objectURL)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: not in local cache", objectURL))
+			continue
+		}
+		if oldest.IsZero() || src.CachedAt.Before(oldest) {
+			oldest = src.CachedAt
+		}
+
+		matches, err := adt.GrepSource(src.Source, pattern, caseInsensitive, contextLines)
+		if err != nil {
+			return newToolResultError(fmt.Sprintf("Invalid regex pattern: %v", err))
+		}
+		if len(matches) > 0 {
+			result.Objects = append(result.Objects, adt.GrepObjectResult{
+				Success:    true,
+				ObjectURL:  objectURL,
+				ObjectName: src.ObjectName,
+				ObjectType: src.ObjectType,
+				Matches:    matches,
+				MatchCount: len(matches),
+				Message:    fmt.Sprintf("Found %d match(es) in %s", len(matches), src.ObjectName),
+			})
+			result.TotalMatches += len(matches)
+		}
+	}
+
+	if oldest.IsZero() {
+		return newToolResultError(fmt.Sprintf("%s: none of the objects are in the local cache", reason))
+	}
+
+	result.Success = true
+	if result.TotalMatches == 0 {
+		result.Message = fmt.Sprintf("No matches found in %d cached object(s)", len(objectURLs)-len(result.Errors))
+	} else {
+		result.Message = fmt.Sprintf("Found %d match(es) across %d object(s)", result.TotalMatches, len(result.Objects))
+	}
+
+	output, _ := json.MarshalIndent(result, "", "  ")
+	return mcp.NewToolResultText(offlineNotice(reason, oldest) + string(output))
+}
+
+func (s *Server) offlineGetPackage(ctx context.Context, reason, packageName string) *mcp.CallToolResult {
+	store, err := s.getSourceStore()
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("%s: failed to open local cache: %v", reason, err))
+	}
+	cached, err := store.GetPackage(ctx, strings.ToUpper(packageName))
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("%s: package %s is not in the local cache", reason, strings.ToUpper(packageName)))
+	}
+
+	pkg := &adt.PackageContent{
+		URI:         cached.URI,
+		Type:        "DEVC/K",
+		Name:        cached.Name,
+		SubPackages: cached.SubPackages,
+	}
+	for _, obj := range cached.Objects {
+		pkg.Objects = append(pkg.Objects, adt.PackageObject{
+			Type:        obj.ObjectType,
+			Name:        obj.ObjectName,
+			URI:         obj.URI,
+			Description: obj.Description,
+		})
+	}
+
+	output, _ := json.MarshalIndent(pkg, "", "  ")
+	return mcp.NewToolResultText(offlineNotice(reason, cached.CachedAt) + string(output))
+}
+
+func (s *Server) offlineSearchObject(ctx context.Context, reason, query string, maxResults int) *mcp.CallToolResult {
+	store, err := s.getSourceStore()
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("%s: failed to open local cache: %v", reason, err))
+	}
+	objects, err := store.SearchObjects(ctx, query, maxResults)
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("%s: cache search failed: %v", reason, err))
+	}
+	if len(objects) == 0 {
+		return newToolResultError(fmt.Sprintf("%s: no cached objects match %q", reason, query))
+	}
+
+	results := make([]adt.SearchResult, 0, len(objects))
+	oldest := objects[0].CachedAt
+	for _, obj := range objects {
+		results = append(results, adt.SearchResult{
+			URI:         obj.URI,
+			Type:        obj.ObjectType,
+			Name:        obj.ObjectName,
+			PackageName: obj.Package,
+			Description: obj.Description,
+		})
+		if obj.CachedAt.Before(oldest) {
+			oldest = obj.CachedAt
+		}
+	}
+
+	output, _ := json.MarshalIndent(results, "", "  ")
+	return mcp.NewToolResultText(offlineNotice(reason, oldest) + string(output))
+}
+
+// graphCachedAt returns the oldest cache time of the given graph nodes.
+func graphCachedAt(ctx context.Context, c cache.Cache, ids []string) time.Time {
+	var nodes []*cache.Node
+	for _, id := range ids {
+		if n, err := c.GetNode(ctx, id); err == nil {
+			nodes = append(nodes, n)
+		}
+	}
+	return oldestNode(nodes)
+}
+
+// packageCachedAt returns the oldest cache time of the graph nodes of the given packages.
+func packageCachedAt(ctx context.Context, c cache.Cache, packages []string) time.Time {
+	var nodes []*cache.Node
+	for _, pkg := range packages {
+		if pkgNodes, err := c.GetNodesByPackage(ctx, pkg); err == nil {
+			nodes = append(nodes, pkgNodes...)
+		}
+	}
+	return oldestNode(nodes)
+}
+
+func oldestNode(nodes []*cache.Node) time.Time {
+	var oldest time.Time
+	for _, n := range nodes {
+		if oldest.IsZero() || n.CachedAt.Before(oldest) {
+			oldest = n.CachedAt
+		}
+	}
+	return oldest
+}
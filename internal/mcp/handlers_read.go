@@ -228,10 +228,18 @@ func (s *Server) handleGetPackage(ctx context.Context, request mcp.CallToolReque
 		return newToolResultError("package_name is required"), nil
 	}
 
+	if s.config.Offline {
+		return s.offlineGetPackage(ctx, reasonOffline, packageName), nil
+	}
+
 	pkg, err := s.adtClient.GetPackage(ctx, packageName)
+	if isConnectionError(err) {
+		return s.offlineGetPackage(ctx, reasonUnreachable, packageName), nil
+	}
 	if err != nil {
 		return newToolResultError(fmt.Sprintf("Failed to get package: %v", err)), nil
 	}
+	s.cachePackage(ctx, pkg)
 
 	result, _ := json.MarshalIndent(pkg, "", "  ")
 	return mcp.NewToolResultText(string(result)), nil
@@ -23,10 +23,18 @@ func (s *Server) handleSearchObject(ctx context.Context, request mcp.CallToolReq
 		maxResults = int(mr)
 	}
 
+	if s.config.Offline {
+		return s.offlineSearchObject(ctx, reasonOffline, query, maxResults), nil
+	}
+
 	results, err := s.adtClient.SearchObject(ctx, query, maxResults)
+	if isConnectionError(err) {
+		return s.offlineSearchObject(ctx, reasonUnreachable, query, maxResults), nil
+	}
 	if err != nil {
 		return newToolResultError(fmt.Sprintf("Failed to search: %v", err)), nil
 	}
+	s.cacheSearchResults(ctx, results)
 
 	output, _ := json.MarshalIndent(results, "", "  ")
 	return mcp.NewToolResultText(string(output)), nil
@@ -0,0 +1,111 @@
+// Package mcp provides the MCP server implementation for ABAP ADT tools.
+// handlers_versions.go contains handlers for object version history.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+// --- Version History Handlers ---
+
+// versionArgs reads the object parameters shared by the version tools.
+func versionArgs(request mcp.CallToolRequest) (objectType, name string, opts *adt.GetSourceOptions, errResult *mcp.CallToolResult) {
+	objectType, _ = request.Params.Arguments["object_type"].(string)
+	name, _ = request.Params.Arguments["name"].(string)
+	if objectType == "" || name == "" {
+		return "", "", nil, newToolResultError("object_type and name are required")
+	}
+
+	opts = &adt.GetSourceOptions{}
+	if inc, ok := request.Params.Arguments["include"].(string); ok {
+		opts.Include = inc
+	}
+	if parent, ok := request.Params.Arguments["parent"].(string); ok {
+		opts.Parent = parent
+	}
+	if method, ok := request.Params.Arguments["method"].(string); ok {
+		opts.Method = method
+	}
+	return objectType, name, opts, nil
+}
+
+func (s *Server) handleGetVersions(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	objectType, name, opts, errResult := versionArgs(request)
+	if errResult != nil {
+		return errResult, nil
+	}
+
+	versions, err := s.adtClient.GetVersions(ctx, objectType, name, opts)
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("GetVersions failed: %v", err)), nil
+	}
+
+	output, _ := json.MarshalIndent(versions, "", "  ")
+	return mcp.NewToolResultText(string(output)), nil
+}
+
+func (s *Server) handleGetVersionSource(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	objectType, name, opts, errResult := versionArgs(request)
+	if errResult != nil {
+		return errResult, nil
+	}
+	version, _ := request.Params.Arguments["version"].(string)
+	if version == "" {
+		return newToolResultError("version is required (version ID from GetVersions, or 'current')"), nil
+	}
+
+	source, err := s.adtClient.GetVersionSource(ctx, objectType, name, version, opts)
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("GetVersionSource failed: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(source), nil
+}
+
+func (s *Server) handleDiffVersions(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	objectType, name, opts, errResult := versionArgs(request)
+	if errResult != nil {
+		return errResult, nil
+	}
+	fromVersion, _ := request.Params.Arguments["from_version"].(string)
+	toVersion, _ := request.Params.Arguments["to_version"].(string)
+	if fromVersion == "" {
+		return newToolResultError("from_version is required"), nil
+	}
+
+	diff, err := s.adtClient.DiffVersions(ctx, objectType, name, fromVersion, toVersion, opts)
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("DiffVersions failed: %v", err)), nil
+	}
+
+	output, _ := json.MarshalIndent(diff, "", "  ")
+	return mcp.NewToolResultText(string(output)), nil
+}
+
+func (s *Server) handleRestoreVersion(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	objectType, name, opts, errResult := versionArgs(request)
+	if errResult != nil {
+		return errResult, nil
+	}
+	version, _ := request.Params.Arguments["version"].(string)
+	if version == "" {
+		return newToolResultError("version is required (version ID from GetVersions)"), nil
+	}
+	transport, _ := request.Params.Arguments["transport"].(string)
+
+	result, err := s.adtClient.RestoreVersion(ctx, objectType, name, version, &adt.RestoreVersionOptions{
+		Method:    opts.Method,
+		Transport: transport,
+	})
+	if err != nil {
+		return newToolResultError(fmt.Sprintf("RestoreVersion failed: %v", err)), nil
+	}
+
+	output, _ := json.MarshalIndent(result, "", "  ")
+	return mcp.NewToolResultText(string(output)), nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/oisee/vibing-steampunk/pkg/adt"
+	"github.com/oisee/vibing-steampunk/pkg/cache"
 )
 
 // AsyncTask represents a background task status.
@@ -34,6 +35,10 @@ type Server struct {
 	featureProber  *adt.FeatureProber         // Feature detection system (safety network)
 	featureConfig  adt.FeatureConfig          // Feature configuration
 
+	// Object graph cache (SQLite, opened lazily by graph tools)
+	graphCache   cache.Cache
+	graphCacheMu sync.Mutex
+
 	// Async task management
 	asyncTasks   map[string]*AsyncTask
 	asyncTasksMu sync.RWMutex
@@ -87,6 +92,10 @@ type Config struct {
 	// Debugger configuration
 	TerminalID string // SAP GUI terminal ID for cross-tool breakpoint sharing
 
+	// Object graph cache
+	CachePath string // Path to the SQLite graph cache (default: .cache/graph.db)
+	Offline   bool   // Serve reads from the cache without contacting SAP; write tools are disabled
+
 	// Granular tool visibility (from .vsp.json)
 	// Key: tool name, Value: true=enabled, false=disabled
 	// Takes highest priority over mode and disabled groups
@@ -109,8 +118,21 @@ func NewServer(cfg *Config) *Server {
 	if cfg.Verbose {
 		opts = append(opts, adt.WithVerbose())
 	}
+	opts = append(opts, adt.WithSafety(safetyConfig(cfg)))
+
+	return newServer(cfg, adt.NewClient(cfg.BaseURL, cfg.Username, cfg.Password, opts...))
+}
+
+// NewServerWithClient creates an MCP server on top of an existing ADT client,
+// e.g. one already used by a CLI command. The client's connection settings are
+// kept; its safety settings are replaced by the ones in cfg.
+func NewServerWithClient(cfg *Config, adtClient *adt.Client) *Server {
+	*adtClient.Safety() = safetyConfig(cfg)
+	return newServer(cfg, adtClient)
+}
 
-	// Configure safety settings
+// safetyConfig builds the ADT safety settings from the server configuration.
+func safetyConfig(cfg *Config) adt.SafetyConfig {
 	safety := adt.UnrestrictedSafetyConfig() // Default: unrestricted for backwards compatibility
 	if cfg.ReadOnly {
 		safety.ReadOnly = true
@@ -139,10 +161,13 @@ func NewServer(cfg *Config) *Server {
 	if cfg.AllowTransportableEdits {
 		safety.AllowTransportableEdits = true
 	}
-	opts = append(opts, adt.WithSafety(safety))
-
-	adtClient := adt.NewClient(cfg.BaseURL, cfg.Username, cfg.Password, opts...)
+	if cfg.Offline {
+		safety.Offline = true
+	}
+	return safety
+}
 
+func newServer(cfg *Config, adtClient *adt.Client) *Server {
 	// Set terminal ID for debugger operations
 	// Priority: 1) Custom ID (SAP GUI), 2) User-based ID
 	if cfg.TerminalID != "" {
@@ -198,6 +223,12 @@ func parseFeatureMode(s string) adt.FeatureMode {
 	}
 }
 
+// ADTClient returns the server's ADT client, configured with its connection
+// and safety settings.
+func (s *Server) ADTClient() *adt.Client {
+	return s.adtClient
+}
+
 // ServeStdio starts the MCP server on stdin/stdout.
 func (s *Server) ServeStdio() error {
 	return server.ServeStdio(s.mcpServer)
@@ -212,7 +243,7 @@ func (s *Server) ServeStdio() error {
 //   - "H" = HANA/AMDP debugger (7 tools)
 //   - "D" = ABAP Debugger (6 session tools)
 //   - "C" = CTS/Transport tools (5 tools)
-//   - "G" = Git/abapGit tools (2 tools)
+//   - "G" = Git/abapGit tools (3 tools)
 //   - "R" = Report tools (4 tools)
 //   - "I" = Install tools (4 tools)
 //   - "X" = EXPERIMENTAL: All debugger + RunReport (17 tools) - use to disable unreliable features
@@ -229,7 +260,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 			"UI5ListApps", "UI5GetApp", "UI5GetFileContent",
 		},
 		"T": { // Test tools
-			"RunUnitTests", "RunATCCheck",
+			"RunUnitTests", "GetCoverage", "RunAffectedTests", "RunATCCheck",
 		},
 		"H": { // HANA/AMDP debugger
 			"AMDPDebuggerStart", "AMDPDebuggerResume", "AMDPDebuggerStop",
@@ -243,8 +274,8 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 			"ListTransports", "GetTransport",
 			"CreateTransport", "ReleaseTransport", "DeleteTransport",
 		},
-		"G": { // Git/abapGit tools (via ZADT_VSP WebSocket)
-			"GitTypes", "GitExport",
+		"G": { // Git/abapGit tools (export via ZADT_VSP WebSocket, import via ADT)
+			"GitTypes", "GitExport", "GitImport",
 		},
 		"R": { // Report execution tools (via ZADT_VSP WebSocket)
 			"RunReport", "GetVariants", "GetTextElements", "SetTextElements",
@@ -286,10 +317,11 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 		"GetSource":   true,
 		"WriteSource": true,
 
-		// Search tools (3) - foundation
+		// Search tools (4) - foundation
 		"GrepObjects":  true, // Multi-object search (replaces GrepObject)
 		"GrepPackages": true, // Multi-package + recursive (replaces GrepPackage)
 		"SearchObject": true,
+		"SearchCode":   true, // Ranked full-text search over the local source index
 
 		// Primary workflow (1)
 		"EditSource": true,
@@ -307,9 +339,11 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 		"FindDefinition":  true,
 		"FindReferences":  true,
 
-		// Development tools (11)
+		// Development tools (17)
 		"SyntaxCheck":         true,
 		"RunUnitTests":        true,
+		"GetCoverage":         true,  // Unit test coverage with uncovered lines
+		"RunAffectedTests":    true,  // Tests affected by a transport or diff
 		"RunATCCheck":         true,  // Code quality checks
 		"Activate":            true,  // Re-activate objects without editing
 		"ActivatePackage":     true,  // Batch activation of all inactive objects
@@ -318,6 +352,9 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 		"CreatePackage":       true,  // Create local packages ($...)
 		"CreateTable":         true,  // Create DDIC tables from JSON
 		"CompareSource":       true,  // Diff two objects
+		"GetVersions":         true,  // Version history (transport, author, date)
+		"GetVersionSource":    true,  // Source of an old version
+		"DiffVersions":        true,  // Diff two versions
 		"CloneObject":         true,  // Copy object to new name
 		"GetClassInfo":        true,  // Quick class metadata
 
@@ -333,7 +370,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 		"GetSystemInfo":         true, // System ID, release, kernel
 		"GetInstalledComponents": true, // Installed software components
 
-		// Code analysis (7)
+		// Code analysis (9)
 		"GetCallGraph":       true, // Call hierarchy for methods/functions
 		"GetObjectStructure": true, // Object explorer tree
 		"GetCallersOf":       true, // Simplified up traversal
@@ -341,6 +378,8 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 		"AnalyzeCallGraph":   true, // Call graph statistics
 		"CompareCallGraphs":  true, // Compare static vs actual execution
 		"TraceExecution":     true, // Composite RCA tool
+		"AnalyzeImpact":      true, // Transitive dependents from cached graph
+		"ExportGraph":        true, // DOT/GraphML/Mermaid export
 
 		// Runtime errors / Short dumps (2)
 		"ListDumps": true, // List runtime errors (consistent with List* pattern)
@@ -395,9 +434,10 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 		"ListTransports": true, // List transport requests
 		"GetTransport":   true, // Get transport details with objects
 
-		// Git/abapGit Integration (via ZADT_VSP WebSocket)
+		// Git/abapGit Integration (export via ZADT_VSP WebSocket, import via ADT)
 		"GitTypes":  true, // List 158 supported object types
 		"GitExport": true, // Export packages/objects to abapGit ZIP
+		"GitImport": true, // Import abapGit ZIP into a package (via ADT)
 
 		// Report Execution (via ZADT_VSP WebSocket)
 		"RunReport":        true, // Execute reports with params/variants, capture ALV
@@ -803,6 +843,65 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 		), s.handleTraceExecution)
 	}
 
+	// AnalyzeImpact - transitive dependents from the cached object graph
+	if shouldRegister("AnalyzeImpact") {
+		s.mcpServer.AddTool(mcp.NewTool("AnalyzeImpact",
+			mcp.WithDescription("Impact analysis over the cached object graph. Returns every object that transitively depends on a changed object or method (up to N hops), grouped by package and object type. Marks dependents that have unit tests and dependents locked in other transports. Use before changing shared code."),
+			mcp.WithString("object_name",
+				mcp.Required(),
+				mcp.Description("Changed object name (e.g., ZCL_UTIL) or cached node ID of a method"),
+			),
+			mcp.WithString("object_type",
+				mcp.Description("Object type filter (e.g., CLAS, PROG, FUNC). Default: any"),
+			),
+			mcp.WithNumber("max_depth",
+				mcp.Description("Maximum number of hops (default: 3)"),
+			),
+			mcp.WithString("edge_types",
+				mcp.Description("Comma-separated edge types to follow (e.g., CALLS,USES). Default: all"),
+			),
+			mcp.WithString("transport",
+				mcp.Description("Transport carrying the change. Dependents locked in any other transport are flagged"),
+			),
+			mcp.WithBoolean("annotate",
+				mcp.Description("Look up test classes and transport locks in the SAP system (default: true)"),
+			),
+		), s.handleAnalyzeImpact)
+	}
+
+	// ExportGraph - render call graphs, CDS trees or cached package graphs
+	if shouldRegister("ExportGraph") {
+		s.mcpServer.AddTool(mcp.NewTool("ExportGraph",
+			mcp.WithDescription("Export an object graph as DOT (Graphviz), GraphML (Gephi, yEd) or Mermaid flowchart. Sources: 'callgraph' (ADT call hierarchy), 'cds' (CDS dependency tree), 'package' (cached object graph of packages)."),
+			mcp.WithString("source",
+				mcp.Required(),
+				mcp.Description("Graph source: 'callgraph', 'cds' or 'package'"),
+			),
+			mcp.WithString("target",
+				mcp.Required(),
+				mcp.Description("callgraph: ADT object URI; cds: DDLS name; package: comma-separated package names"),
+			),
+			mcp.WithString("format",
+				mcp.Description("Output format: 'mermaid' (default), 'dot' or 'graphml'"),
+			),
+			mcp.WithString("direction",
+				mcp.Description("callgraph only: 'callees' (default) or 'callers'"),
+			),
+			mcp.WithNumber("max_depth",
+				mcp.Description("Maximum depth from the root/packages (default: 3)"),
+			),
+			mcp.WithString("packages",
+				mcp.Description("Keep only nodes in these packages (comma-separated, wildcards like Z*)"),
+			),
+			mcp.WithString("types",
+				mcp.Description("Keep only these object types (comma-separated, e.g. CLAS,PROG)"),
+			),
+			mcp.WithBoolean("collapse",
+				mcp.Description("Collapse nodes into one node per package (default: false)"),
+			),
+		), s.handleExportGraph)
+	}
+
 	// --- Runtime Errors / Short Dumps (RABAX) ---
 
 	// ListDumps (renamed from GetDumps for consistency with List* pattern)
@@ -1075,6 +1174,9 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 			mcp.Required(),
 			mcp.Description("ABAP source code to check"),
 		),
+		mcp.WithString("format",
+			mcp.Description("Output format: 'json' (default), 'sarif' (SARIF 2.1.0) or 'checkstyle' (Checkstyle XML)"),
+		),
 	), s.handleSyntaxCheck)
 	}
 
@@ -1121,9 +1223,63 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 		mcp.WithBoolean("include_long",
 			mcp.Description("Include long duration tests (default: false)"),
 		),
+		mcp.WithString("format",
+			mcp.Description("Output format: 'json' (default), 'junit' (JUnit XML) or 'tap' (Test Anything Protocol)"),
+		),
 	), s.handleRunUnitTests)
 	}
 
+	// GetCoverage - Unit tests with coverage measurement
+	if shouldRegister("GetCoverage") {
+		s.mcpServer.AddTool(mcp.NewTool("GetCoverage",
+			mcp.WithDescription("Run ABAP Unit tests with coverage measurement. Returns statement, branch and procedure coverage per object and method, and the uncovered lines per source file (abapGit file names), so new tests can target them."),
+			mcp.WithString("object_url",
+				mcp.Required(),
+				mcp.Description("ADT URL of the object (e.g., /sap/bc/adt/oo/classes/ZCL_TEST)"),
+			),
+			mcp.WithBoolean("include_dangerous",
+				mcp.Description("Include dangerous risk level tests (default: false)"),
+			),
+			mcp.WithBoolean("include_long",
+				mcp.Description("Include long duration tests (default: false)"),
+			),
+			mcp.WithString("format",
+				mcp.Description("Output format: 'json' (default), 'lcov' (LCOV tracefile) or 'cobertura' (Cobertura XML)"),
+			),
+		), s.handleGetCoverage)
+	}
+
+	// RunAffectedTests - Change-based test selection
+	if shouldRegister("RunAffectedTests") {
+		s.mcpServer.AddTool(mcp.NewTool("RunAffectedTests",
+			mcp.WithDescription("Run only the unit tests affected by a change. Changed objects come from a transport, an object list or abapGit file paths (e.g. git diff --name-only); their dependents are found in the cached object graph, the callers and the where-used list. Classes without test classes are listed as untested."),
+			mcp.WithString("transport",
+				mcp.Description("Transport request whose objects changed (e.g., A4HK900123)"),
+			),
+			mcp.WithString("objects",
+				mcp.Description("Changed objects as TYPE:NAME, comma separated (e.g., CLAS:ZCL_UTIL,INTF:ZIF_UTIL)"),
+			),
+			mcp.WithString("files",
+				mcp.Description("Changed files in abapGit layout, comma or newline separated (e.g., src/zcl_util.clas.abap)"),
+			),
+			mcp.WithNumber("max_depth",
+				mcp.Description("Maximum hops from a changed object (default: 3)"),
+			),
+			mcp.WithBoolean("dry_run",
+				mcp.Description("Only select the tests, do not run them (default: false)"),
+			),
+			mcp.WithBoolean("include_dangerous",
+				mcp.Description("Include dangerous risk level tests (default: false)"),
+			),
+			mcp.WithBoolean("include_long",
+				mcp.Description("Include long duration tests (default: false)"),
+			),
+			mcp.WithString("format",
+				mcp.Description("Output format: 'json' (default), 'junit' (JUnit XML) or 'tap' (Test Anything Protocol)"),
+			),
+		), s.handleRunAffectedTests)
+	}
+
 	// --- ATC (Code Quality) ---
 
 	// RunATCCheck - Convenience tool (combines variant + run + worklist)
@@ -1140,6 +1296,9 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 			mcp.WithNumber("max_results",
 				mcp.Description("Maximum number of findings to return (default: 100)"),
 			),
+			mcp.WithString("format",
+				mcp.Description("Output format: 'json' (default), 'sarif' (SARIF 2.1.0) or 'checkstyle' (Checkstyle XML)"),
+			),
 		), s.handleRunATCCheck)
 	}
 
@@ -1333,6 +1492,111 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 		), s.handleCompareSource)
 	}
 
+	// GetVersions - Version history of an object
+	if shouldRegister("GetVersions") {
+		s.mcpServer.AddTool(mcp.NewTool("GetVersions",
+			mcp.WithDescription("List the version history of an object's source, newest first: version ID, transport request, author, date. Use the IDs with GetVersionSource, DiffVersions and RestoreVersion."),
+			mcp.WithString("object_type",
+				mcp.Required(),
+				mcp.Description("Object type: PROG, CLAS, INTF, FUNC, INCL, DDLS, BDEF, SRVD"),
+			),
+			mcp.WithString("name",
+				mcp.Required(),
+				mcp.Description("Object name"),
+			),
+			mcp.WithString("include",
+				mcp.Description("Class include type for CLAS: main (default), definitions, implementations, macros, testclasses"),
+			),
+			mcp.WithString("parent",
+				mcp.Description("Function group name (required only for FUNC type)"),
+			),
+		), s.handleGetVersions)
+	}
+
+	// GetVersionSource - Source of an old version
+	if shouldRegister("GetVersionSource") {
+		s.mcpServer.AddTool(mcp.NewTool("GetVersionSource",
+			mcp.WithDescription("Get the source of one version from GetVersions. Use method to get only one method of a class version."),
+			mcp.WithString("object_type",
+				mcp.Required(),
+				mcp.Description("Object type: PROG, CLAS, INTF, FUNC, INCL, DDLS, BDEF, SRVD"),
+			),
+			mcp.WithString("name",
+				mcp.Required(),
+				mcp.Description("Object name"),
+			),
+			mcp.WithString("include",
+				mcp.Description("Class include type for CLAS: main (default), definitions, implementations, macros, testclasses"),
+			),
+			mcp.WithString("parent",
+				mcp.Description("Function group name (required only for FUNC type)"),
+			),
+			mcp.WithString("version",
+				mcp.Required(),
+				mcp.Description("Version ID from GetVersions, or 'current' for the current source"),
+			),
+			mcp.WithString("method",
+				mcp.Description("For CLAS only: return only this method's METHOD...ENDMETHOD block"),
+			),
+		), s.handleGetVersionSource)
+	}
+
+	// DiffVersions - Diff two versions of an object
+	if shouldRegister("DiffVersions") {
+		s.mcpServer.AddTool(mcp.NewTool("DiffVersions",
+			mcp.WithDescription("Unified diff between two versions of an object (e.g. what changed in a method since an older version). Use method to compare only one method of a class."),
+			mcp.WithString("object_type",
+				mcp.Required(),
+				mcp.Description("Object type: PROG, CLAS, INTF, FUNC, INCL, DDLS, BDEF, SRVD"),
+			),
+			mcp.WithString("name",
+				mcp.Required(),
+				mcp.Description("Object name"),
+			),
+			mcp.WithString("include",
+				mcp.Description("Class include type for CLAS: main (default), definitions, implementations, macros, testclasses"),
+			),
+			mcp.WithString("parent",
+				mcp.Description("Function group name (required only for FUNC type)"),
+			),
+			mcp.WithString("from_version",
+				mcp.Required(),
+				mcp.Description("Older version ID from GetVersions"),
+			),
+			mcp.WithString("to_version",
+				mcp.Description("Newer version ID from GetVersions (default: current)"),
+			),
+			mcp.WithString("method",
+				mcp.Description("For CLAS only: compare only this method"),
+			),
+		), s.handleDiffVersions)
+	}
+
+	// RestoreVersion - Write an old version back
+	if shouldRegister("RestoreVersion") {
+		s.mcpServer.AddTool(mcp.NewTool("RestoreVersion",
+			mcp.WithDescription("Write an old version back as the current source (via WriteSource: lock, write, syntax check, activate). Supports PROG, CLAS (main source, or one method), INTF, DDLS, BDEF, SRVD."),
+			mcp.WithString("object_type",
+				mcp.Required(),
+				mcp.Description("Object type: PROG, CLAS, INTF, DDLS, BDEF, SRVD"),
+			),
+			mcp.WithString("name",
+				mcp.Required(),
+				mcp.Description("Object name"),
+			),
+			mcp.WithString("version",
+				mcp.Required(),
+				mcp.Description("Version ID from GetVersions"),
+			),
+			mcp.WithString("method",
+				mcp.Description("For CLAS only: restore only this method"),
+			),
+			mcp.WithString("transport",
+				mcp.Description("Transport request number"),
+			),
+		), s.handleRestoreVersion)
+	}
+
 	// CloneObject - Copy object to new name
 	if shouldRegister("CloneObject") {
 		s.mcpServer.AddTool(mcp.NewTool("CloneObject",
@@ -1583,7 +1847,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 	// DeployFromFile (Recommended)
 	if shouldRegister("DeployFromFile") {
 		s.mcpServer.AddTool(mcp.NewTool("DeployFromFile",
-		mcp.WithDescription("✅ RECOMMENDED - Smart deploy from file: auto-detects if object exists and creates/updates accordingly. Solves token limit problem for large generated files (ML models, 3948+ lines). Example: DeployFromFile(file_path=\"/path/to/zcl_ml_iris.clas.abap\", package_name=\"$ZAML_IRIS\") deploys any size file. Workflow: Parse → Check existence → Create or Update → Lock → SyntaxCheck → Write → Unlock → Activate. Supports .clas.abap, .prog.abap, .intf.abap, .fugr.abap, .func.abap. Use this for all file-based deployments."),
+		mcp.WithDescription("✅ RECOMMENDED - Smart deploy from file: auto-detects if object exists and creates/updates accordingly. Solves token limit problem for large generated files (ML models, 3948+ lines). Example: DeployFromFile(file_path=\"/path/to/zcl_ml_iris.clas.abap\", package_name=\"$ZAML_IRIS\") deploys any size file. Workflow: Parse → Check existence → Create or Update → Lock → SyntaxCheck → Write → Unlock → Activate. Supports .clas.abap, .prog.abap, .intf.abap, .fugr.abap, .func.abap, and abapGit DDIC files (.tabl.xml, .dtel.xml, .doma.xml, .ttyp.xml, .msag.xml). Use this for all file-based deployments."),
 		mcp.WithString("file_path",
 			mcp.Required(),
 			mcp.Description("Absolute path to ABAP source file"),
@@ -1602,7 +1866,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 	// SaveToFile
 	if shouldRegister("SaveToFile") {
 		s.mcpServer.AddTool(mcp.NewTool("SaveToFile",
-		mcp.WithDescription("Save ABAP object source to local file (SAP → File). Enables BIDIRECTIONAL SYNC WORKFLOW: (1) SaveToFile downloads object from SAP, (2) edit locally with vim/VS Code/AI assistants, (3) DeployFromFile uploads changes back to SAP. Example: SaveToFile(objType=\"CLAS/OC\", objectName=\"ZCL_ML_IRIS\", outputPath=\"./src/\") creates ./src/zcl_ml_iris.clas.abap. Then edit locally and use DeployFromFile to sync back. Recommended for iterative development. Auto-determines file extension."),
+		mcp.WithDescription("Save ABAP object source to local file (SAP → File). Enables BIDIRECTIONAL SYNC WORKFLOW: (1) SaveToFile downloads object from SAP, (2) edit locally with vim/VS Code/AI assistants, (3) DeployFromFile uploads changes back to SAP. Example: SaveToFile(objType=\"CLAS/OC\", objectName=\"ZCL_ML_IRIS\", outputPath=\"./src/\") creates ./src/zcl_ml_iris.clas.abap. Then edit locally and use DeployFromFile to sync back. Recommended for iterative development. Auto-determines file extension. Classes, interfaces and programs also get their abapGit .xml metadata file (description, language, fixed-point arithmetic, text pool, class exposure), so the folder can be pulled with abapGit."),
 		mcp.WithString("objType",
 			mcp.Required(),
 			mcp.Description("Object type: CLAS/OC (class), PROG/P (program), INTF/OI (interface), FUGR/F (function group), FUGR/FF (function module)"),
@@ -1750,6 +2014,38 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 		s.registerGrepPackages()
 	}
 
+	// SearchCode - full-text search over the local source index
+	if shouldRegister("SearchCode") {
+		s.mcpServer.AddTool(mcp.NewTool("SearchCode",
+			mcp.WithDescription("Fast ranked full-text search over a local index of ABAP sources. The given packages are indexed on first use and re-synced incrementally from ADT change timestamps on every search; without packages, the result's indexed_at shows how old the hits are. Supports \"exact phrases\", prefix* terms and AND/OR/NOT. Use live=true to grep the SAP system directly with a regex instead."),
+			mcp.WithString("query",
+				mcp.Required(),
+				mcp.Description("Full-text query, e.g. 'zcl_order', '\"read table\" AND binary', 'bapi_po*'. With live=true: a regex."),
+			),
+			mcp.WithString("packages",
+				mcp.Description("Comma-separated packages to search (and index if needed). Empty = search the whole index."),
+			),
+			mcp.WithBoolean("include_subpackages",
+				mcp.Description("Also search subpackages (default: false)"),
+			),
+			mcp.WithString("object_types",
+				mcp.Description("Comma-separated object types to search: PROG, INCL, CLAS, INTF, FUNC, DDLS, BDEF, SRVD"),
+			),
+			mcp.WithNumber("max_results",
+				mcp.Description("Maximum number of objects returned (default: 50)"),
+			),
+			mcp.WithNumber("context_lines",
+				mcp.Description("Lines of context before/after each matching line (default: 0)"),
+			),
+			mcp.WithBoolean("refresh",
+				mcp.Description("Rebuild the index of the packages, re-reading every source instead of only changed ones (default: false)"),
+			),
+			mcp.WithBoolean("live",
+				mcp.Description("Skip the index and grep the sources on the SAP system with a regex (slow; default: false)"),
+			),
+		), s.handleSearchCode)
+	}
+
 
 	// --- Code Intelligence Tools ---
 
@@ -2210,7 +2506,7 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 		), s.handleDeleteTransport)
 	}
 
-	// --- Git/abapGit Integration (via ZADT_VSP WebSocket) ---
+	// --- Git/abapGit Integration (export via ZADT_VSP WebSocket, import via ADT) ---
 
 	// GitTypes
 	if shouldRegister("GitTypes") {
@@ -2238,6 +2534,29 @@ func (s *Server) registerTools(mode string, disabledGroups string, toolsConfig m
 		), s.handleGitExport)
 	}
 
+	// GitImport
+	if shouldRegister("GitImport") {
+		s.mcpServer.AddTool(mcp.NewTool("GitImport",
+			mcp.WithDescription("Import an abapGit ZIP (e.g. from GitExport) into a package. Objects are created or updated in dependency order (from .abapgit.xml and the .xml metadata), written inactive and activated in one batch. Returns a result per object. Supports CLAS, INTF, PROG, DDLS, BDEF, SRVD and the DDIC types DOMA, DTEL, TABL, TTYP; other types are reported as skipped. Uses ADT only (no ZADT_VSP needed)."),
+			mcp.WithString("zip_path",
+				mcp.Description("Path to the abapGit ZIP file"),
+			),
+			mcp.WithString("zip_base64",
+				mcp.Description("Base64-encoded ZIP (alternative to zip_path)"),
+			),
+			mcp.WithString("package",
+				mcp.Required(),
+				mcp.Description("Target package for new objects (e.g., '$ZRAY')"),
+			),
+			mcp.WithString("transport",
+				mcp.Description("Transport request number (for transportable packages)"),
+			),
+			mcp.WithBoolean("dry_run",
+				mcp.Description("Only list the objects in import order without changing anything (default: false)"),
+			),
+		), s.handleGitImport)
+	}
+
 	// --- Report Execution Tools (via ZADT_VSP WebSocket) ---
 
 	// RunReport
@@ -2573,13 +2892,17 @@ func (s *Server) requireActiveAMDPSession() *mcp.CallToolResult {
 // - handlers_read.go: GetProgram, GetClass, GetTable, etc.
 // - handlers_system.go: GetSystemInfo, GetFeatures, etc.
 // - handlers_analysis.go: GetCallGraph, TraceExecution, etc.
+// - handlers_graph.go: AnalyzeImpact, ExportGraph
+// - handlers_offline.go: local source cache for offline mode and connection fallback
+// - handlers_codesearch.go: SearchCode
 // - handlers_diagnostics.go: ListDumps, ListTraces, etc.
 // - handlers_devtools.go: SyntaxCheck, Activate, ATC, etc.
 // - handlers_crud.go: Lock, Create, Update, Delete, etc.
+// - handlers_versions.go: GetVersions, GetVersionSource, DiffVersions, RestoreVersion
 // - handlers_debug.go: SetBreakpoint, DebuggerListen, etc.
 // - handlers_amdp.go: AMDPDebugger* handlers
 // - handlers_ui5.go: UI5ListApps, UI5GetApp, etc.
-// - handlers_git.go: GitTypes, GitExport
+// - handlers_git.go: GitTypes, GitExport, GitImport
 // - handlers_report.go: RunReport, GetVariants, etc.
 // - handlers_install.go: InstallZADTVSP, InstallAbapGit, etc.
 // - handlers_transport.go: ListTransports, GetTransport, etc.
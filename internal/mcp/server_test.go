@@ -1,9 +1,14 @@
 package mcp
 
 import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oisee/vibing-steampunk/pkg/adt"
 )
 
 func TestNewToolResultError(t *testing.T) {
@@ -82,3 +87,170 @@ func TestNewServer(t *testing.T) {
 		t.Error("ADT client should not be nil")
 	}
 }
+
+func TestOfflineMode(t *testing.T) {
+	ctx := context.Background()
+	cfg := &Config{
+		BaseURL:   "http://127.0.0.1:1",
+		Username:  "testuser",
+		Password:  "testpass",
+		CachePath: filepath.Join(t.TempDir(), "graph.db"),
+	}
+	server := NewServer(cfg)
+
+	call := func(handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]interface{}) *mcp.CallToolResult {
+		var req mcp.CallToolRequest
+		req.Params.Arguments = args
+		result, err := handler(ctx, req)
+		if err != nil {
+			t.Fatalf("handler returned error: %v", err)
+		}
+		return result
+	}
+	text := func(r *mcp.CallToolResult) string {
+		return r.Content[0].(mcp.TextContent).Text
+	}
+
+	getArgs := map[string]interface{}{"object_type": "PROG", "name": "ztest"}
+
+	// Connection fails and nothing is cached yet
+	if r := call(server.handleGetSource, getArgs); !r.IsError || !strings.Contains(text(r), "SAP system unreachable") {
+		t.Errorf("Expected unreachable error, got %q", text(r))
+	}
+
+	server.cacheSource(ctx, "PROG", "ZTEST", &adt.GetSourceOptions{}, "REPORT ztest.\nWRITE 'hi'.")
+
+	// Automatic fallback to the cache
+	r := call(server.handleGetSource, getArgs)
+	if r.IsError || !strings.HasPrefix(text(r), "[SAP system unreachable: served from local cache, data is") ||
+		!strings.HasSuffix(text(r), "REPORT ztest.\nWRITE 'hi'.") {
+		t.Errorf("Expected cached source with age notice, got %q", text(r))
+	}
+
+	grepArgs := map[string]interface{}{
+		"object_urls": []interface{}{"/sap/bc/adt/programs/programs/ZTEST"},
+		"pattern":     "write",
+	}
+	if r := call(server.handleGrepObjects, grepArgs); r.IsError || !strings.HasPrefix(text(r), "[SAP system unreachable:") {
+		t.Errorf("Expected grep served from the cache, got %q", text(r))
+	}
+
+	cfg.Offline = true
+	r = call(server.handleGrepObjects, map[string]interface{}{
+		"object_urls":      []interface{}{"/sap/bc/adt/programs/programs/ZTEST"},
+		"pattern":          "write",
+		"case_insensitive": true,
+	})
+	if r.IsError || !strings.HasPrefix(text(r), "[offline mode:") || !strings.Contains(text(r), `"totalMatches": 1`) {
+		t.Errorf("Expected offline grep hit, got %q", text(r))
+	}
+}
+
+func TestWriteThroughRequiresCachePath(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	server := NewServer(&Config{BaseURL: "http://127.0.0.1:1", Username: "testuser", Password: "testpass"})
+	server.cacheSource(context.Background(), "PROG", "ZTEST", &adt.GetSourceOptions{}, "REPORT ztest.")
+	if _, err := os.Stat(filepath.Join(dir, ".cache")); !os.IsNotExist(err) {
+		t.Errorf("Expected no cache to be created without a cache path, got %v", err)
+	}
+}
+
+func TestCallTool(t *testing.T) {
+	ctx := context.Background()
+	cfg := &Config{
+		BaseURL:     "http://127.0.0.1:1",
+		Username:    "testuser",
+		Password:    "testpass",
+		Mode:        "expert",
+		ReadOnly:    true,
+		CachePath:   filepath.Join(t.TempDir(), "graph.db"),
+		ToolsConfig: map[string]bool{"RunQuery": false},
+	}
+	server := NewServer(cfg)
+	server.cacheSource(ctx, "PROG", "ZTEST", &adt.GetSourceOptions{}, "REPORT ztest.")
+
+	// Served through the registry like any client call (cache fallback)
+	out, err := server.CallToolValue(ctx, "GetSource", map[string]interface{}{"object_type": "PROG", "name": "ZTEST"})
+	if err != nil || !strings.HasSuffix(out.(string), "REPORT ztest.") {
+		t.Errorf("GetSource: got %v, %v", out, err)
+	}
+
+	for _, name := range []string{"NoSuchTool", "RunQuery"} {
+		if _, err := server.CallTool(ctx, name, nil); err == nil || !strings.Contains(err.Error(), "is not available") {
+			t.Errorf("%s: expected not available error, got %v", name, err)
+		}
+	}
+
+	// Safety settings apply to in-process calls too
+	_, err = server.CallToolValue(ctx, "WriteSource", map[string]interface{}{"object_type": "PROG", "name": "ZTEST", "source": "REPORT ztest."})
+	if err == nil || !strings.Contains(err.Error(), "blocked by safety configuration") {
+		t.Errorf("expected safety error, got %v", err)
+	}
+}
+
+func TestReportFormat(t *testing.T) {
+	ctx := context.Background()
+	server := NewServer(&Config{BaseURL: "http://127.0.0.1:1", Username: "u", Password: "p", Mode: "expert"})
+
+	// Unsupported formats are rejected before the system is called
+	tests := []struct {
+		tool   string
+		format string
+	}{
+		{"RunUnitTests", "sarif"},
+		{"RunATCCheck", "junit"},
+		{"SyntaxCheck", "html"},
+		{"GetCoverage", "junit"},
+		{"RunAffectedTests", "sarif"},
+	}
+	for _, tt := range tests {
+		args := map[string]interface{}{"object_url": "/sap/bc/adt/programs/programs/ZTEST", "content": "REPORT ztest.", "format": tt.format}
+		_, err := server.CallToolValue(ctx, tt.tool, args)
+		if err == nil || !strings.Contains(err.Error(), "unsupported format") {
+			t.Errorf("%s format=%s: expected unsupported format error, got %v", tt.tool, tt.format, err)
+		}
+	}
+}
+
+func TestCoverageSummary(t *testing.T) {
+	main := "/sap/bc/adt/oo/classes/zcl_foo/source/main"
+	result := &adt.UnitTestResult{
+		Classes: []adt.UnitTestClass{{Name: "LTCL_TEST", TestMethods: []adt.UnitTestMethod{
+			{Name: "OK"},
+			{Name: "FAILS", Alerts: []adt.UnitTestAlert{{Kind: "failedAssertion"}}},
+		}}},
+		Coverage: &adt.CoverageResult{Objects: []adt.CoverageObject{{
+			Name:       "ZCL_FOO",
+			Type:       "CLAS/OC",
+			Statements: adt.CoverageCounter{Total: 4, Executed: 3},
+			Methods: []adt.CoverageMethod{{
+				Name:       "CALCULATE",
+				URI:        main + "#start=10,2",
+				Statements: adt.CoverageCounter{Total: 4, Executed: 3},
+				Lines:      []adt.CoverageLine{{URI: main, Line: 14}, {URI: main, Line: 11, Hits: 2}, {URI: main, Line: 12}},
+			}},
+		}}},
+	}
+
+	out := coverageSummary(result)
+	if out.Tests != 2 || out.Failed != 1 || len(out.Objects) != 1 {
+		t.Fatalf("summary = %+v", out)
+	}
+	obj := out.Objects[0]
+	if obj.Statements != "75.0% (3/4)" || obj.Branches != "100.0% (0/0)" {
+		t.Errorf("counters = %q, %q", obj.Statements, obj.Branches)
+	}
+	if m := obj.Methods[0]; m.File != "zcl_foo.clas.abap" || m.Line != 10 {
+		t.Errorf("method = %+v", m)
+	}
+	lines := obj.UncoveredLines["zcl_foo.clas.abap"]
+	if len(lines) != 2 || lines[0] != 12 || lines[1] != 14 {
+		t.Errorf("uncovered lines = %v", obj.UncoveredLines)
+	}
+}
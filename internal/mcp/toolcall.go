@@ -0,0 +1,77 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// CallTool calls a registered tool in-process, exactly as an MCP client would:
+// only tools registered for the server's mode, disabled groups and tool
+// configuration can be called, and the ADT client's safety settings apply.
+func (s *Server) CallTool(ctx context.Context, name string, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	message, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": mcp.JSONRPC_VERSION,
+		"id":      1,
+		"method":  string(mcp.MethodToolsCall),
+		"params": map[string]interface{}{
+			"name":      name,
+			"arguments": args,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encoding arguments for %s: %w", name, err)
+	}
+
+	switch response := s.mcpServer.HandleMessage(ctx, message).(type) {
+	case mcp.JSONRPCResponse:
+		switch result := response.Result.(type) {
+		case *mcp.CallToolResult:
+			return result, nil
+		case mcp.CallToolResult:
+			return &result, nil
+		}
+		return nil, fmt.Errorf("unexpected result from %s: %T", name, response.Result)
+	case mcp.JSONRPCError:
+		if response.Error.Code == mcp.INVALID_PARAMS && strings.Contains(response.Error.Message, "not found") {
+			return nil, fmt.Errorf("tool %s is not available (unknown, or disabled by mode or configuration)", name)
+		}
+		return nil, errors.New(response.Error.Message)
+	default:
+		return nil, fmt.Errorf("unexpected response from %s: %T", name, response)
+	}
+}
+
+// CallToolValue calls a tool like CallTool and returns its text output,
+// decoded from JSON when possible. A tool error result is returned as error.
+// It can be used as a workflow tool caller.
+func (s *Server) CallToolValue(ctx context.Context, name string, args map[string]interface{}) (interface{}, error) {
+	result, err := s.CallTool(ctx, name, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var texts []string
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+	text := strings.Join(texts, "\n")
+	if result.IsError {
+		return nil, fmt.Errorf("%s: %s", name, text)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(text), &value); err == nil {
+		return value, nil
+	}
+	return text, nil
+}
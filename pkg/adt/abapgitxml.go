@@ -0,0 +1,400 @@
+package adt
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// --- abapGit XML Metadata ---
+//
+// abapGit keeps the non-source attributes of classes, interfaces and programs
+// in a .xml file next to the .abap source (zcl_foo.clas.xml, ...). The values
+// are read through ADT (object properties and text elements), so no abapGit
+// or ZADT_VSP installation is needed on the system.
+
+// ObjectMetadata holds the attributes abapGit serializes for an object.
+type ObjectMetadata struct {
+	Type                 string          `json:"type"` // abapGit type: CLAS, INTF or PROG
+	Name                 string          `json:"name"`
+	Description          string          `json:"description,omitempty"`
+	Language             string          `json:"language,omitempty"`    // Original language (SAP code, e.g. E)
+	Category             string          `json:"category,omitempty"`    // CLAS: VSEOCLASS-CATEGORY (00 = general)
+	Exposure             string          `json:"exposure,omitempty"`    // CLAS/INTF: 0 private, 1 protected, 2 public
+	ProgramType          string          `json:"programType,omitempty"` // PROG: PROGDIR-SUBC (1 = executable)
+	Final                bool            `json:"final,omitempty"`
+	Abstract             bool            `json:"abstract,omitempty"`
+	WithUnitTests        bool            `json:"withUnitTests,omitempty"`
+	FixedPointArithmetic bool            `json:"fixedPointArithmetic"`
+	UnicodeChecks        bool            `json:"unicodeChecks"`
+	TextPool             []TextPoolEntry `json:"textPool,omitempty"`
+}
+
+// TextPoolEntry is one text element (TEXTPOOL row) of a program or class.
+type TextPoolEntry struct {
+	ID     string `json:"id"` // R title, I text symbol, S selection text
+	Key    string `json:"key,omitempty"`
+	Entry  string `json:"entry"`
+	Length int    `json:"length"`
+}
+
+// abapGitMetadataTypes maps the object types with abapGit metadata to abapGit types.
+var abapGitMetadataTypes = map[CreatableObjectType]string{
+	ObjectTypeClass:     "CLAS",
+	ObjectTypeInterface: "INTF",
+	ObjectTypeProgram:   "PROG",
+}
+
+// HasAbapGitMetadata reports whether GetObjectMetadata supports objects of this type.
+func HasAbapGitMetadata(objType CreatableObjectType) bool {
+	_, ok := abapGitMetadataTypes[objType]
+	return ok
+}
+
+// abapClassCategories maps ADT class categories to VSEOCLASS-CATEGORY.
+var abapClassCategories = map[string]string{
+	"generalObjectType":             "00",
+	"exitClass":                     "01",
+	"testClass":                     "05",
+	"persistentClass":               "10",
+	"factoryForPersistentClass":     "11",
+	"statusClassForPersistentClass": "12",
+	"exceptionClass":                "40",
+	"areaClass":                     "45",
+}
+
+// abapProgramTypes maps ADT program types to PROGDIR-SUBC.
+var abapProgramTypes = map[string]string{
+	"executableProgram": "1",
+	"include":           "I",
+	"modulePool":        "M",
+	"functionGroup":     "F",
+	"subroutinePool":    "S",
+	"interfacePool":     "J",
+	"classPool":         "K",
+	"typePool":          "T",
+}
+
+// sapLanguages maps ISO language codes (as returned by ADT) to the one
+// character SAP language keys of the standard languages (table T002).
+// The keys are case-sensitive: A is Arabic, a is Afrikaans.
+var sapLanguages = map[string]string{
+	"SR": "0", "ZH": "1", "TH": "2", "KO": "3", "RO": "4", "SL": "5",
+	"HR": "6", "MS": "7", "UK": "8", "ET": "9", "AR": "A", "HE": "B",
+	"CS": "C", "DE": "D", "EN": "E", "FR": "F", "EL": "G", "HU": "H",
+	"IT": "I", "JA": "J", "DA": "K", "PL": "L", "ZF": "M", "NL": "N",
+	"NO": "O", "PT": "P", "SK": "Q", "RU": "R", "ES": "S", "TR": "T",
+	"FI": "U", "SV": "V", "BG": "W", "LT": "X", "LV": "Y", "Z1": "Z",
+	"AF": "a", "IS": "b", "CA": "c", "SH": "d", "ID": "i",
+}
+
+// adtObjectProperties holds the attributes of the ADT object document
+// (class:abapClass, intf:abapInterface, program:abapProgram).
+type adtObjectProperties struct {
+	Description    string `xml:"description,attr"`
+	MasterLanguage string `xml:"masterLanguage,attr"`
+	Language       string `xml:"language,attr"`
+	Final          string `xml:"final,attr"`
+	Abstract       string `xml:"abstract,attr"`
+	Visibility     string `xml:"visibility,attr"`
+	Category       string `xml:"category,attr"`
+	ProgramType    string `xml:"programType,attr"`
+	FixPointArith  string `xml:"fixPointArithmetic,attr"`
+	UnicodeCheck   string `xml:"activeUnicodeCheck,attr"`
+	Includes       []struct {
+		IncludeType string `xml:"includeType,attr"`
+	} `xml:"include"`
+}
+
+// GetObjectMetadata reads the abapGit metadata of a class, interface or program.
+//
+// Workflow: GET object properties → GET text elements (symbols, and selection texts for programs)
+//
+// Missing text elements are not an error; the text pool is then empty.
+func (c *Client) GetObjectMetadata(ctx context.Context, objType CreatableObjectType, name string) (*ObjectMetadata, error) {
+	if err := c.checkSafety(OpRead, "GetObjectMetadata"); err != nil {
+		return nil, err
+	}
+
+	gitType, ok := abapGitMetadataTypes[objType]
+	if !ok {
+		return nil, fmt.Errorf("no abapGit metadata for object type %s", objType)
+	}
+
+	name = strings.ToUpper(name)
+	objectURL := GetObjectURL(objType, name, "")
+	resp, err := c.transport.Request(ctx, objectURL, &RequestOptions{
+		Method: http.MethodGet,
+		Accept: "application/*",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting %s %s: %w", gitType, name, err)
+	}
+
+	var props adtObjectProperties
+	if err := xml.Unmarshal(resp.Body, &props); err != nil {
+		return nil, fmt.Errorf("parsing %s %s: %w", gitType, name, err)
+	}
+
+	meta := &ObjectMetadata{
+		Type:                 gitType,
+		Name:                 name,
+		Description:          props.Description,
+		FixedPointArithmetic: props.FixPointArith == "true",
+		UnicodeChecks:        props.UnicodeCheck == "true",
+	}
+	if meta.Language, err = sapLanguage(props.MasterLanguage, props.Language); err != nil {
+		return nil, fmt.Errorf("%s %s: %w", gitType, name, err)
+	}
+
+	switch objType {
+	case ObjectTypeClass:
+		meta.Category = abapClassCategories[props.Category]
+		meta.Exposure = abapExposure(props.Visibility)
+		meta.Final = props.Final == "true"
+		meta.Abstract = props.Abstract == "true"
+		for _, inc := range props.Includes {
+			if inc.IncludeType == string(ClassIncludeTestClasses) {
+				meta.WithUnitTests = true
+			}
+		}
+	case ObjectTypeInterface:
+		meta.Exposure = abapExposure(props.Visibility)
+	case ObjectTypeProgram:
+		meta.ProgramType = abapProgramTypes[props.ProgramType]
+		if meta.ProgramType == "" {
+			meta.ProgramType = "1"
+		}
+		if meta.Description != "" {
+			meta.TextPool = append(meta.TextPool, TextPoolEntry{ID: "R", Entry: meta.Description, Length: len([]rune(meta.Description))})
+		}
+	}
+
+	if objType == ObjectTypeClass || objType == ObjectTypeProgram {
+		kind := "programs"
+		if objType == ObjectTypeClass {
+			kind = "classes"
+		}
+		symbols, err := c.getTextElements(ctx, kind, name, "symbols")
+		if err != nil {
+			return nil, err
+		}
+		meta.TextPool = append(meta.TextPool, parseTextElements("I", symbols)...)
+
+		if objType == ObjectTypeProgram {
+			selections, err := c.getTextElements(ctx, kind, name, "selections")
+			if err != nil {
+				return nil, err
+			}
+			meta.TextPool = append(meta.TextPool, parseTextElements("S", selections)...)
+		}
+	}
+
+	return meta, nil
+}
+
+// getTextElements reads one text element source (symbols or selections).
+// Objects without text elements return an empty source.
+func (c *Client) getTextElements(ctx context.Context, kind, name, source string) (string, error) {
+	path := fmt.Sprintf("/sap/bc/adt/textelements/%s/%s/source/%s", kind, url.PathEscape(strings.ToLower(name)), source)
+	resp, err := c.transport.Request(ctx, path, &RequestOptions{
+		Method: http.MethodGet,
+		Accept: "application/vnd.sap.adt.textelements." + source + ".v1, text/plain",
+	})
+	if err != nil {
+		if IsNotFoundError(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("getting text %s of %s: %w", source, name, err)
+	}
+	return string(resp.Body), nil
+}
+
+// parseTextElements parses an ADT text element source into text pool entries.
+// Each entry is a KEY=text line, optionally preceded by @MaxLength:n.
+func parseTextElements(id, source string) []TextPoolEntry {
+	var entries []TextPoolEntry
+	maxLength := 0
+	for _, line := range strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "@") {
+			if v, ok := strings.CutPrefix(line, "@MaxLength:"); ok {
+				maxLength, _ = strconv.Atoi(strings.TrimSpace(v))
+			}
+			continue
+		}
+		key, text, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		entry := TextPoolEntry{ID: id, Key: strings.ToUpper(strings.TrimSpace(key)), Entry: text}
+		if id == "S" {
+			// Selection texts keep abapGit's 8-character prefix (DDIC reference flag)
+			entry.Entry = "        " + text
+		}
+		entry.Length = max(maxLength, len([]rune(entry.Entry)))
+		entries = append(entries, entry)
+		maxLength = 0
+	}
+	return entries
+}
+
+// abapExposure maps an ADT visibility to VSEOCLASS-EXPOSURE.
+func abapExposure(visibility string) string {
+	switch visibility {
+	case "private":
+		return "0"
+	case "protected":
+		return "1"
+	default:
+		return "2"
+	}
+}
+
+// sapLanguage returns the SAP language key of the first non-empty code, an
+// ISO code or a SAP key itself, and E if all codes are empty. Codes that are
+// not in sapLanguages are an error.
+func sapLanguage(codes ...string) (string, error) {
+	for _, code := range codes {
+		if code == "" {
+			continue
+		}
+		if key, ok := sapLanguages[strings.ToUpper(code)]; ok {
+			return key, nil
+		}
+		if isoLanguage(code) != "" {
+			return code, nil
+		}
+		return "", fmt.Errorf("unknown language %q", code)
+	}
+	return "E", nil
+}
+
+// isoLanguage returns the ISO code of a SAP language key such as E, or ""
+// if the key is unknown. Known ISO codes are returned in upper case.
+func isoLanguage(key string) string {
+	if len(key) != 1 {
+		if _, ok := sapLanguages[strings.ToUpper(key)]; ok {
+			return strings.ToUpper(key)
+		}
+		return ""
+	}
+	for iso, k := range sapLanguages {
+		if k == key {
+			return iso
+		}
+	}
+	return ""
+}
+
+// abapGitFlag returns X for true, as abapGit writes ABAP booleans.
+func abapGitFlag(b bool) string {
+	if b {
+		return "X"
+	}
+	return ""
+}
+
+// BuildAbapGitXML returns the abapGit .xml file content for meta.
+// Empty values are left out, as abapGit does.
+func BuildAbapGitXML(meta *ObjectMetadata) (string, error) {
+	var sb strings.Builder
+	elem := func(indent, name, value string) {
+		if value != "" {
+			sb.WriteString(fmt.Sprintf("%s<%s>%s</%s>\n", indent, name, escapeXML(value), name))
+		}
+	}
+
+	sb.WriteString(`<?xml version="1.0" encoding="utf-8"?>` + "\n")
+	sb.WriteString(fmt.Sprintf(`<abapGit version="v1.0.0" serializer="LCL_OBJECT_%s" serializer_version="v1.0.0">`+"\n", meta.Type))
+	sb.WriteString(` <asx:abap xmlns:asx="http://www.sap.com/abapxml" version="1.0">` + "\n")
+	sb.WriteString("  <asx:values>\n")
+
+	switch meta.Type {
+	case "CLAS":
+		sb.WriteString("   <VSEOCLASS>\n")
+		elem("    ", "CLSNAME", meta.Name)
+		elem("    ", "LANGU", meta.Language)
+		elem("    ", "DESCRIPT", meta.Description)
+		if meta.Category != "00" {
+			elem("    ", "CATEGORY", meta.Category)
+		}
+		elem("    ", "EXPOSURE", meta.Exposure)
+		elem("    ", "STATE", "1")
+		elem("    ", "CLSFINAL", abapGitFlag(meta.Final))
+		elem("    ", "CLSABSTRCT", abapGitFlag(meta.Abstract))
+		elem("    ", "CLSCCINCL", "X")
+		elem("    ", "FIXPT", abapGitFlag(meta.FixedPointArithmetic))
+		elem("    ", "UNICODE", abapGitFlag(meta.UnicodeChecks))
+		elem("    ", "WITH_UNIT_TESTS", abapGitFlag(meta.WithUnitTests))
+		sb.WriteString("   </VSEOCLASS>\n")
+	case "INTF":
+		sb.WriteString("   <VSEOINTERF>\n")
+		elem("    ", "CLSNAME", meta.Name)
+		elem("    ", "LANGU", meta.Language)
+		elem("    ", "DESCRIPT", meta.Description)
+		elem("    ", "EXPOSURE", meta.Exposure)
+		elem("    ", "STATE", "1")
+		elem("    ", "UNICODE", abapGitFlag(meta.UnicodeChecks))
+		sb.WriteString("   </VSEOINTERF>\n")
+	case "PROG":
+		sb.WriteString("   <PROGDIR>\n")
+		elem("    ", "NAME", meta.Name)
+		elem("    ", "SUBC", meta.ProgramType)
+		elem("    ", "RLOAD", meta.Language)
+		elem("    ", "FIXPT", abapGitFlag(meta.FixedPointArithmetic))
+		elem("    ", "UCCHECK", abapGitFlag(meta.UnicodeChecks))
+		sb.WriteString("   </PROGDIR>\n")
+	default:
+		return "", fmt.Errorf("unsupported abapGit type %s", meta.Type)
+	}
+
+	if len(meta.TextPool) > 0 {
+		sb.WriteString("   <TPOOL>\n")
+		for _, e := range meta.TextPool {
+			sb.WriteString("    <item>\n")
+			elem("     ", "ID", e.ID)
+			elem("     ", "KEY", e.Key)
+			elem("     ", "ENTRY", e.Entry)
+			elem("     ", "LENGTH", strconv.Itoa(e.Length))
+			sb.WriteString("    </item>\n")
+		}
+		sb.WriteString("   </TPOOL>\n")
+	}
+
+	sb.WriteString("  </asx:values>\n </asx:abap>\n</abapGit>\n")
+	return sb.String(), nil
+}
+
+// BuildAbapGitRepoXML returns a .abapgit.xml for a repository whose objects
+// are all in startingFolder (e.g. / or /src/), with prefix folder logic.
+func BuildAbapGitRepoXML(language, startingFolder string) string {
+	if language == "" {
+		language = "E"
+	}
+	if startingFolder == "" {
+		startingFolder = "/"
+	}
+	return `<?xml version="1.0" encoding="utf-8"?>
+<asx:abap xmlns:asx="http://www.sap.com/abapxml" version="1.0">
+ <asx:values>
+  <DATA>
+   <MASTER_LANGUAGE>` + escapeXML(language) + `</MASTER_LANGUAGE>
+   <STARTING_FOLDER>` + escapeXML(startingFolder) + `</STARTING_FOLDER>
+   <FOLDER_LOGIC>PREFIX</FOLDER_LOGIC>
+   <IGNORE>
+    <item>/.gitignore</item>
+    <item>/LICENSE</item>
+    <item>/README.md</item>
+   </IGNORE>
+  </DATA>
+ </asx:values>
+</asx:abap>
+`
+}
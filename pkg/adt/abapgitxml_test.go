@@ -0,0 +1,193 @@
+package adt
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseTextElements(t *testing.T) {
+	symbols := "@MaxLength:20\n001=Hello\n002=World\n"
+	entries := parseTextElements("I", symbols)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0] != (TextPoolEntry{ID: "I", Key: "001", Entry: "Hello", Length: 20}) {
+		t.Errorf("entry 0 = %+v", entries[0])
+	}
+	if entries[1].Length != 5 {
+		t.Errorf("entry 1 length = %d, want 5 (no @MaxLength)", entries[1].Length)
+	}
+
+	selections := parseTextElements("S", "p_file=File name\r\n")
+	if len(selections) != 1 || selections[0].Key != "P_FILE" || selections[0].Entry != "        File name" {
+		t.Errorf("selections = %+v", selections)
+	}
+}
+
+func TestBuildAbapGitXML(t *testing.T) {
+	xml, err := BuildAbapGitXML(&ObjectMetadata{
+		Type:                 "PROG",
+		Name:                 "ZREPORT",
+		Description:          "Report <A&B>",
+		Language:             "E",
+		ProgramType:          "1",
+		FixedPointArithmetic: true,
+		UnicodeChecks:        true,
+		TextPool: []TextPoolEntry{
+			{ID: "R", Entry: "Report <A&B>", Length: 12},
+			{ID: "I", Key: "001", Entry: "Hello", Length: 20},
+		},
+	})
+	if err != nil {
+		t.Fatalf("BuildAbapGitXML failed: %v", err)
+	}
+	for _, want := range []string{
+		`serializer="LCL_OBJECT_PROG"`,
+		"<NAME>ZREPORT</NAME>",
+		"<SUBC>1</SUBC>",
+		"<RLOAD>E</RLOAD>",
+		"<FIXPT>X</FIXPT>",
+		"<UCCHECK>X</UCCHECK>",
+		"<ENTRY>Report &lt;A&amp;B&gt;</ENTRY>",
+		"<KEY>001</KEY>",
+		"<LENGTH>20</LENGTH>",
+	} {
+		if !strings.Contains(xml, want) {
+			t.Errorf("missing %s in:\n%s", want, xml)
+		}
+	}
+	if strings.Contains(xml, "<KEY></KEY>") {
+		t.Error("empty values should be left out")
+	}
+
+	// The metadata must parse back the way GitImport reads it
+	meta := parseAbapGitXML(xml)
+	if meta.description() != "Report <A&B>" || meta.values["SUBC"] != "1" {
+		t.Errorf("round trip: description=%q SUBC=%q", meta.description(), meta.values["SUBC"])
+	}
+
+	if _, err := BuildAbapGitXML(&ObjectMetadata{Type: "DDLS"}); err == nil {
+		t.Error("expected error for unsupported type")
+	}
+}
+
+func TestSAPLanguage(t *testing.T) {
+	tests := []struct {
+		codes []string
+		want  string
+		err   bool
+	}{
+		{[]string{"DE", "EN"}, "D", false},
+		{[]string{"", "en"}, "E", false},
+		{[]string{"AF"}, "a", false},
+		{[]string{"AR"}, "A", false},
+		{[]string{"SK"}, "Q", false},
+		{[]string{"J"}, "J", false},
+		{nil, "E", false},
+		{[]string{"XX"}, "", true},
+		{[]string{"x"}, "", true},
+	}
+	for _, tt := range tests {
+		got, err := sapLanguage(tt.codes...)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("sapLanguage(%q) = %q, %v; want %q (error: %v)", tt.codes, got, err, tt.want, tt.err)
+		}
+	}
+	if iso := isoLanguage("a"); iso != "AF" {
+		t.Errorf("isoLanguage(a) = %q, want AF", iso)
+	}
+}
+
+func TestSaveToFile_WritesAbapGitMetadata(t *testing.T) {
+	classXML := `<?xml version="1.0" encoding="utf-8"?>
+<class:abapClass xmlns:class="http://www.sap.com/adt/oo/classes" xmlns:adtcore="http://www.sap.com/adt/core" xmlns:abapsource="http://www.sap.com/adt/abapsource"
+  class:final="true" class:abstract="false" class:visibility="public" class:category="generalObjectType"
+  abapsource:fixPointArithmetic="true" abapsource:activeUnicodeCheck="true"
+  adtcore:name="ZCL_ORDER" adtcore:description="Orders" adtcore:language="EN" adtcore:masterLanguage="DE">
+  <class:include class:includeType="main" adtcore:name="ZCL_ORDER"/>
+  <class:include class:includeType="testclasses" adtcore:name="ZCL_ORDER"/>
+</class:abapClass>`
+
+	mock := &mockHTTPClient{
+		responses: []*http.Response{
+			newMockResponse(200, "CLASS zcl_order DEFINITION PUBLIC FINAL.\nENDCLASS.", nil),
+			newMockResponse(200, classXML, nil),
+			newMockResponse(200, "@MaxLength:10\n001=Total\n", nil),
+		},
+	}
+	cfg := NewConfig("https://sap.example.com:44300", "user", "pass")
+	client := NewClientWithTransport(cfg, NewTransportWithClient(cfg, mock))
+
+	dir := t.TempDir()
+	result, err := client.SaveToFile(context.Background(), ObjectTypeClass, "ZCL_ORDER", dir)
+	if err != nil {
+		t.Fatalf("SaveToFile failed: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("SaveToFile not successful: %s", result.Message)
+	}
+	if result.MetadataPath != filepath.Join(dir, "zcl_order.clas.xml") {
+		t.Fatalf("MetadataPath = %q (%s)", result.MetadataPath, result.Message)
+	}
+	if result.Language != "D" {
+		t.Errorf("Language = %q, want D", result.Language)
+	}
+
+	content, err := os.ReadFile(result.MetadataPath)
+	if err != nil {
+		t.Fatalf("reading metadata: %v", err)
+	}
+	for _, want := range []string{
+		"<CLSNAME>ZCL_ORDER</CLSNAME>",
+		"<LANGU>D</LANGU>",
+		"<DESCRIPT>Orders</DESCRIPT>",
+		"<EXPOSURE>2</EXPOSURE>",
+		"<CLSFINAL>X</CLSFINAL>",
+		"<FIXPT>X</FIXPT>",
+		"<WITH_UNIT_TESTS>X</WITH_UNIT_TESTS>",
+		"<ENTRY>Total</ENTRY>",
+		"<LENGTH>10</LENGTH>",
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("missing %s in:\n%s", want, content)
+		}
+	}
+	for _, unwanted := range []string{"<CATEGORY>", "<CLSABSTRCT>"} {
+		if strings.Contains(string(content), unwanted) {
+			t.Errorf("unexpected %s in:\n%s", unwanted, content)
+		}
+	}
+	if !strings.Contains(mock.requests[2].URL.Path, "/textelements/classes/zcl_order/source/symbols") {
+		t.Errorf("text elements request = %s", mock.requests[2].URL.Path)
+	}
+}
+
+func TestSaveToFile_MetadataFailureKeepsSource(t *testing.T) {
+	mock := &mockHTTPClient{
+		responses: []*http.Response{
+			newMockResponse(200, "REPORT zreport.", nil),
+			newMockResponse(403, "forbidden", nil),
+		},
+	}
+	cfg := NewConfig("https://sap.example.com:44300", "user", "pass")
+	client := NewClientWithTransport(cfg, NewTransportWithClient(cfg, mock))
+
+	dir := t.TempDir()
+	result, err := client.SaveToFile(context.Background(), ObjectTypeProgram, "ZREPORT", dir)
+	if err != nil {
+		t.Fatalf("SaveToFile failed: %v", err)
+	}
+	if !result.Success || result.MetadataPath != "" {
+		t.Errorf("Success=%v MetadataPath=%q", result.Success, result.MetadataPath)
+	}
+	if !strings.Contains(result.Message, "metadata not written") {
+		t.Errorf("Message = %q", result.Message)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "zreport.prog.abap")); err != nil {
+		t.Errorf("source file missing: %v", err)
+	}
+}
@@ -1,12 +1,14 @@
 package adt
 
 import (
+	"bytes"
 	"context"
 	"encoding/xml"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 )
 
 // Client is the main ADT API client.
@@ -460,6 +462,46 @@ func (c *Client) GetMessageClass(ctx context.Context, msgClassName string) (*Mes
 	return &mc, nil
 }
 
+// --- Object Metadata ---
+
+// GetObjectChangedAt returns the last change timestamp (adtcore:changedAt) of an object.
+// objectURL is the ADT object URL (e.g., /sap/bc/adt/programs/programs/ZTEST).
+// A zero time is returned if the object metadata carries no timestamp.
+func (c *Client) GetObjectChangedAt(ctx context.Context, objectURL string) (time.Time, error) {
+	objectURL = strings.TrimSuffix(objectURL, "/source/main")
+
+	resp, err := c.transport.Request(ctx, objectURL, &RequestOptions{
+		Method: http.MethodGet,
+		Accept: "application/*",
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("getting object metadata: %w", err)
+	}
+
+	return parseChangedAt(resp.Body)
+}
+
+// parseChangedAt reads the changedAt attribute of the root element of ADT object metadata.
+func parseChangedAt(data []byte) (time.Time, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := decoder.Token()
+		if err != nil {
+			return time.Time{}, fmt.Errorf("parsing object metadata: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		for _, attr := range start.Attr {
+			if attr.Name.Local == "changedAt" {
+				return time.Parse(time.RFC3339, attr.Value)
+			}
+		}
+		return time.Time{}, nil
+	}
+}
+
 // --- Package Operations ---
 
 // GetPackage retrieves the contents of a package using the nodestructure API.
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 )
 
 // mockTransportClient is a mock for testing the ADT client.
@@ -104,6 +105,32 @@ WRITE 'Hello World'.`
 	}
 }
 
+func TestClient_GetObjectChangedAt(t *testing.T) {
+	metadata := `<?xml version="1.0" encoding="utf-8"?>
+<program:abapProgram xmlns:program="http://www.sap.com/adt/programs/programs" xmlns:adtcore="http://www.sap.com/adt/core"
+  adtcore:name="ZTEST" adtcore:type="PROG/P" adtcore:changedAt="2025-11-03T14:25:10Z" adtcore:changedBy="DEVELOPER">
+</program:abapProgram>`
+
+	mock := &mockTransportClient{
+		responses: map[string]*http.Response{
+			"/sap/bc/adt/programs/programs/ZTEST": newTestResponse(metadata),
+			"discovery":                           newTestResponse("OK"),
+		},
+	}
+
+	cfg := NewConfig("https://sap.example.com:44300", "user", "pass")
+	transport := NewTransportWithClient(cfg, mock)
+	client := NewClientWithTransport(cfg, transport)
+
+	changedAt, err := client.GetObjectChangedAt(context.Background(), "/sap/bc/adt/programs/programs/ZTEST/source/main")
+	if err != nil {
+		t.Fatalf("GetObjectChangedAt failed: %v", err)
+	}
+	if want := time.Date(2025, 11, 3, 14, 25, 10, 0, time.UTC); !changedAt.Equal(want) {
+		t.Errorf("changedAt = %v, want %v", changedAt, want)
+	}
+}
+
 func TestClient_GetClass(t *testing.T) {
 	sourceCode := `CLASS zcl_test DEFINITION PUBLIC.
 ENDCLASS.
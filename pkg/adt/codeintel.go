@@ -467,6 +467,15 @@ func (c *Client) GetClassComponents(ctx context.Context, classURL string) (*Clas
 	return parseClassComponents(resp.Body)
 }
 
+// GetClassTestClasses returns the names of the local test classes of a class.
+func (c *Client) GetClassTestClasses(ctx context.Context, className string) ([]string, error) {
+	comp, err := c.GetClassComponents(ctx, GetObjectURL(ObjectTypeClass, className, ""))
+	if err != nil {
+		return nil, err
+	}
+	return comp.TestClasses(), nil
+}
+
 // xmlClassComponent is the internal XML structure for parsing
 type xmlClassComponent struct {
 	Name        string              `xml:"name,attr"`
@@ -537,6 +546,21 @@ func convertXMLElement(e *xmlClassComponent) *ClassComponent {
 	return comp
 }
 
+// TestClasses returns the names of local test classes found in the class structure.
+// Test classes are recognized by their link into the testclasses include.
+func (comp *ClassComponent) TestClasses() []string {
+	var names []string
+	for i := range comp.Components {
+		child := &comp.Components[i]
+		if strings.Contains(child.Href, "/includes/testclasses") && strings.HasPrefix(child.Type, "CLAS/OC") {
+			names = append(names, child.Name)
+			continue
+		}
+		names = append(names, child.TestClasses()...)
+	}
+	return names
+}
+
 // --- Type Hierarchy ---
 
 // HierarchyNode represents a node in the type hierarchy.
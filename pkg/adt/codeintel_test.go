@@ -156,3 +156,34 @@ func TestParseClassComponentsNested(t *testing.T) {
 		t.Errorf("expected local type name 'LT_LOCAL', got '%s'", localType.Name)
 	}
 }
+
+func TestClassComponentTestClasses(t *testing.T) {
+	xmlData := `<?xml version="1.0" encoding="utf-8"?>
+<abapsource:objectStructureElement xmlns:abapsource="http://www.sap.com/adt/abapsource"
+    xmlns:adtcore="http://www.sap.com/adt/core"
+    xmlns:atom="http://www.w3.org/2005/Atom"
+    adtcore:name="ZCL_UTIL" adtcore:type="CLAS/OC" visibility="public">
+  <abapsource:objectStructureElement adtcore:name="FORMAT" adtcore:type="CLAS/OM" visibility="public">
+    <atom:link href="/sap/bc/adt/oo/classes/ZCL_UTIL/source/main#start=10,9" rel="self"/>
+  </abapsource:objectStructureElement>
+  <abapsource:objectStructureElement adtcore:name="LCL_HELPER" adtcore:type="CLAS/OCL" visibility="private">
+    <atom:link href="/sap/bc/adt/oo/classes/ZCL_UTIL/includes/implementations#start=1,1" rel="self"/>
+  </abapsource:objectStructureElement>
+  <abapsource:objectStructureElement adtcore:name="LTCL_FORMAT" adtcore:type="CLAS/OCN" visibility="private">
+    <atom:link href="/sap/bc/adt/oo/classes/ZCL_UTIL/includes/testclasses#start=3,1" rel="self"/>
+    <abapsource:objectStructureElement adtcore:name="TEST_EMPTY" adtcore:type="CLAS/OM" visibility="private">
+      <atom:link href="/sap/bc/adt/oo/classes/ZCL_UTIL/includes/testclasses#start=8,5" rel="self"/>
+    </abapsource:objectStructureElement>
+  </abapsource:objectStructureElement>
+</abapsource:objectStructureElement>`
+
+	result, err := parseClassComponents([]byte(xmlData))
+	if err != nil {
+		t.Fatalf("parseClassComponents failed: %v", err)
+	}
+
+	testClasses := result.TestClasses()
+	if len(testClasses) != 1 || testClasses[0] != "LTCL_FORMAT" {
+		t.Errorf("expected [LTCL_FORMAT], got %v", testClasses)
+	}
+}
@@ -0,0 +1,339 @@
+package adt
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// --- ABAP Unit Coverage ---
+
+// CoverageCounter counts the executed and total items of one coverage type.
+type CoverageCounter struct {
+	Total    int `json:"total"`
+	Executed int `json:"executed"`
+}
+
+// Percent returns the executed share in percent (100 if there is nothing to cover).
+func (c CoverageCounter) Percent() float64 {
+	if c.Total == 0 {
+		return 100
+	}
+	return float64(c.Executed) * 100 / float64(c.Total)
+}
+
+// CoverageResult is the coverage measured during a unit test run.
+type CoverageResult struct {
+	MeasurementID string           `json:"measurementId"`
+	Objects       []CoverageObject `json:"objects"`
+}
+
+// CoverageObject is the coverage of one object (class, program, function group).
+type CoverageObject struct {
+	URI        string           `json:"uri"`
+	Type       string           `json:"type"`
+	Name       string           `json:"name"`
+	Statements CoverageCounter  `json:"statements"`
+	Branches   CoverageCounter  `json:"branches"`
+	Procedures CoverageCounter  `json:"procedures"`
+	Methods    []CoverageMethod `json:"methods,omitempty"`
+}
+
+// CoverageMethod is the coverage of one method, form or function module.
+type CoverageMethod struct {
+	Name       string          `json:"name"`
+	URI        string          `json:"uri"` // Source position of the method, with #start=line,column
+	Statements CoverageCounter `json:"statements"`
+	Branches   CoverageCounter `json:"branches"`
+	Lines      []CoverageLine  `json:"lines,omitempty"`
+}
+
+// CoverageLine is the coverage of one source line.
+type CoverageLine struct {
+	URI  string `json:"uri"` // Source include, e.g. /sap/bc/adt/oo/classes/zcl_foo/source/main
+	Line int    `json:"line"`
+	Hits int    `json:"hits"` // Times executed; 0 = not covered
+}
+
+// UncoveredLines returns the lines of the object that no test executed,
+// ordered by source and line.
+func (o *CoverageObject) UncoveredLines() []CoverageLine {
+	var lines []CoverageLine
+	for _, m := range o.Methods {
+		for _, l := range m.Lines {
+			if l.Hits == 0 {
+				lines = append(lines, l)
+			}
+		}
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		if lines[i].URI != lines[j].URI {
+			return lines[i].URI < lines[j].URI
+		}
+		return lines[i].Line < lines[j].Line
+	})
+	return lines
+}
+
+// coverageStart matches the #start=line[,column] fragment of statement URIs.
+var coverageStart = regexp.MustCompile(`#start=(\d+)`)
+
+// GetCoverage retrieves the coverage of a unit test run measured with
+// UnitTestRunFlags.Coverage: statement, branch and procedure counters per
+// object and method, and the executed lines of each method.
+func (c *Client) GetCoverage(ctx context.Context, measurementID string) (*CoverageResult, error) {
+	measurementURI := "/sap/bc/adt/runtime/traces/coverage/measurements/" + measurementID
+	body := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<cov:query xmlns:cov="http://www.sap.com/adt/cov" xmlns:adtcore="http://www.sap.com/adt/core">
+  <adtcore:objectReferences>
+    <adtcore:objectReference adtcore:uri="%s"/>
+  </adtcore:objectReferences>
+</cov:query>`, measurementURI)
+
+	resp, err := c.transport.Request(ctx, measurementURI, &RequestOptions{
+		Method:      http.MethodPost,
+		Body:        []byte(body),
+		ContentType: "application/xml",
+		Accept:      "application/xml",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting coverage: %w", err)
+	}
+
+	result, links, err := parseCoverageResult(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	result.MeasurementID = measurementID
+
+	for i := range result.Objects {
+		obj := &result.Objects[i]
+		if len(links[i]) == 0 {
+			continue
+		}
+		hrefs := make([]string, len(links[i]))
+		for k, l := range links[i] {
+			hrefs[k] = l.href
+		}
+		statements, err := c.getCoverageStatements(ctx, hrefs)
+		if err != nil {
+			return nil, err
+		}
+		for k, l := range links[i] {
+			obj.Methods[l.method].Lines = statements[k]
+		}
+	}
+	return result, nil
+}
+
+// getCoverageStatements fetches the statement coverage behind the given
+// statement links in one bulk request and returns the lines of each link.
+func (c *Client) getCoverageStatements(ctx context.Context, links []string) ([][]CoverageLine, error) {
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<cov:statementsBulkRequest xmlns:cov="http://www.sap.com/adt/cov">`)
+	for _, link := range links {
+		fmt.Fprintf(&sb, "\n  <cov:statementsRequest get=\"%s\"/>", xmlEscape(link))
+	}
+	sb.WriteString("\n</cov:statementsBulkRequest>")
+
+	resp, err := c.transport.Request(ctx, "/sap/bc/adt/runtime/traces/coverage/results/statements", &RequestOptions{
+		Method:      http.MethodPost,
+		Body:        []byte(sb.String()),
+		ContentType: "application/xml",
+		Accept:      "application/xml",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting statement coverage: %w", err)
+	}
+	return parseCoverageStatements(resp.Body, len(links))
+}
+
+type coverageLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+// methodLink is the statement coverage link of a method.
+type methodLink struct {
+	method int // Index in CoverageObject.Methods
+	href   string
+}
+
+type coverageNode struct {
+	ObjectReference struct {
+		URI  string `xml:"uri,attr"`
+		Type string `xml:"type,attr"`
+		Name string `xml:"name,attr"`
+	} `xml:"objectReference"`
+	Coverages struct {
+		Items []struct {
+			Type     string `xml:"type,attr"`
+			Total    int    `xml:"total,attr"`
+			Executed int    `xml:"executed,attr"`
+		} `xml:"coverage"`
+	} `xml:"coverages"`
+	Links []coverageLink `xml:"link"`
+	Nodes struct {
+		Items []coverageNode `xml:"node"`
+	} `xml:"nodes"`
+}
+
+// counters returns the statement, branch and procedure counters of a node.
+func (n *coverageNode) counters() (statements, branches, procedures CoverageCounter) {
+	for _, cov := range n.Coverages.Items {
+		counter := CoverageCounter{Total: cov.Total, Executed: cov.Executed}
+		switch cov.Type {
+		case "statement":
+			statements = counter
+		case "branch":
+			branches = counter
+		case "procedure":
+			procedures = counter
+		}
+	}
+	return
+}
+
+// statementsLink returns the link to the statement coverage of a node.
+func (n *coverageNode) statementsLink() string {
+	for _, l := range n.Links {
+		if strings.HasSuffix(l.Rel, "/statements") {
+			return l.Href
+		}
+	}
+	return ""
+}
+
+// parseCoverageResult parses the coverage node tree. Package nodes are
+// flattened; every other top-level node is an object whose leaf nodes are its
+// methods. It also returns, per object, the statement links of its methods.
+func parseCoverageResult(data []byte) (*CoverageResult, [][]methodLink, error) {
+	xmlStr := string(data)
+	xmlStr = strings.ReplaceAll(xmlStr, "cov:", "")
+	xmlStr = strings.ReplaceAll(xmlStr, "adtcore:", "")
+	xmlStr = strings.ReplaceAll(xmlStr, "atom:", "")
+
+	var resp struct {
+		Nodes struct {
+			Items []coverageNode `xml:"node"`
+		} `xml:"nodes"`
+	}
+	if err := xml.Unmarshal([]byte(xmlStr), &resp); err != nil {
+		return nil, nil, fmt.Errorf("parsing coverage result: %w", err)
+	}
+
+	result := &CoverageResult{Objects: []CoverageObject{}}
+	var links [][]methodLink
+
+	var methods func(n *coverageNode, obj *CoverageObject, objLinks *[]methodLink)
+	methods = func(n *coverageNode, obj *CoverageObject, objLinks *[]methodLink) {
+		for i := range n.Nodes.Items {
+			child := &n.Nodes.Items[i]
+			if len(child.Nodes.Items) > 0 {
+				// Local class or include: its children are the methods
+				methods(child, obj, objLinks)
+				continue
+			}
+			m := CoverageMethod{Name: child.ObjectReference.Name, URI: child.ObjectReference.URI}
+			m.Statements, m.Branches, _ = child.counters()
+			if href := child.statementsLink(); href != "" {
+				*objLinks = append(*objLinks, methodLink{method: len(obj.Methods), href: href})
+			}
+			obj.Methods = append(obj.Methods, m)
+		}
+	}
+
+	var walk func(nodes []coverageNode)
+	walk = func(nodes []coverageNode) {
+		for i := range nodes {
+			n := &nodes[i]
+			if strings.HasPrefix(n.ObjectReference.Type, "DEVC") {
+				walk(n.Nodes.Items)
+				continue
+			}
+			obj := CoverageObject{
+				URI:  n.ObjectReference.URI,
+				Type: n.ObjectReference.Type,
+				Name: n.ObjectReference.Name,
+			}
+			obj.Statements, obj.Branches, obj.Procedures = n.counters()
+			var objLinks []methodLink
+			methods(n, &obj, &objLinks)
+			result.Objects = append(result.Objects, obj)
+			links = append(links, objLinks)
+		}
+	}
+	walk(resp.Nodes.Items)
+
+	return result, links, nil
+}
+
+// parseCoverageStatements parses a statements bulk response into the lines
+// of each of the n requests. Several statements on a line count as one line
+// with the highest execution count.
+func parseCoverageStatements(data []byte, n int) ([][]CoverageLine, error) {
+	xmlStr := string(data)
+	xmlStr = strings.ReplaceAll(xmlStr, "cov:", "")
+	xmlStr = strings.ReplaceAll(xmlStr, "adtcore:", "")
+
+	var resp struct {
+		Responses []struct {
+			Statements []struct {
+				Executed        int `xml:"executed,attr"`
+				ObjectReference struct {
+					URI string `xml:"uri,attr"`
+				} `xml:"objectReference"`
+			} `xml:"statement"`
+		} `xml:"statementsResponse"`
+	}
+	if err := xml.Unmarshal([]byte(xmlStr), &resp); err != nil {
+		return nil, fmt.Errorf("parsing statement coverage: %w", err)
+	}
+
+	result := make([][]CoverageLine, n)
+	for i, r := range resp.Responses {
+		if i >= n {
+			break
+		}
+		type key struct {
+			uri  string
+			line int
+		}
+		index := make(map[key]int)
+		var lines []CoverageLine
+		for _, st := range r.Statements {
+			m := coverageStart.FindStringSubmatch(st.ObjectReference.URI)
+			if m == nil {
+				continue
+			}
+			line, _ := strconv.Atoi(m[1])
+			uri, _, _ := strings.Cut(st.ObjectReference.URI, "#")
+			k := key{uri, line}
+			if j, ok := index[k]; ok {
+				lines[j].Hits = max(lines[j].Hits, st.Executed)
+				continue
+			}
+			index[k] = len(lines)
+			lines = append(lines, CoverageLine{URI: uri, Line: line, Hits: st.Executed})
+		}
+		sort.Slice(lines, func(a, b int) bool { return lines[a].Line < lines[b].Line })
+		result[i] = lines
+	}
+	return result, nil
+}
+
+// coverageMeasurementID returns the measurement id of a coverage URI such as
+// /sap/bc/adt/runtime/traces/coverage/measurements/<id>.
+func coverageMeasurementID(uri string) string {
+	if uri == "" {
+		return ""
+	}
+	return path.Base(uri)
+}
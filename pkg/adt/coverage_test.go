@@ -0,0 +1,239 @@
+package adt
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const testCoverageResult = `<?xml version="1.0" encoding="utf-8"?>
+<cov:result xmlns:cov="http://www.sap.com/adt/cov" xmlns:adtcore="http://www.sap.com/adt/core" xmlns:atom="http://www.w3.org/2005/Atom">
+  <cov:nodes>
+    <cov:node>
+      <adtcore:objectReference adtcore:uri="/sap/bc/adt/packages/%24zfoo" adtcore:type="DEVC/K" adtcore:name="$ZFOO"/>
+      <cov:coverages>
+        <cov:coverage type="statement" total="10" executed="6"/>
+      </cov:coverages>
+      <cov:nodes>
+        <cov:node>
+          <adtcore:objectReference adtcore:uri="/sap/bc/adt/oo/classes/zcl_foo" adtcore:type="CLAS/OC" adtcore:name="ZCL_FOO"/>
+          <cov:coverages>
+            <cov:coverage type="branch" total="4" executed="1"/>
+            <cov:coverage type="procedure" total="2" executed="1"/>
+            <cov:coverage type="statement" total="10" executed="6"/>
+          </cov:coverages>
+          <cov:nodes>
+            <cov:node>
+              <adtcore:objectReference adtcore:uri="/sap/bc/adt/oo/classes/zcl_foo/source/main#start=10,2" adtcore:type="CLAS/OM" adtcore:name="CALCULATE"/>
+              <atom:link href="/sap/bc/adt/runtime/traces/coverage/results/M1/statements?uri=calc" rel="http://www.sap.com/adt/relations/runtime/traces/coverage/statements"/>
+              <cov:coverages>
+                <cov:coverage type="branch" total="4" executed="1"/>
+                <cov:coverage type="statement" total="8" executed="6"/>
+              </cov:coverages>
+            </cov:node>
+            <cov:node>
+              <adtcore:objectReference adtcore:uri="/sap/bc/adt/oo/classes/zcl_foo/source/main#start=30,2" adtcore:type="CLAS/OM" adtcore:name="UNUSED"/>
+              <cov:coverages>
+                <cov:coverage type="statement" total="2" executed="0"/>
+              </cov:coverages>
+            </cov:node>
+          </cov:nodes>
+        </cov:node>
+      </cov:nodes>
+    </cov:node>
+  </cov:nodes>
+</cov:result>`
+
+const testCoverageStatements = `<?xml version="1.0" encoding="utf-8"?>
+<cov:statementsBulkResponse xmlns:cov="http://www.sap.com/adt/cov" xmlns:adtcore="http://www.sap.com/adt/core">
+  <cov:statementsResponse name="calc">
+    <cov:statement executed="3">
+      <adtcore:objectReference adtcore:uri="/sap/bc/adt/oo/classes/zcl_foo/source/main#start=12,4;end=12,20"/>
+    </cov:statement>
+    <cov:statement executed="0">
+      <adtcore:objectReference adtcore:uri="/sap/bc/adt/oo/classes/zcl_foo/source/main#start=14,6;end=14,30"/>
+    </cov:statement>
+    <cov:statement executed="1">
+      <adtcore:objectReference adtcore:uri="/sap/bc/adt/oo/classes/zcl_foo/source/main#start=12,22;end=12,40"/>
+    </cov:statement>
+    <cov:statement executed="2">
+      <adtcore:objectReference adtcore:uri="/sap/bc/adt/oo/classes/zcl_foo/source/main#start=11,4;end=11,20"/>
+    </cov:statement>
+  </cov:statementsResponse>
+</cov:statementsBulkResponse>`
+
+func TestParseCoverageResult(t *testing.T) {
+	result, links, err := parseCoverageResult([]byte(testCoverageResult))
+	if err != nil {
+		t.Fatalf("parseCoverageResult failed: %v", err)
+	}
+	if len(result.Objects) != 1 {
+		t.Fatalf("expected 1 object (package flattened), got %d", len(result.Objects))
+	}
+	obj := result.Objects[0]
+	if obj.Name != "ZCL_FOO" || obj.Statements != (CoverageCounter{Total: 10, Executed: 6}) ||
+		obj.Branches != (CoverageCounter{Total: 4, Executed: 1}) || obj.Procedures.Total != 2 {
+		t.Errorf("object = %+v", obj)
+	}
+	if len(obj.Methods) != 2 || obj.Methods[0].Name != "CALCULATE" || obj.Methods[1].Statements.Executed != 0 {
+		t.Errorf("methods = %+v", obj.Methods)
+	}
+	if len(links) != 1 || len(links[0]) != 1 || links[0][0].method != 0 ||
+		!strings.HasSuffix(links[0][0].href, "/statements?uri=calc") {
+		t.Errorf("links = %+v", links)
+	}
+	if p := obj.Statements.Percent(); p != 60 {
+		t.Errorf("Percent = %v, want 60", p)
+	}
+}
+
+func TestParseCoverageStatements(t *testing.T) {
+	lines, err := parseCoverageStatements([]byte(testCoverageStatements), 1)
+	if err != nil {
+		t.Fatalf("parseCoverageStatements failed: %v", err)
+	}
+	want := []CoverageLine{
+		{URI: "/sap/bc/adt/oo/classes/zcl_foo/source/main", Line: 11, Hits: 2},
+		{URI: "/sap/bc/adt/oo/classes/zcl_foo/source/main", Line: 12, Hits: 3},
+		{URI: "/sap/bc/adt/oo/classes/zcl_foo/source/main", Line: 14, Hits: 0},
+	}
+	if len(lines) != 1 || len(lines[0]) != len(want) {
+		t.Fatalf("lines = %+v", lines)
+	}
+	for i, l := range lines[0] {
+		if l != want[i] {
+			t.Errorf("line %d = %+v, want %+v", i, l, want[i])
+		}
+	}
+}
+
+func TestRunUnitTestsWithCoverage(t *testing.T) {
+	runResponse := `<?xml version="1.0" encoding="utf-8"?>
+<aunit:runResult xmlns:aunit="http://www.sap.com/adt/aunit" xmlns:adtcore="http://www.sap.com/adt/core">
+  <external>
+    <coverage adtcore:uri="/sap/bc/adt/runtime/traces/coverage/measurements/M1"/>
+  </external>
+  <program adtcore:uri="/sap/bc/adt/oo/classes/zcl_foo" adtcore:name="ZCL_FOO">
+    <testClasses>
+      <testClass adtcore:name="LTCL_TEST">
+        <testMethods>
+          <testMethod adtcore:name="TEST_CALC" executionTime="0.01"/>
+        </testMethods>
+      </testClass>
+    </testClasses>
+  </program>
+</aunit:runResult>`
+
+	mock := &mockHTTPClient{
+		responses: []*http.Response{
+			newMockResponse(200, "OK", map[string]string{"X-CSRF-Token": "test-token"}),
+			newMockResponse(200, runResponse, nil),
+			newMockResponse(200, testCoverageResult, nil),
+			newMockResponse(200, testCoverageStatements, nil),
+		},
+	}
+	cfg := NewConfig("https://sap.example.com:44300", "user", "pass")
+	client := NewClientWithTransport(cfg, NewTransportWithClient(cfg, mock))
+
+	flags := DefaultUnitTestFlags()
+	flags.Coverage = true
+	result, err := client.RunUnitTests(context.Background(), "/sap/bc/adt/oo/classes/ZCL_FOO", &flags)
+	if err != nil {
+		t.Fatalf("RunUnitTests failed: %v", err)
+	}
+	if len(result.Classes) != 1 || result.Coverage == nil || result.Coverage.MeasurementID != "M1" {
+		t.Fatalf("result = %+v", result)
+	}
+
+	obj := result.Coverage.Objects[0]
+	if len(obj.Methods[0].Lines) != 3 || obj.Methods[1].Lines != nil {
+		t.Errorf("method lines = %+v", obj.Methods)
+	}
+	uncovered := obj.UncoveredLines()
+	if len(uncovered) != 1 || uncovered[0].Line != 14 {
+		t.Errorf("UncoveredLines = %+v", uncovered)
+	}
+
+	// Coverage is switched on in the run configuration and the statements
+	// of the linked method are requested
+	var runBody, statementsBody string
+	for _, req := range mock.requests {
+		if req.Body == nil {
+			continue
+		}
+		data, _ := io.ReadAll(req.Body)
+		switch {
+		case strings.Contains(req.URL.Path, "testruns"):
+			runBody = string(data)
+		case strings.Contains(req.URL.Path, "statements"):
+			statementsBody = string(data)
+		}
+	}
+	if !strings.Contains(runBody, `<coverage active="true"/>`) {
+		t.Errorf("run configuration without coverage:\n%s", runBody)
+	}
+	if !strings.Contains(statementsBody, `get="/sap/bc/adt/runtime/traces/coverage/results/M1/statements?uri=calc"`) {
+		t.Errorf("statements request = %s", statementsBody)
+	}
+}
+
+func TestRunUnitTestsCoverageMissing(t *testing.T) {
+	program := `<program adtcore:uri="/sap/bc/adt/oo/classes/zcl_foo" adtcore:name="ZCL_FOO">
+    <testClasses>
+      <testClass adtcore:name="LTCL_TEST">
+        <testMethods>
+          <testMethod adtcore:name="TEST_CALC" executionTime="0.01"/>
+        </testMethods>
+      </testClass>
+    </testClasses>
+  </program>`
+	withCoverage := `<?xml version="1.0" encoding="utf-8"?>
+<aunit:runResult xmlns:aunit="http://www.sap.com/adt/aunit" xmlns:adtcore="http://www.sap.com/adt/core">
+  <external>
+    <coverage adtcore:uri="/sap/bc/adt/runtime/traces/coverage/measurements/M1"/>
+  </external>
+  ` + program + `
+</aunit:runResult>`
+	withoutCoverage := `<?xml version="1.0" encoding="utf-8"?>
+<aunit:runResult xmlns:aunit="http://www.sap.com/adt/aunit" xmlns:adtcore="http://www.sap.com/adt/core">
+  ` + program + `
+</aunit:runResult>`
+
+	tests := []struct {
+		name      string
+		responses []*http.Response
+		wantError string
+	}{
+		{"no measurement", []*http.Response{newMockResponse(200, withoutCoverage, nil)}, "no coverage measurement"},
+		{"fetch fails", []*http.Response{
+			newMockResponse(200, withCoverage, nil),
+			newMockResponse(500, "coverage down", nil),
+		}, "fetching coverage"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockHTTPClient{
+				responses: append([]*http.Response{
+					newMockResponse(200, "OK", map[string]string{"X-CSRF-Token": "test-token"}),
+				}, tt.responses...),
+			}
+			cfg := NewConfig("https://sap.example.com:44300", "user", "pass")
+			client := NewClientWithTransport(cfg, NewTransportWithClient(cfg, mock))
+
+			flags := DefaultUnitTestFlags()
+			flags.Coverage = true
+			result, err := client.RunUnitTests(context.Background(), "/sap/bc/adt/oo/classes/ZCL_FOO", &flags)
+			if err != nil {
+				t.Fatalf("RunUnitTests failed: %v", err)
+			}
+			if len(result.Classes) != 1 || result.Coverage != nil {
+				t.Fatalf("result = %+v", result)
+			}
+			if !strings.Contains(result.CoverageError, tt.wantError) {
+				t.Errorf("CoverageError = %q, want %q", result.CoverageError, tt.wantError)
+			}
+		})
+	}
+}
@@ -155,6 +155,12 @@ const (
 	ObjectTypeFunctionMod   CreatableObjectType = "FUGR/FF"
 	ObjectTypeTable         CreatableObjectType = "TABL/DT"
 	ObjectTypePackage       CreatableObjectType = "DEVC/K"
+	// DDIC object types (deployed from abapGit .xml files, see ddicfile.go)
+	ObjectTypeStructure    CreatableObjectType = "TABL/DS"
+	ObjectTypeDataElement  CreatableObjectType = "DTEL/DE"
+	ObjectTypeDomain       CreatableObjectType = "DOMA/DD"
+	ObjectTypeTableType    CreatableObjectType = "TTYP/DA"
+	ObjectTypeMessageClass CreatableObjectType = "MSAG/N"
 	// RAP object types (read-only via ADT, created via RAP generators)
 	ObjectTypeDDLS CreatableObjectType = "DDLS/DF"  // CDS DDL Source
 	ObjectTypeBDEF CreatableObjectType = "BDEF/BDO" // Behavior Definition
@@ -624,6 +630,19 @@ func GetObjectURL(objectType CreatableObjectType, name string, parentName string
 		return fmt.Sprintf("/sap/bc/adt/functions/groups/%s/fmodules/%s", encodedParent, encodedName)
 	case ObjectTypePackage:
 		return fmt.Sprintf("/sap/bc/adt/packages/%s", encodedName)
+	// DDIC object types
+	case ObjectTypeTable:
+		return fmt.Sprintf("/sap/bc/adt/ddic/tables/%s", url.PathEscape(strings.ToLower(name)))
+	case ObjectTypeStructure:
+		return fmt.Sprintf("/sap/bc/adt/ddic/structures/%s", url.PathEscape(strings.ToLower(name)))
+	case ObjectTypeDataElement:
+		return fmt.Sprintf("/sap/bc/adt/ddic/dataelements/%s", url.PathEscape(strings.ToLower(name)))
+	case ObjectTypeDomain:
+		return fmt.Sprintf("/sap/bc/adt/ddic/domains/%s", url.PathEscape(strings.ToLower(name)))
+	case ObjectTypeTableType:
+		return fmt.Sprintf("/sap/bc/adt/ddic/tabletypes/%s", url.PathEscape(strings.ToLower(name)))
+	case ObjectTypeMessageClass:
+		return fmt.Sprintf("/sap/bc/adt/messageclass/%s", url.PathEscape(strings.ToLower(name)))
 	// RAP object types - use lowercase for CDS objects
 	case ObjectTypeDDLS:
 		return fmt.Sprintf("/sap/bc/adt/ddic/ddl/sources/%s", url.PathEscape(strings.ToLower(name)))
@@ -0,0 +1,701 @@
+package adt
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// --- DDIC Objects from abapGit XML Files ---
+//
+// Tables, structures, data elements, domains, table types and message classes
+// have no source in abapGit; they are serialized as .xml files with the DDIC
+// table rows (DD02V, DD04V, ...). Tables and structures are written through
+// ADT as DDL source, the others as ADT XML documents.
+
+// ddicFileTypes maps abapGit DDIC file suffixes to object types.
+// TABL files may also hold structures; see parseDDICFile.
+var ddicFileTypes = map[string]CreatableObjectType{
+	".tabl.xml": ObjectTypeTable,
+	".dtel.xml": ObjectTypeDataElement,
+	".doma.xml": ObjectTypeDomain,
+	".ttyp.xml": ObjectTypeTableType,
+	".msag.xml": ObjectTypeMessageClass,
+}
+
+// IsDDICFileType reports whether objects of this type are deployed from abapGit .xml files.
+func IsDDICFileType(objType CreatableObjectType) bool {
+	switch objType {
+	case ObjectTypeTable, ObjectTypeStructure, ObjectTypeDataElement, ObjectTypeDomain, ObjectTypeTableType, ObjectTypeMessageClass:
+		return true
+	}
+	return false
+}
+
+// ddicContentTypes are the ADT content types for writing DDIC XML documents.
+var ddicContentTypes = map[CreatableObjectType]string{
+	ObjectTypeDataElement:  "application/vnd.sap.adt.dataelements.v2+xml",
+	ObjectTypeDomain:       "application/vnd.sap.adt.domains.v2+xml",
+	ObjectTypeTableType:    "application/vnd.sap.adt.tabletype.v1+xml",
+	ObjectTypeMessageClass: "application/vnd.sap.adt.mc.messageclass+xml",
+}
+
+// abapGitDDIC holds the values of an abapGit DDIC or message class .xml file
+// (the asx:values element).
+type abapGitDDIC struct {
+	DD01V     ddicDomain       `xml:"DD01V"`
+	FixValues []ddicFixValue   `xml:"DD07V_TAB>DD07V"`
+	DD02V     ddicTable        `xml:"DD02V"`
+	Fields    []ddicField      `xml:"DD03P_TABLE>DD03P"`
+	DD04V     ddicDataElement  `xml:"DD04V"`
+	DD40V     ddicTableType    `xml:"DD40V"`
+	T100A     ddicMessageClass `xml:"T100A"`
+	Messages  []ddicMessage    `xml:"T100>T100"`
+
+	masterLanguage string // SAP language key of the repository (.abapgit.xml), if known
+}
+
+type ddicDomain struct {
+	Name       string `xml:"DOMNAME"`
+	Language   string `xml:"DDLANGUAGE"`
+	DataType   string `xml:"DATATYPE"`
+	Length     string `xml:"LENG"`
+	OutputLen  string `xml:"OUTPUTLEN"`
+	Decimals   string `xml:"DECIMALS"`
+	Lowercase  string `xml:"LOWERCASE"`
+	SignFlag   string `xml:"SIGNFLAG"`
+	ConvExit   string `xml:"CONVEXIT"`
+	ValueTable string `xml:"ENTITYTAB"`
+	Text       string `xml:"DDTEXT"`
+}
+
+type ddicFixValue struct {
+	Position string `xml:"VALPOS"`
+	Low      string `xml:"DOMVALUE_L"`
+	High     string `xml:"DOMVALUE_H"`
+	Text     string `xml:"DDTEXT"`
+}
+
+type ddicTable struct {
+	Name            string `xml:"TABNAME"`
+	Class           string `xml:"TABCLASS"` // TRANSP, INTTAB (structure), ...
+	Text            string `xml:"DDTEXT"`
+	DeliveryClass   string `xml:"CONTFLAG"`
+	EnhancementCat  string `xml:"EXCLASS"`
+	DataMaintenance string `xml:"MATEFLAG"`
+}
+
+type ddicField struct {
+	Name     string `xml:"FIELDNAME"`
+	Key      string `xml:"KEYFLAG"`
+	RollName string `xml:"ROLLNAME"`
+	Include  string `xml:"PRECFIELD"` // Included structure of .INCLUDE
+	NotNull  string `xml:"NOTNULL"`
+	DataType string `xml:"DATATYPE"`
+	Length   string `xml:"LENG"`
+	Decimals string `xml:"DECIMALS"`
+	RefTable string `xml:"REFTABLE"`
+	RefField string `xml:"REFFIELD"`
+}
+
+type ddicDataElement struct {
+	Name        string `xml:"ROLLNAME"`
+	Language    string `xml:"DDLANGUAGE"`
+	Domain      string `xml:"DOMNAME"`
+	RefKind     string `xml:"REFKIND"` // D=domain, R=reference, empty=predefined type
+	RefType     string `xml:"REFTYPE"` // For REFKIND R: C=class/interface
+	DataType    string `xml:"DATATYPE"`
+	Length      string `xml:"LENG"`
+	Decimals    string `xml:"DECIMALS"`
+	Text        string `xml:"DDTEXT"`
+	Heading     string `xml:"REPTEXT"`
+	Short       string `xml:"SCRTEXT_S"`
+	Medium      string `xml:"SCRTEXT_M"`
+	Long        string `xml:"SCRTEXT_L"`
+	HeadingLen  string `xml:"HEADLEN"`
+	ShortLen    string `xml:"SCRLEN1"`
+	MediumLen   string `xml:"SCRLEN2"`
+	LongLen     string `xml:"SCRLEN3"`
+	SearchHelp  string `xml:"SHLPNAME"`
+	SearchField string `xml:"SHLPFIELD"`
+	MemoryID    string `xml:"MEMORYID"`
+}
+
+type ddicTableType struct {
+	Name       string `xml:"TYPENAME"`
+	Language   string `xml:"DDLANGUAGE"`
+	RowType    string `xml:"ROWTYPE"`
+	DataType   string `xml:"DATATYPE"`
+	Length     string `xml:"LENG"`
+	Decimals   string `xml:"DECIMALS"`
+	AccessMode string `xml:"ACCESSMODE"` // T=standard, S=sorted, H=hashed, I=index, A=any
+	KeyDef     string `xml:"KEYDEF"`     // D=default, T=row type, K=key components
+	KeyKind    string `xml:"KEYKIND"`    // N=non-unique, U=unique, G=generic
+	Text       string `xml:"DDTEXT"`
+}
+
+type ddicMessageClass struct {
+	Name     string `xml:"ARBGB"`
+	Language string `xml:"MASTERLANG"`
+	Text     string `xml:"STEXT"`
+}
+
+type ddicMessage struct {
+	Number string `xml:"MSGNR"`
+	Text   string `xml:"TEXT"`
+}
+
+// ddicFileType returns the object type of an abapGit DDIC file name, if any.
+func ddicFileType(baseName string) (CreatableObjectType, string, bool) {
+	lower := strings.ToLower(baseName)
+	for suffix, objType := range ddicFileTypes {
+		if strings.HasSuffix(lower, suffix) {
+			return objType, baseName[:len(baseName)-len(suffix)], true
+		}
+	}
+	return "", "", false
+}
+
+// readDDICFile parses an abapGit DDIC .xml file.
+func readDDICFile(filePath string) (*abapGitDDIC, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("reading file: %w", err)
+	}
+	return parseDDICXML(data)
+}
+
+// parseDDICXML parses the content of an abapGit DDIC .xml file.
+func parseDDICXML(data []byte) (*abapGitDDIC, error) {
+	// The values are wrapped in <abapGit><asx:abap> or just <asx:abap>
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("parsing abapGit XML: no asx:values element")
+		}
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Local == "values" {
+			var file abapGitDDIC
+			if err := dec.DecodeElement(&file, &start); err != nil {
+				return nil, fmt.Errorf("parsing abapGit XML: %w", err)
+			}
+			return &file, nil
+		}
+	}
+}
+
+// parseDDICFile reads the name and description of an abapGit DDIC .xml file.
+// The name comes from the XML content, falling back to the file name.
+func parseDDICFile(filePath string) (*ABAPFileInfo, error) {
+	objType, fileName, _ := ddicFileType(filepath.Base(filePath))
+	file, err := readDDICFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return ddicFileInfo(filePath, objType, fileName, file)
+}
+
+// ddicFileInfo returns the object described by the parsed DDIC file v.
+func ddicFileInfo(filePath string, objType CreatableObjectType, fileName string, v *abapGitDDIC) (*ABAPFileInfo, error) {
+	info := &ABAPFileInfo{FilePath: filePath, ObjectType: objType}
+	switch objType {
+	case ObjectTypeTable:
+		info.ObjectName, info.Description = v.DD02V.Name, v.DD02V.Text
+		if v.DD02V.Class == "INTTAB" {
+			info.ObjectType = ObjectTypeStructure
+		} else if v.DD02V.Class != "" && v.DD02V.Class != "TRANSP" {
+			return nil, fmt.Errorf("table category %s is not supported (expected TRANSP or INTTAB)", v.DD02V.Class)
+		}
+	case ObjectTypeDataElement:
+		info.ObjectName, info.Description = v.DD04V.Name, v.DD04V.Text
+	case ObjectTypeDomain:
+		info.ObjectName, info.Description = v.DD01V.Name, v.DD01V.Text
+	case ObjectTypeTableType:
+		info.ObjectName, info.Description = v.DD40V.Name, v.DD40V.Text
+	case ObjectTypeMessageClass:
+		info.ObjectName, info.Description = v.T100A.Name, v.T100A.Text
+	}
+
+	if info.ObjectName == "" {
+		// Convert # back to / for namespaced objects (abapGit convention)
+		info.ObjectName = strings.ReplaceAll(fileName, "#", "/")
+	}
+	info.ObjectName = strings.ToUpper(info.ObjectName)
+	if info.Description == "" {
+		info.Description = fmt.Sprintf("Generated from %s", filepath.Base(filePath))
+	}
+	return info, nil
+}
+
+// deployDDICFile creates (if create is set) and writes a DDIC object from an
+// abapGit .xml file, then activates it unless activate is false.
+//
+// Workflow: Parse → [Create] → Lock → Write → Unlock → [Activate]
+func (c *Client) deployDDICFile(ctx context.Context, info *ABAPFileInfo, packageName, transport string, create, activate bool) (*DeployResult, error) {
+	file, err := readDDICFile(info.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	return c.deployDDIC(ctx, info, file, packageName, transport, create, activate)
+}
+
+// deployDDIC creates (if create is set), writes and activates (if activate is
+// set) the DDIC object described by info from its parsed abapGit file.
+func (c *Client) deployDDIC(ctx context.Context, info *ABAPFileInfo, file *abapGitDDIC, packageName, transport string, create, activate bool) (*DeployResult, error) {
+	objectURL := GetObjectURL(info.ObjectType, info.ObjectName, "")
+	result := &DeployResult{
+		FilePath:   info.FilePath,
+		ObjectURL:  objectURL,
+		ObjectName: info.ObjectName,
+		ObjectType: string(info.ObjectType),
+	}
+	fail := func(format string, args ...any) (*DeployResult, error) {
+		msg := fmt.Sprintf(format, args...)
+		result.Errors = append(result.Errors, msg)
+		result.Message = fmt.Sprintf("Failed to deploy %s %s: %s", info.ObjectType, info.ObjectName, msg)
+		return result, nil
+	}
+
+	var content, contentType string
+	switch info.ObjectType {
+	case ObjectTypeTable, ObjectTypeStructure:
+		content, contentType = generateDDICTableSource(info, file), "text/plain"
+	default:
+		content, contentType = buildDDICObjectXML(info, file, packageName, c.ddicLanguage(info, file)), ddicContentTypes[info.ObjectType]
+	}
+
+	// 1. Create
+	if create {
+		packageName = strings.ToUpper(packageName)
+		if err := c.checkPackageSafety(packageName); err != nil {
+			return nil, err
+		}
+		// Validate the package first: SAP leaves an orphan lock when creation fails (see CreateObject)
+		if !c.packageExists(ctx, packageName) {
+			return fail("package %s does not exist - create it first to avoid orphan locks", packageName)
+		}
+		if err := c.createDDICObject(ctx, info, file, packageName, transport, content, contentType); err != nil {
+			return fail("create failed: %v", err)
+		}
+		result.Created = true
+	}
+
+	// 2. Lock
+	lock, err := c.LockObject(ctx, objectURL, "MODIFY")
+	if err != nil {
+		return fail("lock failed: %v", err)
+	}
+
+	// 3. Write (tables and structures as DDL source, others as XML)
+	writeURL := objectURL
+	if contentType == "text/plain" {
+		writeURL += "/source/main"
+	}
+	params := url.Values{}
+	params.Set("lockHandle", lock.LockHandle)
+	if transport != "" {
+		params.Set("corrNr", transport)
+	}
+	_, err = c.transport.Request(ctx, writeURL, &RequestOptions{
+		Method:      http.MethodPut,
+		Query:       params,
+		Body:        []byte(content),
+		ContentType: contentType,
+	})
+	unlockErr := c.UnlockObject(ctx, objectURL, lock.LockHandle)
+	if err != nil {
+		return fail("write failed: %v", err)
+	}
+	if unlockErr != nil {
+		return fail("unlock failed: %v", unlockErr)
+	}
+
+	if !activate {
+		result.Success = true
+		result.Message = fmt.Sprintf("Wrote %s %s from %s (inactive)", info.ObjectType, info.ObjectName, info.FilePath)
+		return result, nil
+	}
+
+	// 4. Activate
+	activation, err := c.Activate(ctx, objectURL, info.ObjectName)
+	if err != nil {
+		return fail("activation failed: %v", err)
+	}
+	if !activation.Success {
+		for _, msg := range activation.Messages {
+			if msg.Type == "E" || msg.Type == "A" {
+				result.Errors = append(result.Errors, msg.ShortText)
+			}
+		}
+		result.Message = fmt.Sprintf("%s %s written but activation failed", info.ObjectType, info.ObjectName)
+		return result, nil
+	}
+
+	verb := "updated"
+	if create {
+		verb = "created"
+	}
+	result.Success = true
+	result.Message = fmt.Sprintf("Successfully %s and activated %s %s from %s", verb, info.ObjectType, info.ObjectName, info.FilePath)
+	return result, nil
+}
+
+// createDDICObject creates an empty DDIC object; its content is written afterwards.
+func (c *Client) createDDICObject(ctx context.Context, info *ABAPFileInfo, file *abapGitDDIC, packageName, transport, content, contentType string) error {
+	collection := path.Dir(GetObjectURL(info.ObjectType, info.ObjectName, ""))
+
+	body := content
+	switch info.ObjectType {
+	case ObjectTypeTable, ObjectTypeStructure:
+		// Same as CreateTable: create an empty source object, write the DDL afterwards
+		contentType = "application/vnd.sap.adt.tables.v2+xml"
+		if info.ObjectType == ObjectTypeStructure {
+			contentType = "application/vnd.sap.adt.structures.v2+xml"
+		}
+		body = fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<blue:blueSource xmlns:blue="http://www.sap.com/wbobj/blue"
+                 xmlns:adtcore="http://www.sap.com/adt/core"
+                 adtcore:name="%s"
+                 adtcore:type="%s"
+                 adtcore:description="%s">
+  <adtcore:packageRef adtcore:name="%s"/>
+</blue:blueSource>`, escapeXML(info.ObjectName), info.ObjectType, escapeXML(info.Description), escapeXML(packageName))
+	}
+
+	params := url.Values{}
+	if transport != "" {
+		params.Set("corrNr", transport)
+	}
+	_, err := c.transport.Request(ctx, collection, &RequestOptions{
+		Method:      http.MethodPost,
+		Query:       params,
+		Body:        []byte(body),
+		ContentType: contentType,
+		Accept:      contentType,
+	})
+	return err
+}
+
+// generateDDICTableSource converts the DD02V/DD03P rows of a table or
+// structure to DDL source (like generateTableDDL does for CreateTable).
+func generateDDICTableSource(info *ABAPFileInfo, file *abapGitDDIC) string {
+	table := file.DD02V
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("@EndUserText.label : '%s'\n", escapeQuote(info.Description)))
+	sb.WriteString(fmt.Sprintf("@AbapCatalog.enhancement.category : #%s\n", ddicEnhancementCategory(table.EnhancementCat)))
+	if info.ObjectType == ObjectTypeStructure {
+		sb.WriteString(fmt.Sprintf("define structure %s {\n\n", strings.ToLower(info.ObjectName)))
+	} else {
+		deliveryClass := table.DeliveryClass
+		if deliveryClass == "" {
+			deliveryClass = "A"
+		}
+		sb.WriteString("@AbapCatalog.tableCategory : #TRANSPARENT\n")
+		sb.WriteString(fmt.Sprintf("@AbapCatalog.deliveryClass : #%s\n", deliveryClass))
+		sb.WriteString(fmt.Sprintf("@AbapCatalog.dataMaintenance : #%s\n", ddicDataMaintenance(table.DataMaintenance)))
+		sb.WriteString(fmt.Sprintf("define table %s {\n\n", strings.ToLower(info.ObjectName)))
+	}
+
+	for _, f := range file.Fields {
+		switch f.Name {
+		case ".INCLUDE":
+			if f.Include != "" {
+				sb.WriteString(fmt.Sprintf("  include %s;\n", strings.ToLower(f.Include)))
+			}
+			continue
+		case ".APPEND":
+			continue // Appends belong to their own append structure
+		}
+
+		if f.RefTable != "" && f.RefField != "" {
+			ref := fmt.Sprintf("'%s.%s'", strings.ToLower(f.RefTable), strings.ToLower(f.RefField))
+			if strings.ToUpper(f.DataType) == "QUAN" {
+				sb.WriteString(fmt.Sprintf("  @Semantics.quantity.unitOfMeasure : %s\n", ref))
+			} else {
+				sb.WriteString(fmt.Sprintf("  @Semantics.amount.currencyCode : %s\n", ref))
+			}
+		}
+
+		line := fmt.Sprintf("%s : %s", strings.ToLower(f.Name), ddicFieldType(f))
+		if f.Key == "X" {
+			line = "key " + line + " not null"
+		} else if f.NotNull == "X" {
+			line += " not null"
+		}
+		sb.WriteString("  " + line + ";\n")
+	}
+
+	sb.WriteString("\n}\n")
+	return sb.String()
+}
+
+// ddicFieldType returns the DDL type of a field: its data element, or the
+// built-in type from DATATYPE/LENG/DECIMALS.
+func ddicFieldType(f ddicField) string {
+	if f.RollName != "" {
+		return strings.ToLower(f.RollName)
+	}
+	return ddicBuiltinType(f.DataType, f.Length, f.Decimals)
+}
+
+// ddicBuiltinType converts a DDIC data type with length and decimals to abap.* DDL syntax.
+func ddicBuiltinType(dataType, length, decimals string) string {
+	t := strings.ToLower(dataType)
+	leng, _ := strconv.Atoi(length)
+	dec, _ := strconv.Atoi(decimals)
+	switch t {
+	case "clnt", "dats", "tims", "int1", "int2", "int4", "int8", "fltp", "lang", "accp", "prec",
+		"d16n", "d34n", "datn", "timn", "utcl", "utclong":
+		return "abap." + t
+	case "string", "rawstring":
+		return fmt.Sprintf("abap.%s(%d)", t, leng)
+	case "dec", "curr", "quan", "d16d", "d34d", "d16r", "d34r":
+		return fmt.Sprintf("abap.%s(%d,%d)", t, leng, dec)
+	default:
+		return fmt.Sprintf("abap.%s(%d)", t, leng)
+	}
+}
+
+func ddicEnhancementCategory(exclass string) string {
+	switch exclass {
+	case "1":
+		return "NOT_EXTENSIBLE"
+	case "2":
+		return "EXTENSIBLE_CHARACTER"
+	case "3":
+		return "EXTENSIBLE_CHARACTER_NUMERIC"
+	case "4":
+		return "EXTENSIBLE_ANY"
+	default:
+		return "NOT_CLASSIFIED"
+	}
+}
+
+func ddicDataMaintenance(mateflag string) string {
+	switch mateflag {
+	case "X":
+		return "ALLOWED"
+	case "N":
+		return "NOT_ALLOWED"
+	default:
+		return "RESTRICTED"
+	}
+}
+
+// ddicLanguage returns the ISO language of a DDIC object written from file:
+// the DDLANGUAGE (MASTERLANG for message classes) of the file, else the
+// MASTER_LANGUAGE of its abapGit repository, else the logon language.
+func (c *Client) ddicLanguage(info *ABAPFileInfo, file *abapGitDDIC) string {
+	var key string
+	switch info.ObjectType {
+	case ObjectTypeDomain:
+		key = file.DD01V.Language
+	case ObjectTypeDataElement:
+		key = file.DD04V.Language
+	case ObjectTypeTableType:
+		key = file.DD40V.Language
+	case ObjectTypeMessageClass:
+		key = file.T100A.Language
+	}
+	for _, k := range []string{key, file.masterLanguage} {
+		if iso := isoLanguage(k); iso != "" {
+			return iso
+		}
+	}
+	if c.config.Language != "" {
+		return strings.ToUpper(c.config.Language)
+	}
+	return "EN"
+}
+
+// buildDDICObjectXML builds the ADT XML document of a data element, domain,
+// table type or message class in the given ISO language.
+func buildDDICObjectXML(info *ABAPFileInfo, file *abapGitDDIC, packageName, language string) string {
+	v := file
+	header := func(root, namespace string) string {
+		h := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<%s %s xmlns:adtcore="http://www.sap.com/adt/core"
+  adtcore:name="%s"
+  adtcore:type="%s"
+  adtcore:description="%s"
+  adtcore:language="%s"
+  adtcore:masterLanguage="%s">
+`, root, namespace, escapeXML(info.ObjectName), info.ObjectType, escapeXML(info.Description), language, language)
+		if packageName != "" { // Only set on creation
+			h += fmt.Sprintf("  <adtcore:packageRef adtcore:name=\"%s\"/>\n", escapeXML(strings.ToUpper(packageName)))
+		}
+		return h
+	}
+	elem := func(sb *strings.Builder, indent, name, value string) {
+		if value == "" {
+			sb.WriteString(fmt.Sprintf("%s<%s/>\n", indent, name))
+			return
+		}
+		sb.WriteString(fmt.Sprintf("%s<%s>%s</%s>\n", indent, name, escapeXML(value), name))
+	}
+
+	var sb strings.Builder
+	switch info.ObjectType {
+	case ObjectTypeDomain:
+		d := v.DD01V
+		sb.WriteString(header("doma:domain", `xmlns:doma="http://www.sap.com/dictionary/domain"`))
+		sb.WriteString("  <doma:content>\n    <doma:typeInformation>\n")
+		elem(&sb, "      ", "doma:datatype", d.DataType)
+		elem(&sb, "      ", "doma:length", ddicNumber(d.Length))
+		elem(&sb, "      ", "doma:decimals", ddicNumber(d.Decimals))
+		sb.WriteString("    </doma:typeInformation>\n    <doma:outputInformation>\n")
+		elem(&sb, "      ", "doma:length", ddicNumber(d.OutputLen))
+		elem(&sb, "      ", "doma:style", "00")
+		elem(&sb, "      ", "doma:conversionExit", d.ConvExit)
+		elem(&sb, "      ", "doma:signExists", ddicBool(d.SignFlag))
+		elem(&sb, "      ", "doma:lowercase", ddicBool(d.Lowercase))
+		elem(&sb, "      ", "doma:ampmFormat", "false")
+		sb.WriteString("    </doma:outputInformation>\n    <doma:valueInformation>\n")
+		sb.WriteString(fmt.Sprintf("      <doma:valueTableRef adtcore:name=\"%s\"/>\n", escapeXML(d.ValueTable)))
+		elem(&sb, "      ", "doma:appendExists", "false")
+		sb.WriteString("      <doma:fixValues>\n")
+		for _, fv := range v.FixValues {
+			sb.WriteString("        <doma:fixValue>\n")
+			elem(&sb, "          ", "doma:position", fv.Position)
+			elem(&sb, "          ", "doma:low", fv.Low)
+			elem(&sb, "          ", "doma:high", fv.High)
+			elem(&sb, "          ", "doma:text", fv.Text)
+			sb.WriteString("        </doma:fixValue>\n")
+		}
+		sb.WriteString("      </doma:fixValues>\n    </doma:valueInformation>\n  </doma:content>\n</doma:domain>\n")
+
+	case ObjectTypeDataElement:
+		d := v.DD04V
+		typeKind, typeName := "predefinedAbapType", ""
+		switch {
+		case d.RefKind == "D":
+			typeKind, typeName = "domain", d.Domain
+		case d.RefKind == "R" && d.RefType == "C":
+			typeKind, typeName = "refToClifType", d.Domain
+		case d.RefKind == "R":
+			typeKind, typeName = "refToDictionaryType", d.Domain
+		}
+		sb.WriteString(header("blue:wbobj", `xmlns:blue="http://www.sap.com/wbobj/dictionary/dtel"`))
+		sb.WriteString("  <dtel:dataElement xmlns:dtel=\"http://www.sap.com/adt/dictionary/dataelements\">\n")
+		elem(&sb, "    ", "dtel:typeKind", typeKind)
+		elem(&sb, "    ", "dtel:typeName", typeName)
+		elem(&sb, "    ", "dtel:dataType", d.DataType)
+		elem(&sb, "    ", "dtel:dataTypeLength", ddicNumber(d.Length))
+		elem(&sb, "    ", "dtel:dataTypeDecimals", ddicNumber(d.Decimals))
+		for _, label := range []struct{ name, text, length string }{
+			{"short", d.Short, d.ShortLen},
+			{"medium", d.Medium, d.MediumLen},
+			{"long", d.Long, d.LongLen},
+			{"heading", d.Heading, d.HeadingLen},
+		} {
+			elem(&sb, "    ", "dtel:"+label.name+"FieldLabel", label.text)
+			elem(&sb, "    ", "dtel:"+label.name+"FieldLength", ddicNumber(label.length))
+			elem(&sb, "    ", "dtel:"+label.name+"FieldMaxLength", ddicNumber(label.length))
+		}
+		elem(&sb, "    ", "dtel:searchHelp", d.SearchHelp)
+		elem(&sb, "    ", "dtel:searchHelpParameter", d.SearchField)
+		elem(&sb, "    ", "dtel:setGetParameter", d.MemoryID)
+		elem(&sb, "    ", "dtel:defaultComponentName", "")
+		elem(&sb, "    ", "dtel:deactivateInputHistory", "false")
+		elem(&sb, "    ", "dtel:changeDocument", "false")
+		elem(&sb, "    ", "dtel:leftToRightDirection", "false")
+		elem(&sb, "    ", "dtel:deactivateBIDIFiltering", "false")
+		sb.WriteString("  </dtel:dataElement>\n</blue:wbobj>\n")
+
+	case ObjectTypeTableType:
+		t := v.DD40V
+		sb.WriteString(header("ttyp:tableType", `xmlns:ttyp="http://www.sap.com/dictionary/tabletype"`))
+		sb.WriteString("  <ttyp:rowType>\n")
+		if t.RowType != "" {
+			elem(&sb, "    ", "ttyp:typeKind", "dictionaryType")
+			elem(&sb, "    ", "ttyp:typeName", t.RowType)
+		} else {
+			elem(&sb, "    ", "ttyp:typeKind", "predefinedAbapType")
+			sb.WriteString("    <ttyp:builtInType>\n")
+			elem(&sb, "      ", "ttyp:dataType", t.DataType)
+			elem(&sb, "      ", "ttyp:length", ddicNumber(t.Length))
+			elem(&sb, "      ", "ttyp:decimals", ddicNumber(t.Decimals))
+			sb.WriteString("    </ttyp:builtInType>\n")
+		}
+		sb.WriteString("  </ttyp:rowType>\n")
+		elem(&sb, "  ", "ttyp:initialRowCount", "0")
+		elem(&sb, "  ", "ttyp:accessType", ddicAccessType(t.AccessMode))
+		sb.WriteString("  <ttyp:primaryKey ttyp:isVisible=\"true\" ttyp:isEditable=\"true\">\n")
+		elem(&sb, "    ", "ttyp:definition", ddicKeyDefinition(t.KeyDef))
+		elem(&sb, "    ", "ttyp:kind", ddicKeyKind(t.KeyKind))
+		sb.WriteString("  </ttyp:primaryKey>\n</ttyp:tableType>\n")
+
+	case ObjectTypeMessageClass:
+		sb.WriteString(header("mc:messageClass", `xmlns:mc="http://www.sap.com/adt/MessageClass"`))
+		for _, m := range v.Messages {
+			sb.WriteString(fmt.Sprintf("  <mc:messages mc:msgno=\"%s\" mc:msgtext=\"%s\" mc:selfexplainatory=\"true\"/>\n", escapeXML(m.Number), escapeXML(m.Text)))
+		}
+		sb.WriteString("</mc:messageClass>\n")
+	}
+	return sb.String()
+}
+
+// ddicNumber strips the leading zeros of DDIC NUMC values like 000010.
+func ddicNumber(s string) string {
+	s = strings.TrimLeft(s, "0")
+	if s == "" {
+		return "0"
+	}
+	return s
+}
+
+// ddicAccessType maps DD40V-ACCESSMODE to the ADT table type access type.
+func ddicAccessType(mode string) string {
+	switch mode {
+	case "S":
+		return "sorted"
+	case "H":
+		return "hashed"
+	case "I":
+		return "index"
+	case "A":
+		return "any"
+	default:
+		return "standard"
+	}
+}
+
+// ddicKeyDefinition maps DD40V-KEYDEF to the ADT primary key definition.
+func ddicKeyDefinition(keyDef string) string {
+	switch keyDef {
+	case "T":
+		return "rowType"
+	case "K":
+		return "keyComponents"
+	default:
+		return "standard"
+	}
+}
+
+// ddicKeyKind maps DD40V-KEYKIND to the ADT primary key kind.
+func ddicKeyKind(kind string) string {
+	switch kind {
+	case "U":
+		return "unique"
+	case "G":
+		return "generic"
+	default:
+		return "nonUnique"
+	}
+}
+
+func ddicBool(flag string) string {
+	if flag == "X" {
+		return "true"
+	}
+	return "false"
+}
@@ -0,0 +1,255 @@
+package adt
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testDDICFiles = map[string]string{
+	"zorder.tabl.xml": `<?xml version="1.0" encoding="utf-8"?>
+<abapGit version="v1.0.0" serializer="LCL_OBJECT_TABL" serializer_version="v1.0.0">
+ <asx:abap xmlns:asx="http://www.sap.com/abapxml" version="1.0">
+  <asx:values>
+   <DD02V>
+    <TABNAME>ZORDER</TABNAME>
+    <DDLANGUAGE>E</DDLANGUAGE>
+    <TABCLASS>TRANSP</TABCLASS>
+    <DDTEXT>Orders</DDTEXT>
+    <MATEFLAG>X</MATEFLAG>
+    <CONTFLAG>A</CONTFLAG>
+    <EXCLASS>1</EXCLASS>
+   </DD02V>
+   <DD03P_TABLE>
+    <DD03P><FIELDNAME>MANDT</FIELDNAME><KEYFLAG>X</KEYFLAG><ROLLNAME>MANDT</ROLLNAME><NOTNULL>X</NOTNULL></DD03P>
+    <DD03P><FIELDNAME>ORDER_ID</FIELDNAME><KEYFLAG>X</KEYFLAG><ROLLNAME>ZORDER_ID</ROLLNAME><NOTNULL>X</NOTNULL></DD03P>
+    <DD03P><FIELDNAME>AMOUNT</FIELDNAME><DATATYPE>CURR</DATATYPE><LENG>000015</LENG><DECIMALS>000002</DECIMALS><REFTABLE>ZORDER</REFTABLE><REFFIELD>CURRENCY</REFFIELD></DD03P>
+    <DD03P><FIELDNAME>CURRENCY</FIELDNAME><DATATYPE>CUKY</DATATYPE><LENG>000005</LENG></DD03P>
+    <DD03P><FIELDNAME>CREATED_ON</FIELDNAME><DATATYPE>DATS</DATATYPE><LENG>000008</LENG></DD03P>
+    <DD03P><FIELDNAME>.INCLUDE</FIELDNAME><PRECFIELD>ZORDER_ADMIN</PRECFIELD><COMPTYPE>S</COMPTYPE></DD03P>
+   </DD03P_TABLE>
+  </asx:values>
+ </asx:abap>
+</abapGit>`,
+	"zorder_admin.tabl.xml": `<abapGit><asx:abap xmlns:asx="http://www.sap.com/abapxml"><asx:values>
+<DD02V><TABNAME>ZORDER_ADMIN</TABNAME><TABCLASS>INTTAB</TABCLASS><DDTEXT>Admin fields</DDTEXT></DD02V>
+</asx:values></asx:abap></abapGit>`,
+	"zorder_status.doma.xml": `<abapGit><asx:abap xmlns:asx="http://www.sap.com/abapxml"><asx:values>
+<DD01V><DOMNAME>ZORDER_STATUS</DOMNAME><DATATYPE>CHAR</DATATYPE><LENG>000001</LENG><OUTPUTLEN>000001</OUTPUTLEN><VALEXI>X</VALEXI><DDTEXT>Order status</DDTEXT></DD01V>
+<DD07V_TAB>
+ <DD07V><DOMNAME>ZORDER_STATUS</DOMNAME><VALPOS>0001</VALPOS><DOMVALUE_L>N</DOMVALUE_L><DDTEXT>New</DDTEXT></DD07V>
+ <DD07V><DOMNAME>ZORDER_STATUS</DOMNAME><VALPOS>0002</VALPOS><DOMVALUE_L>S</DOMVALUE_L><DDTEXT>Shipped &amp; billed</DDTEXT></DD07V>
+</DD07V_TAB>
+</asx:values></asx:abap></abapGit>`,
+	"zorder_id.dtel.xml": `<abapGit><asx:abap xmlns:asx="http://www.sap.com/abapxml"><asx:values>
+<DD04V><ROLLNAME>ZORDER_ID</ROLLNAME><DOMNAME>ZORDER_ID</DOMNAME><REFKIND>D</REFKIND><DDTEXT>Order ID</DDTEXT><SCRTEXT_S>Order</SCRTEXT_S><SCRLEN1>10</SCRLEN1></DD04V>
+</asx:values></asx:abap></abapGit>`,
+	"zorder_tt.ttyp.xml": `<abapGit><asx:abap xmlns:asx="http://www.sap.com/abapxml"><asx:values>
+<DD40V><TYPENAME>ZORDER_TT</TYPENAME><ROWTYPE>ZORDER</ROWTYPE><ROWKIND>S</ROWKIND><ACCESSMODE>S</ACCESSMODE><KEYDEF>K</KEYDEF><KEYKIND>U</KEYKIND><DDTEXT>Orders</DDTEXT></DD40V>
+</asx:values></asx:abap></abapGit>`,
+	"zorder.msag.xml": `<abapGit><asx:abap xmlns:asx="http://www.sap.com/abapxml"><asx:values>
+<T100A><ARBGB>ZORDER</ARBGB><STEXT>Order messages</STEXT></T100A>
+<T100>
+ <T100><SPRSL>E</SPRSL><ARBGB>ZORDER</ARBGB><MSGNR>001</MSGNR><TEXT>Order &amp;1 not found</TEXT></T100>
+ <T100><SPRSL>E</SPRSL><ARBGB>ZORDER</ARBGB><MSGNR>002</MSGNR><TEXT>Order &amp;1 saved</TEXT></T100>
+</T100>
+</asx:values></asx:abap></abapGit>`,
+}
+
+func writeDDICTestFiles(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range testDDICFiles {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestParseABAPFile_DDIC(t *testing.T) {
+	dir := writeDDICTestFiles(t)
+
+	tests := []struct {
+		file        string
+		objectType  CreatableObjectType
+		name        string
+		description string
+	}{
+		{"zorder.tabl.xml", ObjectTypeTable, "ZORDER", "Orders"},
+		{"zorder_admin.tabl.xml", ObjectTypeStructure, "ZORDER_ADMIN", "Admin fields"},
+		{"zorder_status.doma.xml", ObjectTypeDomain, "ZORDER_STATUS", "Order status"},
+		{"zorder_id.dtel.xml", ObjectTypeDataElement, "ZORDER_ID", "Order ID"},
+		{"zorder_tt.ttyp.xml", ObjectTypeTableType, "ZORDER_TT", "Orders"},
+		{"zorder.msag.xml", ObjectTypeMessageClass, "ZORDER", "Order messages"},
+	}
+	for _, tt := range tests {
+		info, err := ParseABAPFile(filepath.Join(dir, tt.file))
+		if err != nil {
+			t.Errorf("%s: %v", tt.file, err)
+			continue
+		}
+		if info.ObjectType != tt.objectType || info.ObjectName != tt.name || info.Description != tt.description {
+			t.Errorf("%s: got %s %s %q", tt.file, info.ObjectType, info.ObjectName, info.Description)
+		}
+	}
+
+	if _, err := ParseABAPFile(filepath.Join(dir, "zorder.clas.xml")); err == nil {
+		t.Error("expected error for unsupported XML file")
+	}
+}
+
+func TestGenerateDDICTableSource(t *testing.T) {
+	dir := writeDDICTestFiles(t)
+	info, err := ParseABAPFile(filepath.Join(dir, "zorder.tabl.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := readDDICFile(info.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source := generateDDICTableSource(info, file)
+	for _, want := range []string{
+		"@EndUserText.label : 'Orders'",
+		"@AbapCatalog.enhancement.category : #NOT_EXTENSIBLE",
+		"@AbapCatalog.deliveryClass : #A",
+		"@AbapCatalog.dataMaintenance : #ALLOWED",
+		"define table zorder {",
+		"  key mandt : mandt not null;",
+		"  key order_id : zorder_id not null;",
+		"  @Semantics.amount.currencyCode : 'zorder.currency'\n  amount : abap.curr(15,2);",
+		"  currency : abap.cuky(5);",
+		"  created_on : abap.dats;",
+		"  include zorder_admin;",
+	} {
+		if !strings.Contains(source, want) {
+			t.Errorf("expected %q in source:\n%s", want, source)
+		}
+	}
+}
+
+func TestBuildDDICObjectXML(t *testing.T) {
+	dir := writeDDICTestFiles(t)
+	build := func(name, pkg string) string {
+		info, err := ParseABAPFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		file, err := readDDICFile(info.FilePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return buildDDICObjectXML(info, file, pkg, "DE")
+	}
+
+	domain := build("zorder_status.doma.xml", "$zorders")
+	for _, want := range []string{
+		`adtcore:type="DOMA/DD"`,
+		`adtcore:masterLanguage="DE"`,
+		`<adtcore:packageRef adtcore:name="$ZORDERS"/>`,
+		"<doma:datatype>CHAR</doma:datatype>",
+		"<doma:length>1</doma:length>",
+		"<doma:low>S</doma:low>",
+		"<doma:text>Shipped &amp; billed</doma:text>",
+	} {
+		if !strings.Contains(domain, want) {
+			t.Errorf("expected %q in domain:\n%s", want, domain)
+		}
+	}
+
+	dtel := build("zorder_id.dtel.xml", "")
+	if strings.Contains(dtel, "packageRef") {
+		t.Error("expected no package reference on update")
+	}
+	if !strings.Contains(dtel, "<dtel:typeKind>domain</dtel:typeKind>") || !strings.Contains(dtel, "<dtel:shortFieldLabel>Order</dtel:shortFieldLabel>") {
+		t.Errorf("unexpected data element:\n%s", dtel)
+	}
+
+	ttyp := build("zorder_tt.ttyp.xml", "")
+	for _, want := range []string{"<ttyp:typeName>ZORDER</ttyp:typeName>", "<ttyp:accessType>sorted</ttyp:accessType>", "<ttyp:kind>unique</ttyp:kind>"} {
+		if !strings.Contains(ttyp, want) {
+			t.Errorf("expected %q in table type:\n%s", want, ttyp)
+		}
+	}
+
+	msag := build("zorder.msag.xml", "")
+	if strings.Count(msag, "<mc:messages ") != 2 || !strings.Contains(msag, `mc:msgtext="Order &amp;1 saved"`) {
+		t.Errorf("unexpected message class:\n%s", msag)
+	}
+}
+
+func TestDDICLanguage(t *testing.T) {
+	cfg := NewConfig("https://sap.example.com:44300", "user", "pass", WithLanguage("fr"))
+	client := NewClientWithTransport(cfg, NewTransportWithClient(cfg, &mockHTTPClient{}))
+	domain := &ABAPFileInfo{ObjectType: ObjectTypeDomain}
+
+	tests := []struct {
+		name string
+		file *abapGitDDIC
+		want string
+	}{
+		{"file language", &abapGitDDIC{DD01V: ddicDomain{Language: "D"}, masterLanguage: "E"}, "DE"},
+		{"repository language", &abapGitDDIC{masterLanguage: "J"}, "JA"},
+		{"logon language", &abapGitDDIC{}, "FR"},
+	}
+	for _, tt := range tests {
+		if got := client.ddicLanguage(domain, tt.file); got != tt.want {
+			t.Errorf("%s: ddicLanguage = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDeployFromFile_Domain(t *testing.T) {
+	dir := writeDDICTestFiles(t)
+	mock := &mockHTTPClient{
+		responses: []*http.Response{
+			newMockResponse(404, "Not found", nil), // existence check
+			newMockResponse(200, "OK", map[string]string{"X-CSRF-Token": "test-token"}),
+			newMockResponse(200, "", nil), // package check
+			newMockResponse(200, "", nil), // create
+			newMockResponse(200, `<asx:abap><asx:values><DATA><LOCK_HANDLE>handle</LOCK_HANDLE></DATA></asx:values></asx:abap>`, nil),
+			newMockResponse(200, "", nil), // write
+			newMockResponse(200, "", nil), // unlock
+			newMockResponse(200, "", nil), // activate
+		},
+	}
+	cfg := NewConfig("https://sap.example.com:44300", "user", "pass")
+	client := NewClientWithTransport(cfg, NewTransportWithClient(cfg, mock))
+
+	result, err := client.DeployFromFile(context.Background(), filepath.Join(dir, "zorder_status.doma.xml"), "$ZORDERS", "")
+	if err != nil {
+		t.Fatalf("DeployFromFile failed: %v", err)
+	}
+	if !result.Success || !result.Created {
+		t.Fatalf("expected created domain, got %+v", result)
+	}
+	if result.ObjectURL != "/sap/bc/adt/ddic/domains/zorder_status" {
+		t.Errorf("unexpected object URL %s", result.ObjectURL)
+	}
+
+	var create, write *http.Request
+	for _, req := range mock.requests {
+		switch {
+		case req.Method == http.MethodPost && req.URL.Path == "/sap/bc/adt/ddic/domains":
+			create = req
+		case req.Method == http.MethodPut:
+			write = req
+		}
+	}
+	if create == nil || write == nil {
+		t.Fatalf("expected create and write requests, got %d requests", len(mock.requests))
+	}
+	if write.URL.Path != "/sap/bc/adt/ddic/domains/zorder_status" || write.Header.Get("Content-Type") != "application/vnd.sap.adt.domains.v2+xml" {
+		t.Errorf("unexpected write request %s %s", write.URL.Path, write.Header.Get("Content-Type"))
+	}
+	body, _ := io.ReadAll(write.Body)
+	if !strings.Contains(string(body), "<doma:low>N</doma:low>") {
+		t.Errorf("expected fixed values in body, got %s", body)
+	}
+}
@@ -129,6 +129,37 @@ type ActivationResultMessage struct {
 	ShortText      string `json:"shortText"`
 }
 
+// ActivationError reports an activation that finished with errors.
+// Lock messages (object locked or being edited elsewhere) are recognized
+// by IsLockConflictError.
+type ActivationError struct {
+	Object   string
+	Messages []ActivationResultMessage
+}
+
+func (e *ActivationError) Error() string {
+	var texts []string
+	for _, m := range e.Messages {
+		if m.Type == "E" || m.Type == "A" || m.Type == "X" {
+			texts = append(texts, m.ShortText)
+		}
+	}
+	if len(texts) == 0 {
+		return fmt.Sprintf("activation failed for %s", e.Object)
+	}
+	return fmt.Sprintf("activation failed for %s: %s", e.Object, strings.Join(texts, "; "))
+}
+
+// IsLockConflict returns true if an activation message says an object is locked elsewhere.
+func (e *ActivationError) IsLockConflict() bool {
+	for _, m := range e.Messages {
+		if isLockMessage(m.ShortText) {
+			return true
+		}
+	}
+	return false
+}
+
 // InactiveObject represents an inactive object.
 type InactiveObject struct {
 	URI       string `json:"uri"`
@@ -149,15 +180,37 @@ type InactiveObjectRecord struct {
 // objectURL is the ADT URL of the object (e.g., "/sap/bc/adt/programs/programs/ZTEST")
 // objectName is the technical name (e.g., "ZTEST")
 func (c *Client) Activate(ctx context.Context, objectURL string, objectName string) (*ActivationResult, error) {
+	return c.activate(ctx, "Activate", []ObjectReference{{URI: objectURL, Name: objectName}})
+}
+
+// ActivateObjects activates several objects in a single request (mass activation).
+// SAP resolves the dependencies between the objects, so objects that reference
+// each other can be activated together even if none of them is active yet.
+func (c *Client) ActivateObjects(ctx context.Context, objects []ObjectReference) (*ActivationResult, error) {
+	if len(objects) == 0 {
+		return &ActivationResult{Success: true, Messages: []ActivationResultMessage{}, Inactive: []InactiveObject{}}, nil
+	}
+	return c.activate(ctx, "ActivateObjects", objects)
+}
+
+func (c *Client) activate(ctx context.Context, opName string, objects []ObjectReference) (*ActivationResult, error) {
 	// Safety check
-	if err := c.checkSafety(OpActivate, "Activate"); err != nil {
+	if err := c.checkSafety(OpActivate, opName); err != nil {
 		return nil, err
 	}
 
+	var refs strings.Builder
+	for _, obj := range objects {
+		refs.WriteString("\n  <adtcore:objectReference")
+		fmt.Fprintf(&refs, ` adtcore:uri="%s"`, escapeXML(obj.URI))
+		if obj.Type != "" {
+			fmt.Fprintf(&refs, ` adtcore:type="%s"`, escapeXML(obj.Type))
+		}
+		fmt.Fprintf(&refs, ` adtcore:name="%s"/>`, escapeXML(obj.Name))
+	}
 	body := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
-<adtcore:objectReferences xmlns:adtcore="http://www.sap.com/adt/core">
-  <adtcore:objectReference adtcore:uri="%s" adtcore:name="%s"/>
-</adtcore:objectReferences>`, objectURL, objectName)
+<adtcore:objectReferences xmlns:adtcore="http://www.sap.com/adt/core">%s
+</adtcore:objectReferences>`, refs.String())
 
 	resp, err := c.transport.Request(ctx, "/sap/bc/adt/activation?method=activate&preauditRequested=true", &RequestOptions{
 		Method:      http.MethodPost,
@@ -225,6 +278,13 @@ func parseActivationResult(data []byte) (*ActivationResult, error) {
 		})
 		return result, nil
 	}
+	// Activation errors usually come as a bare <chkl:messages> document
+	if len(resp.Messages.Msgs) == 0 {
+		var root messages
+		if xml.Unmarshal(data, &root) == nil {
+			resp.Messages = root
+		}
+	}
 
 	for _, m := range resp.Messages.Msgs {
 		result.Messages = append(result.Messages, ActivationResultMessage{
@@ -361,15 +421,18 @@ type ActivationFailed struct {
 // Lower number = activate first (interfaces before classes, etc.)
 func objectTypePriority(objType string) int {
 	priorities := map[string]int{
-		"DOMA/DD": 1, // Domains first
-		"DTEL/DE": 2, // Data elements
-		"TABL/DT": 3, // Tables/structures
-		"TTYP/TT": 4, // Table types
-		"INTF/OI": 5, // Interfaces before classes
-		"CLAS/OC": 6, // Classes
-		"FUGR/F":  7, // Function groups
-		"PROG/P":  8, // Programs
-		"DDLS/DF": 9, // CDS views
+		string(ObjectTypeDomain):        1,  // Domains first
+		string(ObjectTypeDataElement):   2,  // Data elements
+		string(ObjectTypeTable):         3,  // Tables/structures
+		string(ObjectTypeTableType):     4,  // Table types
+		string(ObjectTypeInterface):     5,  // Interfaces before classes
+		string(ObjectTypeClass):         6,  // Classes
+		string(ObjectTypeFunctionGroup): 7,  // Function groups
+		string(ObjectTypeProgram):       8,  // Programs
+		string(ObjectTypeDDLS):          9,  // CDS views
+		string(ObjectTypeBDEF):          10, // Behavior definitions on top of CDS views
+		string(ObjectTypeSRVD):          11, // Service definitions
+		string(ObjectTypeSRVB):          12, // Service bindings
 	}
 	if p, ok := priorities[objType]; ok {
 		return p
@@ -469,6 +532,7 @@ type UnitTestRunFlags struct {
 	Short     bool `json:"short"`     // Run short duration tests
 	Medium    bool `json:"medium"`    // Run medium duration tests
 	Long      bool `json:"long"`      // Run long duration tests
+	Coverage  bool `json:"coverage"`  // Measure coverage and attach it to the result
 }
 
 // DefaultUnitTestFlags returns the default test run configuration.
@@ -485,7 +549,9 @@ func DefaultUnitTestFlags() UnitTestRunFlags {
 
 // UnitTestResult represents the complete result of a unit test run.
 type UnitTestResult struct {
-	Classes []UnitTestClass `json:"classes"`
+	Classes  []UnitTestClass `json:"classes"`
+	Coverage      *CoverageResult `json:"coverage,omitempty"`      // Set when run with UnitTestRunFlags.Coverage
+	CoverageError string          `json:"coverageError,omitempty"` // Why coverage was requested but is missing
 }
 
 // UnitTestClass represents a test class result.
@@ -541,7 +607,7 @@ func (c *Client) RunUnitTests(ctx context.Context, objectURL string, flags *Unit
 	body := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
 <aunit:runConfiguration xmlns:aunit="http://www.sap.com/adt/aunit">
   <external>
-    <coverage active="false"/>
+    <coverage active="%t"/>
   </external>
   <options>
     <uriType value="semantic"/>
@@ -558,6 +624,7 @@ func (c *Client) RunUnitTests(ctx context.Context, objectURL string, flags *Unit
     </objectSet>
   </adtcore:objectSets>
 </aunit:runConfiguration>`,
+		flags.Coverage,
 		flags.Harmless, flags.Dangerous, flags.Critical,
 		flags.Short, flags.Medium, flags.Long,
 		objectURL)
@@ -572,7 +639,25 @@ func (c *Client) RunUnitTests(ctx context.Context, objectURL string, flags *Unit
 		return nil, fmt.Errorf("running unit tests: %w", err)
 	}
 
-	return parseUnitTestResult(resp.Body)
+	result, err := parseUnitTestResult(resp.Body)
+	if err != nil || !flags.Coverage {
+		return result, err
+	}
+
+	// The test results stand on their own: coverage problems are reported
+	// with them rather than failing the run.
+	if result.Coverage == nil {
+		result.CoverageError = "the test run returned no coverage measurement"
+		return result, nil
+	}
+	coverage, err := c.GetCoverage(ctx, result.Coverage.MeasurementID)
+	if err != nil {
+		result.Coverage = nil
+		result.CoverageError = fmt.Sprintf("fetching coverage: %v", err)
+		return result, nil
+	}
+	result.Coverage = coverage
+	return result, nil
 }
 
 func parseUnitTestResult(data []byte) (*UnitTestResult, error) {
@@ -641,6 +726,11 @@ func parseUnitTestResult(data []byte) (*UnitTestResult, error) {
 		} `xml:"testClasses"`
 	}
 	type runResult struct {
+		External struct {
+			Coverage struct {
+				URI string `xml:"uri,attr"`
+			} `xml:"coverage"`
+		} `xml:"external"`
 		Programs []program `xml:"program"`
 	}
 
@@ -652,6 +742,9 @@ func parseUnitTestResult(data []byte) (*UnitTestResult, error) {
 	result := &UnitTestResult{
 		Classes: []UnitTestClass{},
 	}
+	if id := coverageMeasurementID(resp.External.Coverage.URI); id != "" {
+		result.Coverage = &CoverageResult{MeasurementID: id}
+	}
 
 	// Helper to convert alerts
 	convertAlerts := func(alerts []alert) []UnitTestAlert {
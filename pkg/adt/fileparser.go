@@ -126,11 +126,17 @@ func ParseABAPFile(filePath string) (*ABAPFileInfo, error) {
 		info.ObjectType = ObjectTypeBDEF
 	case strings.HasSuffix(baseName, ".srvd.srvdsrv"):
 		info.ObjectType = ObjectTypeSRVD
+	// DDIC objects and message classes (abapGit XML serialization)
+	case ext == ".xml":
+		if _, _, ok := ddicFileType(baseName); ok {
+			return parseDDICFile(filePath)
+		}
+		return nil, fmt.Errorf("unsupported XML file: %s (expected .tabl.xml, .dtel.xml, .doma.xml, .ttyp.xml or .msag.xml)", baseName)
 	case ext == ".abap":
 		// Generic .abap: detect from content
 		return parseFromContent(filePath)
 	default:
-		return nil, fmt.Errorf("unsupported file extension: %s (expected .clas.abap, .clas.testclasses.abap, .clas.locals_def.abap, .clas.locals_imp.abap, .prog.abap, .intf.abap, .fugr.abap, .func.abap, .ddls.asddls, .bdef.asbdef, or .srvd.srvdsrv)", ext)
+		return nil, fmt.Errorf("unsupported file extension: %s (expected .clas.abap, .clas.testclasses.abap, .clas.locals_def.abap, .clas.locals_imp.abap, .prog.abap, .intf.abap, .fugr.abap, .func.abap, .ddls.asddls, .bdef.asbdef, .srvd.srvdsrv, .tabl.xml, .dtel.xml, .doma.xml, .ttyp.xml, or .msag.xml)", ext)
 	}
 
 	// 2. Parse file content to extract name and metadata
@@ -339,10 +345,10 @@ func parseFunctionModuleName(line string) string {
 	return ""
 }
 
-// parseDDLSName extracts CDS view name from "define view [entity] <name>" or "@AbapCatalog.viewEnhancementCategory"
+// parseDDLSName extracts CDS view name from "define [root] view [entity] <name>" or "@AbapCatalog.viewEnhancementCategory"
 func parseDDLSName(line string) string {
-	// Pattern: define view [entity] NAME
-	re := regexp.MustCompile(`(?i)^\s*define\s+view\s+(?:entity\s+)?([a-z0-9_/]+)`)
+	// Pattern: define [root] view [entity] NAME
+	re := regexp.MustCompile(`(?i)^\s*define\s+(?:root\s+)?view\s+(?:entity\s+)?([a-z0-9_/]+)`)
 	matches := re.FindStringSubmatch(line)
 	if len(matches) > 1 {
 		return strings.ToUpper(matches[1])
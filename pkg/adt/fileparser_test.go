@@ -133,6 +133,34 @@ ENDINTERFACE.
 	}
 }
 
+func TestParseABAPFile_RootViewEntity(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "zi_travel.ddls.asddls")
+
+	source := `@EndUserText.label: 'Travel'
+define root view entity ZI_Travel
+  as select from ztravel
+{
+  key travel_id
+}
+`
+	if err := os.WriteFile(filePath, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := ParseABAPFile(filePath)
+	if err != nil {
+		t.Fatalf("ParseABAPFile failed: %v", err)
+	}
+
+	if info.ObjectName != "ZI_TRAVEL" {
+		t.Errorf("Expected ObjectName ZI_TRAVEL, got %s", info.ObjectName)
+	}
+	if info.ObjectType != ObjectTypeDDLS {
+		t.Errorf("Expected ObjectType %s, got %s", ObjectTypeDDLS, info.ObjectType)
+	}
+}
+
 func TestParseABAPFile_InvalidExtension(t *testing.T) {
 	tmpDir := t.TempDir()
 	filePath := filepath.Join(tmpDir, "test.txt")
@@ -0,0 +1,634 @@
+package adt
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// AbapGitRepo is an abapGit repository read from a ZIP archive.
+type AbapGitRepo struct {
+	StartingFolder string           `json:"startingFolder"`
+	MasterLanguage string           `json:"masterLanguage,omitempty"`
+	Objects        []*AbapGitObject `json:"objects"` // In dependency order
+}
+
+// AbapGitObject is one object of an abapGit repository with all its files.
+type AbapGitObject struct {
+	Type        string            `json:"type"` // abapGit type, e.g. CLAS
+	Name        string            `json:"name"`
+	Folder      string            `json:"folder"` // Folder relative to the starting folder
+	Description string            `json:"description,omitempty"`
+	Include     bool              `json:"include,omitempty"`   // PROG: include program (SUBC I)
+	DependsOn   []string          `json:"dependsOn,omitempty"` // TYPE:NAME of objects in the same repository
+	Files       map[string]string `json:"-"`                   // File suffix (e.g. "abap", "testclasses.abap", "xml") -> content
+
+	refs []string // Names referenced by the XML metadata
+}
+
+// Key returns the object as TYPE:NAME.
+func (o *AbapGitObject) Key() string {
+	return o.Type + ":" + o.Name
+}
+
+// abapGitSourceFiles maps the importable abapGit types to the file holding the main source.
+var abapGitSourceFiles = map[string]string{
+	"CLAS": "abap",
+	"INTF": "abap",
+	"PROG": "abap",
+	"DDLS": "asddls",
+	"BDEF": "asbdef",
+	"SRVD": "srvdsrv",
+}
+
+// abapGitDDICTypes maps the abapGit DDIC types to the object types deployed
+// from their .xml file (see deployDDIC). TABL files may also hold structures.
+var abapGitDDICTypes = map[string]CreatableObjectType{
+	"DOMA": ObjectTypeDomain,
+	"DTEL": ObjectTypeDataElement,
+	"TABL": ObjectTypeTable,
+	"TTYP": ObjectTypeTableType,
+}
+
+// abapGitADTTypes maps abapGit types to ADT types (used for ordering and creation).
+var abapGitADTTypes = map[string]string{
+	"DOMA": string(ObjectTypeDomain),
+	"DTEL": string(ObjectTypeDataElement),
+	"TABL": string(ObjectTypeTable),
+	"TTYP": string(ObjectTypeTableType),
+	"INTF": string(ObjectTypeInterface),
+	"CLAS": string(ObjectTypeClass),
+	"FUGR": string(ObjectTypeFunctionGroup),
+	"PROG": string(ObjectTypeProgram),
+	"DDLS": string(ObjectTypeDDLS),
+	"BDEF": string(ObjectTypeBDEF),
+	"SRVD": string(ObjectTypeSRVD),
+	"SRVB": string(ObjectTypeSRVB),
+}
+
+// abapGitClassIncludes maps abapGit class include files to class include types.
+var abapGitClassIncludes = []struct {
+	suffix  string
+	include ClassIncludeType
+}{
+	{"locals_def.abap", ClassIncludeDefinitions},
+	{"locals_imp.abap", ClassIncludeImplementations},
+	{"macros.abap", ClassIncludeMacros},
+	{"testclasses.abap", ClassIncludeTestClasses},
+}
+
+// ParseAbapGitZip reads an abapGit repository from a ZIP archive.
+// Files outside the STARTING_FOLDER of .abapgit.xml (default: the whole
+// archive) are ignored, as are package (DEVC) objects. Dependencies between
+// objects come from the DDIC references in the .xml metadata files
+// (domains, data elements, row types, superclasses).
+func ParseAbapGitZip(data []byte) (*AbapGitRepo, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("reading ZIP: %w", err)
+	}
+
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("opening %s: %w", f.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", f.Name, err)
+		}
+		files["/"+strings.TrimPrefix(path.Clean("/"+f.Name), "/")] = strings.TrimPrefix(string(content), "\ufeff")
+	}
+
+	repo := &AbapGitRepo{StartingFolder: "/"}
+	if name := rootAbapGitXML(files); name != "" {
+		meta := parseAbapGitXML(files[name])
+		if folder := meta.values["STARTING_FOLDER"]; folder != "" {
+			repo.StartingFolder = path.Join(path.Dir(name), folder) + "/"
+			repo.StartingFolder = strings.ReplaceAll(repo.StartingFolder, "//", "/")
+		}
+		repo.MasterLanguage = meta.values["MASTER_LANGUAGE"]
+	}
+
+	objects := make(map[string]*AbapGitObject)
+	for name, content := range files {
+		if !strings.HasPrefix(name, repo.StartingFolder) {
+			continue
+		}
+		objName, objType, suffix, ok := splitAbapGitFilename(path.Base(name))
+		if !ok || objType == "DEVC" {
+			continue
+		}
+		key := objType + ":" + objName
+		obj := objects[key]
+		if obj == nil {
+			obj = &AbapGitObject{
+				Type:   objType,
+				Name:   objName,
+				Folder: strings.TrimPrefix(path.Dir(name)+"/", repo.StartingFolder),
+				Files:  make(map[string]string),
+			}
+			objects[key] = obj
+		}
+		obj.Files[suffix] = content
+	}
+
+	if len(objects) == 0 {
+		return nil, fmt.Errorf("no abapGit objects found below %s", repo.StartingFolder)
+	}
+
+	byName := make(map[string][]*AbapGitObject)
+	for _, obj := range objects {
+		if content, ok := obj.Files["xml"]; ok {
+			meta := parseAbapGitXML(content)
+			obj.Description = meta.description()
+			obj.Include = obj.Type == "PROG" && meta.values["SUBC"] == "I"
+			obj.refs = meta.refs
+		}
+		byName[obj.Name] = append(byName[obj.Name], obj)
+	}
+	for _, obj := range objects {
+		seen := make(map[string]bool)
+		for _, ref := range obj.refs {
+			for _, dep := range byName[ref] {
+				if dep != obj && !seen[dep.Key()] {
+					seen[dep.Key()] = true
+					obj.DependsOn = append(obj.DependsOn, dep.Key())
+				}
+			}
+		}
+		sort.Strings(obj.DependsOn)
+	}
+
+	repo.Objects = orderAbapGitObjects(objects)
+	return repo, nil
+}
+
+// rootAbapGitXML returns the .abapgit.xml closest to the archive root, e.g.
+// /repo-main/.abapgit.xml in a GitHub download. Files at the same depth are
+// compared by path, so the choice does not depend on the archive order.
+func rootAbapGitXML(files map[string]string) string {
+	var root string
+	for name := range files {
+		if path.Base(name) != ".abapgit.xml" {
+			continue
+		}
+		depth, rootDepth := strings.Count(name, "/"), strings.Count(root, "/")
+		if root == "" || depth < rootDepth || depth == rootDepth && name < root {
+			root = name
+		}
+	}
+	return root
+}
+
+// splitAbapGitFilename splits an abapGit filename like "#dmo#cl_foo.clas.testclasses.abap"
+// into name (/DMO/CL_FOO), type (CLAS) and file suffix (testclasses.abap).
+func splitAbapGitFilename(filename string) (name, objType, suffix string, ok bool) {
+	parts := strings.SplitN(filename, ".", 3)
+	if len(parts) < 3 || parts[0] == "" || len(parts[1]) != 4 {
+		return "", "", "", false
+	}
+	for _, r := range parts[1] {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return "", "", "", false
+		}
+	}
+	name = strings.ToUpper(strings.ReplaceAll(parts[0], "#", "/"))
+	return name, strings.ToUpper(parts[1]), strings.ToLower(parts[2]), true
+}
+
+// orderAbapGitObjects sorts objects by type priority and name, then moves
+// dependencies ahead of the objects that need them. Cycles keep the type order;
+// the mass activation sorts them out.
+func orderAbapGitObjects(objects map[string]*AbapGitObject) []*AbapGitObject {
+	sorted := make([]*AbapGitObject, 0, len(objects))
+	for _, obj := range objects {
+		sorted = append(sorted, obj)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		pi := objectTypePriority(abapGitADTTypes[sorted[i].Type])
+		pj := objectTypePriority(abapGitADTTypes[sorted[j].Type])
+		if pi != pj {
+			return pi < pj
+		}
+		if sorted[i].Type != sorted[j].Type {
+			return sorted[i].Type < sorted[j].Type
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+	ordered := make([]*AbapGitObject, 0, len(sorted))
+	var visit func(obj *AbapGitObject)
+	visit = func(obj *AbapGitObject) {
+		if state[obj.Key()] != 0 {
+			return
+		}
+		state[obj.Key()] = visiting
+		for _, dep := range obj.DependsOn {
+			if d := objects[dep]; d != nil {
+				visit(d)
+			}
+		}
+		state[obj.Key()] = done
+		ordered = append(ordered, obj)
+	}
+	for _, obj := range sorted {
+		visit(obj)
+	}
+	return ordered
+}
+
+// abapGitMeta holds the values of an abapGit .xml metadata file.
+type abapGitMeta struct {
+	values map[string]string // First value of each element
+	title  string            // Program title (TPOOL entry with ID R)
+	refs   []string          // Referenced object names
+}
+
+// abapGitRefElements are metadata elements that name other objects.
+var abapGitRefElements = map[string]bool{
+	"DOMNAME":    true, // DTEL -> DOMA
+	"ROLLNAME":   true, // TABL field -> DTEL
+	"ROWTYPE":    true, // TTYP -> TABL/DTEL
+	"REFCLSNAME": true, // CLAS -> superclass
+	"CHECKTABLE": true, // TABL/DOMA -> check table
+}
+
+func parseAbapGitXML(content string) abapGitMeta {
+	meta := abapGitMeta{values: make(map[string]string)}
+	dec := xml.NewDecoder(strings.NewReader(content))
+	var element, lastID string
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			element = t.Name.Local
+		case xml.EndElement:
+			element = ""
+		case xml.CharData:
+			text := strings.TrimSpace(string(t))
+			if element == "" || text == "" {
+				continue
+			}
+			if _, ok := meta.values[element]; !ok {
+				meta.values[element] = text
+			}
+			switch {
+			case element == "ID":
+				lastID = text
+			case element == "ENTRY" && lastID == "R" && meta.title == "":
+				meta.title = text
+			case abapGitRefElements[element]:
+				meta.refs = append(meta.refs, strings.ToUpper(text))
+			}
+		}
+	}
+	return meta
+}
+
+// description returns the object description from the metadata.
+func (m abapGitMeta) description() string {
+	for _, key := range []string{"DESCRIPT", "DDTEXT", "AREAT", "DESCRIPTION"} {
+		if v := m.values[key]; v != "" {
+			return v
+		}
+	}
+	return m.title
+}
+
+// --- Import ---
+
+// GitImportOptions configures GitImport.
+type GitImportOptions struct {
+	Package   string // Target package for new objects (required)
+	Transport string // Transport request (for transportable packages)
+	DryRun    bool   // Only parse and order the objects
+}
+
+// GitImportObjectResult is the import result of one object.
+type GitImportObjectResult struct {
+	Type      string                    `json:"type"`
+	Name      string                    `json:"name"`
+	ObjectURL string                    `json:"objectUrl,omitempty"`
+	Status    string                    `json:"status"` // planned, created, updated, skipped, failed
+	Activated bool                      `json:"activated"`
+	DependsOn []string                  `json:"dependsOn,omitempty"`
+	Messages  []ActivationResultMessage `json:"messages,omitempty"`
+	Error     string                    `json:"error,omitempty"`
+}
+
+// GitImportResult is the result of GitImport.
+type GitImportResult struct {
+	Package        string                  `json:"package"`
+	StartingFolder string                  `json:"startingFolder"`
+	Success        bool                    `json:"success"`
+	Objects        []GitImportObjectResult `json:"objects"`
+	Activation     *ActivationResult       `json:"activation,omitempty"`
+	Warnings       []string                `json:"warnings,omitempty"`
+	Message        string                  `json:"message"`
+}
+
+// GitImport imports an abapGit ZIP archive (as written by GitExport) into a package.
+//
+// Workflow: Parse → for each object in dependency order: CreateObject if missing,
+// Lock → UpdateSource (main source and class includes) → Unlock → ActivateObjects (one batch)
+//
+// Objects are written inactive and activated together, so objects that depend on
+// each other don't need to be activated one by one. Supported types: CLAS, INTF,
+// PROG (programs and includes), DDLS, BDEF, SRVD and the DDIC objects DOMA,
+// DTEL, TABL (tables and structures) and TTYP, which are written from their
+// .xml files like DeployFromFile does; other objects are reported as skipped.
+// Objects from subpackage folders are imported into the target package as well.
+func (c *Client) GitImport(ctx context.Context, zipData []byte, opts GitImportOptions) (*GitImportResult, error) {
+	if err := c.checkSafety(OpWorkflow, "GitImport"); err != nil {
+		return nil, err
+	}
+	if opts.Package == "" {
+		return nil, fmt.Errorf("package is required")
+	}
+	if err := c.checkTransportableEdit(opts.Transport, "GitImport"); err != nil {
+		return nil, err
+	}
+
+	repo, err := ParseAbapGitZip(zipData)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &GitImportResult{
+		Package:        strings.ToUpper(opts.Package),
+		StartingFolder: repo.StartingFolder,
+		Objects:        []GitImportObjectResult{},
+	}
+	subfolders := 0
+	for _, obj := range repo.Objects {
+		if obj.Folder != "" {
+			subfolders++
+		}
+	}
+	if subfolders > 0 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%d object(s) are in subpackage folders and will be imported into %s", subfolders, result.Package))
+	}
+
+	var toActivate []ObjectReference
+	for _, obj := range repo.Objects {
+		res := GitImportObjectResult{Type: obj.Type, Name: obj.Name, DependsOn: obj.DependsOn}
+		objType, ok := gitImportObjectType(obj)
+		if !ok {
+			res.Status = "skipped"
+			res.Error = fmt.Sprintf("object type %s is not supported by GitImport", obj.Type)
+			result.Objects = append(result.Objects, res)
+			continue
+		}
+		sourceFile := gitImportSourceFile(obj)
+		if _, ok := obj.Files[sourceFile]; !ok {
+			res.Status = "skipped"
+			res.Error = fmt.Sprintf("source file %s.%s.%s is missing", strings.ToLower(obj.Name), strings.ToLower(obj.Type), sourceFile)
+			result.Objects = append(result.Objects, res)
+			continue
+		}
+		var ddicInfo *ABAPFileInfo
+		var ddicFile *abapGitDDIC
+		if IsDDICFileType(objType) {
+			if ddicInfo, ddicFile, err = abapGitDDICFile(obj, objType, repo.MasterLanguage); err != nil {
+				res.Status = "skipped"
+				res.Error = err.Error()
+				result.Objects = append(result.Objects, res)
+				continue
+			}
+			objType = ddicInfo.ObjectType
+		}
+		res.ObjectURL = GetObjectURL(objType, obj.Name, "")
+		if opts.DryRun {
+			res.Status = "planned"
+			result.Objects = append(result.Objects, res)
+			continue
+		}
+
+		if ddicFile != nil {
+			err = c.importAbapGitDDIC(ctx, ddicInfo, ddicFile, res.ObjectURL, opts, &res)
+		} else {
+			err = c.importAbapGitObject(ctx, obj, objType, res.ObjectURL, opts, &res)
+		}
+		if err != nil {
+			res.Status = "failed"
+			res.Error = err.Error()
+		} else {
+			toActivate = append(toActivate, ObjectReference{URI: res.ObjectURL, Type: string(objType), Name: obj.Name})
+		}
+		result.Objects = append(result.Objects, res)
+	}
+
+	if opts.DryRun {
+		result.Success = true
+		result.Message = fmt.Sprintf("Dry run: %d object(s) in %s", len(result.Objects), repo.StartingFolder)
+		return result, nil
+	}
+
+	if len(toActivate) > 0 {
+		activation, err := c.ActivateObjects(ctx, toActivate)
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("activation failed: %v", err))
+		} else {
+			result.Activation = activation
+			applyGitImportActivation(result, activation)
+		}
+	}
+
+	written, activated, failed := 0, 0, 0
+	for _, obj := range result.Objects {
+		switch obj.Status {
+		case "created", "updated":
+			written++
+		case "failed":
+			failed++
+		}
+		if obj.Activated {
+			activated++
+		}
+	}
+	result.Success = failed == 0 && activated == written && (result.Activation == nil || result.Activation.Success)
+	result.Message = fmt.Sprintf("Imported %d object(s) into %s: %d activated, %d failed", written, result.Package, activated, failed)
+	return result, nil
+}
+
+// gitImportObjectType returns the ADT type used to create obj.
+func gitImportObjectType(obj *AbapGitObject) (CreatableObjectType, bool) {
+	if objType, ok := abapGitDDICTypes[obj.Type]; ok {
+		return objType, true
+	}
+	if _, ok := abapGitSourceFiles[obj.Type]; !ok {
+		return "", false
+	}
+	if obj.Include {
+		return ObjectTypeInclude, true
+	}
+	return CreatableObjectType(abapGitADTTypes[obj.Type]), true
+}
+
+// gitImportSourceFile returns the suffix of the file obj is imported from:
+// the main source, or the .xml file for DDIC objects.
+func gitImportSourceFile(obj *AbapGitObject) string {
+	if _, ok := abapGitDDICTypes[obj.Type]; ok {
+		return "xml"
+	}
+	return abapGitSourceFiles[obj.Type]
+}
+
+// abapGitDDICFile parses the .xml file of a DDIC object of a repository with
+// the given master language. The object type of a TABL file becomes
+// ObjectTypeStructure for structures.
+func abapGitDDICFile(obj *AbapGitObject, objType CreatableObjectType, masterLanguage string) (*ABAPFileInfo, *abapGitDDIC, error) {
+	fileName := strings.ToLower(strings.ReplaceAll(obj.Name, "/", "#")) + "." + strings.ToLower(obj.Type) + ".xml"
+	file, err := parseDDICXML([]byte(obj.Files["xml"]))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", fileName, err)
+	}
+	file.masterLanguage = masterLanguage
+	info, err := ddicFileInfo(fileName, objType, obj.Name, file)
+	if err != nil {
+		return nil, nil, err
+	}
+	return info, file, nil
+}
+
+// importAbapGitDDIC creates a DDIC object if needed and writes it from its
+// abapGit .xml file without activating.
+func (c *Client) importAbapGitDDIC(ctx context.Context, info *ABAPFileInfo, file *abapGitDDIC, objectURL string, opts GitImportOptions, res *GitImportObjectResult) error {
+	_, err := c.transport.Request(ctx, objectURL, &RequestOptions{
+		Method: "GET",
+		Accept: "application/*",
+	})
+	create := err != nil && IsNotFoundError(err)
+
+	deploy, err := c.deployDDIC(ctx, info, file, opts.Package, opts.Transport, create, false)
+	if err != nil {
+		return err
+	}
+	if !deploy.Success {
+		return fmt.Errorf("%s", strings.Join(deploy.Errors, "; "))
+	}
+	res.Status = "updated"
+	if create {
+		res.Status = "created"
+	}
+	return nil
+}
+
+// importAbapGitObject creates obj if needed and writes its sources without activating.
+func (c *Client) importAbapGitObject(ctx context.Context, obj *AbapGitObject, objType CreatableObjectType, objectURL string, opts GitImportOptions, res *GitImportObjectResult) error {
+	source := obj.Files[abapGitSourceFiles[obj.Type]]
+
+	res.Status = "updated"
+	_, err := c.transport.Request(ctx, objectURL, &RequestOptions{
+		Method: "GET",
+		Accept: "text/plain",
+	})
+	if err != nil && IsNotFoundError(err) {
+		description := obj.Description
+		if description == "" {
+			description = obj.Name
+		}
+		if len(description) > 60 {
+			description = description[:60]
+		}
+		createOpts := CreateObjectOptions{
+			ObjectType:  objType,
+			Name:        obj.Name,
+			Description: description,
+			PackageName: opts.Package,
+			Transport:   opts.Transport,
+		}
+		if objType == ObjectTypeBDEF {
+			createOpts.Source = source // BDEF requires source embedded in creation request
+		}
+		if err := c.CreateObject(ctx, createOpts); err != nil {
+			return fmt.Errorf("create failed: %w", err)
+		}
+		res.Status = "created"
+	}
+
+	lock, err := c.LockObject(ctx, objectURL, "MODIFY")
+	if err != nil {
+		return fmt.Errorf("lock failed: %w", err)
+	}
+	unlocked := false
+	defer func() {
+		if !unlocked {
+			_ = c.UnlockObject(ctx, objectURL, lock.LockHandle)
+		}
+	}()
+
+	if err := c.UpdateSource(ctx, objectURL+"/source/main", source, lock.LockHandle, opts.Transport); err != nil {
+		return fmt.Errorf("write source failed: %w", err)
+	}
+	if obj.Type == "CLAS" {
+		for _, inc := range abapGitClassIncludes {
+			incSource, ok := obj.Files[inc.suffix]
+			if !ok {
+				continue
+			}
+			err := c.UpdateClassInclude(ctx, obj.Name, inc.include, incSource, lock.LockHandle, opts.Transport)
+			if err != nil && inc.include == ClassIncludeTestClasses {
+				if createErr := c.CreateTestInclude(ctx, obj.Name, lock.LockHandle, opts.Transport); createErr == nil {
+					err = c.UpdateClassInclude(ctx, obj.Name, inc.include, incSource, lock.LockHandle, opts.Transport)
+				}
+			}
+			if err != nil {
+				return fmt.Errorf("write %s include failed: %w", inc.include, err)
+			}
+		}
+	}
+
+	unlocked = true
+	if err := c.UnlockObject(ctx, objectURL, lock.LockHandle); err != nil {
+		return fmt.Errorf("unlock failed: %w", err)
+	}
+	return nil
+}
+
+// applyGitImportActivation assigns activation messages and inactive objects to the object results.
+func applyGitImportActivation(result *GitImportResult, activation *ActivationResult) {
+	for i := range result.Objects {
+		obj := &result.Objects[i]
+		if obj.Status != "created" && obj.Status != "updated" {
+			continue
+		}
+		obj.Activated = true
+		uri := strings.ToLower(obj.ObjectURL)
+		for _, msg := range activation.Messages {
+			href := strings.ToLower(msg.Href)
+			if href != uri && !strings.HasPrefix(href, uri+"/") && !strings.HasPrefix(href, uri+"#") && !strings.HasPrefix(href, uri+"?") {
+				continue
+			}
+			obj.Messages = append(obj.Messages, msg)
+			if strings.ContainsAny(msg.Type, "EAX") {
+				obj.Activated = false
+			}
+		}
+		for _, inactive := range activation.Inactive {
+			if strings.EqualFold(inactive.URI, obj.ObjectURL) || strings.EqualFold(inactive.Name, obj.Name) {
+				obj.Activated = false
+			}
+		}
+	}
+}
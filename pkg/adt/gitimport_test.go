@@ -0,0 +1,204 @@
+package adt
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func buildTestZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+var testAbapGitFiles = map[string]string{
+	".abapgit.xml": `<?xml version="1.0" encoding="utf-8"?>
+<asx:abap xmlns:asx="http://www.sap.com/abapxml" version="1.0">
+ <asx:values>
+  <DATA>
+   <MASTER_LANGUAGE>E</MASTER_LANGUAGE>
+   <STARTING_FOLDER>/src/</STARTING_FOLDER>
+   <FOLDER_LOGIC>PREFIX</FOLDER_LOGIC>
+  </DATA>
+ </asx:values>
+</asx:abap>`,
+	"README.md":                          "# Demo",
+	"src/package.devc.xml":               `<asx:abap><asx:values><DEVC><CTEXT>Demo</CTEXT></DEVC></asx:values></asx:abap>`,
+	"src/zcl_demo.clas.abap":             "CLASS zcl_demo DEFINITION PUBLIC.\nENDCLASS.\nCLASS zcl_demo IMPLEMENTATION.\nENDCLASS.",
+	"src/zcl_demo.clas.testclasses.abap": "CLASS ltcl_demo DEFINITION FOR TESTING.\nENDCLASS.",
+	"src/zcl_demo.clas.xml":              `<asx:abap><asx:values><VSEOCLASS><CLSNAME>ZCL_DEMO</CLSNAME><DESCRIPT>Demo class</DESCRIPT></VSEOCLASS></asx:values></asx:abap>`,
+	"src/zif_demo.intf.abap":             "INTERFACE zif_demo PUBLIC.\nENDINTERFACE.",
+	"src/zif_demo.intf.xml":              `<asx:abap><asx:values><VSEOINTERF><CLSNAME>ZIF_DEMO</CLSNAME><DESCRIPT>Demo interface</DESCRIPT></VSEOINTERF></asx:values></asx:abap>`,
+	"src/sub/zdemo_top.prog.abap":        "DATA gv_x TYPE i.",
+	"src/sub/zdemo_top.prog.xml": `<asx:abap><asx:values><PROGDIR><NAME>ZDEMO_TOP</NAME><SUBC>I</SUBC></PROGDIR>
+<TPOOL><item><ID>R</ID><ENTRY>Demo include</ENTRY></item></TPOOL></asx:values></asx:abap>`,
+	"src/zdemo_tab.tabl.xml": `<asx:abap><asx:values><DD02V><TABNAME>ZDEMO_TAB</TABNAME><DDTEXT>Demo table</DDTEXT></DD02V>
+<DD03P_TABLE><DD03P><FIELDNAME>AMOUNT</FIELDNAME><ROLLNAME>ZDEMO_AMOUNT</ROLLNAME></DD03P></DD03P_TABLE></asx:values></asx:abap>`,
+	"src/zdemo_amount.dtel.xml": `<asx:abap><asx:values><DD04V><ROLLNAME>ZDEMO_AMOUNT</ROLLNAME><DDTEXT>Amount</DDTEXT></DD04V></asx:values></asx:abap>`,
+	"src/#dmo#demo.ddls.asddls": "define view entity /DMO/DEMO as select from zdemo_tab { key amount }",
+}
+
+func TestParseAbapGitZip(t *testing.T) {
+	repo, err := ParseAbapGitZip(buildTestZip(t, testAbapGitFiles))
+	if err != nil {
+		t.Fatalf("ParseAbapGitZip failed: %v", err)
+	}
+
+	if repo.StartingFolder != "/src/" {
+		t.Errorf("expected starting folder /src/, got %s", repo.StartingFolder)
+	}
+	if repo.MasterLanguage != "E" {
+		t.Errorf("expected master language E, got %s", repo.MasterLanguage)
+	}
+
+	var keys []string
+	objects := make(map[string]*AbapGitObject)
+	for _, obj := range repo.Objects {
+		keys = append(keys, obj.Key())
+		objects[obj.Key()] = obj
+	}
+	expected := []string{"DTEL:ZDEMO_AMOUNT", "TABL:ZDEMO_TAB", "INTF:ZIF_DEMO", "CLAS:ZCL_DEMO", "PROG:ZDEMO_TOP", "DDLS:/DMO/DEMO"}
+	if strings.Join(keys, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected order %v, got %v", expected, keys)
+	}
+
+	class := objects["CLAS:ZCL_DEMO"]
+	if class.Description != "Demo class" {
+		t.Errorf("expected class description, got %q", class.Description)
+	}
+	if _, ok := class.Files["testclasses.abap"]; !ok {
+		t.Errorf("expected test class include, got files %v", class.Files)
+	}
+
+	include := objects["PROG:ZDEMO_TOP"]
+	if !include.Include || include.Description != "Demo include" || include.Folder != "sub/" {
+		t.Errorf("unexpected include: %+v", include)
+	}
+	if deps := objects["TABL:ZDEMO_TAB"].DependsOn; len(deps) != 1 || deps[0] != "DTEL:ZDEMO_AMOUNT" {
+		t.Errorf("expected table to depend on data element, got %v", deps)
+	}
+}
+
+func TestParseAbapGitZipRootMetadata(t *testing.T) {
+	files := map[string]string{
+		"repo/.abapgit.xml":               `<asx:abap><asx:values><DATA><MASTER_LANGUAGE>E</MASTER_LANGUAGE><STARTING_FOLDER>/src/</STARTING_FOLDER></DATA></asx:values></asx:abap>`,
+		"repo/vendor/.abapgit.xml":        `<asx:abap><asx:values><DATA><MASTER_LANGUAGE>D</MASTER_LANGUAGE><STARTING_FOLDER>/lib/</STARTING_FOLDER></DATA></asx:values></asx:abap>`,
+		"repo/src/zif_demo.intf.abap":     "INTERFACE zif_demo PUBLIC.\nENDINTERFACE.",
+		"repo/vendor/lib/zif_x.intf.abap": "INTERFACE zif_x PUBLIC.\nENDINTERFACE.",
+	}
+	for i := 0; i < 10; i++ {
+		repo, err := ParseAbapGitZip(buildTestZip(t, files))
+		if err != nil {
+			t.Fatalf("ParseAbapGitZip failed: %v", err)
+		}
+		if repo.StartingFolder != "/repo/src/" || repo.MasterLanguage != "E" {
+			t.Fatalf("expected the root .abapgit.xml, got folder %s, language %s", repo.StartingFolder, repo.MasterLanguage)
+		}
+	}
+}
+
+func TestParseAbapGitZipEmpty(t *testing.T) {
+	_, err := ParseAbapGitZip(buildTestZip(t, map[string]string{"README.md": "nothing here"}))
+	if err == nil {
+		t.Fatal("expected error for archive without objects")
+	}
+}
+
+func TestGitImportDryRun(t *testing.T) {
+	cfg := NewConfig("https://sap.example.com:44300", "user", "pass")
+	client := NewClientWithTransport(cfg, NewTransportWithClient(cfg, &mockHTTPClient{}))
+
+	result, err := client.GitImport(context.Background(), buildTestZip(t, testAbapGitFiles), GitImportOptions{Package: "$tmp", DryRun: true})
+	if err != nil {
+		t.Fatalf("GitImport failed: %v", err)
+	}
+	if !result.Success || result.Package != "$TMP" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if len(result.Warnings) != 1 {
+		t.Errorf("expected a subpackage folder warning, got %v", result.Warnings)
+	}
+
+	status := make(map[string]GitImportObjectResult)
+	for _, obj := range result.Objects {
+		status[obj.Type+":"+obj.Name] = obj
+	}
+	if obj := status["TABL:ZDEMO_TAB"]; obj.Status != "planned" || obj.ObjectURL != "/sap/bc/adt/ddic/tables/zdemo_tab" {
+		t.Errorf("expected TABL to be planned, got %+v", obj)
+	}
+	if obj := status["DTEL:ZDEMO_AMOUNT"]; obj.Status != "planned" || obj.ObjectURL != "/sap/bc/adt/ddic/dataelements/zdemo_amount" {
+		t.Errorf("expected DTEL to be planned, got %+v", obj)
+	}
+	if obj := status["PROG:ZDEMO_TOP"]; obj.Status != "planned" || obj.ObjectURL != "/sap/bc/adt/programs/includes/ZDEMO_TOP" {
+		t.Errorf("expected include to be planned, got %+v", obj)
+	}
+	if obj := status["DDLS:/DMO/DEMO"]; obj.Status != "planned" || obj.ObjectURL != "/sap/bc/adt/ddic/ddl/sources/%2Fdmo%2Fdemo" {
+		t.Errorf("expected DDLS to be planned, got %+v", obj)
+	}
+}
+
+func TestActivateObjects(t *testing.T) {
+	mock := &mockHTTPClient{
+		responses: []*http.Response{
+			newMockResponse(200, "OK", map[string]string{"X-CSRF-Token": "test-token"}),
+			newMockResponse(200, `<?xml version="1.0" encoding="utf-8"?>
+<chkl:messages xmlns:chkl="http://www.sap.com/abapxml/checklist">
+  <msg objDescr="Class ZCL_DEMO" type="E" line="1" href="/sap/bc/adt/oo/classes/zcl_demo/source/main#start=3,1">
+    <shortText><txt>Type ZIF_MISSING is unknown</txt></shortText>
+  </msg>
+</chkl:messages>`, nil),
+		},
+	}
+	cfg := NewConfig("https://sap.example.com:44300", "user", "pass")
+	client := NewClientWithTransport(cfg, NewTransportWithClient(cfg, mock))
+
+	result, err := client.ActivateObjects(context.Background(), []ObjectReference{
+		{URI: "/sap/bc/adt/oo/interfaces/ZIF_DEMO", Type: "INTF/OI", Name: "ZIF_DEMO"},
+		{URI: "/sap/bc/adt/oo/classes/ZCL_DEMO", Type: "CLAS/OC", Name: "ZCL_DEMO"},
+	})
+	if err != nil {
+		t.Fatalf("ActivateObjects failed: %v", err)
+	}
+	if result.Success || len(result.Messages) != 1 {
+		t.Errorf("expected failed activation with one message, got %+v", result)
+	}
+
+	req := mock.requests[len(mock.requests)-1]
+	body, _ := io.ReadAll(req.Body)
+	if strings.Count(string(body), "<adtcore:objectReference ") != 2 {
+		t.Errorf("expected two object references, got %s", body)
+	}
+	if !strings.Contains(string(body), `adtcore:type="CLAS/OC"`) {
+		t.Errorf("expected object types in request, got %s", body)
+	}
+
+	importResult := &GitImportResult{Objects: []GitImportObjectResult{
+		{Type: "INTF", Name: "ZIF_DEMO", ObjectURL: "/sap/bc/adt/oo/interfaces/ZIF_DEMO", Status: "created"},
+		{Type: "CLAS", Name: "ZCL_DEMO", ObjectURL: "/sap/bc/adt/oo/classes/ZCL_DEMO", Status: "updated"},
+	}}
+	applyGitImportActivation(importResult, result)
+	if !importResult.Objects[0].Activated {
+		t.Error("expected interface to be activated")
+	}
+	if importResult.Objects[1].Activated || len(importResult.Objects[1].Messages) != 1 {
+		t.Errorf("expected class activation error, got %+v", importResult.Objects[1])
+	}
+}
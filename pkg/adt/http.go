@@ -436,6 +436,29 @@ func (e *APIError) IsSessionExpired() bool {
 		strings.Contains(msg, "session no longer exists")
 }
 
+// IsLockConflict returns true if the object is locked by another user or session
+// (SAP enqueue lock). Such errors are usually transient.
+func (e *APIError) IsLockConflict() bool {
+	if e.StatusCode != http.StatusForbidden && e.StatusCode != http.StatusConflict && e.StatusCode != http.StatusLocked {
+		return false
+	}
+	return isLockMessage(e.Message)
+}
+
+// isLockMessage reports whether an SAP message says an object is locked elsewhere.
+func isLockMessage(message string) bool {
+	msg := strings.ToLower(message)
+	return strings.Contains(msg, "locked") ||
+		strings.Contains(msg, "enqueue") ||
+		strings.Contains(msg, "currently editing") ||
+		strings.Contains(msg, "being edited")
+}
+
+// IsServerError returns true for 5xx responses.
+func (e *APIError) IsServerError() bool {
+	return e.StatusCode >= 500 && e.StatusCode <= 599
+}
+
 // IsNotFoundError checks if an error is an API 404 Not Found error.
 func IsNotFoundError(err error) bool {
 	if err == nil {
@@ -459,3 +482,31 @@ func IsSessionExpiredError(err error) bool {
 	}
 	return false
 }
+
+// IsLockConflictError checks if an error is caused by a lock held elsewhere.
+func IsLockConflictError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.IsLockConflict()
+	}
+	var actErr *ActivationError
+	if errors.As(err, &actErr) {
+		return actErr.IsLockConflict()
+	}
+	return false
+}
+
+// IsServerError checks if an error is an API 5xx error.
+func IsServerError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.IsServerError()
+	}
+	return false
+}
@@ -359,6 +359,40 @@ func TestIsSessionExpiredError(t *testing.T) {
 	}
 }
 
+func TestIsLockConflictError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil error", nil, false},
+		{"403 locked by user", &APIError{StatusCode: 403, Message: "Object ZCL_TEST is locked by user DEVELOPER"}, true},
+		{"403 enqueue", &APIError{StatusCode: 403, Message: "Enqueue failure"}, true},
+		{"409 currently editing", &APIError{StatusCode: 409, Message: "User X is currently editing ZTEST"}, true},
+		{"403 no authorization", &APIError{StatusCode: 403, Message: "No authorization"}, false},
+		{"500 locked", &APIError{StatusCode: 500, Message: "locked"}, false},
+		{"wrapped lock conflict", fmt.Errorf("activate: %w", &APIError{StatusCode: 403, Message: "locked by BOB"}), true},
+		{"non-API error", fmt.Errorf("locked"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsLockConflictError(tt.err); got != tt.want {
+				t.Errorf("IsLockConflictError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsServerError(t *testing.T) {
+	if IsServerError(nil) || IsServerError(&APIError{StatusCode: 404}) {
+		t.Error("expected false for nil and 404")
+	}
+	if !IsServerError(&APIError{StatusCode: 503}) || !IsServerError(fmt.Errorf("wrapped: %w", &APIError{StatusCode: 500})) {
+		t.Error("expected true for 5xx")
+	}
+}
+
 func TestTransport_Request_CookieAuth(t *testing.T) {
 	mock := &mockHTTPClient{
 		responses: []*http.Response{
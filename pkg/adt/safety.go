@@ -70,6 +70,11 @@ type SafetyConfig struct {
 	//   - User takes responsibility for transport management
 	// Use --allow-transportable-edits or SAP_ALLOW_TRANSPORTABLE_EDITS=true to enable
 	AllowTransportableEdits bool
+
+	// Offline marks a session that serves reads from the local cache without an SAP connection
+	// When true, all operations that change the system (create, update, delete, activate,
+	// lock, workflow, transport) are blocked with an offline-mode error
+	Offline bool
 }
 
 // DefaultSafetyConfig returns a safe default configuration (read-only, no free SQL)
@@ -119,6 +124,9 @@ const (
 	OpTransport    OperationType = 'X' // Transport management (requires explicit opt-in)
 )
 
+// offlineBlockedOps are the operation types that need a live SAP connection to change the system
+const offlineBlockedOps = "CDUALWX"
+
 // IsOperationAllowed checks if an operation type is allowed by the safety config
 func (s *SafetyConfig) IsOperationAllowed(op OperationType) bool {
 	opChar := rune(op)
@@ -128,6 +136,11 @@ func (s *SafetyConfig) IsOperationAllowed(op OperationType) bool {
 		return true
 	}
 
+	// Check Offline mode - blocks all write operations including locks and transports
+	if s.Offline && strings.ContainsRune(offlineBlockedOps, opChar) {
+		return false
+	}
+
 	// Check ReadOnly mode - blocks all write operations
 	if s.ReadOnly {
 		writeOps := "CDUAW" // Create, Delete, Update, Activate, Workflow
@@ -161,6 +174,9 @@ func (s *SafetyConfig) IsOperationAllowed(op OperationType) bool {
 
 // CheckOperation returns an error if the operation is not allowed
 func (s *SafetyConfig) CheckOperation(op OperationType, opName string) error {
+	if s.Offline && !s.DryRun && strings.ContainsRune(offlineBlockedOps, rune(op)) {
+		return fmt.Errorf("operation '%s' is disabled in offline mode: write tools need a connection to the SAP system", opName)
+	}
 	if !s.IsOperationAllowed(op) {
 		return fmt.Errorf("operation '%s' (type %c) is blocked by safety configuration", opName, op)
 	}
@@ -345,6 +361,10 @@ func (s *SafetyConfig) isTransportInWhitelist(transport string) bool {
 func (s *SafetyConfig) String() string {
 	var parts []string
 
+	if s.Offline {
+		parts = append(parts, "OFFLINE")
+	}
+
 	if s.ReadOnly {
 		parts = append(parts, "READ-ONLY")
 	}
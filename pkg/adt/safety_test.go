@@ -1,6 +1,7 @@
 package adt
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -141,6 +142,21 @@ func TestSafetyConfig_CheckOperation(t *testing.T) {
 	}
 }
 
+func TestSafetyConfig_CheckOperation_Offline(t *testing.T) {
+	config := SafetyConfig{Offline: true}
+
+	if err := config.CheckOperation(OpSearch, "SearchObject"); err != nil {
+		t.Errorf("CheckOperation(OpSearch) should not error offline, got: %v", err)
+	}
+
+	for _, op := range []OperationType{OpCreate, OpUpdate, OpDelete, OpActivate, OpLock, OpWorkflow, OpTransport} {
+		err := config.CheckOperation(op, "WriteSource")
+		if err == nil || !strings.Contains(err.Error(), "offline mode") {
+			t.Errorf("CheckOperation(%c) should fail with offline error, got: %v", op, err)
+		}
+	}
+}
+
 func TestSafetyConfig_IsPackageAllowed(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -532,6 +532,91 @@ func (c *Client) listTransportsViaSQL(ctx context.Context, user string) ([]Trans
 	return transports, nil
 }
 
+// GetOpenTransportsForObjects returns the modifiable transport requests that contain
+// any of the given objects. Objects and result keys are "TYPE/NAME" (e.g. "CLAS/ZCL_FOO"),
+// values are request numbers (tasks are resolved to their parent request).
+// Partial entries (LIMU METH, class sections and includes, LIMU REPS) count for their
+// object.
+// Uses E070/E071 via freestyle SQL, so it is subject to the BlockFreeSQL safety setting.
+func (c *Client) GetOpenTransportsForObjects(ctx context.Context, objects []string) (map[string][]string, error) {
+	result := make(map[string][]string)
+	if len(objects) == 0 {
+		return result, nil
+	}
+
+	wanted := make(map[string]bool)
+	var conditions []string
+	seenName := make(map[string]bool)
+	for _, o := range objects {
+		typ, name, _ := strings.Cut(strings.ToUpper(o), "/")
+		wanted[typ+"/"+name] = true
+		if seenName[name] {
+			continue
+		}
+		seenName[name] = true
+		quoted := strings.ReplaceAll(name, "'", "''")
+		conditions = append(conditions, "e071~OBJ_NAME = '"+quoted+"'")
+		if typ == "CLAS" {
+			// LIMU METH (class name padded to 30 + method) and CINC (class name padded with '=')
+			conditions = append(conditions, "e071~OBJ_NAME LIKE '"+quoted+"%'")
+		}
+	}
+
+	query := `SELECT e071~TRKORR, e071~PGMID, e071~OBJECT, e071~OBJ_NAME, e070~STRKORR
+		FROM E071 AS e071
+		INNER JOIN E070 AS e070 ON e071~TRKORR = e070~TRKORR
+		WHERE e070~TRSTATUS = 'D'
+		AND ( ` + strings.Join(conditions, " OR ") + ` )`
+
+	rows, err := c.RunQuery(ctx, query, 1000)
+	if err != nil {
+		return nil, fmt.Errorf("querying open transports: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, row := range rows.Rows {
+		key := e071ObjectKey(getString(row, "PGMID"), getString(row, "OBJECT"), getString(row, "OBJ_NAME"))
+		if !wanted[key] {
+			continue
+		}
+		number := getString(row, "STRKORR")
+		if number == "" {
+			number = getString(row, "TRKORR")
+		}
+		if seen[key+"|"+number] {
+			continue
+		}
+		seen[key+"|"+number] = true
+		result[key] = append(result[key], number)
+	}
+
+	return result, nil
+}
+
+// e071ObjectKey returns the "TYPE/NAME" key of the object an E071 entry belongs to.
+func e071ObjectKey(pgmid, object, objName string) string {
+	if pgmid != "LIMU" {
+		return object + "/" + strings.TrimSpace(objName)
+	}
+	switch object {
+	case "METH": // Class name padded to 30 characters, then the method name
+		if len(objName) > 30 {
+			objName = objName[:30]
+		}
+		return "CLAS/" + strings.TrimSpace(objName)
+	case "CINC": // Class name padded with '=' to 30 characters, then the include suffix
+		if len(objName) > 30 {
+			objName = objName[:30]
+		}
+		return "CLAS/" + strings.TrimRight(objName, "= ")
+	case "CLSD", "CPUB", "CPRO", "CPRI":
+		return "CLAS/" + strings.TrimSpace(objName)
+	case "REPS":
+		return "PROG/" + strings.TrimSpace(objName)
+	}
+	return "LIMU " + object + "/" + strings.TrimSpace(objName)
+}
+
 // getString safely extracts a string value from a row map
 func getString(row map[string]interface{}, key string) string {
 	if v, ok := row[key]; ok {
@@ -286,3 +286,22 @@ func TestTransportTypes(t *testing.T) {
 		t.Errorf("TransportInfo.LockedByUser mismatch")
 	}
 }
+
+func TestE071ObjectKey(t *testing.T) {
+	tests := []struct {
+		pgmid, object, name string
+		want                string
+	}{
+		{"R3TR", "CLAS", "ZCL_ORDER", "CLAS/ZCL_ORDER"},
+		{"LIMU", "METH", "ZCL_ORDER                     SAVE", "CLAS/ZCL_ORDER"},
+		{"LIMU", "CINC", "ZCL_ORDER=====================CCAU", "CLAS/ZCL_ORDER"},
+		{"LIMU", "CPUB", "ZCL_ORDER", "CLAS/ZCL_ORDER"},
+		{"LIMU", "REPS", "ZORDER_REPORT", "PROG/ZORDER_REPORT"},
+		{"LIMU", "FUNC", "Z_ORDER_SAVE", "LIMU FUNC/Z_ORDER_SAVE"},
+	}
+	for _, tt := range tests {
+		if got := e071ObjectKey(tt.pgmid, tt.object, tt.name); got != tt.want {
+			t.Errorf("e071ObjectKey(%s, %s, %q) = %q, want %q", tt.pgmid, tt.object, tt.name, got, tt.want)
+		}
+	}
+}
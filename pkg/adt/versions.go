@@ -0,0 +1,294 @@
+package adt
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// --- Object Version History ---
+//
+// ADT keeps the version history (SE80 "Utilities → Versions") of every source
+// include as an Atom feed at <source URL>/versions. Each entry names the
+// author, the date, the transport request and a URL to the version's source.
+
+// CurrentVersion is the version ID of the current (active) source.
+const CurrentVersion = "current"
+
+// ObjectVersion is one entry of an object's version history.
+type ObjectVersion struct {
+	ID             string `json:"id"`
+	Description    string `json:"description,omitempty"`
+	Author         string `json:"author,omitempty"`
+	Date           string `json:"date,omitempty"` // RFC 3339
+	Transport      string `json:"transport,omitempty"`
+	TransportTitle string `json:"transportTitle,omitempty"`
+	ContentURL     string `json:"contentUrl"`
+}
+
+// versionFeed is the ADT version list (Atom feed).
+type versionFeed struct {
+	Entries []struct {
+		ID      string `xml:"id"`
+		Title   string `xml:"title"`
+		Updated string `xml:"updated"`
+		Author  struct {
+			Name string `xml:"name"`
+		} `xml:"author"`
+		Content struct {
+			Src string `xml:"src,attr"`
+		} `xml:"content"`
+		Links []struct {
+			Href  string `xml:"href,attr"`
+			Rel   string `xml:"rel,attr"`
+			Name  string `xml:"name,attr"`
+			Title string `xml:"title,attr"`
+		} `xml:"link"`
+	} `xml:"entry"`
+}
+
+// transportRelation is the link relation of an entry's transport request.
+const transportRelation = "http://www.sap.com/adt/relations/transport/request"
+
+// versionSourceURL returns the URL of the source include whose versions are listed.
+//
+// Supported types: PROG, CLAS (opts.Include, default main), INTF, FUNC (opts.Parent), INCL, DDLS, BDEF, SRVD.
+func versionSourceURL(objectType, name string, opts *GetSourceOptions) (string, error) {
+	switch strings.ToUpper(objectType) {
+	case "PROG":
+		return GetSourceURL(ObjectTypeProgram, name, ""), nil
+	case "CLAS":
+		include := ClassIncludeMain
+		if opts.Include != "" {
+			include = ClassIncludeType(strings.ToLower(opts.Include))
+		}
+		return fmt.Sprintf("%s/includes/%s", GetObjectURL(ObjectTypeClass, name, ""), url.PathEscape(string(include))), nil
+	case "INTF":
+		return GetSourceURL(ObjectTypeInterface, name, ""), nil
+	case "FUNC":
+		if opts.Parent == "" {
+			return "", fmt.Errorf("parent (function group name) is required for FUNC type")
+		}
+		return GetSourceURL(ObjectTypeFunctionMod, name, opts.Parent), nil
+	case "INCL":
+		return GetSourceURL(ObjectTypeInclude, name, ""), nil
+	case "DDLS":
+		return GetSourceURL(ObjectTypeDDLS, name, ""), nil
+	case "BDEF":
+		return GetSourceURL(ObjectTypeBDEF, name, ""), nil
+	case "SRVD":
+		return GetSourceURL(ObjectTypeSRVD, name, ""), nil
+	default:
+		return "", fmt.Errorf("unsupported object type: %s (supported: PROG, CLAS, INTF, FUNC, INCL, DDLS, BDEF, SRVD)", objectType)
+	}
+}
+
+// GetVersions lists the versions of an object's source, newest first.
+// For classes, opts.Include selects the include (default: main).
+func (c *Client) GetVersions(ctx context.Context, objectType, name string, opts *GetSourceOptions) ([]ObjectVersion, error) {
+	if err := c.checkSafety(OpRead, "GetVersions"); err != nil {
+		return nil, err
+	}
+	if opts == nil {
+		opts = &GetSourceOptions{}
+	}
+
+	sourceURL, err := versionSourceURL(objectType, name, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.transport.Request(ctx, sourceURL+"/versions", &RequestOptions{
+		Method: http.MethodGet,
+		Accept: "application/atom+xml;type=feed",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting versions of %s %s: %w", strings.ToUpper(objectType), strings.ToUpper(name), err)
+	}
+
+	var feed versionFeed
+	if err := xml.Unmarshal(resp.Body, &feed); err != nil {
+		return nil, fmt.Errorf("parsing versions: %w", err)
+	}
+
+	versions := make([]ObjectVersion, 0, len(feed.Entries))
+	for _, e := range feed.Entries {
+		v := ObjectVersion{
+			ID:          e.ID,
+			Description: e.Title,
+			Author:      e.Author.Name,
+			Date:        e.Updated,
+			ContentURL:  e.Content.Src,
+		}
+		for _, link := range e.Links {
+			if link.Rel == transportRelation {
+				v.Transport = link.Name
+				v.TransportTitle = link.Title
+			}
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+// GetVersionSource returns the source of one version from GetVersions.
+// The version is given by its ID (or content URL); CurrentVersion or ""
+// returns the current source. With opts.Method (CLAS only), only that
+// method's METHOD...ENDMETHOD block is returned.
+func (c *Client) GetVersionSource(ctx context.Context, objectType, name, versionID string, opts *GetSourceOptions) (string, error) {
+	if err := c.checkSafety(OpRead, "GetVersionSource"); err != nil {
+		return "", err
+	}
+	if opts == nil {
+		opts = &GetSourceOptions{}
+	}
+
+	if versionID == "" || strings.EqualFold(versionID, CurrentVersion) {
+		return c.GetSource(ctx, objectType, name, opts)
+	}
+
+	versions, err := c.GetVersions(ctx, objectType, name, opts)
+	if err != nil {
+		return "", err
+	}
+	var version *ObjectVersion
+	for i := range versions {
+		if versions[i].ID == versionID || versions[i].ContentURL == versionID {
+			version = &versions[i]
+			break
+		}
+	}
+	if version == nil {
+		return "", fmt.Errorf("version %s of %s %s not found", versionID, strings.ToUpper(objectType), strings.ToUpper(name))
+	}
+
+	source, err := c.GetVersionContent(ctx, *version)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.Method != "" {
+		block, ok := extractMethodBlock(source, opts.Method)
+		if !ok {
+			return "", fmt.Errorf("method %s not found in version %s", strings.ToUpper(opts.Method), versionID)
+		}
+		return block, nil
+	}
+	return source, nil
+}
+
+// GetVersionContent returns the source of a version listed by GetVersions.
+func (c *Client) GetVersionContent(ctx context.Context, version ObjectVersion) (string, error) {
+	if err := c.checkSafety(OpRead, "GetVersionContent"); err != nil {
+		return "", err
+	}
+
+	resp, err := c.transport.Request(ctx, version.ContentURL, &RequestOptions{
+		Method: http.MethodGet,
+		Accept: "text/plain",
+	})
+	if err != nil {
+		return "", fmt.Errorf("getting version %s: %w", version.ID, err)
+	}
+	return string(resp.Body), nil
+}
+
+// DiffVersions returns a unified diff between two versions of an object.
+// toVersion defaults to the current source. With opts.Method (CLAS only),
+// only that method is compared.
+func (c *Client) DiffVersions(ctx context.Context, objectType, name, fromVersion, toVersion string, opts *GetSourceOptions) (*SourceDiff, error) {
+	if fromVersion == "" {
+		return nil, fmt.Errorf("from version is required")
+	}
+	if toVersion == "" {
+		toVersion = CurrentVersion
+	}
+
+	source1, err := c.GetVersionSource(ctx, objectType, name, fromVersion, opts)
+	if err != nil {
+		return nil, err
+	}
+	source2, err := c.GetVersionSource(ctx, objectType, name, toVersion, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	label := fmt.Sprintf("%s:%s", strings.ToUpper(objectType), strings.ToUpper(name))
+	if opts != nil && opts.Method != "" {
+		label += "~" + strings.ToUpper(opts.Method)
+	}
+	return newSourceDiff(label+"@"+fromVersion, label+"@"+toVersion, source1, source2), nil
+}
+
+// RestoreVersionOptions configures RestoreVersion.
+type RestoreVersionOptions struct {
+	Method    string // For CLAS only: restore only this method
+	Transport string // Transport request number
+}
+
+// RestoreVersion writes an old version back as the current source.
+//
+// Workflow: GetVersionSource → WriteSource (lock, write, syntax check, activate)
+//
+// Supported types are those WriteSource updates: PROG, CLAS (main source or
+// one method), INTF, DDLS, BDEF and SRVD. The usual WriteSource safety and
+// transport checks apply.
+func (c *Client) RestoreVersion(ctx context.Context, objectType, name, versionID string, opts *RestoreVersionOptions) (*WriteSourceResult, error) {
+	if err := c.checkSafety(OpWorkflow, "RestoreVersion"); err != nil {
+		return nil, err
+	}
+	if opts == nil {
+		opts = &RestoreVersionOptions{}
+	}
+	if versionID == "" || strings.EqualFold(versionID, CurrentVersion) {
+		return nil, fmt.Errorf("a version ID from GetVersions is required")
+	}
+
+	source, err := c.GetVersionSource(ctx, objectType, name, versionID, &GetSourceOptions{Method: opts.Method})
+	if err != nil {
+		return nil, err
+	}
+
+	// Upsert: the object exists (its versions were just read); without a
+	// package, WriteSource never falls back to creating it.
+	result, err := c.WriteSource(ctx, objectType, name, source, &WriteSourceOptions{
+		Mode:      WriteModeUpsert,
+		Method:    opts.Method,
+		Transport: opts.Transport,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Success {
+		result.Message = fmt.Sprintf("Restored version %s: %s", versionID, result.Message)
+	}
+	return result, nil
+}
+
+// extractMethodBlock returns the METHOD...ENDMETHOD block of method from a class source.
+func extractMethodBlock(source, method string) (string, bool) {
+	method = strings.ToUpper(method)
+	lines := strings.Split(source, "\n")
+	start := -1
+	for i, line := range lines {
+		upper := strings.ToUpper(strings.TrimSpace(line))
+		if start < 0 {
+			rest, ok := strings.CutPrefix(upper, "METHOD ")
+			if !ok {
+				continue
+			}
+			rest = strings.TrimSpace(rest)
+			if rest == method+"." || strings.HasPrefix(rest, method+" ") {
+				start = i
+			}
+			continue
+		}
+		if strings.HasPrefix(upper, "ENDMETHOD") {
+			return strings.Join(lines[start:i+1], "\n"), true
+		}
+	}
+	return "", false
+}
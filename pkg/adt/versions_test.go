@@ -0,0 +1,133 @@
+package adt
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const testVersionFeed = `<?xml version="1.0" encoding="utf-8"?>
+<atom:feed xmlns:atom="http://www.w3.org/2005/Atom" xmlns:adtcore="http://www.sap.com/adt/core">
+  <atom:title>Version List of ZCL_ORDER</atom:title>
+  <atom:entry>
+    <atom:author><atom:name>JSMITH</atom:name></atom:author>
+    <atom:content type="text/plain" src="/sap/bc/adt/oo/classes/zcl_order/includes/main/versions/20260410093000/00002/content"/>
+    <atom:id>00002</atom:id>
+    <atom:link href="/sap/bc/adt/cts/transportrequests/DEVK900123" rel="http://www.sap.com/adt/relations/transport/request" adtcore:name="DEVK900123" adtcore:type="CORR" title="Order totals"/>
+    <atom:title>Order totals</atom:title>
+    <atom:updated>2026-04-10T09:30:00Z</atom:updated>
+  </atom:entry>
+  <atom:entry>
+    <atom:author><atom:name>JDOE</atom:name></atom:author>
+    <atom:content type="text/plain" src="/sap/bc/adt/oo/classes/zcl_order/includes/main/versions/20260301120000/00001/content"/>
+    <atom:id>00001</atom:id>
+    <atom:title>Initial version</atom:title>
+    <atom:updated>2026-03-01T12:00:00Z</atom:updated>
+  </atom:entry>
+</atom:feed>`
+
+const testOldClassSource = `CLASS zcl_order IMPLEMENTATION.
+  METHOD get_total.
+    rv_total = 0.
+  ENDMETHOD.
+  METHOD get_id.
+    rv_id = mv_id.
+  ENDMETHOD.
+ENDCLASS.`
+
+func TestGetVersions(t *testing.T) {
+	mock := &mockHTTPClient{
+		responses: []*http.Response{
+			newMockResponse(200, testVersionFeed, nil),
+		},
+	}
+	cfg := NewConfig("https://sap.example.com:44300", "user", "pass")
+	client := NewClientWithTransport(cfg, NewTransportWithClient(cfg, mock))
+
+	versions, err := client.GetVersions(context.Background(), "CLAS", "ZCL_ORDER", nil)
+	if err != nil {
+		t.Fatalf("GetVersions failed: %v", err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(versions))
+	}
+	v := versions[0]
+	if v.ID != "00002" || v.Author != "JSMITH" || v.Transport != "DEVK900123" || v.TransportTitle != "Order totals" || v.Date != "2026-04-10T09:30:00Z" {
+		t.Errorf("unexpected version: %+v", v)
+	}
+	if versions[1].Transport != "" {
+		t.Errorf("version without transport link: %+v", versions[1])
+	}
+	if got := mock.requests[0].URL.Path; got != "/sap/bc/adt/oo/classes/ZCL_ORDER/includes/main/versions" {
+		t.Errorf("versions URL = %s", got)
+	}
+
+	if _, err := client.GetVersions(context.Background(), "FUNC", "Z_ORDER_TOTAL", nil); err == nil {
+		t.Error("expected error for FUNC without parent")
+	}
+}
+
+func TestGetVersionSourceMethod(t *testing.T) {
+	mock := &mockHTTPClient{
+		responses: []*http.Response{
+			newMockResponse(200, testVersionFeed, nil),
+			newMockResponse(200, testOldClassSource, nil),
+		},
+	}
+	cfg := NewConfig("https://sap.example.com:44300", "user", "pass")
+	client := NewClientWithTransport(cfg, NewTransportWithClient(cfg, mock))
+
+	source, err := client.GetVersionSource(context.Background(), "CLAS", "ZCL_ORDER", "00001", &GetSourceOptions{Method: "get_total"})
+	if err != nil {
+		t.Fatalf("GetVersionSource failed: %v", err)
+	}
+	want := "  METHOD get_total.\n    rv_total = 0.\n  ENDMETHOD."
+	if source != want {
+		t.Errorf("source = %q, want %q", source, want)
+	}
+	if got := mock.requests[1].URL.Path; !strings.HasSuffix(got, "/versions/20260301120000/00001/content") {
+		t.Errorf("content URL = %s", got)
+	}
+}
+
+func TestDiffVersions(t *testing.T) {
+	mock := &mockHTTPClient{
+		responses: []*http.Response{
+			newMockResponse(200, testVersionFeed, nil),
+			newMockResponse(200, testOldClassSource, nil),
+			newMockResponse(200, testVersionFeed, nil),
+			newMockResponse(200, strings.Replace(testOldClassSource, "rv_total = 0.", "rv_total = lines( mt_items ).", 1), nil),
+		},
+	}
+	cfg := NewConfig("https://sap.example.com:44300", "user", "pass")
+	client := NewClientWithTransport(cfg, NewTransportWithClient(cfg, mock))
+
+	diff, err := client.DiffVersions(context.Background(), "CLAS", "ZCL_ORDER", "00001", "00002", nil)
+	if err != nil {
+		t.Fatalf("DiffVersions failed: %v", err)
+	}
+	if diff.Identical || diff.AddedLines != 1 || diff.RemovedLines != 1 {
+		t.Errorf("unexpected diff: %+v", diff)
+	}
+	if diff.Object1 != "CLAS:ZCL_ORDER@00001" || diff.Object2 != "CLAS:ZCL_ORDER@00002" {
+		t.Errorf("labels = %s, %s", diff.Object1, diff.Object2)
+	}
+	if !strings.Contains(diff.Diff, "+    rv_total = lines( mt_items ).") {
+		t.Errorf("diff:\n%s", diff.Diff)
+	}
+}
+
+func TestExtractMethodBlock(t *testing.T) {
+	if _, ok := extractMethodBlock(testOldClassSource, "GET"); ok {
+		t.Error("GET should not match GET_TOTAL or GET_ID")
+	}
+	block, ok := extractMethodBlock(testOldClassSource, "GET_ID")
+	if !ok || !strings.HasPrefix(block, "  METHOD get_id.") || !strings.HasSuffix(block, "ENDMETHOD.") {
+		t.Errorf("block = %q, ok = %v", block, ok)
+	}
+	source := "METHOD zif_order~get_total.\n  rv_total = 1.\nENDMETHOD."
+	if _, ok := extractMethodBlock(source, "zif_order~get_total"); !ok {
+		t.Error("interface method not found")
+	}
+}
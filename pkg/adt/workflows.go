@@ -425,7 +425,8 @@ type DeployResult struct {
 // Workflow: Parse → Create → Lock → SyntaxCheck → Write → Unlock → Activate
 //
 // The function automatically detects the object type and name from the file extension
-// and content. Supported file extensions: .clas.abap, .prog.abap, .intf.abap
+// and content. Supported file extensions: .clas.abap, .prog.abap, .intf.abap, and the
+// abapGit DDIC files .tabl.xml, .dtel.xml, .doma.xml, .ttyp.xml and .msag.xml
 //
 // Example:
 //   result, err := client.CreateFromFile(ctx, "/path/to/zcl_test.clas.abap", "$TMP", "")
@@ -441,6 +442,11 @@ func (c *Client) CreateFromFile(ctx context.Context, filePath, packageName, tran
 		return nil, fmt.Errorf("parsing file: %w", err)
 	}
 
+	// DDIC objects from abapGit .xml files have their own workflow
+	if IsDDICFileType(info.ObjectType) {
+		return c.deployDDICFile(ctx, info, packageName, transport, true, true)
+	}
+
 	// 2. Read source code
 	sourceBytes, err := os.ReadFile(filePath)
 	if err != nil {
@@ -596,13 +602,35 @@ func (c *Client) UpdateFromFile(ctx context.Context, filePath, transport string)
 	if err := c.checkSafety(OpUpdate, "UpdateFromFile"); err != nil {
 		return nil, err
 	}
+	return c.updateFromFile(ctx, filePath, transport, true)
+}
 
+// WriteFromFile writes a file to an existing ABAP object without checking or
+// activating it, leaving an inactive version (like saving in the editor).
+//
+// Workflow: Parse → Lock → Write → Unlock
+//
+// Use SyntaxCheck and Activate afterwards, e.g. for watch mode.
+func (c *Client) WriteFromFile(ctx context.Context, filePath, transport string) (*DeployResult, error) {
+	// Safety check
+	if err := c.checkSafety(OpUpdate, "WriteFromFile"); err != nil {
+		return nil, err
+	}
+	return c.updateFromFile(ctx, filePath, transport, false)
+}
+
+func (c *Client) updateFromFile(ctx context.Context, filePath, transport string, activate bool) (*DeployResult, error) {
 	// 1. Parse file to detect type and name
 	info, err := ParseABAPFile(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("parsing file: %w", err)
 	}
 
+	// DDIC objects from abapGit .xml files have their own workflow
+	if IsDDICFileType(info.ObjectType) {
+		return c.deployDDICFile(ctx, info, "", transport, false, activate)
+	}
+
 	// Check if this is a class include (testclasses, locals_def, etc.)
 	isClassInclude := info.ObjectType == ObjectTypeClass &&
 		info.ClassIncludeType != "" &&
@@ -644,7 +672,7 @@ func (c *Client) UpdateFromFile(ctx context.Context, filePath, transport string)
 	}()
 
 	// 5. Syntax check (skip for class includes - will check after update)
-	if !isClassInclude {
+	if activate && !isClassInclude {
 		syntaxErrors, err := c.SyntaxCheck(ctx, objectURL, source)
 		if err != nil {
 			return &DeployResult{
@@ -737,6 +765,23 @@ func (c *Client) UpdateFromFile(ctx context.Context, filePath, transport string)
 		}, nil
 	}
 
+	// Build result message
+	objTypeStr := string(info.ObjectType)
+	if isClassInclude {
+		objTypeStr = fmt.Sprintf("%s.%s", info.ObjectType, info.ClassIncludeType)
+	}
+
+	if !activate {
+		return &DeployResult{
+			FilePath:   filePath,
+			ObjectURL:  objectURL,
+			ObjectName: info.ObjectName,
+			ObjectType: objTypeStr,
+			Success:    true,
+			Message:    fmt.Sprintf("Wrote %s %s from %s (inactive)", objTypeStr, info.ObjectName, filePath),
+		}, nil
+	}
+
 	// 8. Activate
 	_, err = c.Activate(ctx, objectURL, info.ObjectName)
 	if err != nil {
@@ -751,12 +796,6 @@ func (c *Client) UpdateFromFile(ctx context.Context, filePath, transport string)
 		}, nil
 	}
 
-	// Build result message
-	objTypeStr := string(info.ObjectType)
-	if isClassInclude {
-		objTypeStr = fmt.Sprintf("%s.%s", info.ObjectType, info.ClassIncludeType)
-	}
-
 	return &DeployResult{
 		FilePath:   filePath,
 		ObjectURL:  objectURL,
@@ -778,9 +817,13 @@ func (c *Client) UpdateFromFile(ctx context.Context, filePath, transport string)
 // Supports class includes (.clas.testclasses.abap, .clas.locals_def.abap, etc.)
 // For class includes, the parent class must already exist.
 //
+// Also supports the abapGit XML files of DDIC objects and message classes
+// (.tabl.xml, .dtel.xml, .doma.xml, .ttyp.xml, .msag.xml).
+//
 // Example:
 //   result, err := client.DeployFromFile(ctx, "/path/to/zcl_test.clas.abap", "$TMP", "")
 //   result, err := client.DeployFromFile(ctx, "/path/to/zcl_test.clas.testclasses.abap", "$TMP", "")
+//   result, err := client.DeployFromFile(ctx, "/path/to/zorder_status.doma.xml", "$TMP", "")
 func (c *Client) DeployFromFile(ctx context.Context, filePath, packageName, transport string) (*DeployResult, error) {
 	// 1. Parse file
 	info, err := ParseABAPFile(filePath)
@@ -806,9 +849,13 @@ func (c *Client) DeployFromFile(ctx context.Context, filePath, packageName, tran
 	}
 
 	// Try to get object (if 404, doesn't exist)
+	accept := "text/plain"
+	if IsDDICFileType(info.ObjectType) {
+		accept = "application/*" // DDIC objects are XML documents
+	}
 	_, err = c.transport.Request(ctx, objectURL, &RequestOptions{
 		Method: "GET",
-		Accept: "text/plain",
+		Accept: accept,
 	})
 
 	if err != nil {
@@ -838,6 +885,78 @@ func (c *Client) DeployFromFile(ctx context.Context, filePath, packageName, tran
 	return c.UpdateFromFile(ctx, filePath, transport)
 }
 
+// DeployInactiveFromFile creates or updates an object from a file without
+// checking or activating it, leaving an inactive version. Objects that refer
+// to each other are deployed this way first and then activated together with
+// ActivateObjects.
+//
+// Workflow: Parse → Create (if missing) → Lock → Write → Unlock
+func (c *Client) DeployInactiveFromFile(ctx context.Context, filePath, packageName, transport string) (*DeployResult, error) {
+	info, err := ParseABAPFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("parsing file: %w", err)
+	}
+
+	isClassInclude := info.ObjectType == ObjectTypeClass &&
+		info.ClassIncludeType != "" &&
+		info.ClassIncludeType != ClassIncludeMain
+
+	objectURL, err := c.buildObjectURLWithParent(info.ObjectType, info.ObjectName, info.ParentName)
+	if err != nil {
+		return nil, err
+	}
+
+	accept := "text/plain"
+	if IsDDICFileType(info.ObjectType) {
+		accept = "application/*"
+	}
+	_, err = c.transport.Request(ctx, objectURL, &RequestOptions{
+		Method: "GET",
+		Accept: accept,
+	})
+	if err == nil || !IsNotFoundError(err) || isClassInclude {
+		// Exists (or unknown): write an inactive version. A class include
+		// of a missing class fails at the lock.
+		return c.WriteFromFile(ctx, filePath, transport)
+	}
+
+	if err := c.checkSafety(OpCreate, "DeployInactiveFromFile"); err != nil {
+		return nil, err
+	}
+	if IsDDICFileType(info.ObjectType) {
+		return c.deployDDICFile(ctx, info, packageName, transport, true, false)
+	}
+
+	err = c.CreateObject(ctx, CreateObjectOptions{
+		ObjectType:  info.ObjectType,
+		Name:        info.ObjectName,
+		ParentName:  info.ParentName,
+		Description: info.Description,
+		PackageName: packageName,
+		Transport:   transport,
+	})
+	if err != nil {
+		return &DeployResult{
+			FilePath:   filePath,
+			ObjectName: info.ObjectName,
+			ObjectType: string(info.ObjectType),
+			Success:    false,
+			Errors:     []string{fmt.Sprintf("create failed: %v", err)},
+			Message:    fmt.Sprintf("Failed to create %s %s", info.ObjectType, info.ObjectName),
+		}, nil
+	}
+
+	result, err := c.updateFromFile(ctx, filePath, transport, false)
+	if err != nil {
+		return nil, err
+	}
+	result.Created = true
+	if result.Success {
+		result.Message = fmt.Sprintf("Created %s %s from %s (inactive)", info.ObjectType, info.ObjectName, filePath)
+	}
+	return result, nil
+}
+
 // buildObjectURL constructs the ADT URL for an object type and name
 func (c *Client) buildObjectURL(objType CreatableObjectType, name string) (string, error) {
 	return c.buildObjectURLWithParent(objType, name, "")
@@ -873,6 +992,9 @@ func (c *Client) buildObjectURLWithParent(objType CreatableObjectType, name, par
 		return fmt.Sprintf("/sap/bc/adt/bo/behaviordefinitions/%s", encodedName), nil
 	case ObjectTypeSRVD:
 		return fmt.Sprintf("/sap/bc/adt/ddic/srvd/sources/%s", encodedName), nil
+	// DDIC object types
+	case ObjectTypeTable, ObjectTypeStructure, ObjectTypeDataElement, ObjectTypeDomain, ObjectTypeTableType, ObjectTypeMessageClass:
+		return GetObjectURL(objType, name, ""), nil
 	default:
 		return "", fmt.Errorf("unsupported object type for URL building: %s", objType)
 	}
@@ -1004,13 +1126,21 @@ type SaveToFileResult struct {
 	LineCount  int    `json:"lineCount"`
 	Success    bool   `json:"success"`
 	Message    string `json:"message,omitempty"`
+
+	// abapGit metadata file (classes, interfaces and programs)
+	MetadataPath string `json:"metadataPath,omitempty"`
+	Language     string `json:"language,omitempty"` // Original language (SAP code) from the metadata
 }
 
 // SaveToFile saves an ABAP object's source code to a local file.
 //
-// Workflow: GetSource → WriteFile
+// Workflow: GetSource → WriteFile → GetObjectMetadata → WriteFile (.xml)
 //
 // The file extension is automatically determined based on object type.
+// Classes, interfaces and programs also get their abapGit .xml metadata file
+// (e.g. zcl_foo.clas.xml) next to the source, so the folder can be pulled
+// with abapGit. If the metadata can't be read, the source is still saved and
+// the message says why the .xml file is missing.
 func (c *Client) SaveToFile(ctx context.Context, objType CreatableObjectType, objectName, outputPath string) (*SaveToFileResult, error) {
 	result := &SaveToFileResult{
 		ObjectName: objectName,
@@ -1082,9 +1212,35 @@ func (c *Client) SaveToFile(ctx context.Context, objType CreatableObjectType, ob
 
 	result.Success = true
 	result.Message = fmt.Sprintf("Saved %s %s to %s (%d lines)", objType, objectName, result.FilePath, result.LineCount)
+
+	// 5. Write abapGit metadata next to the source
+	if HasAbapGitMetadata(objType) {
+		if err := c.saveMetadataFile(ctx, objType, objectName, result); err != nil {
+			result.Message += fmt.Sprintf("; metadata not written: %v", err)
+		}
+	}
 	return result, nil
 }
 
+// saveMetadataFile writes the abapGit .xml file for the source saved in result.
+func (c *Client) saveMetadataFile(ctx context.Context, objType CreatableObjectType, objectName string, result *SaveToFileResult) error {
+	meta, err := c.GetObjectMetadata(ctx, objType, objectName)
+	if err != nil {
+		return err
+	}
+	content, err := BuildAbapGitXML(meta)
+	if err != nil {
+		return err
+	}
+	metadataPath := strings.TrimSuffix(result.FilePath, ".abap") + ".xml"
+	if err := os.WriteFile(metadataPath, []byte(content), 0644); err != nil {
+		return err
+	}
+	result.MetadataPath = metadataPath
+	result.Language = meta.Language
+	return nil
+}
+
 // SaveClassIncludeToFile saves a class include's source code to a local file.
 //
 // Workflow: GetClassInclude → WriteFile
@@ -1565,6 +1721,7 @@ type GrepObjectResult struct {
 	Matches    []GrepMatch `json:"matches"`
 	MatchCount int         `json:"matchCount"`
 	Message    string      `json:"message,omitempty"`
+	Err        error       `json:"-"` // Error reading the source, if any
 }
 
 // GrepPackageResult represents the result of grepping an ABAP package.
@@ -1597,13 +1754,8 @@ func (c *Client) GrepObject(ctx context.Context, objectURL, pattern string, case
 		result.ObjectName = parts[len(parts)-1]
 	}
 
-	// Compile regex pattern
-	regexPattern := pattern
-	if caseInsensitive {
-		regexPattern = "(?i)" + pattern
-	}
-	re, err := regexp.Compile(regexPattern)
-	if err != nil {
+	// Validate regex pattern before reading the source
+	if _, err := compileGrepPattern(pattern, caseInsensitive); err != nil {
 		result.Message = fmt.Sprintf("Invalid regex pattern: %v", err)
 		return result, nil
 	}
@@ -1620,13 +1772,35 @@ func (c *Client) GrepObject(ctx context.Context, objectURL, pattern string, case
 	})
 	if err != nil {
 		result.Message = fmt.Sprintf("Failed to read source: %v", err)
+		result.Err = err
 		return result, nil
 	}
 
-	source := string(resp.Body)
+	result.Matches, _ = GrepSource(string(resp.Body), pattern, caseInsensitive, contextLines)
+
+	result.MatchCount = len(result.Matches)
+	result.Success = true
+
+	if result.MatchCount == 0 {
+		result.Message = "No matches found"
+	} else {
+		result.Message = fmt.Sprintf("Found %d match(es) in %s", result.MatchCount, result.ObjectName)
+	}
+
+	return result, nil
+}
+
+// GrepSource searches source code line by line for a regex pattern.
+// Line numbers are 1-based; contextLines adds up to that many lines before/after each match.
+func GrepSource(source, pattern string, caseInsensitive bool, contextLines int) ([]GrepMatch, error) {
+	re, err := compileGrepPattern(pattern, caseInsensitive)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []GrepMatch{}
 	lines := strings.Split(source, "\n")
 
-	// Search for matches
 	for i, line := range lines {
 		if re.MatchString(line) {
 			match := GrepMatch{
@@ -1652,20 +1826,18 @@ func (c *Client) GrepObject(ctx context.Context, objectURL, pattern string, case
 				match.ContextAfter = lines[i+1 : end]
 			}
 
-			result.Matches = append(result.Matches, match)
+			matches = append(matches, match)
 		}
 	}
 
-	result.MatchCount = len(result.Matches)
-	result.Success = true
+	return matches, nil
+}
 
-	if result.MatchCount == 0 {
-		result.Message = "No matches found"
-	} else {
-		result.Message = fmt.Sprintf("Found %d match(es) in %s", result.MatchCount, result.ObjectName)
+func compileGrepPattern(pattern string, caseInsensitive bool) (*regexp.Regexp, error) {
+	if caseInsensitive {
+		pattern = "(?i)" + pattern
 	}
-
-	return result, nil
+	return regexp.Compile(pattern)
 }
 
 // GrepObjectsResult represents the result of grepping multiple ABAP objects.
@@ -1673,7 +1845,9 @@ type GrepObjectsResult struct {
 	Success      bool               `json:"success"`
 	Objects      []GrepObjectResult `json:"objects"`
 	TotalMatches int                `json:"totalMatches"`
+	Errors       []string           `json:"errors,omitempty"` // Objects whose source could not be read
 	Message      string             `json:"message,omitempty"`
+	ReadError    error              `json:"-"` // First error reading a source
 }
 
 // GrepObjects searches for a regex pattern in multiple ABAP objects' source code.
@@ -1704,6 +1878,14 @@ func (c *Client) GrepObjects(ctx context.Context, objectURLs []string, pattern s
 			continue
 		}
 
+		if !objResult.Success {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %s", objectURL, objResult.Message))
+			if result.ReadError == nil {
+				result.ReadError = objResult.Err
+			}
+			continue
+		}
+
 		// Only include objects with matches
 		if objResult.MatchCount > 0 {
 			result.Objects = append(result.Objects, *objResult)
@@ -3210,15 +3392,20 @@ func (c *Client) CompareSource(ctx context.Context, type1, name1, type2, name2 s
 		return nil, fmt.Errorf("getting source for %s %s: %w", type2, name2, err)
 	}
 
+	return newSourceDiff(fmt.Sprintf("%s:%s", type1, name1), fmt.Sprintf("%s:%s", type2, name2), source1, source2), nil
+}
+
+// newSourceDiff builds the SourceDiff of two sources labelled object1 and object2.
+func newSourceDiff(object1, object2, source1, source2 string) *SourceDiff {
 	result := &SourceDiff{
-		Object1:   fmt.Sprintf("%s:%s", type1, name1),
-		Object2:   fmt.Sprintf("%s:%s", type2, name2),
+		Object1:   object1,
+		Object2:   object2,
 		Identical: source1 == source2,
 	}
 
 	if result.Identical {
 		result.Diff = "Sources are identical"
-		return result, nil
+		return result
 	}
 
 	// Generate unified diff
@@ -3237,7 +3424,7 @@ func (c *Client) CompareSource(ctx context.Context, type1, name1, type2, name2 s
 		}
 	}
 
-	return result, nil
+	return result
 }
 
 // generateUnifiedDiff creates a unified diff between two sets of lines.
@@ -291,6 +291,26 @@ WRITE: 'Hello, World!'.`
 	}
 }
 
+// TestGrepSource tests matching and context lines on plain source text
+func TestGrepSource(t *testing.T) {
+	source := "REPORT ztest.\nDATA lv TYPE i.\nlv = 1.\nWRITE lv."
+
+	matches, err := GrepSource(source, "^lv", true, 1)
+	if err != nil {
+		t.Fatalf("GrepSource failed: %v", err)
+	}
+	if len(matches) != 1 || matches[0].LineNumber != 3 {
+		t.Fatalf("Expected one match on line 3, got %+v", matches)
+	}
+	if len(matches[0].ContextBefore) != 1 || matches[0].ContextAfter[0] != "WRITE lv." {
+		t.Errorf("Unexpected context: %+v", matches[0])
+	}
+
+	if _, err := GrepSource(source, "(", false, 0); err == nil {
+		t.Error("Expected error for invalid pattern")
+	}
+}
+
 // TestClient_GrepPackages tests GrepPackages with single package
 func TestClient_GrepPackages(t *testing.T) {
 	packageContents := `<?xml version="1.0" encoding="UTF-8"?>
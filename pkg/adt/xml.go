@@ -140,11 +140,12 @@ type FunctionGroup struct {
 
 // FunctionModule represents a function module.
 type FunctionModule struct {
-	XMLName xml.Name `xml:"functionModule"`
-	URI     string   `xml:"uri,attr"`
-	Type    string   `xml:"type,attr"`
-	Name    string   `xml:"name,attr"`
-	Links   []Link   `xml:"link"`
+	XMLName   xml.Name `xml:"functionModule"`
+	URI       string   `xml:"uri,attr"`
+	Type      string   `xml:"type,attr"`
+	Name      string   `xml:"name,attr"`
+	ChangedAt string   `xml:"changedAt,attr,omitempty"` // adtcore:changedAt, if listed
+	Links     []Link   `xml:"link"`
 }
 
 // Interface represents an ABAP interface structure.
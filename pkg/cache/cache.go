@@ -21,6 +21,7 @@ type Cache interface {
 	DeleteNode(ctx context.Context, id string) error
 	InvalidateNode(ctx context.Context, id string, reason string) error
 	GetNodesByPackage(ctx context.Context, pkg string) ([]*Node, error)
+	FindNodes(ctx context.Context, objectType, objectName string) ([]*Node, error)
 
 	// Edge operations
 	PutEdge(ctx context.Context, edge *Edge) error
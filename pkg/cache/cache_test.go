@@ -2,9 +2,14 @@ package cache_test
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/oisee/vibing-steampunk/pkg/adt"
 	"github.com/oisee/vibing-steampunk/pkg/cache"
 )
 
@@ -225,6 +230,274 @@ func TestMemoryCache_Clear(t *testing.T) {
 	}
 }
 
+func TestMemoryCache_FindNodes(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewMemoryCache(cache.DefaultConfig())
+
+	c.PutNode(ctx, &cache.Node{ID: "CLAS:ZCL_UTIL", ObjectType: "CLAS", ObjectName: "ZCL_UTIL", Valid: true})
+	c.PutNode(ctx, &cache.Node{ID: "ME:ZCL_UTIL=>FORMAT", ObjectType: "METH", ObjectName: "FORMAT",
+		EnclosingType: "CLAS", EnclosingName: "ZCL_UTIL", Valid: true})
+	c.PutNode(ctx, &cache.Node{ID: "PROG:ZCL_UTIL", ObjectType: "PROG", ObjectName: "ZCL_UTIL", Valid: true})
+
+	nodes, err := c.FindNodes(ctx, "CLAS", "ZCL_UTIL")
+	if err != nil {
+		t.Fatalf("FindNodes failed: %v", err)
+	}
+	if len(nodes) != 2 {
+		t.Errorf("Expected class node and method node, got %d nodes", len(nodes))
+	}
+
+	nodes, _ = c.FindNodes(ctx, "", "ZCL_UTIL")
+	if len(nodes) != 3 {
+		t.Errorf("Expected 3 nodes without type filter, got %d", len(nodes))
+	}
+}
+
+func TestAnalyzeImpact(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewMemoryCache(cache.DefaultConfig())
+
+	// ZCL_UTIL <- ZCL_ORDER=>SAVE <- ZORDER_REPORT <- ZORDER_JOB
+	//          <- ZCL_PRICE (USES)
+	c.PutNodes(ctx, []*cache.Node{
+		{ID: "CLAS:ZCL_UTIL", ObjectType: "CLAS", ObjectName: "ZCL_UTIL", Package: "$BASE", Valid: true},
+		{ID: "ME:ZCL_UTIL=>FORMAT", ObjectType: "METH", ObjectName: "FORMAT", EnclosingType: "CLAS", EnclosingName: "ZCL_UTIL", Package: "$BASE", Valid: true},
+		{ID: "ME:ZCL_ORDER=>SAVE", ObjectType: "METH", ObjectName: "SAVE", EnclosingType: "CLAS", EnclosingName: "ZCL_ORDER", Package: "$SD", Valid: true},
+		{ID: "ME:ZCL_ORDER=>LOAD", ObjectType: "METH", ObjectName: "LOAD", EnclosingType: "CLAS", EnclosingName: "ZCL_ORDER", Package: "$SD", Valid: true},
+		{ID: "PROG:ZORDER_REPORT", ObjectType: "PROG", ObjectName: "ZORDER_REPORT", Package: "$SD", Valid: true},
+		{ID: "PROG:ZORDER_JOB", ObjectType: "PROG", ObjectName: "ZORDER_JOB", Package: "$SD", Valid: true},
+		{ID: "CLAS:ZCL_PRICE", ObjectType: "CLAS", ObjectName: "ZCL_PRICE", Package: "$MM", Valid: true},
+	})
+	c.PutEdges(ctx, []*cache.Edge{
+		{FromID: "ME:ZCL_ORDER=>SAVE", ToID: "CLAS:ZCL_UTIL", EdgeType: "CALLS", Valid: true},
+		{FromID: "ME:ZCL_ORDER=>LOAD", ToID: "CLAS:ZCL_UTIL", EdgeType: "CALLS", Valid: true},
+		{FromID: "PROG:ZORDER_REPORT", ToID: "ME:ZCL_ORDER=>SAVE", EdgeType: "CALLS", Valid: true},
+		{FromID: "PROG:ZORDER_JOB", ToID: "PROG:ZORDER_REPORT", EdgeType: "INCLUDES", Valid: true},
+		{FromID: "CLAS:ZCL_PRICE", ToID: "CLAS:ZCL_UTIL", EdgeType: "USES", Valid: true},
+	})
+
+	roots, err := cache.ResolveImpactRoots(ctx, c, "CLAS", "zcl_util")
+	if err != nil {
+		t.Fatalf("ResolveImpactRoots failed: %v", err)
+	}
+
+	t.Run("all hops", func(t *testing.T) {
+		result, err := cache.AnalyzeImpact(ctx, c, roots, cache.ImpactOptions{MaxDepth: 5})
+		if err != nil {
+			t.Fatalf("AnalyzeImpact failed: %v", err)
+		}
+		if result.Total != 4 {
+			t.Fatalf("Expected 4 dependents, got %d", result.Total)
+		}
+		if got := result.ByPackage["$SD"]["CLAS"]; len(got) != 1 || got[0] != "ZCL_ORDER" {
+			t.Errorf("Expected $SD/CLAS = [ZCL_ORDER], got %v", got)
+		}
+		if got := result.ByPackage["$SD"]["PROG"]; len(got) != 2 {
+			t.Errorf("Expected 2 programs in $SD, got %v", got)
+		}
+		if got := result.ByPackage["$MM"]["CLAS"]; len(got) != 1 {
+			t.Errorf("Expected 1 class in $MM, got %v", got)
+		}
+
+		for _, obj := range result.Dependents {
+			switch obj.ObjectName {
+			case "ZCL_ORDER":
+				if obj.Depth != 1 || len(obj.Members) != 2 {
+					t.Errorf("ZCL_ORDER: depth=%d members=%v", obj.Depth, obj.Members)
+				}
+			case "ZORDER_JOB":
+				if obj.Depth != 3 {
+					t.Errorf("ZORDER_JOB: expected depth 3, got %d", obj.Depth)
+				}
+			}
+		}
+	})
+
+	t.Run("depth limit", func(t *testing.T) {
+		result, _ := cache.AnalyzeImpact(ctx, c, roots, cache.ImpactOptions{MaxDepth: 1})
+		if result.Total != 2 {
+			t.Errorf("Expected 2 direct dependents, got %d", result.Total)
+		}
+	})
+
+	t.Run("edge filter", func(t *testing.T) {
+		result, _ := cache.AnalyzeImpact(ctx, c, roots, cache.ImpactOptions{MaxDepth: 5, EdgeTypes: []string{"USES"}})
+		if result.Total != 1 || result.Dependents[0].ObjectName != "ZCL_PRICE" {
+			t.Errorf("Expected only ZCL_PRICE, got %+v", result.Dependents)
+		}
+	})
+
+	t.Run("method root includes class dependents", func(t *testing.T) {
+		result, err := cache.AnalyzeImpact(ctx, c, []string{"ME:ZCL_UTIL=>FORMAT"}, cache.ImpactOptions{MaxDepth: 1})
+		if err != nil {
+			t.Fatalf("AnalyzeImpact failed: %v", err)
+		}
+		if result.Total != 2 {
+			t.Errorf("Expected the 2 direct dependents of ZCL_UTIL, got %+v", result.Dependents)
+		}
+	})
+
+	t.Run("unknown object", func(t *testing.T) {
+		if _, err := cache.ResolveImpactRoots(ctx, c, "", "ZCL_MISSING"); err != cache.ErrNotFound {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+}
+
+func TestSQLiteCache_SourceStore(t *testing.T) {
+	ctx := context.Background()
+	cfg := cache.DefaultConfig()
+	cfg.Type = "sqlite"
+	cfg.Path = filepath.Join(t.TempDir(), "graph.db")
+
+	c, err := cache.NewSQLiteCache(cfg)
+	if err != nil {
+		t.Fatalf("NewSQLiteCache failed: %v", err)
+	}
+	defer c.Close()
+
+	src := &cache.CachedSource{
+		ObjectType: "PROG",
+		ObjectName: "ZTEST",
+		URL:        "/sap/bc/adt/programs/programs/ZTEST",
+		Source:     "REPORT ztest.",
+	}
+	if err := c.PutSource(ctx, src); err != nil {
+		t.Fatalf("PutSource failed: %v", err)
+	}
+
+	got, err := c.GetSourceByURL(ctx, "/sap/bc/adt/programs/programs/ZTEST/source/main")
+	if err != nil {
+		t.Fatalf("GetSourceByURL failed: %v", err)
+	}
+	if got.Source != "REPORT ztest." || got.SourceHash != cache.HashSource("REPORT ztest.") {
+		t.Errorf("Unexpected source: %+v", got)
+	}
+	if _, err := c.GetSource(ctx, "PROG", "ZTEST", "main"); err != cache.ErrNotFound {
+		t.Errorf("Expected ErrNotFound for unknown variant, got %v", err)
+	}
+
+	err = c.PutPackage(ctx, &cache.CachedPackage{
+		Name:        "$ZSD",
+		SubPackages: []string{"$ZSD_UI"},
+		Objects: []*cache.CachedObject{
+			{ObjectType: "CLAS/OC", ObjectName: "ZCL_ORDER"},
+			{ObjectType: "PROG/P", ObjectName: "ZORDER_REPORT"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("PutPackage failed: %v", err)
+	}
+
+	// A search hit without package information keeps the known package
+	c.PutObjects(ctx, []*cache.CachedObject{{ObjectType: "CLAS/OC", ObjectName: "ZCL_ORDER", Description: "Order"}})
+	c.PutObjects(ctx, []*cache.CachedObject{{ObjectType: "CLAS/OC", ObjectName: "ZCL_CUSTOMER", Package: "$ZMD"}})
+
+	pkg, err := c.GetPackage(ctx, "$ZSD")
+	if err != nil {
+		t.Fatalf("GetPackage failed: %v", err)
+	}
+	if len(pkg.SubPackages) != 1 || len(pkg.Objects) != 2 {
+		t.Errorf("Expected 1 subpackage and 2 objects, got %+v", pkg)
+	}
+
+	hits, err := c.SearchObjects(ctx, "zcl_*", 10)
+	if err != nil {
+		t.Fatalf("SearchObjects failed: %v", err)
+	}
+	if len(hits) != 2 || hits[1].ObjectName != "ZCL_ORDER" || hits[1].Package != "$ZSD" {
+		t.Errorf("Unexpected search hits: %+v", hits)
+	}
+
+	if pkg, err := c.GetPackage(ctx, "$ZMD"); err != nil || len(pkg.Objects) != 1 {
+		t.Errorf("Expected unlisted package from catalog, got %+v, %v", pkg, err)
+	}
+	if _, err := c.GetPackage(ctx, "$NONE"); err != cache.ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestSQLiteCache_SearchCode(t *testing.T) {
+	ctx := context.Background()
+	cfg := cache.DefaultConfig()
+	cfg.Type = "sqlite"
+	cfg.Path = filepath.Join(t.TempDir(), "graph.db")
+
+	c, err := cache.NewSQLiteCache(cfg)
+	if err != nil {
+		t.Fatalf("NewSQLiteCache failed: %v", err)
+	}
+	defer c.Close()
+
+	sources := []*cache.CachedSource{
+		{ObjectType: "PROG", ObjectName: "ZORDER_REPORT", Package: "$ZSD",
+			Source: "REPORT zorder_report.\nDATA lv_amount TYPE p.\nREAD TABLE lt_orders INTO ls_order BINARY SEARCH.\nWRITE lv_amount."},
+		{ObjectType: "CLAS", ObjectName: "ZCL_ORDER", Package: "$ZSD",
+			Source: "CLASS zcl_order DEFINITION.\nENDCLASS.\nCLASS zcl_order IMPLEMENTATION.\n  METHOD get.\n    READ TABLE mt_items INTO ls_item INDEX 1.\n  ENDMETHOD.\nENDCLASS."},
+		{ObjectType: "CLAS", ObjectName: "ZCL_CUSTOMER", Package: "$ZMD",
+			Source: "CLASS zcl_customer DEFINITION.\nENDCLASS.\n* table of orders\n"},
+	}
+	for _, src := range sources {
+		if err := c.PutSource(ctx, src); err != nil {
+			t.Fatalf("PutSource failed: %v", err)
+		}
+	}
+
+	search := func(opts cache.CodeSearchOptions) []*cache.CodeHit {
+		t.Helper()
+		hits, err := c.SearchCode(ctx, opts)
+		if err != nil {
+			t.Fatalf("SearchCode(%q) failed: %v", opts.Query, err)
+		}
+		return hits
+	}
+
+	// Phrase: "read table" matches both programs, not the comment in ZCL_CUSTOMER
+	hits := search(cache.CodeSearchOptions{Query: `"read table"`})
+	if len(hits) != 2 {
+		t.Fatalf("Expected 2 phrase hits, got %d", len(hits))
+	}
+	for _, hit := range hits {
+		if hit.MatchCount != 1 || !strings.Contains(strings.ToUpper(hit.Matches[0].MatchedLine), "READ TABLE") {
+			t.Errorf("Unexpected matches for %s: %+v", hit.ObjectName, hit.Matches)
+		}
+	}
+
+	// Prefix with context lines
+	hits = search(cache.CodeSearchOptions{Query: "lv_amou*", ContextLines: 1})
+	if len(hits) != 1 || hits[0].ObjectName != "ZORDER_REPORT" || hits[0].MatchCount != 2 {
+		t.Fatalf("Unexpected prefix hits: %+v", hits)
+	}
+	if len(hits[0].Matches[0].ContextBefore) != 1 || len(hits[0].Matches[0].ContextAfter) != 1 {
+		t.Errorf("Expected 1 line of context, got %+v", hits[0].Matches[0])
+	}
+
+	// Type and package filters
+	if hits := search(cache.CodeSearchOptions{Query: "table", Types: []string{"clas"}}); len(hits) != 2 {
+		t.Errorf("Expected 2 CLAS hits, got %d", len(hits))
+	}
+	if hits := search(cache.CodeSearchOptions{Query: "table", Packages: []string{"$zmd"}}); len(hits) != 1 || hits[0].ObjectName != "ZCL_CUSTOMER" {
+		t.Errorf("Unexpected package-filtered hits: %+v", hits)
+	}
+
+	// Updated and deleted sources are reflected in the index
+	sources[1].Source = "CLASS zcl_order DEFINITION.\nENDCLASS."
+	if err := c.PutSource(ctx, sources[1]); err != nil {
+		t.Fatalf("PutSource failed: %v", err)
+	}
+	if err := c.DeleteSource(ctx, "PROG", "ZORDER_REPORT", ""); err != nil {
+		t.Fatalf("DeleteSource failed: %v", err)
+	}
+	if hits := search(cache.CodeSearchOptions{Query: `"read table"`}); len(hits) != 0 {
+		t.Errorf("Expected no hits after update and delete, got %+v", hits)
+	}
+
+	listed, err := c.ListSources(ctx, "$ZSD")
+	if err != nil || len(listed) != 1 || listed[0].ObjectName != "ZCL_ORDER" {
+		t.Errorf("Unexpected ListSources result: %+v, %v", listed, err)
+	}
+}
+
 func BenchmarkMemoryCache_PutNode(b *testing.B) {
 	ctx := context.Background()
 	c := cache.NewMemoryCache(cache.DefaultConfig())
@@ -262,3 +535,158 @@ func BenchmarkMemoryCache_GetNode(b *testing.B) {
 		c.GetNode(ctx, string(rune(i%1000)))
 	}
 }
+
+func TestSyncSources_FunctionGroup(t *testing.T) {
+	ctx := context.Background()
+	cfg := cache.DefaultConfig()
+	cfg.Type = "sqlite"
+	cfg.Path = filepath.Join(t.TempDir(), "graph.db")
+	c, err := cache.NewSQLiteCache(cfg)
+	if err != nil {
+		t.Fatalf("NewSQLiteCache failed: %v", err)
+	}
+	defer c.Close()
+
+	cachedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, src := range []*cache.CachedSource{
+		{ObjectType: "FUNC", ObjectName: "Z_FM", Package: "$SD", URL: cache.SourceObjectURL("FUNC", "Z_FM", "ZFG"), Source: "FUNCTION z_fm.", LastModifiedADT: cachedAt},
+		{ObjectType: "PROG", ObjectName: "ZOLD", Package: "$SD", URL: cache.SourceObjectURL("PROG", "ZOLD", ""), Source: "REPORT zold.", LastModifiedADT: cachedAt},
+	} {
+		if err := c.PutSource(ctx, src); err != nil {
+			t.Fatalf("PutSource failed: %v", err)
+		}
+	}
+
+	const listing = `<asx:abap xmlns:asx="http://www.sap.com/abapxml"><asx:values><DATA><TREE_CONTENT>
+<SEU_ADT_REPOSITORY_OBJ_NODE><OBJECT_TYPE>FUGR/F</OBJECT_TYPE><OBJECT_NAME>ZFG</OBJECT_NAME></SEU_ADT_REPOSITORY_OBJ_NODE>
+<SEU_ADT_REPOSITORY_OBJ_NODE><OBJECT_TYPE>PROG/P</OBJECT_TYPE><OBJECT_NAME>ZREP</OBJECT_NAME></SEU_ADT_REPOSITORY_OBJ_NODE>
+</TREE_CONTENT></DATA></asx:values></asx:abap>`
+	groupFails := true
+	moduleReads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "discovery"):
+			w.Header().Set("X-CSRF-Token", "token")
+		case strings.HasSuffix(r.URL.Path, "/nodestructure"):
+			w.Write([]byte(listing))
+		case strings.HasSuffix(r.URL.Path, "/functions/groups/ZFG"):
+			if groupFails {
+				http.Error(w, "group locked", http.StatusInternalServerError)
+				return
+			}
+			w.Write([]byte(`<group name="ZFG"><functionModule name="Z_FM" changedAt="2025-06-01T00:00:00Z"/></group>`))
+		case strings.Contains(r.URL.Path, "/fmodules/"):
+			moduleReads++
+			http.NotFound(w, r)
+		case strings.HasSuffix(r.URL.Path, "/programs/programs/ZREP"):
+			w.Write([]byte(`<program:abapProgram xmlns:adtcore="http://www.sap.com/adt/core" adtcore:changedAt="2026-02-01T00:00:00Z"/>`))
+		case strings.HasSuffix(r.URL.Path, "/programs/programs/ZREP/source/main"):
+			w.Write([]byte("REPORT zrep."))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := adt.NewClient(server.URL, "u", "p")
+
+	result, err := cache.SyncSources(ctx, client, c, []string{"$SD"}, cache.SyncOptions{})
+	if err != nil {
+		t.Fatalf("SyncSources failed: %v", err)
+	}
+	if result.Updated != 1 || result.Removed != 1 || len(result.Failed) != 1 {
+		t.Errorf("Expected ZREP updated, ZOLD removed and ZFG failed, got %+v", result)
+	}
+	if _, err := c.GetSource(ctx, "FUNC", "Z_FM", ""); err != nil {
+		t.Errorf("Function module of the unreadable group was removed: %v", err)
+	}
+
+	// The group listing has the module's timestamp: no metadata read needed
+	groupFails = false
+	result, err = cache.SyncSources(ctx, client, c, []string{"$SD"}, cache.SyncOptions{})
+	if err != nil {
+		t.Fatalf("SyncSources failed: %v", err)
+	}
+	if moduleReads != 0 || result.Unchanged != 2 || len(result.Failed) != 0 {
+		t.Errorf("Expected Z_FM unchanged without a read, got %d reads, %+v", moduleReads, result)
+	}
+}
+
+func TestSQLiteCache_TestHistory(t *testing.T) {
+	ctx := context.Background()
+	cfg := cache.DefaultConfig()
+	cfg.Type = "sqlite"
+	cfg.Path = filepath.Join(t.TempDir(), "graph.db")
+
+	c, err := cache.NewSQLiteCache(cfg)
+	if err != nil {
+		t.Fatalf("NewSQLiteCache failed: %v", err)
+	}
+	defer c.Close()
+
+	start := time.Date(2026, 1, 1, 2, 0, 0, 0, time.UTC)
+	record := func(day int, method, hash string, passed bool) *cache.TestRecord {
+		return &cache.TestRecord{
+			RunID:      "run",
+			System:     "DEV",
+			ObjectType: "CLAS",
+			ObjectName: "ZCL_ORDER",
+			ClassName:  "LTCL_TEST",
+			Method:     method,
+			RunAt:      start.AddDate(0, 0, day),
+			Passed:     passed,
+			Message:    "failed on day " + string(rune('0'+day)),
+			SourceHash: hash,
+			Duration:   1500 * time.Millisecond,
+		}
+	}
+	records := []*cache.TestRecord{
+		// Flips on the same source: flaky
+		record(0, "FLAKY", "h1", true),
+		record(1, "FLAKY", "h1", false),
+		record(2, "FLAKY", "h1", true),
+		record(3, "FLAKY", "h1", false),
+		// Failed after a source change: a regression, not flaky
+		record(0, "REGRESSION", "h1", true),
+		record(1, "REGRESSION", "h2", false),
+		record(2, "REGRESSION", "h2", false),
+		// No source hash: cannot tell
+		record(0, "UNKNOWN", "", true),
+		record(1, "UNKNOWN", "", false),
+	}
+	if err := c.RecordTests(ctx, records); err != nil {
+		t.Fatalf("RecordTests failed: %v", err)
+	}
+
+	history, err := c.TestHistory(ctx, cache.TestHistoryQuery{ObjectName: "zcl_ord*", Method: "flaky"})
+	if err != nil {
+		t.Fatalf("TestHistory failed: %v", err)
+	}
+	if len(history) != 4 || !history[0].RunAt.Equal(start.AddDate(0, 0, 3)) || history[0].Passed ||
+		history[0].Duration != 1500*time.Millisecond || history[0].Attempt != 1 {
+		t.Errorf("history = %+v", history)
+	}
+
+	flaky, err := c.FlakyTests(ctx, cache.FlakyOptions{System: "DEV"})
+	if err != nil {
+		t.Fatalf("FlakyTests failed: %v", err)
+	}
+	if len(flaky) != 1 {
+		t.Fatalf("expected 1 flaky test, got %+v", flaky)
+	}
+	f := flaky[0]
+	if f.Method != "FLAKY" || f.Runs != 4 || f.Passed != 2 || f.Failed != 2 || f.Flips != 3 ||
+		f.LastFailure != "failed on day 3" || f.FailureRate() != 0.5 {
+		t.Errorf("flaky = %+v", f)
+	}
+
+	total, withSource, err := c.CountTestRecords(ctx, "DEV", time.Time{})
+	if err != nil || total != 9 || withSource != 7 {
+		t.Errorf("CountTestRecords = %d, %d, %v; want 9, 7", total, withSource, err)
+	}
+
+	// Runs before Since are not considered
+	flaky, err = c.FlakyTests(ctx, cache.FlakyOptions{Since: start.AddDate(0, 0, 3)})
+	if err != nil || len(flaky) != 0 {
+		t.Errorf("expected no flaky tests since day 3, got %+v, %v", flaky, err)
+	}
+}
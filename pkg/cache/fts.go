@@ -0,0 +1,253 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+// CodeSearchOptions configures a full-text search over cached sources
+type CodeSearchOptions struct {
+	// Query uses SQLite full-text syntax: words, "exact phrases", prefix* terms,
+	// AND / OR / NOT. Words match whole identifier parts: "amount" finds lv_amount.
+	Query        string
+	Types        []string // Restrict to object types (PROG, CLAS, ...)
+	Packages     []string // Restrict to packages
+	MaxResults   int      // Maximum objects returned (default: 50)
+	ContextLines int      // Lines of context before/after each matching line
+}
+
+// CodeHit is an object matching a code search, with its matching lines
+type CodeHit struct {
+	ObjectType      string          `json:"object_type"`
+	ObjectName      string          `json:"object_name"`
+	Variant         string          `json:"variant,omitempty"`
+	Package         string          `json:"package,omitempty"`
+	URL             string          `json:"url,omitempty"`
+	Score           float64         `json:"score"` // Higher is more relevant
+	Matches         []adt.GrepMatch `json:"matches"`
+	MatchCount      int             `json:"match_count"`
+	LastModifiedADT time.Time       `json:"last_modified_adt"`
+	CachedAt        time.Time       `json:"cached_at"`
+}
+
+// initSourceIndex creates the full-text index over cached_sources. FTS5 is used when
+// the sqlite driver is built with it (-tags sqlite_fts5), otherwise FTS4.
+// Sources cached before the index existed are indexed when it is created.
+func initSourceIndex(db *sql.DB) (string, error) {
+	var existing string
+	err := db.QueryRow("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'source_fts'").Scan(&existing)
+	if err == nil {
+		if strings.Contains(strings.ToLower(existing), "fts5") {
+			return "fts5", nil
+		}
+		return "fts4", nil
+	}
+	if err != sql.ErrNoRows {
+		return "", err
+	}
+
+	module := "fts5"
+	fts5 := `CREATE VIRTUAL TABLE source_fts USING fts5(
+		object_type UNINDEXED, object_name, variant UNINDEXED, package UNINDEXED, source,
+		tokenize = 'unicode61')`
+	if _, err := db.Exec(fts5); err != nil {
+		if !strings.Contains(err.Error(), "no such module") {
+			return "", err
+		}
+		module = "fts4"
+		fts4 := `CREATE VIRTUAL TABLE source_fts USING fts4(
+			object_type, object_name, variant, package, source,
+			notindexed=object_type, notindexed=variant, notindexed=package,
+			tokenize=unicode61)`
+		if _, err := db.Exec(fts4); err != nil {
+			return "", err
+		}
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO source_fts (rowid, object_type, object_name, variant, package, source)
+		SELECT rowid, object_type, object_name, variant, package, source FROM cached_sources`)
+	return module, err
+}
+
+// indexSource (re)writes the index entry of a stored source.
+// The index row shares its rowid with the cached_sources row.
+func indexSource(ctx context.Context, tx *sql.Tx, objectType, objectName, variant string) error {
+	var rowid int64
+	var pkg, source string
+	err := tx.QueryRowContext(ctx,
+		"SELECT rowid, package, source FROM cached_sources WHERE object_type = ? AND object_name = ? AND variant = ?",
+		objectType, objectName, variant,
+	).Scan(&rowid, &pkg, &source)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM source_fts WHERE rowid = ?", rowid); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO source_fts (rowid, object_type, object_name, variant, package, source) VALUES (?, ?, ?, ?, ?, ?)",
+		rowid, objectType, objectName, variant, pkg, source,
+	)
+	return err
+}
+
+// FTS4Notice is reported with search results when the source index falls back to FTS4.
+const FTS4Notice = "source index uses SQLite FTS4 because vsp was built without -tags sqlite_fts5: ranking is by number of matching lines, not BM25"
+
+// SourceIndexModule returns the SQLite full-text module backing SearchCode (fts5 or fts4)
+func (s *SQLiteCache) SourceIndexModule() string {
+	return s.ftsModule
+}
+
+// SearchCode searches cached sources using the full-text index. Results are ranked
+// by BM25 (FTS5) or by number of matching lines (FTS4).
+func (s *SQLiteCache) SearchCode(ctx context.Context, opts CodeSearchOptions) ([]*CodeHit, error) {
+	if strings.TrimSpace(opts.Query) == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+	if opts.MaxResults <= 0 {
+		opts.MaxResults = 50
+	}
+
+	score := "0"
+	if s.ftsModule == "fts5" {
+		score = "-bm25(source_fts)"
+	}
+
+	query := `
+		SELECT f.object_type, f.object_name, f.variant, f.package, c.url, c.source,
+		       c.last_modified_adt, c.cached_at, ` + score + `
+		FROM source_fts f
+		JOIN cached_sources c ON c.rowid = f.rowid
+		WHERE source_fts MATCH ?`
+	args := []interface{}{opts.Query}
+
+	if len(opts.Types) > 0 {
+		query += " AND f.object_type IN (" + placeholders(len(opts.Types)) + ")"
+		for _, t := range opts.Types {
+			args = append(args, strings.ToUpper(t))
+		}
+	}
+	if len(opts.Packages) > 0 {
+		query += " AND f.package IN (" + placeholders(len(opts.Packages)) + ")"
+		for _, p := range opts.Packages {
+			args = append(args, strings.ToUpper(p))
+		}
+	}
+	if s.ftsModule == "fts5" {
+		query += " ORDER BY bm25(source_fts) LIMIT ?"
+		args = append(args, opts.MaxResults)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("code search failed: %w", err)
+	}
+	defer rows.Close()
+
+	linePattern := queryLinePattern(opts.Query)
+	var hits []*CodeHit
+	for rows.Next() {
+		var hit CodeHit
+		var source string
+		var lastModifiedUnix, cachedAtUnix int64
+		if err := rows.Scan(&hit.ObjectType, &hit.ObjectName, &hit.Variant, &hit.Package, &hit.URL, &source,
+			&lastModifiedUnix, &cachedAtUnix, &hit.Score); err != nil {
+			return nil, err
+		}
+		hit.LastModifiedADT = time.Unix(lastModifiedUnix, 0)
+		hit.CachedAt = time.Unix(cachedAtUnix, 0)
+
+		hit.Matches = []adt.GrepMatch{}
+		if linePattern != "" {
+			if matches, err := adt.GrepSource(source, linePattern, true, opts.ContextLines); err == nil {
+				hit.Matches = matches
+			}
+		}
+		hit.MatchCount = len(hit.Matches)
+		if s.ftsModule != "fts5" {
+			hit.Score = float64(hit.MatchCount)
+		}
+		hits = append(hits, &hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("code search failed: %w", err)
+	}
+
+	if s.ftsModule != "fts5" {
+		sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+		if len(hits) > opts.MaxResults {
+			hits = hits[:opts.MaxResults]
+		}
+	}
+	return hits, nil
+}
+
+var (
+	ftsPhrase = regexp.MustCompile(`"([^"]*)"`)
+	ftsTerm   = regexp.MustCompile(`[\p{L}\p{N}_]+\*?`)
+	ftsToken  = regexp.MustCompile(`[\p{L}\p{N}]+`)
+)
+
+// queryLinePattern converts a full-text query into a regex that finds the lines
+// containing any of its positive terms, for context display.
+func queryLinePattern(query string) string {
+	var alternatives []string
+
+	addTerm := func(term string) {
+		prefix := strings.HasSuffix(term, "*")
+		tokens := ftsToken.FindAllString(term, -1)
+		if len(tokens) == 0 {
+			return
+		}
+		for i, tok := range tokens {
+			tokens[i] = regexp.QuoteMeta(tok)
+		}
+		pattern := `(^|[^\p{L}\p{N}])` + strings.Join(tokens, `[^\p{L}\p{N}]+`)
+		if prefix {
+			pattern += `[\p{L}\p{N}]*`
+		} else {
+			pattern += `([^\p{L}\p{N}]|$)`
+		}
+		alternatives = append(alternatives, pattern)
+	}
+
+	// Phrases first, then remaining terms (skipping operators and negated terms)
+	for _, m := range ftsPhrase.FindAllStringSubmatch(query, -1) {
+		addTerm(m[1])
+	}
+	rest := ftsPhrase.ReplaceAllString(query, " ")
+	skipNext := false
+	for _, term := range ftsTerm.FindAllString(rest, -1) {
+		switch term {
+		case "AND", "OR", "NEAR":
+			continue
+		case "NOT":
+			skipNext = true
+			continue
+		}
+		if skipNext {
+			skipNext = false
+			continue
+		}
+		addTerm(term)
+	}
+
+	if len(alternatives) == 0 {
+		return ""
+	}
+	return strings.Join(alternatives, "|")
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
@@ -0,0 +1,248 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ImpactOptions configures impact analysis
+type ImpactOptions struct {
+	MaxDepth  int      // Maximum number of hops from the changed object (default: 3)
+	EdgeTypes []string // Only follow these edge types (empty = all)
+}
+
+// ImpactedObject is a transitive dependent of a changed object
+type ImpactedObject struct {
+	ObjectType string   `json:"objectType"`
+	ObjectName string   `json:"objectName"`
+	Package    string   `json:"package,omitempty"`
+	Depth      int      `json:"depth"`             // Hops from the changed object (1 = direct dependent)
+	EdgeType   string   `json:"edgeType"`          // Edge type of the first path found
+	Via        string   `json:"via,omitempty"`     // Node ID this object depends on along that path
+	Members    []string `json:"members,omitempty"` // Dependent member nodes (methods, forms)
+
+	// Enrichment (see ImpactResult.Annotate)
+	HasTests       bool     `json:"hasTests"`
+	TestClasses    []string `json:"testClasses,omitempty"`
+	Transports     []string `json:"transports,omitempty"`     // Modifiable requests containing the object
+	OtherTransport bool     `json:"otherTransport,omitempty"` // Locked in a request other than the change's one
+}
+
+// ImpactClient is the system access used by ImpactResult.Annotate (implemented by *adt.Client).
+type ImpactClient interface {
+	GetClassTestClasses(ctx context.Context, className string) ([]string, error)
+	GetOpenTransportsForObjects(ctx context.Context, objects []string) (map[string][]string, error)
+}
+
+// ImpactResult is the result of an impact analysis
+type ImpactResult struct {
+	Roots      []string                       `json:"roots"`
+	MaxDepth   int                            `json:"maxDepth"`
+	Total      int                            `json:"total"`
+	Dependents []*ImpactedObject              `json:"dependents"`
+	ByPackage  map[string]map[string][]string `json:"byPackage"` // package -> object type -> names
+	Warnings   []string                       `json:"warnings,omitempty"`
+}
+
+// AnalyzeImpact walks the cached graph backwards from the root nodes and returns every
+// object that transitively depends on them, up to opts.MaxDepth hops.
+// Member nodes (methods, function modules in a group) are folded into their enclosing object,
+// and the dependents of the enclosing object itself are followed too.
+func AnalyzeImpact(ctx context.Context, c Cache, rootIDs []string, opts ImpactOptions) (*ImpactResult, error) {
+	if len(rootIDs) == 0 {
+		return nil, fmt.Errorf("at least one root node is required")
+	}
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = 3
+	}
+
+	followEdge := func(edgeType string) bool {
+		if len(opts.EdgeTypes) == 0 {
+			return true
+		}
+		for _, t := range opts.EdgeTypes {
+			if t == edgeType {
+				return true
+			}
+		}
+		return false
+	}
+
+	type queued struct {
+		id    string
+		depth int
+	}
+
+	visited := make(map[string]bool)
+	var queue []queued
+	for _, id := range rootIDs {
+		visited[id] = true
+		queue = append(queue, queued{id: id})
+	}
+
+	objects := make(map[string]*ImpactedObject)
+	var order []string
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if current.depth >= opts.MaxDepth {
+			continue
+		}
+
+		// A changed member changes its enclosing object: follow the object's own dependents
+		if node, err := c.GetNode(ctx, current.id); err == nil && node.EnclosingName != "" {
+			enclosing, err := c.FindNodes(ctx, node.EnclosingType, node.EnclosingName)
+			if err != nil {
+				return nil, fmt.Errorf("failed to find nodes of %s: %w", node.EnclosingName, err)
+			}
+			for _, n := range enclosing {
+				if n.EnclosingName == "" && !visited[n.ID] {
+					visited[n.ID] = true
+					queue = append(queue, queued{id: n.ID, depth: current.depth})
+				}
+			}
+		}
+
+		edges, err := c.GetEdgesTo(ctx, current.id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get edges to %s: %w", current.id, err)
+		}
+
+		for _, edge := range edges {
+			if visited[edge.FromID] || !followEdge(edge.EdgeType) {
+				continue
+			}
+			visited[edge.FromID] = true
+			depth := current.depth + 1
+			queue = append(queue, queued{id: edge.FromID, depth: depth})
+
+			var objType, objName, pkg, member string
+			if node, err := c.GetNode(ctx, edge.FromID); err == nil {
+				objType, objName, pkg = node.ObjectType, node.ObjectName, node.Package
+				if node.EnclosingName != "" {
+					objType, objName, member = node.EnclosingType, node.EnclosingName, node.ObjectName
+				}
+			} else {
+				// Node not cached (only known through the edge)
+				objType, objName = "", edge.FromID
+			}
+
+			key := objType + "/" + objName
+			obj, exists := objects[key]
+			if !exists {
+				obj = &ImpactedObject{
+					ObjectType: objType,
+					ObjectName: objName,
+					Package:    pkg,
+					Depth:      depth,
+					EdgeType:   edge.EdgeType,
+					Via:        current.id,
+				}
+				objects[key] = obj
+				order = append(order, key)
+			}
+			if member != "" {
+				obj.Members = append(obj.Members, member)
+			}
+		}
+	}
+
+	result := &ImpactResult{
+		Roots:      rootIDs,
+		MaxDepth:   opts.MaxDepth,
+		Dependents: make([]*ImpactedObject, 0, len(order)),
+		ByPackage:  make(map[string]map[string][]string),
+	}
+	for _, key := range order {
+		result.Dependents = append(result.Dependents, objects[key])
+	}
+	sort.SliceStable(result.Dependents, func(i, j int) bool {
+		a, b := result.Dependents[i], result.Dependents[j]
+		if a.Depth != b.Depth {
+			return a.Depth < b.Depth
+		}
+		return a.ObjectName < b.ObjectName
+	})
+
+	for _, obj := range result.Dependents {
+		pkg := obj.Package
+		if pkg == "" {
+			pkg = "(unknown)"
+		}
+		if result.ByPackage[pkg] == nil {
+			result.ByPackage[pkg] = make(map[string][]string)
+		}
+		result.ByPackage[pkg][obj.ObjectType] = append(result.ByPackage[pkg][obj.ObjectType], obj.ObjectName)
+	}
+	result.Total = len(result.Dependents)
+
+	return result, nil
+}
+
+// Annotate enriches dependents with live system data: which classes have local test
+// classes and which objects are locked in modifiable transports.
+// Objects locked in a request other than transport (any request, if transport is empty)
+// are flagged as OtherTransport.
+// Lookup failures are recorded as warnings rather than returned as errors.
+func (r *ImpactResult) Annotate(ctx context.Context, client ImpactClient, transport string) {
+	var objs []string
+	for _, obj := range r.Dependents {
+		if obj.ObjectType == "" {
+			continue
+		}
+		objs = append(objs, obj.ObjectType+"/"+obj.ObjectName)
+
+		if obj.ObjectType != "CLAS" {
+			continue
+		}
+		testClasses, err := client.GetClassTestClasses(ctx, obj.ObjectName)
+		if err != nil {
+			r.Warnings = append(r.Warnings, fmt.Sprintf("test lookup for %s failed: %v", obj.ObjectName, err))
+			continue
+		}
+		obj.TestClasses = testClasses
+		obj.HasTests = len(obj.TestClasses) > 0
+	}
+
+	locks, err := client.GetOpenTransportsForObjects(ctx, objs)
+	if err != nil {
+		r.Warnings = append(r.Warnings, fmt.Sprintf("transport lookup failed: %v", err))
+		return
+	}
+	for _, obj := range r.Dependents {
+		obj.Transports = locks[obj.ObjectType+"/"+obj.ObjectName]
+		for _, tr := range obj.Transports {
+			if tr != transport {
+				obj.OtherTransport = true
+			}
+		}
+	}
+}
+
+// ResolveImpactRoots finds the cached node IDs for an object or node ID.
+// If name is a node ID present in the cache it is used directly; otherwise all nodes
+// of the object (including its members) are returned.
+func ResolveImpactRoots(ctx context.Context, c Cache, objectType, name string) ([]string, error) {
+	if node, err := c.GetNode(ctx, name); err == nil {
+		return []string{node.ID}, nil
+	}
+
+	nodes, err := c.FindNodes(ctx, strings.ToUpper(objectType), strings.ToUpper(name))
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, ErrNotFound
+	}
+
+	ids := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		ids = append(ids, n.ID)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
@@ -162,6 +162,27 @@ func (m *MemoryCache) GetNodesByPackage(ctx context.Context, pkg string) ([]*Nod
 	return validNodes, nil
 }
 
+// FindNodes returns all valid nodes of an object, including member nodes
+// (methods, form routines) whose enclosing object matches
+func (m *MemoryCache) FindNodes(ctx context.Context, objectType, objectName string) ([]*Node, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	nodes := make([]*Node, 0)
+	for _, node := range m.nodes {
+		if !node.Valid {
+			continue
+		}
+		if node.ObjectName == objectName && (objectType == "" || node.ObjectType == objectType) {
+			nodes = append(nodes, node)
+		} else if node.EnclosingName == objectName && (objectType == "" || node.EnclosingType == objectType) {
+			nodes = append(nodes, node)
+		}
+	}
+
+	return nodes, nil
+}
+
 // PutEdge stores an edge in the cache
 func (m *MemoryCache) PutEdge(ctx context.Context, edge *Edge) error {
 	m.mu.Lock()
@@ -0,0 +1,365 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CachedSource is the source code of an object (or one of its includes/methods)
+// as last read from the SAP system
+type CachedSource struct {
+	ObjectType      string    `json:"object_type"`       // PROG, CLAS, INTF, FUNC, INCL, DDLS, ...
+	ObjectName      string    `json:"object_name"`       // Object name
+	Variant         string    `json:"variant,omitempty"` // Include or method qualifier ("" = main source)
+	Package         string    `json:"package,omitempty"` // DEVCLASS, if known
+	URL             string    `json:"url,omitempty"`     // ADT object URL (used by GrepObjects)
+	Source          string    `json:"source"`
+	SourceHash      string    `json:"source_hash"`
+	LastModifiedADT time.Time `json:"last_modified_adt"`
+	CachedAt        time.Time `json:"cached_at"`
+}
+
+// CachedObject is a catalog entry learned from package listings and searches
+type CachedObject struct {
+	ObjectType  string    `json:"type"`
+	ObjectName  string    `json:"name"`
+	Package     string    `json:"package,omitempty"`
+	URI         string    `json:"uri,omitempty"`
+	Description string    `json:"description,omitempty"`
+	CachedAt    time.Time `json:"cached_at"`
+}
+
+// CachedPackage is a package listing: its subpackages plus the catalog entries
+// of the objects it contains
+type CachedPackage struct {
+	Name        string          `json:"name"`
+	URI         string          `json:"uri,omitempty"`
+	SubPackages []string        `json:"sub_packages"`
+	Objects     []*CachedObject `json:"objects"`
+	CachedAt    time.Time       `json:"cached_at"`
+}
+
+// SourceStore keeps object sources and catalog data for offline reads.
+// Entries never expire: callers report their age instead.
+type SourceStore interface {
+	PutSource(ctx context.Context, src *CachedSource) error
+	GetSource(ctx context.Context, objectType, objectName, variant string) (*CachedSource, error)
+	GetSourceByURL(ctx context.Context, url string) (*CachedSource, error)
+	DeleteSource(ctx context.Context, objectType, objectName, variant string) error
+	ListSources(ctx context.Context, pkg string) ([]*CachedSource, error)
+	SearchCode(ctx context.Context, opts CodeSearchOptions) ([]*CodeHit, error)
+
+	PutObjects(ctx context.Context, objects []*CachedObject) error
+	SearchObjects(ctx context.Context, pattern string, maxResults int) ([]*CachedObject, error)
+
+	PutPackage(ctx context.Context, pkg *CachedPackage) error
+	GetPackage(ctx context.Context, name string) (*CachedPackage, error)
+}
+
+// HashSource returns the SHA256 hex digest used as CachedSource.SourceHash
+func HashSource(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:])
+}
+
+// PutSource stores (or replaces) a source and updates the full-text index.
+// A known package is kept when the source is stored again without one.
+func (s *SQLiteCache) PutSource(ctx context.Context, src *CachedSource) error {
+	if src.CachedAt.IsZero() {
+		src.CachedAt = time.Now()
+	}
+	if src.SourceHash == "" {
+		src.SourceHash = HashSource(src.Source)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO cached_sources
+		(object_type, object_name, variant, package, url, source, source_hash, last_modified_adt, cached_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(object_type, object_name, variant) DO UPDATE SET
+			package = CASE WHEN excluded.package = '' THEN cached_sources.package ELSE excluded.package END,
+			url = excluded.url,
+			source = excluded.source,
+			source_hash = excluded.source_hash,
+			last_modified_adt = excluded.last_modified_adt,
+			cached_at = excluded.cached_at
+	`
+
+	_, err = tx.ExecContext(ctx, query,
+		src.ObjectType,
+		src.ObjectName,
+		src.Variant,
+		src.Package,
+		src.URL,
+		src.Source,
+		src.SourceHash,
+		src.LastModifiedADT.Unix(),
+		src.CachedAt.Unix(),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := indexSource(ctx, tx, src.ObjectType, src.ObjectName, src.Variant); err != nil {
+		return fmt.Errorf("failed to index source: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// DeleteSource removes a source and its index entry
+func (s *SQLiteCache) DeleteSource(ctx context.Context, objectType, objectName, variant string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var rowid int64
+	err = tx.QueryRowContext(ctx,
+		"SELECT rowid FROM cached_sources WHERE object_type = ? AND object_name = ? AND variant = ?",
+		objectType, objectName, variant,
+	).Scan(&rowid)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM source_fts WHERE rowid = ?", rowid); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM cached_sources WHERE rowid = ?", rowid); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListSources returns the sources of a package without their text
+func (s *SQLiteCache) ListSources(ctx context.Context, pkg string) ([]*CachedSource, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT object_type, object_name, variant, package, url, source_hash, last_modified_adt, cached_at
+		FROM cached_sources
+		WHERE package = ?
+		ORDER BY object_type, object_name, variant
+	`, pkg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sources []*CachedSource
+	for rows.Next() {
+		var src CachedSource
+		var lastModifiedUnix, cachedAtUnix int64
+		if err := rows.Scan(&src.ObjectType, &src.ObjectName, &src.Variant, &src.Package, &src.URL,
+			&src.SourceHash, &lastModifiedUnix, &cachedAtUnix); err != nil {
+			return nil, err
+		}
+		src.LastModifiedADT = time.Unix(lastModifiedUnix, 0)
+		src.CachedAt = time.Unix(cachedAtUnix, 0)
+		sources = append(sources, &src)
+	}
+	return sources, rows.Err()
+}
+
+// GetSource retrieves a source by object type, name and variant
+func (s *SQLiteCache) GetSource(ctx context.Context, objectType, objectName, variant string) (*CachedSource, error) {
+	return s.querySource(ctx, `WHERE object_type = ? AND object_name = ? AND variant = ?`,
+		objectType, objectName, variant)
+}
+
+// GetSourceByURL retrieves the main source stored under an ADT object URL.
+// A trailing /source/main is ignored.
+func (s *SQLiteCache) GetSourceByURL(ctx context.Context, url string) (*CachedSource, error) {
+	url = strings.TrimSuffix(url, "/source/main")
+	return s.querySource(ctx, `WHERE url = ? ORDER BY variant LIMIT 1`, url)
+}
+
+func (s *SQLiteCache) querySource(ctx context.Context, where string, args ...interface{}) (*CachedSource, error) {
+	query := `
+		SELECT object_type, object_name, variant, package, url, source, source_hash, last_modified_adt, cached_at
+		FROM cached_sources
+	` + where
+
+	var src CachedSource
+	var lastModifiedUnix, cachedAtUnix int64
+	err := s.db.QueryRowContext(ctx, query, args...).Scan(
+		&src.ObjectType,
+		&src.ObjectName,
+		&src.Variant,
+		&src.Package,
+		&src.URL,
+		&src.Source,
+		&src.SourceHash,
+		&lastModifiedUnix,
+		&cachedAtUnix,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	src.LastModifiedADT = time.Unix(lastModifiedUnix, 0)
+	src.CachedAt = time.Unix(cachedAtUnix, 0)
+	return &src, nil
+}
+
+// PutObjects stores catalog entries. A known package is kept when an entry
+// without package information is stored again.
+func (s *SQLiteCache) PutObjects(ctx context.Context, objects []*CachedObject) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO cached_objects (object_type, object_name, package, uri, description, cached_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(object_type, object_name) DO UPDATE SET
+			package = CASE WHEN excluded.package = '' THEN cached_objects.package ELSE excluded.package END,
+			uri = excluded.uri,
+			description = excluded.description,
+			cached_at = excluded.cached_at
+	`
+
+	for _, obj := range objects {
+		if obj.CachedAt.IsZero() {
+			obj.CachedAt = time.Now()
+		}
+		if _, err := tx.ExecContext(ctx, query,
+			obj.ObjectType, obj.ObjectName, obj.Package, obj.URI, obj.Description, obj.CachedAt.Unix(),
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SearchObjects returns catalog entries whose name matches pattern.
+// The pattern uses ADT search syntax: * matches any sequence, matching is case-insensitive.
+func (s *SQLiteCache) SearchObjects(ctx context.Context, pattern string, maxResults int) ([]*CachedObject, error) {
+	if maxResults <= 0 {
+		maxResults = 100
+	}
+	like := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`, `*`, `%`).Replace(strings.ToUpper(pattern))
+
+	query := `
+		SELECT object_type, object_name, package, uri, description, cached_at
+		FROM cached_objects
+		WHERE UPPER(object_name) LIKE ? ESCAPE '\'
+		ORDER BY object_name, object_type
+		LIMIT ?
+	`
+	return s.queryObjects(ctx, query, like, maxResults)
+}
+
+// PutPackage stores a package listing and its objects
+func (s *SQLiteCache) PutPackage(ctx context.Context, pkg *CachedPackage) error {
+	if pkg.CachedAt.IsZero() {
+		pkg.CachedAt = time.Now()
+	}
+	for _, obj := range pkg.Objects {
+		if obj.Package == "" {
+			obj.Package = pkg.Name
+		}
+		if obj.CachedAt.IsZero() {
+			obj.CachedAt = pkg.CachedAt
+		}
+	}
+	if err := s.PutObjects(ctx, pkg.Objects); err != nil {
+		return err
+	}
+
+	subPackagesJSON, _ := json.Marshal(pkg.SubPackages)
+	query := `
+		INSERT INTO cached_packages (name, uri, sub_packages, cached_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			uri = excluded.uri,
+			sub_packages = excluded.sub_packages,
+			cached_at = excluded.cached_at
+	`
+	_, err := s.db.ExecContext(ctx, query, pkg.Name, pkg.URI, string(subPackagesJSON), pkg.CachedAt.Unix())
+	return err
+}
+
+// GetPackage returns a package listing. Packages that were never listed but have
+// objects in the catalog (e.g. from searches) are returned with those objects.
+func (s *SQLiteCache) GetPackage(ctx context.Context, name string) (*CachedPackage, error) {
+	pkg := &CachedPackage{Name: name}
+
+	var subPackagesJSON string
+	var cachedAtUnix int64
+	err := s.db.QueryRowContext(ctx,
+		"SELECT uri, sub_packages, cached_at FROM cached_packages WHERE name = ?", name,
+	).Scan(&pkg.URI, &subPackagesJSON, &cachedAtUnix)
+	listed := err == nil
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if listed {
+		pkg.CachedAt = time.Unix(cachedAtUnix, 0)
+		json.Unmarshal([]byte(subPackagesJSON), &pkg.SubPackages)
+	}
+
+	pkg.Objects, err = s.queryObjects(ctx, `
+		SELECT object_type, object_name, package, uri, description, cached_at
+		FROM cached_objects
+		WHERE package = ?
+		ORDER BY object_type, object_name
+	`, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !listed {
+		if len(pkg.Objects) == 0 {
+			return nil, ErrNotFound
+		}
+		pkg.CachedAt = pkg.Objects[0].CachedAt
+		for _, obj := range pkg.Objects {
+			if obj.CachedAt.Before(pkg.CachedAt) {
+				pkg.CachedAt = obj.CachedAt
+			}
+		}
+	}
+	return pkg, nil
+}
+
+func (s *SQLiteCache) queryObjects(ctx context.Context, query string, args ...interface{}) ([]*CachedObject, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var objects []*CachedObject
+	for rows.Next() {
+		var obj CachedObject
+		var cachedAtUnix int64
+		if err := rows.Scan(&obj.ObjectType, &obj.ObjectName, &obj.Package, &obj.URI, &obj.Description, &cachedAtUnix); err != nil {
+			return nil, err
+		}
+		obj.CachedAt = time.Unix(cachedAtUnix, 0)
+		objects = append(objects, &obj)
+	}
+	return objects, rows.Err()
+}
@@ -12,8 +12,9 @@ import (
 
 // SQLiteCache is a SQLite-backed implementation of Cache
 type SQLiteCache struct {
-	db     *sql.DB
-	config Config
+	db        *sql.DB
+	config    Config
+	ftsModule string // fts5, or fts4 when the driver is built without FTS5
 }
 
 // NewSQLiteCache creates a new SQLite cache
@@ -33,7 +34,13 @@ func NewSQLiteCache(config Config) (*SQLiteCache, error) {
 		return nil, fmt.Errorf("failed to init schema: %w", err)
 	}
 
-	return &SQLiteCache{db: db, config: config}, nil
+	ftsModule, err := initSourceIndex(db)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to init source index: %w", err)
+	}
+
+	return &SQLiteCache{db: db, config: config, ftsModule: ftsModule}, nil
 }
 
 func initSQLiteSchema(db *sql.DB) error {
@@ -92,6 +99,61 @@ func initSQLiteSchema(db *sql.DB) error {
 
 	CREATE INDEX IF NOT EXISTS idx_api_module ON cached_apis(module);
 	CREATE INDEX IF NOT EXISTS idx_api_usage ON cached_apis(usage_count DESC);
+
+	CREATE TABLE IF NOT EXISTS cached_sources (
+		object_type TEXT NOT NULL,
+		object_name TEXT NOT NULL,
+		variant TEXT NOT NULL DEFAULT '',
+		package TEXT NOT NULL DEFAULT '',
+		url TEXT,
+		source TEXT NOT NULL,
+		source_hash TEXT,
+		last_modified_adt INTEGER,
+		cached_at INTEGER NOT NULL,
+		PRIMARY KEY (object_type, object_name, variant)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_source_url ON cached_sources(url);
+	CREATE INDEX IF NOT EXISTS idx_source_package ON cached_sources(package);
+
+	CREATE TABLE IF NOT EXISTS cached_objects (
+		object_type TEXT NOT NULL,
+		object_name TEXT NOT NULL,
+		package TEXT NOT NULL DEFAULT '',
+		uri TEXT,
+		description TEXT,
+		cached_at INTEGER NOT NULL,
+		PRIMARY KEY (object_type, object_name)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_object_package ON cached_objects(package);
+
+	CREATE TABLE IF NOT EXISTS cached_packages (
+		name TEXT PRIMARY KEY,
+		uri TEXT,
+		sub_packages TEXT,
+		cached_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS test_results (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		run_id TEXT NOT NULL,
+		system TEXT NOT NULL DEFAULT '',
+		object_type TEXT NOT NULL,
+		object_name TEXT NOT NULL,
+		class_name TEXT NOT NULL,
+		method TEXT NOT NULL,
+		transport TEXT NOT NULL DEFAULT '',
+		run_at INTEGER NOT NULL,
+		attempt INTEGER NOT NULL DEFAULT 1,
+		passed INTEGER NOT NULL,
+		message TEXT,
+		source_hash TEXT NOT NULL DEFAULT '',
+		duration_ms INTEGER
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_test_method ON test_results(system, object_name, class_name, method);
+	CREATE INDEX IF NOT EXISTS idx_test_run_at ON test_results(run_at);
 	`
 
 	_, err := db.Exec(schema)
@@ -232,7 +294,30 @@ func (s *SQLiteCache) GetNodesByPackage(ctx context.Context, pkg string) ([]*Nod
 		WHERE package = ? AND valid = 1
 	`
 
-	rows, err := s.db.QueryContext(ctx, query, pkg)
+	return s.queryNodes(ctx, query, pkg)
+}
+
+// FindNodes returns all valid nodes of an object, including member nodes
+// (methods, form routines) whose enclosing object matches
+func (s *SQLiteCache) FindNodes(ctx context.Context, objectType, objectName string) ([]*Node, error) {
+	query := `
+		SELECT id, object_type, object_name, package, enclosing_type, enclosing_name,
+		       source_hash, last_modified_adt, cached_at, valid, metadata
+		FROM cached_nodes
+		WHERE valid = 1
+		  AND ((object_name = ? AND (? = '' OR object_type = ?))
+		    OR (enclosing_name = ? AND (? = '' OR enclosing_type = ?)))
+	`
+
+	return s.queryNodes(ctx, query,
+		objectName, objectType, objectType,
+		objectName, objectType, objectType,
+	)
+}
+
+// queryNodes runs a node query and scans the result rows
+func (s *SQLiteCache) queryNodes(ctx context.Context, query string, args ...interface{}) ([]*Node, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -429,6 +514,10 @@ func (s *SQLiteCache) Clear(ctx context.Context) error {
 		"DELETE FROM cached_nodes",
 		"DELETE FROM cached_edges",
 		"DELETE FROM cached_apis",
+		"DELETE FROM cached_sources",
+		"DELETE FROM source_fts",
+		"DELETE FROM cached_objects",
+		"DELETE FROM cached_packages",
 	}
 
 	for _, query := range queries {
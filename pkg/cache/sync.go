@@ -0,0 +1,281 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+// indexedTypes maps package listing types to GetSource object types.
+// Function groups are expanded into their function modules.
+var indexedTypes = map[string]string{
+	"PROG/P":   "PROG",
+	"PROG/I":   "INCL",
+	"CLAS/OC":  "CLAS",
+	"INTF/OI":  "INTF",
+	"FUGR/F":   "FUGR",
+	"DDLS/DF":  "DDLS",
+	"BDEF/BDO": "BDEF",
+	"SRVD/SRV": "SRVD",
+}
+
+// ListingType returns the package listing type of a GetSource object type
+// (CLAS -> CLAS/OC). Listing types are returned unchanged.
+func ListingType(objectType string) string {
+	objectType = strings.ToUpper(objectType)
+	if objectType == "FUNC" {
+		return "FUGR/F"
+	}
+	for listing, t := range indexedTypes {
+		if t == objectType {
+			return listing
+		}
+	}
+	return objectType
+}
+
+// SyncOptions configures SyncSources
+type SyncOptions struct {
+	IncludeSubpackages bool                                     // Also sync subpackages (recursively)
+	Force              bool                                     // Re-read every source, ignoring timestamps
+	Progress           func(format string, args ...interface{}) // Optional progress reporting
+}
+
+// SyncResult summarizes a source sync
+type SyncResult struct {
+	Packages  []string      `json:"packages"`
+	Checked   int           `json:"checked"`   // Objects compared against the SAP system
+	Updated   int           `json:"updated"`   // Sources (re)downloaded and indexed
+	Unchanged int           `json:"unchanged"` // Sources skipped because their timestamp did not change
+	Removed   int           `json:"removed"`   // Cached sources of objects no longer in the package
+	Failed    []string      `json:"failed,omitempty"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// sourceRef is an object whose source is indexed
+type sourceRef struct {
+	objectType string
+	name       string
+	parent     string    // Function group of a function module
+	changedAt  time.Time // Change timestamp from the listing (zero: read the object's metadata)
+}
+
+// SyncSources brings the cached sources of the given packages up to date with the
+// SAP system. Each object's ADT change timestamp is compared with the cached one and
+// only changed objects are downloaded, so repeated syncs are cheap. Timestamps are
+// taken from the listing where it has them (function modules of a function group);
+// the package listing has none, so other objects cost one metadata read each.
+// If a function group can't be read, its cached function modules are kept.
+func SyncSources(ctx context.Context, client *adt.Client, store SourceStore, packages []string, opts SyncOptions) (*SyncResult, error) {
+	start := time.Now()
+	result := &SyncResult{}
+	progress := opts.Progress
+	if progress == nil {
+		progress = func(string, ...interface{}) {}
+	}
+
+	queue := make([]string, 0, len(packages))
+	for _, pkg := range packages {
+		queue = append(queue, strings.ToUpper(pkg))
+	}
+	seen := make(map[string]bool)
+
+	for len(queue) > 0 {
+		pkgName := queue[0]
+		queue = queue[1:]
+		if seen[pkgName] {
+			continue
+		}
+		seen[pkgName] = true
+		result.Packages = append(result.Packages, pkgName)
+
+		pkg, err := client.GetPackage(ctx, pkgName)
+		if err != nil {
+			return result, fmt.Errorf("failed to read package %s: %w", pkgName, err)
+		}
+		if err := store.PutPackage(ctx, NewCachedPackage(pkg)); err != nil {
+			return result, err
+		}
+		if opts.IncludeSubpackages {
+			queue = append(queue, pkg.SubPackages...)
+		}
+
+		refs, failedGroups, failed := expandPackageObjects(ctx, client, pkg)
+		result.Failed = append(result.Failed, failed...)
+
+		cached, err := store.ListSources(ctx, pkgName)
+		if err != nil {
+			return result, err
+		}
+		known := make(map[string]*CachedSource, len(cached))
+		for _, src := range cached {
+			if src.Variant == "" {
+				known[src.ObjectType+"/"+src.ObjectName] = src
+			}
+		}
+
+		progress("%s: checking %d objects", pkgName, len(refs))
+		for _, ref := range refs {
+			key := ref.objectType + "/" + ref.name
+			existing := known[key]
+			delete(known, key)
+			result.Checked++
+
+			objectURL := SourceObjectURL(ref.objectType, ref.name, ref.parent)
+			changedAt := ref.changedAt
+			if changedAt.IsZero() {
+				changedAt, err = client.GetObjectChangedAt(ctx, objectURL)
+				if err != nil {
+					result.Failed = append(result.Failed, fmt.Sprintf("%s: %v", key, err))
+					continue
+				}
+			}
+
+			if !opts.Force && existing != nil && !changedAt.IsZero() && !changedAt.After(existing.LastModifiedADT) {
+				result.Unchanged++
+				continue
+			}
+
+			source, err := client.GetSource(ctx, ref.objectType, ref.name, &adt.GetSourceOptions{Parent: ref.parent})
+			if err != nil {
+				result.Failed = append(result.Failed, fmt.Sprintf("%s: %v", key, err))
+				continue
+			}
+			err = store.PutSource(ctx, &CachedSource{
+				ObjectType:      ref.objectType,
+				ObjectName:      ref.name,
+				Package:         pkgName,
+				URL:             objectURL,
+				Source:          source,
+				LastModifiedADT: changedAt,
+			})
+			if err != nil {
+				return result, err
+			}
+			result.Updated++
+			progress("  updated %s", key)
+		}
+
+		// Whatever is left was deleted or moved to another package, except the
+		// function modules of groups that could not be read
+		for _, src := range known {
+			if src.ObjectType == "FUNC" && failedGroups[functionGroupOf(src.URL)] {
+				continue
+			}
+			if err := store.DeleteSource(ctx, src.ObjectType, src.ObjectName, ""); err != nil {
+				return result, err
+			}
+			result.Removed++
+		}
+	}
+
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+// NewCachedPackage converts a package listing read from the SAP system.
+func NewCachedPackage(pkg *adt.PackageContent) *CachedPackage {
+	cached := &CachedPackage{Name: pkg.Name, URI: pkg.URI, SubPackages: pkg.SubPackages}
+	for _, obj := range pkg.Objects {
+		cached.Objects = append(cached.Objects, &CachedObject{
+			ObjectType:  obj.Type,
+			ObjectName:  obj.Name,
+			URI:         obj.URI,
+			Description: obj.Description,
+		})
+	}
+	return cached
+}
+
+// ExpandPackages returns the given packages followed by all of their cached
+// subpackages, recursively. Packages without a cached listing are kept as is.
+func ExpandPackages(ctx context.Context, store SourceStore, packages []string) []string {
+	var expanded []string
+	seen := make(map[string]bool)
+	queue := append([]string(nil), packages...)
+	for len(queue) > 0 {
+		name := strings.ToUpper(queue[0])
+		queue = queue[1:]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		expanded = append(expanded, name)
+		if pkg, err := store.GetPackage(ctx, name); err == nil {
+			queue = append(queue, pkg.SubPackages...)
+		}
+	}
+	return expanded
+}
+
+// sourceADTTypes maps GetSource object types to ADT object types
+var sourceADTTypes = map[string]adt.CreatableObjectType{
+	"PROG": adt.ObjectTypeProgram,
+	"INCL": adt.ObjectTypeInclude,
+	"CLAS": adt.ObjectTypeClass,
+	"INTF": adt.ObjectTypeInterface,
+	"FUNC": adt.ObjectTypeFunctionMod,
+	"DDLS": adt.ObjectTypeDDLS,
+	"BDEF": adt.ObjectTypeBDEF,
+	"SRVD": adt.ObjectTypeSRVD,
+}
+
+// SourceObjectURL returns the ADT object URL of a GetSource object type
+// (PROG, CLAS, FUNC, ...), or "" for types without one. parent is the
+// function group of a function module.
+func SourceObjectURL(objectType, name, parent string) string {
+	adtType, ok := sourceADTTypes[strings.ToUpper(objectType)]
+	if !ok {
+		return ""
+	}
+	return adt.GetObjectURL(adtType, name, parent)
+}
+
+// expandPackageObjects lists the source-bearing objects of a package.
+// Function groups that could not be read are returned by name.
+func expandPackageObjects(ctx context.Context, client *adt.Client, pkg *adt.PackageContent) ([]sourceRef, map[string]bool, []string) {
+	var refs []sourceRef
+	var failed []string
+	failedGroups := make(map[string]bool)
+	for _, obj := range pkg.Objects {
+		objectType, ok := indexedTypes[obj.Type]
+		if !ok {
+			continue
+		}
+		if objectType != "FUGR" {
+			refs = append(refs, sourceRef{objectType: objectType, name: obj.Name})
+			continue
+		}
+
+		fg, err := client.GetFunctionGroup(ctx, obj.Name)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("FUGR/%s: %v", obj.Name, err))
+			failedGroups[strings.ToUpper(obj.Name)] = true
+			continue
+		}
+		for _, fm := range fg.Functions {
+			ref := sourceRef{objectType: "FUNC", name: fm.Name, parent: obj.Name}
+			ref.changedAt, _ = time.Parse(time.RFC3339, fm.ChangedAt)
+			refs = append(refs, ref)
+		}
+	}
+	return refs, failedGroups, failed
+}
+
+// functionGroupOf returns the function group of a function module URL
+// (/sap/bc/adt/functions/groups/{group}/fmodules/{name}).
+func functionGroupOf(objectURL string) string {
+	rest, ok := strings.CutPrefix(objectURL, "/sap/bc/adt/functions/groups/")
+	if !ok {
+		return ""
+	}
+	group, _, _ := strings.Cut(rest, "/")
+	if unescaped, err := url.PathUnescape(group); err == nil {
+		group = unescaped
+	}
+	return strings.ToUpper(group)
+}
@@ -0,0 +1,238 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TestRecord is the result of one test method in one test run
+type TestRecord struct {
+	RunID      string        `json:"run_id"`
+	System     string        `json:"system"`
+	ObjectType string        `json:"object_type"`
+	ObjectName string        `json:"object_name"`
+	ClassName  string        `json:"class_name"`
+	Method     string        `json:"method"`
+	Transport  string        `json:"transport,omitempty"`
+	RunAt      time.Time     `json:"run_at"`
+	Attempt    int           `json:"attempt"` // 1 for the first run, 2+ for reruns of failures
+	Passed     bool          `json:"passed"`
+	Message    string        `json:"message,omitempty"`
+	SourceHash string        `json:"source_hash,omitempty"` // Hash or change marker of the tested source; "" if unknown
+	Duration   time.Duration `json:"duration"`
+}
+
+// TestHistoryQuery filters the test history. Empty fields match everything.
+type TestHistoryQuery struct {
+	System     string
+	ObjectName string // Supports * wildcards
+	Method     string
+	Since      time.Time
+	Limit      int // Default 1000
+}
+
+// FlakyTest is a test method that both passed and failed on the same source
+type FlakyTest struct {
+	System      string    `json:"system"`
+	ObjectType  string    `json:"object_type"`
+	ObjectName  string    `json:"object_name"`
+	ClassName   string    `json:"class_name"`
+	Method      string    `json:"method"`
+	SourceHash  string    `json:"source_hash"`
+	Runs        int       `json:"runs"`
+	Passed      int       `json:"passed"`
+	Failed      int       `json:"failed"`
+	Flips       int       `json:"flips"` // Result changes between consecutive runs
+	FirstRun    time.Time `json:"first_run"`
+	LastRun     time.Time `json:"last_run"`
+	LastFailure string    `json:"last_failure,omitempty"`
+}
+
+// FailureRate returns the share of failed runs (0..1)
+func (f *FlakyTest) FailureRate() float64 {
+	if f.Runs == 0 {
+		return 0
+	}
+	return float64(f.Failed) / float64(f.Runs)
+}
+
+// FlakyOptions filters the flaky test report
+type FlakyOptions struct {
+	System  string
+	Since   time.Time
+	MinRuns int // Minimum runs on the same source (default 2)
+}
+
+// RecordTests stores test results in the test history
+func (s *SQLiteCache) RecordTests(ctx context.Context, records []*TestRecord) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO test_results
+		(run_id, system, object_type, object_name, class_name, method, transport, run_at, attempt, passed, message, source_hash, duration_ms)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range records {
+		if r.RunAt.IsZero() {
+			r.RunAt = time.Now()
+		}
+		if r.Attempt == 0 {
+			r.Attempt = 1
+		}
+		_, err := stmt.ExecContext(ctx,
+			r.RunID, r.System, r.ObjectType, r.ObjectName, r.ClassName, r.Method, r.Transport,
+			r.RunAt.UnixMilli(), r.Attempt, boolToInt(r.Passed), r.Message, r.SourceHash, r.Duration.Milliseconds(),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to record %s.%s: %w", r.ClassName, r.Method, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// TestHistory returns recorded test results, newest first
+func (s *SQLiteCache) TestHistory(ctx context.Context, q TestHistoryQuery) ([]*TestRecord, error) {
+	if q.Limit <= 0 {
+		q.Limit = 1000
+	}
+	where, args := testHistoryFilter(q.System, q.Since)
+	if q.ObjectName != "" {
+		where = append(where, "object_name LIKE ?")
+		args = append(args, strings.ReplaceAll(strings.ToUpper(q.ObjectName), "*", "%"))
+	}
+	if q.Method != "" {
+		where = append(where, "method = ?")
+		args = append(args, strings.ToUpper(q.Method))
+	}
+	args = append(args, q.Limit)
+
+	return s.queryTestRecords(ctx,
+		"WHERE "+strings.Join(where, " AND ")+" ORDER BY run_at DESC, id DESC LIMIT ?", args...)
+}
+
+// FlakyTests reports test methods whose result changed between runs while the
+// tested source stayed the same (same hash or change marker). Results without
+// one are ignored, since a changed result may then be explained by a changed
+// source.
+func (s *SQLiteCache) FlakyTests(ctx context.Context, opts FlakyOptions) ([]*FlakyTest, error) {
+	if opts.MinRuns <= 0 {
+		opts.MinRuns = 2
+	}
+	where, args := testHistoryFilter(opts.System, opts.Since)
+	where = append(where, "source_hash != ''")
+
+	records, err := s.queryTestRecords(ctx,
+		"WHERE "+strings.Join(where, " AND ")+
+			" ORDER BY system, object_name, class_name, method, source_hash, run_at, attempt, id", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var flaky []*FlakyTest
+	var cur *FlakyTest
+	var last bool
+	flush := func() {
+		if cur != nil && cur.Passed > 0 && cur.Failed > 0 && cur.Runs >= opts.MinRuns {
+			flaky = append(flaky, cur)
+		}
+	}
+	for _, r := range records {
+		if cur == nil || cur.System != r.System || cur.ObjectName != r.ObjectName ||
+			cur.ClassName != r.ClassName || cur.Method != r.Method || cur.SourceHash != r.SourceHash {
+			flush()
+			cur = &FlakyTest{
+				System:     r.System,
+				ObjectType: r.ObjectType,
+				ObjectName: r.ObjectName,
+				ClassName:  r.ClassName,
+				Method:     r.Method,
+				SourceHash: r.SourceHash,
+				FirstRun:   r.RunAt,
+			}
+		} else if r.Passed != last {
+			cur.Flips++
+		}
+		last = r.Passed
+		cur.Runs++
+		cur.LastRun = r.RunAt
+		if r.Passed {
+			cur.Passed++
+		} else {
+			cur.Failed++
+			cur.LastFailure = r.Message
+		}
+	}
+	flush()
+
+	sort.SliceStable(flaky, func(i, j int) bool {
+		if flaky[i].Flips != flaky[j].Flips {
+			return flaky[i].Flips > flaky[j].Flips
+		}
+		return flaky[i].LastRun.After(flaky[j].LastRun)
+	})
+	return flaky, nil
+}
+
+// CountTestRecords returns the number of recorded results, and how many of
+// them have a source hash (and so can be used by FlakyTests)
+func (s *SQLiteCache) CountTestRecords(ctx context.Context, system string, since time.Time) (total, withSource int, err error) {
+	where, args := testHistoryFilter(system, since)
+	err = s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COUNT(CASE WHEN source_hash != '' THEN 1 END)
+		FROM test_results WHERE `+strings.Join(where, " AND "), args...).Scan(&total, &withSource)
+	return total, withSource, err
+}
+
+// testHistoryFilter returns the WHERE conditions for a system and start time
+func testHistoryFilter(system string, since time.Time) ([]string, []interface{}) {
+	where := []string{"1 = 1"}
+	var args []interface{}
+	if system != "" {
+		where = append(where, "system = ?")
+		args = append(args, system)
+	}
+	if !since.IsZero() {
+		where = append(where, "run_at >= ?")
+		args = append(args, since.UnixMilli())
+	}
+	return where, args
+}
+
+func (s *SQLiteCache) queryTestRecords(ctx context.Context, clause string, args ...interface{}) ([]*TestRecord, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT run_id, system, object_type, object_name, class_name, method, transport,
+		       run_at, attempt, passed, COALESCE(message, ''), source_hash, COALESCE(duration_ms, 0)
+		FROM test_results `+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*TestRecord
+	for rows.Next() {
+		r := &TestRecord{}
+		var runAt, durationMS int64
+		var passed int
+		if err := rows.Scan(&r.RunID, &r.System, &r.ObjectType, &r.ObjectName, &r.ClassName, &r.Method, &r.Transport,
+			&runAt, &r.Attempt, &passed, &r.Message, &r.SourceHash, &durationMS); err != nil {
+			return nil, err
+		}
+		r.RunAt = time.UnixMilli(runAt)
+		r.Passed = passed != 0
+		r.Duration = time.Duration(durationMS) * time.Millisecond
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
@@ -0,0 +1,362 @@
+package dsl
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+	"github.com/oisee/vibing-steampunk/pkg/cache"
+)
+
+// AffectedTest is an object whose unit tests cover a changed object.
+type AffectedTest struct {
+	Object      ObjectRef `json:"object"`
+	Reason      string    `json:"reason"`        // changed, graph, callers or references
+	Via         string    `json:"via,omitempty"` // Changed object the tests depend on
+	Depth       int       `json:"depth"`         // Hops from the changed object (0 = changed itself)
+	TestClasses []string  `json:"testClasses,omitempty"`
+}
+
+// AffectedTestsResult is the result of a change-based test selection.
+type AffectedTestsResult struct {
+	Changed  []ObjectRef    `json:"changed"`
+	Tests    []AffectedTest `json:"tests"`
+	Untested []ObjectRef    `json:"untested,omitempty"` // Affected classes without test classes
+	Warnings []string       `json:"warnings,omitempty"`
+}
+
+// Objects returns the objects whose tests should run.
+func (r *AffectedTestsResult) Objects() []ObjectRef {
+	objects := make([]ObjectRef, len(r.Tests))
+	for i, t := range r.Tests {
+		objects[i] = t.Object
+	}
+	return objects
+}
+
+// AffectedTestFinder selects the unit tests affected by a set of changed
+// objects. Dependents are found in the cached object graph and, unless
+// GraphOnly is set, through the callers and where-used list in the system.
+type AffectedTestFinder struct {
+	client    *adt.Client
+	graph     cache.Cache
+	depth     int
+	graphOnly bool
+}
+
+// AffectedTests creates a new affected test finder.
+func AffectedTests(client *adt.Client) *AffectedTestFinder {
+	return &AffectedTestFinder{client: client, depth: 3}
+}
+
+// Graph sets the cached object graph to search for dependents.
+func (f *AffectedTestFinder) Graph(c cache.Cache) *AffectedTestFinder {
+	f.graph = c
+	return f
+}
+
+// Depth sets the maximum number of hops from a changed object (default: 3).
+func (f *AffectedTestFinder) Depth(n int) *AffectedTestFinder {
+	if n > 0 {
+		f.depth = n
+	}
+	return f
+}
+
+// GraphOnly skips the GetCallersOf and FindReferences lookups.
+func (f *AffectedTestFinder) GraphOnly() *AffectedTestFinder {
+	f.graphOnly = true
+	return f
+}
+
+// testableTypes are the object types that can hold ABAP Unit tests.
+var testableTypes = map[string]bool{TypeClass: true, TypeProgram: true, TypeFuncGroup: true}
+
+// Find returns the testable objects affected by the changed objects: the
+// changed objects themselves and their dependents. Failed lookups are
+// recorded as warnings.
+func (f *AffectedTestFinder) Find(ctx context.Context, changed []ObjectRef) (*AffectedTestsResult, error) {
+	result := &AffectedTestsResult{Changed: changed, Tests: []AffectedTest{}}
+	found := make(map[string]*AffectedTest)
+	var order []string
+
+	add := func(obj ObjectRef, reason, via string, depth int) {
+		obj.Type = baseType(obj.Type)
+		obj.Name = strings.ToUpper(obj.Name)
+		if !testableTypes[obj.Type] || obj.Name == "" {
+			return
+		}
+		key := obj.Type + "/" + obj.Name
+		if t, ok := found[key]; ok {
+			if depth < t.Depth {
+				t.Reason, t.Via, t.Depth = reason, via, depth
+			}
+			return
+		}
+		found[key] = &AffectedTest{Object: ObjectRef{Type: obj.Type, Name: obj.Name, Package: obj.Package}, Reason: reason, Via: via, Depth: depth}
+		order = append(order, key)
+	}
+
+	for _, obj := range changed {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		name := strings.ToUpper(obj.Name)
+		add(obj, "changed", "", 0)
+
+		if f.graph != nil {
+			if err := f.findInGraph(ctx, obj, add); err != nil {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("graph lookup for %s failed: %v", name, err))
+			}
+		}
+		if f.graphOnly || f.client == nil {
+			continue
+		}
+		objectURL := changedObjectURL(obj)
+		if objectURL == "" {
+			continue
+		}
+		if callers, err := f.client.GetCallersOf(ctx, objectURL, f.depth); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("callers of %s: %v", name, err))
+		} else {
+			walkCallers(callers, 0, func(ref ObjectRef, depth int) { add(ref, "callers", name, depth) })
+		}
+		if refs, err := f.client.FindReferences(ctx, objectURL, 0, 0); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("references to %s: %v", name, err))
+		} else {
+			for _, ref := range refs {
+				if o, ok := objectFromURI(ref.URI); ok {
+					o.Package = ref.PackageName
+					add(o, "references", name, 1)
+				}
+			}
+		}
+	}
+
+	for _, key := range order {
+		t := found[key]
+		if t.Object.Type == TypeClass && f.client != nil {
+			comp, err := f.client.GetClassComponents(ctx, adt.GetObjectURL(adt.ObjectTypeClass, t.Object.Name, ""))
+			if err != nil {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("test lookup for %s failed: %v", t.Object.Name, err))
+			} else if t.TestClasses = comp.TestClasses(); len(t.TestClasses) == 0 {
+				result.Untested = append(result.Untested, t.Object)
+				continue
+			}
+		}
+		result.Tests = append(result.Tests, *t)
+	}
+	sort.SliceStable(result.Tests, func(i, j int) bool {
+		a, b := result.Tests[i], result.Tests[j]
+		if a.Depth != b.Depth {
+			return a.Depth < b.Depth
+		}
+		return a.Object.Name < b.Object.Name
+	})
+	return result, nil
+}
+
+// findInGraph adds the transitive dependents of obj in the cached graph.
+func (f *AffectedTestFinder) findInGraph(ctx context.Context, obj ObjectRef, add func(ObjectRef, string, string, int)) error {
+	roots, err := cache.ResolveImpactRoots(ctx, f.graph, baseType(obj.Type), obj.Name)
+	if err == cache.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	impact, err := cache.AnalyzeImpact(ctx, f.graph, roots, cache.ImpactOptions{MaxDepth: f.depth})
+	if err != nil {
+		return err
+	}
+	for _, dep := range impact.Dependents {
+		add(ObjectRef{Type: dep.ObjectType, Name: dep.ObjectName, Package: dep.Package},
+			"graph", strings.ToUpper(obj.Name), dep.Depth)
+	}
+	return nil
+}
+
+// walkCallers calls fn for every caller below the root of a call graph.
+func walkCallers(node *adt.CallGraphNode, depth int, fn func(ObjectRef, int)) {
+	if node == nil {
+		return
+	}
+	if depth > 0 {
+		if obj, ok := objectFromURI(node.URI); ok {
+			fn(obj, depth)
+		}
+	}
+	for i := range node.Children {
+		walkCallers(&node.Children[i], depth+1, fn)
+	}
+}
+
+// changedObjectURL returns the ADT URL used for the callers and where-used
+// lookups of a changed object, or "" if the type has none.
+func changedObjectURL(obj ObjectRef) string {
+	if obj.URL != "" {
+		return obj.URL
+	}
+	switch baseType(obj.Type) {
+	case TypeClass:
+		return adt.GetObjectURL(adt.ObjectTypeClass, obj.Name, "")
+	case TypeInterface:
+		return adt.GetObjectURL(adt.ObjectTypeInterface, obj.Name, "")
+	case TypeProgram:
+		return adt.GetObjectURL(adt.ObjectTypeProgram, obj.Name, "")
+	case TypeFuncGroup:
+		return adt.GetObjectURL(adt.ObjectTypeFunctionGroup, obj.Name, "")
+	case TypeFunction:
+		if obj.Package != "" {
+			return adt.GetObjectURL(adt.ObjectTypeFunctionMod, obj.Name, obj.Package)
+		}
+	case TypeDDLS:
+		return adt.GetObjectURL(adt.ObjectTypeDDLS, obj.Name, "")
+	case TypeTable:
+		return "/sap/bc/adt/ddic/tables/" + url.PathEscape(strings.ToLower(obj.Name))
+	}
+	return ""
+}
+
+// uriTypes maps ADT URI collections to object types.
+var uriTypes = map[string]string{
+	"oo/classes":        TypeClass,
+	"oo/interfaces":     TypeInterface,
+	"programs/programs": TypeProgram,
+	"programs/includes": "INCL",
+	"functions/groups":  TypeFuncGroup,
+}
+
+// objectFromURI returns the object an ADT URI belongs to, e.g. CLAS ZCL_FOO
+// for /sap/bc/adt/oo/classes/zcl_foo/source/main#start=12.
+func objectFromURI(uri string) (ObjectRef, bool) {
+	uri, _, _ = strings.Cut(uri, "#")
+	uri, _, _ = strings.Cut(uri, "?")
+	parts := strings.Split(strings.Trim(uri, "/"), "/")
+	if len(parts) < 6 || parts[0] != "sap" || parts[1] != "bc" || parts[2] != "adt" {
+		return ObjectRef{}, false
+	}
+	objectType, ok := uriTypes[parts[3]+"/"+parts[4]]
+	if !ok {
+		return ObjectRef{}, false
+	}
+	name := parts[5]
+	if u, err := url.PathUnescape(name); err == nil {
+		name = u
+	}
+	name = strings.ToUpper(name)
+	return ObjectRef{Type: objectType, Name: name, URL: strings.Join(parts[:6], "/")}, true
+}
+
+// baseType strips the ADT subtype from an object type (CLAS/OC -> CLAS).
+func baseType(objectType string) string {
+	t, _, _ := strings.Cut(strings.ToUpper(objectType), "/")
+	return t
+}
+
+// ParseObjectRef parses an object given as TYPE:NAME, e.g. CLAS:ZCL_FOO. A
+// bare name has no type; such objects are only looked up in the graph.
+func ParseObjectRef(s string) ObjectRef {
+	s = strings.TrimSpace(s)
+	if objectType, name, ok := strings.Cut(s, ":"); ok {
+		return ObjectRef{Type: baseType(objectType), Name: strings.ToUpper(strings.TrimSpace(name))}
+	}
+	return ObjectRef{Name: strings.ToUpper(s)}
+}
+
+// ObjectsFromFiles returns the objects of files in abapGit layout, e.g.
+// CLAS ZCL_FOO for src/zcl_foo.clas.testclasses.abap or src/zcl_foo.clas.xml.
+// Files that are not abapGit object files are ignored. The files need not
+// exist, so the paths of a git diff including deletions can be passed.
+func ObjectsFromFiles(paths ...string) []ObjectRef {
+	var objects []ObjectRef
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		parts := strings.Split(filepath.Base(filepath.ToSlash(p)), ".")
+		if len(parts) < 3 || parts[0] == "" {
+			continue
+		}
+		objectType := strings.ToUpper(parts[1])
+		if objectType == TypePackage || len(objectType) != 4 {
+			continue
+		}
+		// abapGit file names are lower case with namespace slashes as #.
+		// Function modules and includes (zgroup.fugr.z_func.abap) belong
+		// to their function group.
+		name := strings.ToUpper(strings.ReplaceAll(parts[0], "#", "/"))
+		key := objectType + "/" + name
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		objects = append(objects, ObjectRef{Type: objectType, Name: name})
+	}
+	return objects
+}
+
+// TransportObjects returns the objects recorded in a transport request and
+// its tasks. Sub-objects (LIMU entries such as methods or class sections) are
+// returned as their enclosing object.
+func TransportObjects(ctx context.Context, client *adt.Client, number string) ([]ObjectRef, error) {
+	tr, err := client.GetTransport(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := append([]adt.TransportObjectV2(nil), tr.Objects...)
+	for _, task := range tr.Tasks {
+		entries = append(entries, task.Objects...)
+	}
+
+	var objects []ObjectRef
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		obj, ok := transportObject(e)
+		if !ok {
+			continue
+		}
+		key := obj.Type + "/" + obj.Name
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// transportObject maps a transport entry to the object it changes.
+func transportObject(e adt.TransportObjectV2) (ObjectRef, bool) {
+	name := strings.TrimSpace(e.Name)
+	if name == "" {
+		return ObjectRef{}, false
+	}
+	switch e.PgmID {
+	case "R3TR":
+		if e.Type == TypePackage {
+			return ObjectRef{}, false
+		}
+		return ObjectRef{Type: e.Type, Name: name}, true
+	case "LIMU":
+		switch e.Type {
+		case "METH":
+			// Class name and method name, padded to 30 characters
+			return ObjectRef{Type: TypeClass, Name: strings.Fields(name)[0]}, true
+		case "CPUB", "CPRO", "CPRI", "CLSD", "CINC":
+			// Class includes are named ZCL_FOO=======CCAU
+			cls, _, _ := strings.Cut(name, "=")
+			return ObjectRef{Type: TypeClass, Name: cls}, true
+		case "INTD":
+			return ObjectRef{Type: TypeInterface, Name: name}, true
+		case "REPS", "REPT":
+			return ObjectRef{Type: TypeProgram, Name: name}, true
+		case "FUNC":
+			return ObjectRef{Type: TypeFunction, Name: name}, true
+		}
+	}
+	return ObjectRef{}, false
+}
@@ -304,6 +304,7 @@ func (p *PipelineBuilder) Build() *Pipeline {
 	pipeline := &Pipeline{
 		Name:   p.name,
 		Stages: make([]Stage, 0, len(p.stages)),
+		client: p.client,
 	}
 
 	for _, sb := range p.stages {
@@ -529,6 +530,19 @@ func (s *StageBuilder) ExportClasses(classNames []string, outputDir string, save
 	return s
 }
 
+// Tool adds a step that calls a tool (see WithToolCaller).
+func (s *StageBuilder) Tool(name string, args map[string]interface{}, saveAs string) *StageBuilder {
+	s.steps = append(s.steps, Step{
+		Action: "tool",
+		Parameters: map[string]interface{}{
+			"tool":      name,
+			"arguments": args,
+		},
+		SaveAs: saveAs,
+	})
+	return s
+}
+
 // SetVariable adds a step to set a variable.
 func (s *StageBuilder) SetVariable(name, value string) *StageBuilder {
 	s.steps = append(s.steps, Step{
@@ -2,7 +2,18 @@ package dsl
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+	"github.com/oisee/vibing-steampunk/pkg/cache"
 )
 
 func TestSearchBuilder(t *testing.T) {
@@ -323,13 +334,21 @@ func TestConditionEvaluation(t *testing.T) {
 	ctx := context.Background()
 	execCtx := NewExecutionContext(ctx, nil)
 	engine := NewWorkflowEngine(nil)
+	met := func(t *testing.T, condition string) bool {
+		t.Helper()
+		ok, err := engine.checkCondition(execCtx, condition)
+		if err != nil {
+			t.Fatalf("%s: %v", condition, err)
+		}
+		return ok
+	}
 
 	t.Run("exists", func(t *testing.T) {
 		execCtx.Set("myVar", "value")
-		if !engine.evaluateCondition(execCtx, "exists:myVar") {
+		if !met(t, "exists:myVar") {
 			t.Error("expected exists:myVar to be true")
 		}
-		if engine.evaluateCondition(execCtx, "exists:nonExistent") {
+		if met(t, "exists:nonExistent") {
 			t.Error("expected exists:nonExistent to be false")
 		}
 	})
@@ -338,10 +357,10 @@ func TestConditionEvaluation(t *testing.T) {
 		execCtx.Set("emptyList", []ObjectRef{})
 		execCtx.Set("fullList", []ObjectRef{{Name: "test"}})
 
-		if !engine.evaluateCondition(execCtx, "empty:emptyList") {
+		if !met(t, "empty:emptyList") {
 			t.Error("expected empty:emptyList to be true")
 		}
-		if engine.evaluateCondition(execCtx, "empty:fullList") {
+		if met(t, "empty:fullList") {
 			t.Error("expected empty:fullList to be false")
 		}
 	})
@@ -350,13 +369,35 @@ func TestConditionEvaluation(t *testing.T) {
 		execCtx.Set("emptyList", []ObjectRef{})
 		execCtx.Set("fullList", []ObjectRef{{Name: "test"}})
 
-		if engine.evaluateCondition(execCtx, "not_empty:emptyList") {
+		if met(t, "not_empty:emptyList") {
 			t.Error("expected not_empty:emptyList to be false")
 		}
-		if !engine.evaluateCondition(execCtx, "not_empty:fullList") {
+		if !met(t, "not_empty:fullList") {
 			t.Error("expected not_empty:fullList to be true")
 		}
 	})
+
+	t.Run("variables", func(t *testing.T) {
+		execCtx.SetVariable("PACKAGE", "$ZA")
+		execCtx.SetVariable("TITLE", `it's "quoted" || true`)
+		execCtx.SetVariable("EXPECTED", "2")
+
+		if !met(t, "${PACKAGE} == '$ZA'") {
+			t.Error("expected ${PACKAGE} == '$ZA' to be true")
+		}
+		if !met(t, `"${PACKAGE}/sub" == "$ZA/sub"`) {
+			t.Error("expected the reference in a string to be expanded")
+		}
+		if met(t, "${TITLE} == 'x'") || !met(t, `startsWith(${TITLE}, "it's")`) {
+			t.Error("expected the value with quotes and operators to be read as a string")
+		}
+		if !met(t, "len(fullList) < ${EXPECTED}") {
+			t.Error("expected a numeric value to compare as a number")
+		}
+		if _, err := engine.checkCondition(execCtx, "${PACKAGE} > 1"); err == nil {
+			t.Error("expected an evaluation error")
+		}
+	})
 }
 
 func TestPipelineBuilder(t *testing.T) {
@@ -426,6 +467,81 @@ func TestBatchBuilder(t *testing.T) {
 	})
 }
 
+func TestTestRunnerRerunFailures(t *testing.T) {
+	const failing = `<aunit:runResult xmlns:aunit="http://www.sap.com/adt/aunit" xmlns:adtcore="http://www.sap.com/adt/core">
+  <program><testClasses><testClass adtcore:name="LTCL_TEST"><testMethods>
+    <testMethod adtcore:name="FLAKY" executionTime="0.5"><alerts><alert kind="failedAssertion"><title>boom</title></alert></alerts></testMethod>
+  </testMethods></testClass></testClasses></program>
+</aunit:runResult>`
+	const passing = `<aunit:runResult xmlns:aunit="http://www.sap.com/adt/aunit" xmlns:adtcore="http://www.sap.com/adt/core">
+  <program><testClasses><testClass adtcore:name="LTCL_TEST"><testMethods>
+    <testMethod adtcore:name="FLAKY" executionTime="0.5"/>
+  </testMethods></testClass></testClasses></program>
+</aunit:runResult>`
+
+	runs := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "discovery"):
+			w.Header().Set("X-CSRF-Token", "token")
+		case strings.Contains(r.URL.Path, "testruns"):
+			runs++
+			if runs == 1 {
+				w.Write([]byte(failing))
+			} else {
+				w.Write([]byte(passing))
+			}
+		case strings.HasSuffix(r.URL.Path, "/source/main"):
+			w.Write([]byte("CLASS zcl_test DEFINITION."))
+		case strings.HasSuffix(r.URL.Path, "/oo/classes/ZCL_TEST"):
+			w.Write([]byte(`<class:abapClass xmlns:class="http://www.sap.com/adt/oo/classes" xmlns:adtcore="http://www.sap.com/adt/core" adtcore:changedAt="2026-05-01T10:00:00Z"/>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	var completed []TestResult
+	summary, err := Test(adt.NewClient(server.URL, "u", "p")).
+		Class("ZCL_TEST").
+		RerunFailures(2).
+		HashSources().
+		OnComplete(func(obj ObjectRef, result TestResult) { completed = append(completed, result) }).
+		Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if runs != 2 {
+		t.Errorf("expected 2 test runs (1 rerun), got %d", runs)
+	}
+	if len(completed) != 1 {
+		t.Fatalf("expected OnComplete once per object, got %d", len(completed))
+	}
+	result := summary.Results[0]
+	if !result.Success || summary.FailedTests != 0 || summary.PassedTests != 1 {
+		t.Errorf("expected the rerun to pass, got %+v", summary)
+	}
+	if len(result.Retries) != 1 || result.Retries[0].Success || result.Retries[0].Classes[0].Methods[0].Message != "boom" {
+		t.Errorf("retries = %+v", result.Retries)
+	}
+	if result.SourceHash == "" || result.Retries[0].SourceHash != result.SourceHash {
+		t.Errorf("source hash = %q, retry hash = %q", result.SourceHash, result.Retries[0].SourceHash)
+	}
+	if ms := result.Classes[0].Methods[0].ExecutionTime.Milliseconds(); ms != 500 {
+		t.Errorf("execution time = %dms, want 500", ms)
+	}
+
+	// Without HashSources, the change timestamp marks the source
+	summary, err = Test(adt.NewClient(server.URL, "u", "p")).Class("ZCL_TEST").Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if got := summary.Results[0].SourceHash; got != "changed:2026-05-01T10:00:00Z" {
+		t.Errorf("source marker = %q", got)
+	}
+}
+
 func TestTestSummary(t *testing.T) {
 	summary := &TestSummary{
 		TotalObjects:  5,
@@ -459,3 +575,866 @@ func TestObjectRef(t *testing.T) {
 		t.Errorf("expected name 'ZCL_TEST', got '%s'", obj.Name)
 	}
 }
+
+func TestAffectedTests(t *testing.T) {
+	ctx := context.Background()
+	graph := cache.NewMemoryCache(cache.DefaultConfig())
+	for _, n := range []*cache.Node{
+		{ID: "INTF.ZIF_UTIL", ObjectType: "INTF", ObjectName: "ZIF_UTIL", Valid: true},
+		{ID: "CLAS.ZCL_UTIL", ObjectType: "CLAS", ObjectName: "ZCL_UTIL", Package: "$ZUTIL", Valid: true},
+		{ID: "CLAS.ZCL_APP", ObjectType: "CLAS", ObjectName: "ZCL_APP", Valid: true},
+		{ID: "CLAS.ZCL_NOTEST", ObjectType: "CLAS", ObjectName: "ZCL_NOTEST", Valid: true},
+	} {
+		graph.PutNode(ctx, n)
+	}
+	graph.PutEdges(ctx, []*cache.Edge{
+		{FromID: "CLAS.ZCL_UTIL", ToID: "INTF.ZIF_UTIL", EdgeType: "USES", Valid: true},
+		{FromID: "CLAS.ZCL_NOTEST", ToID: "INTF.ZIF_UTIL", EdgeType: "USES", Valid: true},
+		{FromID: "CLAS.ZCL_APP", ToID: "CLAS.ZCL_UTIL", EdgeType: "CALLS", Valid: true},
+	})
+
+	const withTests = `<abapsource:objectStructureElement xmlns:abapsource="http://www.sap.com/adt/abapsource" xmlns:adtcore="http://www.sap.com/adt/core" xmlns:atom="http://www.w3.org/2005/Atom" adtcore:name="ZCL" adtcore:type="CLAS/OC">
+  <abapsource:objectStructureElement adtcore:name="LTCL_TEST" adtcore:type="CLAS/OCN">
+    <atom:link href="/sap/bc/adt/oo/classes/ZCL/includes/testclasses#start=3,1" rel="self"/>
+  </abapsource:objectStructureElement>
+</abapsource:objectStructureElement>`
+	const withoutTests = `<abapsource:objectStructureElement xmlns:abapsource="http://www.sap.com/adt/abapsource" xmlns:adtcore="http://www.sap.com/adt/core" adtcore:name="ZCL_NOTEST" adtcore:type="CLAS/OC"/>`
+	const callers = `<callGraph><node uri="/sap/bc/adt/oo/interfaces/zif_util" name="ZIF_UTIL">
+  <node uri="/sap/bc/adt/programs/programs/zreport/source/main#start=5,2" name="ZREPORT"/>
+</node></callGraph>`
+	const references = `<usageReferences:usageReferenceResult xmlns:usageReferences="http://www.sap.com/adt/ris/usageReferences" xmlns:adtcore="http://www.sap.com/adt/core">
+  <usageReferences:referencedObjects>
+    <usageReferences:referencedObject uri="/sap/bc/adt/oo/classes/zcl_app" isResult="true">
+      <usageReferences:adtObject adtcore:name="ZCL_APP" adtcore:type="CLAS/OC"><adtcore:packageRef adtcore:name="$ZAPP"/></usageReferences:adtObject>
+    </usageReferences:referencedObject>
+  </usageReferences:referencedObjects>
+</usageReferences:usageReferenceResult>`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "discovery"):
+			w.Header().Set("X-CSRF-Token", "token")
+		case strings.HasSuffix(r.URL.Path, "ZCL_NOTEST/objectstructure"):
+			w.Write([]byte(withoutTests))
+		case strings.HasSuffix(r.URL.Path, "/objectstructure"):
+			w.Write([]byte(withTests))
+		case strings.HasSuffix(r.URL.Path, "/cai/callgraph"):
+			w.Write([]byte(callers))
+		case strings.HasSuffix(r.URL.Path, "/usageReferences"):
+			w.Write([]byte(references))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	result, err := AffectedTests(adt.NewClient(server.URL, "u", "p")).
+		Graph(graph).
+		Find(ctx, []ObjectRef{{Type: "INTF", Name: "ZIF_UTIL"}})
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if len(result.Warnings) > 0 {
+		t.Errorf("warnings: %v", result.Warnings)
+	}
+
+	got := make(map[string]AffectedTest)
+	for _, test := range result.Tests {
+		got[test.Object.Type+" "+test.Object.Name] = test
+	}
+	if len(got) != 3 {
+		t.Fatalf("tests = %+v", result.Tests)
+	}
+	if u := got["CLAS ZCL_UTIL"]; u.Reason != "graph" || u.Depth != 1 || u.Object.Package != "$ZUTIL" || len(u.TestClasses) != 1 {
+		t.Errorf("ZCL_UTIL = %+v", u)
+	}
+	// Two hops in the graph, but a direct reference
+	if a := got["CLAS ZCL_APP"]; a.Reason != "references" || a.Depth != 1 || a.Via != "ZIF_UTIL" {
+		t.Errorf("ZCL_APP = %+v", a)
+	}
+	if p := got["PROG ZREPORT"]; p.Reason != "callers" {
+		t.Errorf("ZREPORT = %+v", p)
+	}
+	if len(result.Untested) != 1 || result.Untested[0].Name != "ZCL_NOTEST" {
+		t.Errorf("untested = %+v", result.Untested)
+	}
+	if len(result.Objects()) != 3 {
+		t.Errorf("Objects() = %+v", result.Objects())
+	}
+}
+
+func TestObjectsFromFiles(t *testing.T) {
+	objects := ObjectsFromFiles(
+		"src/zcl_foo.clas.abap",
+		"src/zcl_foo.clas.testclasses.abap",
+		"src/zcl_foo.clas.xml",
+		"src/#dmo#if_flight.intf.abap",
+		"src/zgroup.fugr.z_func.abap",
+		"src/package.devc.xml",
+		".abapgit.xml",
+		"README.md",
+	)
+	want := []ObjectRef{
+		{Type: "CLAS", Name: "ZCL_FOO"},
+		{Type: "INTF", Name: "/DMO/IF_FLIGHT"},
+		{Type: "FUGR", Name: "ZGROUP"},
+	}
+	if len(objects) != len(want) {
+		t.Fatalf("objects = %+v", objects)
+	}
+	for i := range want {
+		if objects[i] != want[i] {
+			t.Errorf("object %d = %+v, want %+v", i, objects[i], want[i])
+		}
+	}
+}
+
+func TestTransportObject(t *testing.T) {
+	tests := []struct {
+		entry adt.TransportObjectV2
+		want  ObjectRef
+		ok    bool
+	}{
+		{adt.TransportObjectV2{PgmID: "R3TR", Type: "CLAS", Name: "ZCL_FOO"}, ObjectRef{Type: "CLAS", Name: "ZCL_FOO"}, true},
+		{adt.TransportObjectV2{PgmID: "LIMU", Type: "METH", Name: "ZCL_FOO                       CALCULATE"}, ObjectRef{Type: "CLAS", Name: "ZCL_FOO"}, true},
+		{adt.TransportObjectV2{PgmID: "LIMU", Type: "CINC", Name: "ZCL_FOO=======================CCAU"}, ObjectRef{Type: "CLAS", Name: "ZCL_FOO"}, true},
+		{adt.TransportObjectV2{PgmID: "LIMU", Type: "REPS", Name: "ZREPORT"}, ObjectRef{Type: "PROG", Name: "ZREPORT"}, true},
+		{adt.TransportObjectV2{PgmID: "R3TR", Type: "DEVC", Name: "$ZFOO"}, ObjectRef{}, false},
+		{adt.TransportObjectV2{PgmID: "CORR", Type: "RELE", Name: "A4HK900001"}, ObjectRef{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := transportObject(tt.entry)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("transportObject(%+v) = %+v, %v; want %+v, %v", tt.entry, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSync(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const (
+		classA   = "CLASS zcl_a DEFINITION PUBLIC.\nENDCLASS.\nCLASS zcl_a IMPLEMENTATION.\nENDCLASS.\n"
+		classAv2 = "CLASS zcl_a DEFINITION PUBLIC.\n  PUBLIC SECTION.\nENDCLASS.\nCLASS zcl_a IMPLEMENTATION.\nENDCLASS.\n"
+		intfB    = "INTERFACE zif_b PUBLIC.\nENDINTERFACE.\n"
+		intfE    = "INTERFACE zif_e PUBLIC.\nENDINTERFACE.\n"
+		classD   = "CLASS zcl_d DEFINITION PUBLIC.\nENDCLASS.\nCLASS zcl_d IMPLEMENTATION.\nENDCLASS.\n"
+		testD    = "CLASS ltcl_d DEFINITION FOR TESTING.\nENDCLASS.\n"
+	)
+	write("zcl_a.clas.abap", classAv2) // changed locally
+	write("zif_b.intf.abap", intfB)    // in sync
+	write("zif_e.intf.abap", "INTERFACE zif_e PUBLIC.\n  METHODS local.\nENDINTERFACE.\n")
+	write("zprog_c.prog.abap", "REPORT zprog_c.\n") // added locally
+
+	state := &SyncState{Package: "$ZSYNC", Objects: map[string]SyncStateEntry{
+		"CLAS:ZCL_A": {Path: "zcl_a.clas.abap", Hash: hashSource(classA)},
+		"INTF:ZIF_E": {Path: "zif_e.intf.abap", Hash: hashSource(intfE)},
+	}}
+	if err := state.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	const nodes = `<asx:abap xmlns:asx="http://www.sap.com/abapxml"><asx:values><DATA><TREE_CONTENT>
+<SEU_ADT_REPOSITORY_OBJ_NODE><OBJECT_TYPE>CLAS/OC</OBJECT_TYPE><OBJECT_NAME>ZCL_A</OBJECT_NAME></SEU_ADT_REPOSITORY_OBJ_NODE>
+<SEU_ADT_REPOSITORY_OBJ_NODE><OBJECT_TYPE>INTF/OI</OBJECT_TYPE><OBJECT_NAME>ZIF_B</OBJECT_NAME></SEU_ADT_REPOSITORY_OBJ_NODE>
+<SEU_ADT_REPOSITORY_OBJ_NODE><OBJECT_TYPE>CLAS/OC</OBJECT_TYPE><OBJECT_NAME>ZCL_D</OBJECT_NAME></SEU_ADT_REPOSITORY_OBJ_NODE>
+<SEU_ADT_REPOSITORY_OBJ_NODE><OBJECT_TYPE>INTF/OI</OBJECT_TYPE><OBJECT_NAME>ZIF_E</OBJECT_NAME></SEU_ADT_REPOSITORY_OBJ_NODE>
+<SEU_ADT_REPOSITORY_OBJ_NODE><OBJECT_TYPE>TABL/DT</OBJECT_TYPE><OBJECT_NAME>ZTAB</OBJECT_NAME></SEU_ADT_REPOSITORY_OBJ_NODE>
+</TREE_CONTENT></DATA></asx:values></asx:abap>`
+	sources := map[string]string{
+		"/OO/CLASSES/ZCL_A/SOURCE/MAIN":              classA,
+		"/OO/INTERFACES/ZIF_B/SOURCE/MAIN":           intfB,
+		"/OO/CLASSES/ZCL_D/SOURCE/MAIN":              classD,
+		"/OO/CLASSES/ZCL_D/INCLUDES/TESTCLASSES":     testD,
+		"/OO/CLASSES/ZCL_D/INCLUDES/IMPLEMENTATIONS": "*\"* use this source file for the definition and implementation of\n",
+		"/OO/INTERFACES/ZIF_E/SOURCE/MAIN":           "INTERFACE zif_e PUBLIC.\n  METHODS remote.\nENDINTERFACE.\n",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.ToUpper(r.URL.Path)
+		switch {
+		case strings.Contains(r.URL.Path, "discovery"):
+			w.Header().Set("X-CSRF-Token", "token")
+		case strings.HasSuffix(r.URL.Path, "/nodestructure"):
+			w.Write([]byte(nodes))
+		default:
+			for suffix, source := range sources {
+				if strings.HasSuffix(path, suffix) {
+					w.Write([]byte(source))
+					return
+				}
+			}
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := adt.NewClient(server.URL, "u", "p")
+
+	result, err := Sync(client, dir, "$zsync").Execute(ctx)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	status := make(map[string]SyncStatus)
+	for _, e := range result.Entries {
+		status[e.Key] = e.Status
+	}
+	expected := map[string]SyncStatus{
+		"CLAS:ZCL_A":             SyncLocalChanged,
+		"INTF:ZIF_B":             SyncUnchanged,
+		"PROG:ZPROG_C":           SyncLocalAdded,
+		"CLAS:ZCL_D":             SyncRemoteAdded,
+		"CLAS:ZCL_D:testclasses": SyncRemoteAdded,
+		"INTF:ZIF_E":             SyncConflict,
+	}
+	if len(status) != len(expected) {
+		t.Errorf("expected %d entries, got %v", len(expected), status)
+	}
+	for key, want := range expected {
+		if status[key] != want {
+			t.Errorf("%s: expected %s, got %s", key, want, status[key])
+		}
+	}
+
+	// Pull writes the remote additions; the conflict is left alone
+	result, err = Sync(client, dir, "$ZSYNC").Pull().Execute(ctx)
+	if err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if result.Pulled != 2 {
+		t.Errorf("expected 2 pulled files, got %d", result.Pulled)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "zcl_d.clas.testclasses.abap")); string(data) != testD {
+		t.Errorf("expected pulled test classes, got %q", data)
+	}
+	state, err = LoadSyncState(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Objects["CLAS:ZCL_D"].Hash != hashSource(classD) || state.Objects["INTF:ZIF_B"].Hash != hashSource(intfB) {
+		t.Errorf("expected pulled and unchanged files in state, got %v", state.Objects)
+	}
+	if state.Objects["INTF:ZIF_E"].Hash != hashSource(intfE) {
+		t.Error("expected conflict base to be kept")
+	}
+
+	if _, err := Sync(client, dir, "$OTHER").Execute(ctx); err == nil {
+		t.Error("expected error for a directory synced with another package")
+	}
+	if _, err := Sync(client, dir, "$ZSYNC").Force().Execute(ctx); err == nil {
+		t.Error("expected error for force without a direction")
+	}
+}
+
+func TestSyncPushRemoteChanged(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	const (
+		classA   = "CLASS zcl_a DEFINITION PUBLIC.\nENDCLASS.\nCLASS zcl_a IMPLEMENTATION.\nENDCLASS.\n"
+		classAv2 = "CLASS zcl_a DEFINITION PUBLIC.\n  PUBLIC SECTION.\nENDCLASS.\nCLASS zcl_a IMPLEMENTATION.\nENDCLASS.\n"
+		classAv3 = "CLASS zcl_a DEFINITION PUBLIC.\n  PROTECTED SECTION.\nENDCLASS.\nCLASS zcl_a IMPLEMENTATION.\nENDCLASS.\n"
+	)
+	if err := os.WriteFile(filepath.Join(dir, "zcl_a.clas.abap"), []byte(classAv2), 0644); err != nil {
+		t.Fatal(err)
+	}
+	state := &SyncState{Package: "$ZSYNC", Objects: map[string]SyncStateEntry{
+		"CLAS:ZCL_A": {Path: "zcl_a.clas.abap", Hash: hashSource(classA)},
+	}}
+	if err := state.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	const nodes = `<asx:abap xmlns:asx="http://www.sap.com/abapxml"><asx:values><DATA><TREE_CONTENT>
+<SEU_ADT_REPOSITORY_OBJ_NODE><OBJECT_TYPE>CLAS/OC</OBJECT_TYPE><OBJECT_NAME>ZCL_A</OBJECT_NAME></SEU_ADT_REPOSITORY_OBJ_NODE>
+</TREE_CONTENT></DATA></asx:values></asx:abap>`
+	// The class is edited on the system between the scan and the push
+	reads, writes := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "discovery"):
+			w.Header().Set("X-CSRF-Token", "token")
+		case strings.HasSuffix(r.URL.Path, "/nodestructure"):
+			w.Write([]byte(nodes))
+		case r.Method == http.MethodGet && strings.HasSuffix(strings.ToUpper(r.URL.Path), "/OO/CLASSES/ZCL_A/SOURCE/MAIN"):
+			reads++
+			if reads == 1 {
+				w.Write([]byte(classA))
+			} else {
+				w.Write([]byte(classAv3))
+			}
+		case r.Method != http.MethodGet:
+			writes++
+			http.Error(w, "unexpected write", http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	result, err := Sync(adt.NewClient(server.URL, "u", "p"), dir, "$ZSYNC").Push().Execute(ctx)
+	if err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if result.Pushed != 0 || result.Failed != 1 || writes != 0 {
+		t.Fatalf("expected the push to be aborted, got %+v (%d writes)", result, writes)
+	}
+	if !strings.Contains(result.Entries[0].Error, "changed on the system") {
+		t.Errorf("error = %q", result.Entries[0].Error)
+	}
+	state, err = LoadSyncState(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Objects["CLAS:ZCL_A"].Hash != hashSource(classA) {
+		t.Error("expected the base to be kept")
+	}
+}
+
+func TestSyncFunctionGroup(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	const (
+		fmRun   = "FUNCTION z_fg_run.\nENDFUNCTION.\n"
+		fmNew   = "FUNCTION z_fg_new.\nENDFUNCTION.\n"
+		classA  = "CLASS zcl_a DEFINITION PUBLIC.\nENDCLASS.\nCLASS zcl_a IMPLEMENTATION.\nENDCLASS.\n"
+		comment = "*\"* use this source file for any type of declarations\n"
+	)
+	files := map[string]string{
+		"zfg.fugr.abap":               "FUNCTION-POOL zfg.\n",
+		"zfg.fugr.z_fg_run.func.abap": fmRun,
+		"zcl_a.clas.abap":             classA,
+		"zcl_a.clas.locals_def.abap":  comment,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const nodes = `<asx:abap xmlns:asx="http://www.sap.com/abapxml"><asx:values><DATA><TREE_CONTENT>
+<SEU_ADT_REPOSITORY_OBJ_NODE><OBJECT_TYPE>FUGR/F</OBJECT_TYPE><OBJECT_NAME>ZFG</OBJECT_NAME></SEU_ADT_REPOSITORY_OBJ_NODE>
+<SEU_ADT_REPOSITORY_OBJ_NODE><OBJECT_TYPE>CLAS/OC</OBJECT_TYPE><OBJECT_NAME>ZCL_A</OBJECT_NAME></SEU_ADT_REPOSITORY_OBJ_NODE>
+</TREE_CONTENT></DATA></asx:values></asx:abap>`
+	sources := map[string]string{
+		"/FUNCTIONS/GROUPS/ZFG": `<group name="ZFG"><functionModule name="Z_FG_RUN"/><functionModule name="Z_FG_NEW"/></group>`,
+		"/FUNCTIONS/GROUPS/ZFG/FMODULES/Z_FG_RUN/SOURCE/MAIN": fmRun,
+		"/FUNCTIONS/GROUPS/ZFG/FMODULES/Z_FG_NEW/SOURCE/MAIN": fmNew,
+		"/OO/CLASSES/ZCL_A/SOURCE/MAIN":                       classA,
+		"/OO/CLASSES/ZCL_A/INCLUDES/DEFINITIONS":              comment,
+	}
+	writes := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "discovery"):
+			w.Header().Set("X-CSRF-Token", "token")
+		case strings.HasSuffix(r.URL.Path, "/nodestructure"):
+			w.Write([]byte(nodes))
+		case r.Method != http.MethodGet:
+			writes++
+			http.Error(w, "unexpected write", http.StatusInternalServerError)
+		default:
+			if source, ok := sources[strings.TrimPrefix(strings.ToUpper(r.URL.Path), "/SAP/BC/ADT")]; ok {
+				w.Write([]byte(source))
+				return
+			}
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := adt.NewClient(server.URL, "u", "p")
+
+	// The function group main file can't be compared with the system and the
+	// comment-only include is absent there too: neither is pushed
+	result, err := Sync(client, dir, "$ZSYNC").Push().Execute(ctx)
+	if err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	status := make(map[string]SyncStatus)
+	for _, e := range result.Entries {
+		status[e.Key] = e.Status
+	}
+	expected := map[string]SyncStatus{
+		"FUNC:Z_FG_RUN": SyncUnchanged,
+		"FUNC:Z_FG_NEW": SyncRemoteAdded,
+		"CLAS:ZCL_A":    SyncUnchanged,
+	}
+	if len(status) != len(expected) {
+		t.Errorf("expected %d entries, got %v", len(expected), status)
+	}
+	for key, want := range expected {
+		if status[key] != want {
+			t.Errorf("%s: expected %s, got %s", key, want, status[key])
+		}
+	}
+	if result.Pushed != 0 || writes != 0 {
+		t.Errorf("expected nothing pushed, got %d pushed (%d writes)", result.Pushed, writes)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "zfg.fugr.abap") {
+		t.Errorf("expected a warning for the function group file, got %v", result.Warnings)
+	}
+
+	// Pull with delete writes the new function module and keeps the local files
+	for i := 0; i < 2; i++ {
+		result, err = Sync(client, dir, "$ZSYNC").Pull().DeleteLocal().Execute(ctx)
+		if err != nil {
+			t.Fatalf("Pull failed: %v", err)
+		}
+		if result.Deleted != 0 {
+			t.Errorf("run %d: expected no deleted files, got %+v", i+1, result.Entries)
+		}
+	}
+	for name := range files {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "zfg.fugr.z_fg_new.func.abap")); string(data) != fmNew {
+		t.Errorf("expected pulled function module, got %q", data)
+	}
+}
+
+func TestWatchProcess(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	paths := []string{
+		write("zcl_w.clas.testclasses.abap", "CLASS ltcl_w DEFINITION FOR TESTING.\nENDCLASS.\n"),
+		write("zcl_w.clas.abap", "CLASS zcl_w DEFINITION PUBLIC.\nENDCLASS.\nCLASS zcl_w IMPLEMENTATION.\nENDCLASS.\n"),
+		write("zif_w.intf.abap", "INTERFACE zif_w PUBLIC.\nENDINTERFACE.\n"),
+	}
+
+	var checkErrors bool
+	var writes []string
+	var activation string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "discovery"):
+			w.Header().Set("X-CSRF-Token", "token")
+		case r.URL.Query().Get("_action") == "LOCK":
+			w.Write([]byte(`<asx:abap><asx:values><DATA><LOCK_HANDLE>handle</LOCK_HANDLE></DATA></asx:values></asx:abap>`))
+		case r.Method == http.MethodPut:
+			writes = append(writes, r.URL.Path)
+		case strings.HasSuffix(r.URL.Path, "/checkruns"):
+			body, _ := io.ReadAll(r.Body)
+			if checkErrors && strings.Contains(string(body), "/includes/testclasses") {
+				w.Write([]byte(`<chkrun:checkRunReports xmlns:chkrun="http://www.sap.com/adt/checkrun"><chkrun:checkReport><chkrun:checkMessageList>
+<chkrun:checkMessage chkrun:uri="/sap/bc/adt/oo/classes/zcl_w/includes/testclasses#start=2,4" chkrun:type="E" chkrun:shortText="Statement is not defined"/>
+</chkrun:checkMessageList></chkrun:checkReport></chkrun:checkRunReports>`))
+				return
+			}
+			w.Write([]byte(`<chkrun:checkRunReports xmlns:chkrun="http://www.sap.com/adt/checkrun"/>`))
+		case strings.HasSuffix(r.URL.Path, "/activation"):
+			body, _ := io.ReadAll(r.Body)
+			activation = string(body)
+			w.Write([]byte(`<chkl:messages xmlns:chkl="http://www.sap.com/abapxml/checklist"/>`))
+		}
+	}))
+	defer server.Close()
+	client := adt.NewClient(server.URL, "u", "p")
+	watcher := Watch(client, dir, "$zwatch").Activate()
+
+	checkErrors = true
+	batch := watcher.Process(ctx, paths)
+	var order []string
+	for _, f := range batch.Files {
+		order = append(order, f.Path)
+		if f.Action != "written" {
+			t.Errorf("%s: expected written, got %s (%s)", f.Path, f.Action, f.Error)
+		}
+	}
+	if strings.Join(order, ",") != "zif_w.intf.abap,zcl_w.clas.abap,zcl_w.clas.testclasses.abap" {
+		t.Errorf("unexpected order: %v", order)
+	}
+	if len(writes) != 3 || !strings.Contains(writes[0], "/interfaces/") {
+		t.Errorf("expected interface to be written first, got %v", writes)
+	}
+	msgs := batch.Messages()
+	if len(msgs) != 1 || msgs[0].String() != "zcl_w.clas.testclasses.abap:2:4: error: Statement is not defined" {
+		t.Errorf("unexpected messages: %v", msgs)
+	}
+	if batch.Errors != 1 || batch.Activated || activation != "" {
+		t.Errorf("expected no activation after syntax errors, got %+v", batch)
+	}
+
+	// A clean batch activates the interface and class in one request
+	checkErrors = false
+	batch = watcher.Process(ctx, paths)
+	if batch.Errors != 0 || !batch.Activated {
+		t.Errorf("expected activated batch, got %+v", batch)
+	}
+	if strings.Count(activation, "<adtcore:objectReference ") != 2 {
+		t.Errorf("expected two objects in activation, got %s", activation)
+	}
+}
+
+func TestWatchObjectExistsCache(t *testing.T) {
+	ctx := context.Background()
+	fail := true
+	lookups := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "discovery"):
+			w.Header().Set("X-CSRF-Token", "token")
+		case strings.HasSuffix(r.URL.Path, "/source/main"):
+			lookups++
+			if fail {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte("INTERFACE zif_w PUBLIC.\nENDINTERFACE."))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	watcher := Watch(adt.NewClient(server.URL, "u", "p"), t.TempDir(), "$ZWATCH")
+	f := &WatchFileResult{ObjectType: "INTF", ObjectName: "ZIF_W"}
+
+	// A failed lookup is not cached
+	if !watcher.objectExists(ctx, f) {
+		t.Error("expected the object to be assumed to exist after a failed lookup")
+	}
+	fail = false
+	before := lookups
+	if !watcher.objectExists(ctx, f) || lookups == before {
+		t.Errorf("expected a new lookup after a failed one (%d lookups)", lookups)
+	}
+	// A confirmed result is
+	before = lookups
+	if !watcher.objectExists(ctx, f) || lookups != before {
+		t.Errorf("expected the confirmed result to be cached (%d lookups)", lookups)
+	}
+}
+
+func TestScanDirectoryDDIC(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"zcl_order.clas.abap":    "CLASS zcl_order DEFINITION PUBLIC.\nENDCLASS.\nCLASS zcl_order IMPLEMENTATION.\nENDCLASS.\n",
+		"zcl_order.clas.xml":     `<abapGit><asx:abap><asx:values><VSEOCLASS><CLSNAME>ZCL_ORDER</CLSNAME></VSEOCLASS></asx:values></asx:abap></abapGit>`,
+		"zorder.tabl.xml":        `<abapGit><asx:abap><asx:values><DD02V><TABNAME>ZORDER</TABNAME><TABCLASS>TRANSP</TABCLASS></DD02V></asx:values></asx:abap></abapGit>`,
+		"zorder_id.dtel.xml":     `<abapGit><asx:abap><asx:values><DD04V><ROLLNAME>ZORDER_ID</ROLLNAME></DD04V></asx:values></asx:abap></abapGit>`,
+		"zorder_status.doma.xml": `<abapGit><asx:abap><asx:values><DD01V><DOMNAME>ZORDER_STATUS</DOMNAME></DD01V></asx:values></asx:abap></abapGit>`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scanned, err := ScanDirectory(dir)
+	if err != nil {
+		t.Fatalf("ScanDirectory failed: %v", err)
+	}
+	sort.Slice(scanned, func(i, j int) bool { return scanned[i].Priority < scanned[j].Priority })
+	var order []string
+	for _, f := range scanned {
+		order = append(order, f.ObjectName)
+	}
+	if strings.Join(order, ",") != "ZORDER_STATUS,ZORDER_ID,ZORDER,ZCL_ORDER" {
+		t.Errorf("expected DDIC objects before the class, got %v", order)
+	}
+}
+
+func TestExportWritesAbapGitRepo(t *testing.T) {
+	intfXML := `<intf:abapInterface xmlns:intf="http://www.sap.com/adt/oo/interfaces" xmlns:adtcore="http://www.sap.com/adt/core"
+  adtcore:name="ZIF_ORDER" adtcore:description="Order API" adtcore:masterLanguage="EN"/>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "discovery"):
+			w.Header().Set("X-CSRF-Token", "token")
+		case strings.HasSuffix(r.URL.Path, "/source/main"):
+			w.Write([]byte("INTERFACE zif_order PUBLIC.\nENDINTERFACE."))
+		case strings.HasSuffix(r.URL.Path, "/oo/interfaces/ZIF_ORDER"):
+			w.Write([]byte(intfXML))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	result, err := Export(adt.NewClient(server.URL, "u", "p")).
+		Interfaces("ZIF_ORDER").
+		ToDirectory(dir).
+		Execute(context.Background())
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result.SuccessCount != 1 || result.Results[0].MetadataPath == "" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "zif_order.intf.xml"))
+	if err != nil {
+		t.Fatalf("metadata file not written: %v", err)
+	}
+	if !strings.Contains(string(content), "<DESCRIPT>Order API</DESCRIPT>") {
+		t.Errorf("unexpected metadata:\n%s", content)
+	}
+
+	repo, err := os.ReadFile(filepath.Join(dir, ".abapgit.xml"))
+	if err != nil {
+		t.Fatalf(".abapgit.xml not written: %v", err)
+	}
+	if !strings.Contains(string(repo), "<MASTER_LANGUAGE>E</MASTER_LANGUAGE>") ||
+		!strings.Contains(string(repo), "<STARTING_FOLDER>/</STARTING_FOLDER>") {
+		t.Errorf("unexpected .abapgit.xml:\n%s", repo)
+	}
+
+	// An existing .abapgit.xml is kept
+	if err := os.WriteFile(filepath.Join(dir, ".abapgit.xml"), []byte("custom"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	result, err = Export(adt.NewClient(server.URL, "u", "p")).
+		Interfaces("ZIF_ORDER").
+		ToDirectory(dir).
+		Execute(context.Background())
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result.RepoFile != "" {
+		t.Errorf("RepoFile = %q, want existing file kept", result.RepoFile)
+	}
+}
+
+func TestHistoryReplay(t *testing.T) {
+	const nodes = `<asx:abap xmlns:asx="http://www.sap.com/abapxml"><asx:values><DATA><TREE_CONTENT>
+<SEU_ADT_REPOSITORY_OBJ_NODE><OBJECT_TYPE>CLAS/OC</OBJECT_TYPE><OBJECT_NAME>ZCL_ORDER</OBJECT_NAME></SEU_ADT_REPOSITORY_OBJ_NODE>
+<SEU_ADT_REPOSITORY_OBJ_NODE><OBJECT_TYPE>PROG/I</OBJECT_TYPE><OBJECT_NAME>ZORDER_TOP</OBJECT_NAME></SEU_ADT_REPOSITORY_OBJ_NODE>
+<SEU_ADT_REPOSITORY_OBJ_NODE><OBJECT_TYPE>TABL/DT</OBJECT_TYPE><OBJECT_NAME>ZORDERS</OBJECT_NAME></SEU_ADT_REPOSITORY_OBJ_NODE>
+</TREE_CONTENT></DATA></asx:values></asx:abap>`
+	entry := func(id, author, date, transport, content string) string {
+		link := ""
+		if transport != "" {
+			link = `<atom:link rel="http://www.sap.com/adt/relations/transport/request" adtcore:name="` + transport + `" title="` + transport + ` title"/>`
+		}
+		return `<atom:entry><atom:id>` + id + `</atom:id><atom:author><atom:name>` + author + `</atom:name></atom:author>` +
+			`<atom:updated>` + date + `</atom:updated><atom:content src="` + content + `"/>` + link + `</atom:entry>`
+	}
+	feed := func(entries ...string) string {
+		return `<atom:feed xmlns:atom="http://www.w3.org/2005/Atom" xmlns:adtcore="http://www.sap.com/adt/core">` + strings.Join(entries, "") + `</atom:feed>`
+	}
+	request := func(number, owner, desc, status string) string {
+		return `<tm:root xmlns:tm="http://www.sap.com/cts/adt/tm"><tm:request tm:number="` + number + `" tm:owner="` + owner +
+			`" tm:desc="` + desc + `" tm:status="` + status + `"/></tm:root>`
+	}
+	classXML := `<class:abapClass xmlns:class="http://www.sap.com/adt/oo/classes" xmlns:adtcore="http://www.sap.com/adt/core"
+  adtcore:name="ZCL_ORDER" adtcore:description="Orders" adtcore:masterLanguage="DE"/>`
+
+	responses := map[string]string{
+		"/oo/classes/ZCL_ORDER/includes/main/versions": feed(
+			entry("00002", "JSMITH", "2026-04-10T09:30:00Z", "DEVK900123", "/content/main2"),
+			entry("00001", "JDOE", "2026-03-01T12:00:00Z", "DEVK900100", "/content/main1"),
+		),
+		"/oo/classes/ZCL_ORDER/includes/testclasses/versions": feed(
+			entry("00001", "JSMITH", "2026-04-10T09:00:00Z", "DEVK900123", "/content/test1"),
+		),
+		"/programs/includes/ZORDER_TOP/source/main/versions": feed(
+			entry("00002", "JDOE", "2026-05-01T08:00:00Z", "DEVK900200", "/content/top2"),
+			entry("00001", "JDOE", "2026-02-01T08:00:00Z", "", "/content/top1"),
+		),
+		"/content/main1":                    "CLASS zcl_order DEFINITION PUBLIC.\nENDCLASS.\n",
+		"/content/main2":                    "CLASS zcl_order DEFINITION PUBLIC FINAL.\nENDCLASS.\n",
+		"/content/test1":                    "CLASS ltc_order DEFINITION FOR TESTING.\nENDCLASS.\n",
+		"/cts/transportrequests/DEVK900100": request("DEVK900100", "JDOE", "Create orders", "R"),
+		"/cts/transportrequests/DEVK900123": request("DEVK900123", "JSMITH", "Order totals", "R"),
+		"/cts/transportrequests/DEVK900200": request("DEVK900200", "JDOE", "Order types", "L"),
+		"/oo/classes/ZCL_ORDER":             classXML,
+		"/repository/nodestructure":         nodes,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "discovery") {
+			w.Header().Set("X-CSRF-Token", "token")
+			return
+		}
+		if body, ok := responses[strings.TrimPrefix(r.URL.Path, "/sap/bc/adt")]; ok {
+			w.Write([]byte(body))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	var commits []string
+	result, err := History(adt.NewClient(server.URL, "u", "p", adt.WithEnableTransports()), "$ZORDERS").
+		Replay(context.Background(), dir, func(tr HistoryTransport, paths []string) error {
+			main, _ := os.ReadFile(filepath.Join(dir, "src", "zcl_order.clas.abap"))
+			_, testErr := os.Stat(filepath.Join(dir, "src", "zcl_order.clas.testclasses.abap"))
+			commits = append(commits, fmt.Sprintf("%s %s %s final=%v tests=%v %s",
+				tr.Number, tr.Owner, tr.Description, strings.Contains(string(main), "FINAL"), testErr == nil, strings.Join(paths, ",")))
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+
+	want := []string{
+		"DEVK900100 JDOE Create orders final=false tests=false src/zcl_order.clas.abap,src/zcl_order.clas.xml,.abapgit.xml",
+		"DEVK900123 JSMITH Order totals final=true tests=true src/zcl_order.clas.abap,src/zcl_order.clas.testclasses.abap",
+	}
+	if strings.Join(commits, "\n") != strings.Join(want, "\n") {
+		t.Errorf("commits:\n%s\nwant:\n%s", strings.Join(commits, "\n"), strings.Join(want, "\n"))
+	}
+	if result.Objects != 2 || result.Skipped != 2 {
+		t.Errorf("Objects=%d Skipped=%d, want 2 and 2 (no transport, unreleased transport)", result.Objects, result.Skipped)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "src", "zorder_top.prog.abap")); !os.IsNotExist(err) {
+		t.Error("include from a modifiable transport should not be written")
+	}
+
+	meta, err := os.ReadFile(filepath.Join(dir, "src", "zcl_order.clas.xml"))
+	if err != nil || !strings.Contains(string(meta), "<DESCRIPT>Orders</DESCRIPT>") {
+		t.Errorf("class metadata: %v\n%s", err, meta)
+	}
+	repo, err := os.ReadFile(filepath.Join(dir, ".abapgit.xml"))
+	if err != nil || !strings.Contains(string(repo), "<MASTER_LANGUAGE>D</MASTER_LANGUAGE>") ||
+		!strings.Contains(string(repo), "<STARTING_FOLDER>/src/</STARTING_FOLDER>") {
+		t.Errorf(".abapgit.xml: %v\n%s", err, repo)
+	}
+
+	// A transport that can't be read stops the history
+	delete(responses, "/cts/transportrequests/DEVK900123")
+	_, err = History(adt.NewClient(server.URL, "u", "p", adt.WithEnableTransports()), "$ZORDERS").Collect(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "DEVK900123") {
+		t.Errorf("Collect error = %v, want transport DEVK900123 lookup error", err)
+	}
+}
+
+func writeImportFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestImportGroups(t *testing.T) {
+	dir := writeImportFiles(t, map[string]string{
+		"zif_a.intf.abap":             "INTERFACE zif_a PUBLIC.\n  METHODS create RETURNING VALUE(ro_factory) TYPE REF TO zcl_factory.\nENDINTERFACE.\n",
+		"zcl_factory.clas.abap":       "CLASS zcl_factory DEFINITION PUBLIC.\nENDCLASS.\nCLASS zcl_factory IMPLEMENTATION.\nENDCLASS.\n",
+		"zcl_b.clas.abap":             "CLASS zcl_b DEFINITION PUBLIC INHERITING FROM zcl_c.\n  PUBLIC SECTION.\n    INTERFACES: zif_a.\n    DATA mo_c TYPE REF TO zcl_c.\nENDCLASS.\nCLASS zcl_b IMPLEMENTATION.\nENDCLASS.\n",
+		"zcl_b.clas.testclasses.abap": "CLASS ltcl_b DEFINITION FOR TESTING.\nENDCLASS.\n",
+		"zcl_c.clas.abap":             "CLASS zcl_c DEFINITION PUBLIC.\n  PUBLIC SECTION.\n    DATA mo_b TYPE REF TO zcl_b. \" TYPE REF TO zcl_factory\n* INTERFACES zif_a.\nENDCLASS.\nCLASS zcl_c IMPLEMENTATION.\nENDCLASS.\n",
+		"zi_base.ddls.asddls":         "define view entity ZI_Base as select from ztravel { key travel_id }\n",
+		"zi_travel.ddls.asddls":       "// select from ZI_Other\ndefine root view entity ZI_Travel as select from ZI_Base { key travel_id }\n",
+		"zi_travel.bdef.asbdef":       "managed implementation in class zbp_i_travel unique;\ndefine behavior for ZI_Travel\n{\n}\n",
+	})
+	builder, err := Import(nil).FromDirectory(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var order []string
+	var cycles []string
+	dependsOn := make(map[string]string)
+	for _, g := range builder.Groups() {
+		var names []string
+		for _, f := range g.Files {
+			names = append(names, filepath.Base(f.Path))
+			dependsOn[filepath.Base(f.Path)] = strings.Join(f.DependsOn, ",")
+		}
+		order = append(order, strings.Join(names, "+"))
+		if g.Cycle {
+			cycles = append(cycles, strings.Join(names, "+"))
+		}
+	}
+
+	want := []string{
+		"zcl_factory.clas.abap",
+		"zif_a.intf.abap",
+		"zcl_b.clas.abap+zcl_c.clas.abap",
+		"zcl_b.clas.testclasses.abap",
+		"zi_base.ddls.asddls",
+		"zi_travel.ddls.asddls",
+		"zi_travel.bdef.asbdef",
+	}
+	if strings.Join(order, " ") != strings.Join(want, " ") {
+		t.Errorf("order:\n%s\nwant:\n%s", strings.Join(order, "\n"), strings.Join(want, "\n"))
+	}
+	if len(cycles) != 1 || cycles[0] != "zcl_b.clas.abap+zcl_c.clas.abap" {
+		t.Errorf("cycles = %v", cycles)
+	}
+	for file, deps := range map[string]string{
+		"zcl_b.clas.abap":             "ZIF_A,ZCL_C",
+		"zcl_c.clas.abap":             "ZCL_B",
+		"zcl_b.clas.testclasses.abap": "",
+		"zi_travel.ddls.asddls":       "ZI_BASE",
+		"zi_travel.bdef.asbdef":       "ZI_TRAVEL",
+	} {
+		if dependsOn[file] != deps {
+			t.Errorf("%s depends on %q, want %q", file, dependsOn[file], deps)
+		}
+	}
+}
+
+func TestImportCycleActivatesTogether(t *testing.T) {
+	dir := writeImportFiles(t, map[string]string{
+		"zcl_b.clas.abap": "CLASS zcl_b DEFINITION PUBLIC.\n  PUBLIC SECTION.\n    DATA mo_c TYPE REF TO zcl_c.\nENDCLASS.\nCLASS zcl_b IMPLEMENTATION.\nENDCLASS.\n",
+		"zcl_c.clas.abap": "CLASS zcl_c DEFINITION PUBLIC.\n  PUBLIC SECTION.\n    DATA mo_b TYPE REF TO zcl_b.\nENDCLASS.\nCLASS zcl_c IMPLEMENTATION.\nENDCLASS.\n",
+	})
+
+	var writes []string
+	var activation string
+	var created, checked bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "discovery"):
+			w.Header().Set("X-CSRF-Token", "token")
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/zcl_b"):
+			http.NotFound(w, r) // New class
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/oo/classes"):
+			created = true
+		case r.URL.Query().Get("_action") == "LOCK":
+			w.Write([]byte(`<asx:abap><asx:values><DATA><LOCK_HANDLE>handle</LOCK_HANDLE></DATA></asx:values></asx:abap>`))
+		case r.Method == http.MethodPut:
+			writes = append(writes, r.URL.Path)
+		case strings.HasSuffix(r.URL.Path, "/checkruns"):
+			checked = true
+		case strings.HasSuffix(r.URL.Path, "/activation"):
+			body, _ := io.ReadAll(r.Body)
+			activation = string(body)
+			w.Write([]byte(`<chkl:messages xmlns:chkl="http://www.sap.com/abapxml/checklist"/>`))
+		}
+	}))
+	defer server.Close()
+
+	builder, err := Import(adt.NewClient(server.URL, "u", "p")).FromDirectory(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := builder.ToPackage("$ZORDERS").Execute(context.Background())
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if result.SuccessCount != 2 || result.FailureCount != 0 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if len(result.Cycles) != 1 || strings.Join(result.Cycles[0], ",") != "ZCL_B,ZCL_C" {
+		t.Errorf("cycles = %v", result.Cycles)
+	}
+	if !created || !result.Results[0].Created || result.Results[1].Created {
+		t.Errorf("expected ZCL_B to be created and ZCL_C updated: %+v", result.Results)
+	}
+	if len(writes) != 2 || checked {
+		t.Errorf("expected two inactive writes without syntax check, got %v (checked=%v)", writes, checked)
+	}
+	if strings.Count(activation, "<adtcore:objectReference ") != 2 {
+		t.Errorf("expected both classes in one activation, got %s", activation)
+	}
+}
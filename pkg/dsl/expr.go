@@ -0,0 +1,949 @@
+package dsl
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Workflow expressions are used by step conditions, fail_if and foreach:
+//
+//	tests.failedTests > 0 && env == "DEV"
+//	steps["syntax-fi"].status == "failed" || !all(syntaxResults, "success")
+//	len(objects) > 0 && startsWith(objects[0].name, "ZCL_")
+//
+// Operators: || && ! == != < <= > >= + - * / % and parentheses.
+// Literals: numbers, "strings" or 'strings', true, false, nil, [lists].
+// Values are read with a.b, a["b"] and a[0]. Unknown names and missing
+// map keys evaluate to nil; unknown struct fields are errors.
+// ${NAME} reads a workflow or environment variable (resolved through
+// Lookup("${NAME}")), also inside string literals. Its value is never parsed
+// as expression code; a string that is a number is used as a number.
+
+// ExprEnv resolves names used in expressions.
+type ExprEnv interface {
+	Lookup(name string) (interface{}, bool)
+}
+
+// scopeEnv adds local names (like foreach's item) on top of an environment.
+type scopeEnv struct {
+	parent ExprEnv
+	names  map[string]interface{}
+}
+
+func (s *scopeEnv) Lookup(name string) (interface{}, bool) {
+	if v, ok := s.names[name]; ok {
+		return v, true
+	}
+	return s.parent.Lookup(name)
+}
+
+// Expression is a compiled workflow expression.
+type Expression struct {
+	src  string
+	root exprNode
+}
+
+// CompileExpression parses an expression, reporting syntax errors and
+// unknown functions.
+func CompileExpression(src string) (*Expression, error) {
+	tokens, err := lexExpression(src)
+	if err != nil {
+		return nil, fmt.Errorf("expression %q: %w", src, err)
+	}
+	p := &exprParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err == nil && p.peek().kind != tokEOF {
+		err = fmt.Errorf("unexpected %q at offset %d", p.peek().text, p.peek().pos)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("expression %q: %w", src, err)
+	}
+	return &Expression{src: src, root: root}, nil
+}
+
+// String returns the source of the expression.
+func (x *Expression) String() string {
+	return x.src
+}
+
+// Eval evaluates the expression.
+func (x *Expression) Eval(env ExprEnv) (interface{}, error) {
+	v, err := x.root.eval(env)
+	if err != nil {
+		return nil, fmt.Errorf("evaluating %q: %w", x.src, err)
+	}
+	return v, nil
+}
+
+// EvalBool evaluates the expression and converts the result to a boolean
+// (nil, false, 0, "" and empty collections are false).
+func (x *Expression) EvalBool(env ExprEnv) (bool, error) {
+	v, err := x.Eval(env)
+	if err != nil {
+		return false, err
+	}
+	return truthy(v), nil
+}
+
+// --- Lexer ---
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+var exprOperators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "+", "-", "*", "/", "%", "(", ")", "[", "]", ".", ","}
+
+func lexExpression(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c):
+			start := i
+			for i < len(src) && (unicode.IsDigit(rune(src[i])) || src[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokNumber, src[start:i], start})
+		case c == '_' || unicode.IsLetter(c):
+			start := i
+			for i < len(src) && (src[i] == '_' || unicode.IsLetter(rune(src[i])) || unicode.IsDigit(rune(src[i]))) {
+				i++
+			}
+			tokens = append(tokens, token{tokIdent, src[start:i], start})
+		case c == '"' || c == '\'':
+			start := i
+			var sb strings.Builder
+			i++
+			for ; i < len(src) && rune(src[i]) != c; i++ {
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+				}
+				sb.WriteByte(src[i])
+			}
+			if i >= len(src) {
+				return nil, fmt.Errorf("unterminated string at offset %d", start)
+			}
+			i++
+			tokens = append(tokens, token{tokString, sb.String(), start})
+		case c == '$' && strings.HasPrefix(src[i:], "${"):
+			ref := templateRef.FindString(src[i:])
+			if ref == "" || !strings.HasPrefix(src[i:], ref) {
+				return nil, fmt.Errorf("invalid variable reference at offset %d", i)
+			}
+			tokens = append(tokens, token{tokIdent, ref, i})
+			i += len(ref)
+		default:
+			matched := false
+			for _, op := range exprOperators {
+				if strings.HasPrefix(src[i:], op) {
+					tokens = append(tokens, token{tokOp, op, i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+			}
+		}
+	}
+	return append(tokens, token{tokEOF, "end of expression", len(src)}), nil
+}
+
+// --- Parser ---
+
+type exprParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *exprParser) peek() token { return p.tokens[p.pos] }
+
+func (p *exprParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *exprParser) acceptOp(ops ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokOp {
+		return "", false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *exprParser) expectOp(op string) error {
+	if _, ok := p.acceptOp(op); !ok {
+		return fmt.Errorf("expected %q at offset %d, got %q", op, p.peek().pos, p.peek().text)
+	}
+	return nil
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.acceptOp("||"); !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: "||", left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.acceptOp("&&"); !ok {
+			return left, nil
+		}
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: "&&", left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseComparison() (exprNode, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	if op, ok := p.acceptOp("==", "!=", "<=", ">=", "<", ">"); ok {
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryNode{op: op, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAdditive() (exprNode, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.acceptOp("+", "-")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseMultiplicative() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.acceptOp("*", "/", "%")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if op, ok := p.acceptOp("!", "-"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: op, operand: operand}, nil
+	}
+	return p.parsePostfix()
+}
+
+func (p *exprParser) parsePostfix() (exprNode, error) {
+	node, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.acceptOp("."); ok {
+			t := p.next()
+			if t.kind != tokIdent {
+				return nil, fmt.Errorf("expected field name after '.' at offset %d", t.pos)
+			}
+			node = &indexNode{target: node, index: &literalNode{value: t.text}}
+			continue
+		}
+		if _, ok := p.acceptOp("["); ok {
+			index, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectOp("]"); err != nil {
+				return nil, err
+			}
+			node = &indexNode{target: node, index: index}
+			continue
+		}
+		return node, nil
+	}
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at offset %d", t.text, t.pos)
+		}
+		return &literalNode{value: f}, nil
+	case tokString:
+		if templateRef.MatchString(t.text) {
+			return &templateNode{text: t.text}, nil
+		}
+		return &literalNode{value: t.text}, nil
+	case tokIdent:
+		if strings.HasPrefix(t.text, "${") {
+			return &varRefNode{ref: t.text}, nil
+		}
+		switch t.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "nil", "null":
+			return &literalNode{value: nil}, nil
+		}
+		if _, ok := p.acceptOp("("); ok {
+			return p.parseCall(t)
+		}
+		return &nameNode{name: t.text}, nil
+	case tokOp:
+		switch t.text {
+		case "(":
+			node, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return node, p.expectOp(")")
+		case "[":
+			list := &listNode{}
+			if _, ok := p.acceptOp("]"); ok {
+				return list, nil
+			}
+			for {
+				item, err := p.parseOr()
+				if err != nil {
+					return nil, err
+				}
+				list.items = append(list.items, item)
+				if _, ok := p.acceptOp(","); !ok {
+					return list, p.expectOp("]")
+				}
+			}
+		}
+	}
+	return nil, fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+}
+
+func (p *exprParser) parseCall(name token) (exprNode, error) {
+	fn, ok := exprFunctions[name.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q at offset %d", name.text, name.pos)
+	}
+	call := &callNode{name: name.text, fn: fn}
+	if _, ok := p.acceptOp(")"); !ok {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+			if _, ok := p.acceptOp(","); !ok {
+				break
+			}
+		}
+		if err := p.expectOp(")"); err != nil {
+			return nil, err
+		}
+	}
+	if len(call.args) < fn.minArgs || (fn.maxArgs >= 0 && len(call.args) > fn.maxArgs) {
+		return nil, fmt.Errorf("%s() takes %s, got %d", name.text, fn.arity(), len(call.args))
+	}
+	return call, nil
+}
+
+// --- Evaluation ---
+
+type exprNode interface {
+	eval(env ExprEnv) (interface{}, error)
+}
+
+type literalNode struct{ value interface{} }
+
+func (n *literalNode) eval(ExprEnv) (interface{}, error) { return n.value, nil }
+
+type nameNode struct{ name string }
+
+func (n *nameNode) eval(env ExprEnv) (interface{}, error) {
+	v, _ := env.Lookup(n.name)
+	return v, nil
+}
+
+// varRefNode is a ${NAME} reference outside a string literal.
+type varRefNode struct{ ref string }
+
+func (n *varRefNode) eval(env ExprEnv) (interface{}, error) {
+	v, _ := env.Lookup(n.ref)
+	if s, ok := v.(string); ok {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+			return f, nil
+		}
+	}
+	return v, nil
+}
+
+// templateNode is a string literal containing ${NAME} references.
+type templateNode struct{ text string }
+
+func (n *templateNode) eval(env ExprEnv) (interface{}, error) {
+	return templateRef.ReplaceAllStringFunc(n.text, func(ref string) string {
+		v, _ := env.Lookup(ref)
+		return toString(v)
+	}), nil
+}
+
+type listNode struct{ items []exprNode }
+
+func (n *listNode) eval(env ExprEnv) (interface{}, error) {
+	list := make([]interface{}, 0, len(n.items))
+	for _, item := range n.items {
+		v, err := item.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}
+
+type logicalNode struct {
+	op          string
+	left, right exprNode
+}
+
+func (n *logicalNode) eval(env ExprEnv) (interface{}, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "&&" && !truthy(left) {
+		return false, nil
+	}
+	if n.op == "||" && truthy(left) {
+		return true, nil
+	}
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	return truthy(right), nil
+}
+
+type unaryNode struct {
+	op      string
+	operand exprNode
+}
+
+func (n *unaryNode) eval(env ExprEnv) (interface{}, error) {
+	v, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		return !truthy(v), nil
+	}
+	f, ok := toNumber(v)
+	if !ok {
+		return nil, fmt.Errorf("cannot negate %s", describe(v))
+	}
+	return -f, nil
+}
+
+type binaryNode struct {
+	op          string
+	left, right exprNode
+}
+
+func (n *binaryNode) eval(env ExprEnv) (interface{}, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return valuesEqual(left, right), nil
+	case "!=":
+		return !valuesEqual(left, right), nil
+	case "<", "<=", ">", ">=":
+		cmp, err := compareValues(left, right)
+		if err != nil {
+			return nil, err
+		}
+		switch n.op {
+		case "<":
+			return cmp < 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">":
+			return cmp > 0, nil
+		default:
+			return cmp >= 0, nil
+		}
+	}
+
+	if n.op == "+" {
+		_, ls := left.(string)
+		_, rs := right.(string)
+		if ls || rs {
+			return toString(left) + toString(right), nil
+		}
+	}
+	l, lok := toNumber(left)
+	r, rok := toNumber(right)
+	if !lok || !rok {
+		return nil, fmt.Errorf("operator %s needs numbers, got %s and %s", n.op, describe(left), describe(right))
+	}
+	switch n.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return l / r, nil
+	default:
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return math.Mod(l, r), nil
+	}
+}
+
+type indexNode struct {
+	target, index exprNode
+}
+
+func (n *indexNode) eval(env ExprEnv) (interface{}, error) {
+	target, err := n.target.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	index, err := n.index.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	return indexValue(target, index)
+}
+
+type callNode struct {
+	name string
+	fn   exprFunction
+	args []exprNode
+}
+
+func (n *callNode) eval(env ExprEnv) (interface{}, error) {
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	v, err := n.fn.call(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %w", n.name, err)
+	}
+	return v, nil
+}
+
+// --- Functions ---
+
+type exprFunction struct {
+	minArgs, maxArgs int // maxArgs -1 = variadic
+	call             func(args []interface{}) (interface{}, error)
+}
+
+func (f exprFunction) arity() string {
+	switch {
+	case f.minArgs == f.maxArgs:
+		return fmt.Sprintf("%d argument(s)", f.minArgs)
+	case f.maxArgs < 0:
+		return fmt.Sprintf("at least %d argument(s)", f.minArgs)
+	default:
+		return fmt.Sprintf("%d to %d arguments", f.minArgs, f.maxArgs)
+	}
+}
+
+func stringFunc(fn func(s string, args []string) interface{}, n int) exprFunction {
+	return exprFunction{minArgs: n, maxArgs: n, call: func(args []interface{}) (interface{}, error) {
+		strs := make([]string, len(args))
+		for i, a := range args {
+			strs[i] = toString(a)
+		}
+		return fn(strs[0], strs[1:]), nil
+	}}
+}
+
+var exprFunctions = map[string]exprFunction{
+	"len": {1, 1, func(args []interface{}) (interface{}, error) {
+		return float64(length(args[0])), nil
+	}},
+	"exists": {1, 1, func(args []interface{}) (interface{}, error) {
+		return args[0] != nil, nil
+	}},
+	"empty": {1, 1, func(args []interface{}) (interface{}, error) {
+		return length(args[0]) == 0, nil
+	}},
+	"contains": {2, 2, func(args []interface{}) (interface{}, error) {
+		if s, ok := args[0].(string); ok {
+			return strings.Contains(s, toString(args[1])), nil
+		}
+		for _, item := range toList(args[0]) {
+			if valuesEqual(item, args[1]) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}},
+	"startsWith": stringFunc(func(s string, a []string) interface{} { return strings.HasPrefix(s, a[0]) }, 2),
+	"endsWith":   stringFunc(func(s string, a []string) interface{} { return strings.HasSuffix(s, a[0]) }, 2),
+	"upper":      stringFunc(func(s string, a []string) interface{} { return strings.ToUpper(s) }, 1),
+	"lower":      stringFunc(func(s string, a []string) interface{} { return strings.ToLower(s) }, 1),
+	"trim":       stringFunc(func(s string, a []string) interface{} { return strings.TrimSpace(s) }, 1),
+	"string":     stringFunc(func(s string, a []string) interface{} { return s }, 1),
+	"matches": {2, 2, func(args []interface{}) (interface{}, error) {
+		re, err := regexp.Compile(toString(args[1]))
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString(toString(args[0])), nil
+	}},
+	"number": {1, 1, func(args []interface{}) (interface{}, error) {
+		if f, ok := toNumber(args[0]); ok {
+			return f, nil
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(toString(args[0])), 64)
+		if err != nil {
+			return nil, fmt.Errorf("not a number: %q", toString(args[0]))
+		}
+		return f, nil
+	}},
+	"join": {2, 2, func(args []interface{}) (interface{}, error) {
+		var parts []string
+		for _, item := range toList(args[0]) {
+			parts = append(parts, toString(item))
+		}
+		return strings.Join(parts, toString(args[1])), nil
+	}},
+	// any(list, "field") / all(list, "field") test a field of every item;
+	// without a field the items themselves are tested.
+	"any": {1, 2, func(args []interface{}) (interface{}, error) {
+		return matchItems(args, false)
+	}},
+	"all": {1, 2, func(args []interface{}) (interface{}, error) {
+		return matchItems(args, true)
+	}},
+	"count": {1, 2, func(args []interface{}) (interface{}, error) {
+		n := 0
+		for _, item := range toList(args[0]) {
+			if len(args) == 2 {
+				v, err := indexValue(item, args[1])
+				if err != nil {
+					return nil, err
+				}
+				item = v
+			}
+			if truthy(item) {
+				n++
+			}
+		}
+		return float64(n), nil
+	}},
+}
+
+func matchItems(args []interface{}, all bool) (interface{}, error) {
+	for _, item := range toList(args[0]) {
+		if len(args) == 2 {
+			v, err := indexValue(item, args[1])
+			if err != nil {
+				return nil, err
+			}
+			item = v
+		}
+		if truthy(item) != all {
+			return !all, nil
+		}
+	}
+	return all, nil
+}
+
+// --- Values ---
+
+func truthy(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return val
+	case string:
+		return val != ""
+	}
+	if f, ok := toNumber(v); ok {
+		return f != 0
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len() > 0
+	case reflect.Ptr, reflect.Interface:
+		return !rv.IsNil()
+	}
+	return true
+}
+
+func toNumber(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+func toString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func toList(v interface{}) []interface{} {
+	if list, ok := v.([]interface{}); ok {
+		return list
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil
+	}
+	list := make([]interface{}, rv.Len())
+	for i := range list {
+		list[i] = rv.Index(i).Interface()
+	}
+	return list
+}
+
+func length(v interface{}) int {
+	if v == nil {
+		return 0
+	}
+	if s, ok := v.(string); ok {
+		return len(s)
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len()
+	}
+	return 1
+}
+
+func valuesEqual(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if fa, ok := toNumber(a); ok {
+		fb, ok := toNumber(b)
+		return ok && fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func compareValues(a, b interface{}) (int, error) {
+	if fa, ok := toNumber(a); ok {
+		if fb, ok := toNumber(b); ok {
+			switch {
+			case fa < fb:
+				return -1, nil
+			case fa > fb:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	sa, aok := a.(string)
+	sb, bok := b.(string)
+	if aok && bok {
+		return strings.Compare(sa, sb), nil
+	}
+	return 0, fmt.Errorf("cannot compare %s and %s", describe(a), describe(b))
+}
+
+// indexValue reads a map key, struct field (by JSON name, field name or
+// expr alias, case-insensitive) or list element.
+func indexValue(target, index interface{}) (interface{}, error) {
+	if target == nil {
+		return nil, nil
+	}
+	rv := reflect.ValueOf(target)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		f, ok := toNumber(index)
+		if !ok {
+			return nil, fmt.Errorf("list index must be a number, got %s", describe(index))
+		}
+		i := int(f)
+		if i < 0 {
+			i += rv.Len()
+		}
+		if i < 0 || i >= rv.Len() {
+			return nil, nil
+		}
+		return rv.Index(i).Interface(), nil
+
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("cannot index %s", describe(target))
+		}
+		key := toString(index)
+		if v := rv.MapIndex(reflect.ValueOf(key).Convert(rv.Type().Key())); v.IsValid() {
+			return v.Interface(), nil
+		}
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, k := range keys {
+			if strings.EqualFold(k.String(), key) {
+				return rv.MapIndex(k).Interface(), nil
+			}
+		}
+		return nil, nil
+
+	case reflect.Struct:
+		name := toString(index)
+		t := rv.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+			if strings.EqualFold(field.Name, name) || (jsonName != "" && strings.EqualFold(jsonName, name)) || hasExprAlias(field, name) {
+				return rv.Field(i).Interface(), nil
+			}
+		}
+		return nil, fmt.Errorf("%s has no field %q", t.Name(), name)
+	}
+	return nil, fmt.Errorf("cannot read %q of %s", toString(index), describe(target))
+}
+
+// hasExprAlias reports whether name is one of the field's expression aliases,
+// given as a comma-separated `expr` tag (e.g. `expr:"failed"` on FailedTests).
+func hasExprAlias(field reflect.StructField, name string) bool {
+	for _, alias := range strings.Split(field.Tag.Get("expr"), ",") {
+		if alias != "" && strings.EqualFold(alias, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func describe(v interface{}) string {
+	if v == nil {
+		return "nil"
+	}
+	switch v.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	}
+	if _, ok := toNumber(v); ok {
+		return "number"
+	}
+	if data, err := json.Marshal(v); err == nil && len(data) <= 40 {
+		return fmt.Sprintf("%T %s", v, data)
+	}
+	return fmt.Sprintf("%T", v)
+}
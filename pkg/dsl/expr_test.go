@@ -0,0 +1,245 @@
+package dsl
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type mapEnv map[string]interface{}
+
+func (m mapEnv) Lookup(name string) (interface{}, bool) {
+	v, ok := m[name]
+	return v, ok
+}
+
+func TestExpressionEval(t *testing.T) {
+	env := mapEnv{
+		"env":   "DEV",
+		"tests": &TestSummary{TotalTests: 10, FailedTests: 2},
+		"objects": []ObjectRef{
+			{Type: "CLAS", Name: "ZCL_ORDER", Package: "$ZSD"},
+			{Type: "PROG", Name: "ZREPORT", Package: "$ZSD"},
+		},
+		"syntax": []map[string]interface{}{
+			{"object": "ZCL_ORDER", "success": true},
+			{"object": "ZREPORT", "success": false},
+		},
+		"steps": map[string]interface{}{
+			"syntax-fi": map[string]interface{}{"status": "failed", "success": false},
+		},
+	}
+
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		{`tests.failed > 0 && env == "DEV"`, true},
+		{`tests.failedTests > 0 && env == "DEV"`, true},
+		{`tests.passed + tests.failed + tests.skipped`, 2.0},
+		{`tests.FailedTests * 10 / tests.totalTests`, 2.0},
+		{`env != 'DEV' || len(objects) == 2`, true},
+		{`objects[0].name`, "ZCL_ORDER"},
+		{`objects[-1].type == "PROG"`, true},
+		{`objects[5]`, nil},
+		{`startsWith(objects[1].name, "Z") && !endsWith(objects[1].name, "X")`, true},
+		{`upper(lower("AbC")) + "-" + 1`, "ABC-1"},
+		{`contains(["DEV", "QAS"], env)`, true},
+		{`contains(objects[0].name, "ORDER")`, true},
+		{`matches(objects[0].name, "^ZCL_")`, true},
+		{`steps["syntax-fi"].status == "failed"`, true},
+		{`steps.unknown.status`, nil},
+		{`!all(syntax, "success") && any(syntax, "success")`, true},
+		{`count(syntax, "success")`, 1.0},
+		{`exists(missing) || empty(missing)`, true},
+		{`number("42") + 1 >= 43`, true},
+		{`join(["a", "b"], ",")`, "a,b"},
+		{`(1 + 2) * 3 % 4`, 1.0},
+		{`-tests.failedTests < 0`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			x, err := CompileExpression(tt.expr)
+			if err != nil {
+				t.Fatalf("CompileExpression failed: %v", err)
+			}
+			got, err := x.Eval(env)
+			if err != nil {
+				t.Fatalf("Eval failed: %v", err)
+			}
+			if !valuesEqual(got, tt.want) {
+				t.Errorf("got %v (%T), want %v", got, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpressionErrors(t *testing.T) {
+	compileErrors := map[string]string{
+		`tests.failed >`:     "unexpected",
+		`a && (b || c`:       `expected ")"`,
+		`"unterminated`:      "unterminated string",
+		`size(objects) > 0`:  `unknown function "size"`,
+		`contains("a")`:      "contains() takes 2 argument(s), got 1",
+		`a # b`:              "unexpected character",
+		`objects.`:           "expected field name",
+		`len(objects) > 0 0`: "unexpected",
+	}
+	for expr, want := range compileErrors {
+		if _, err := CompileExpression(expr); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("CompileExpression(%q): expected error containing %q, got %v", expr, want, err)
+		}
+	}
+
+	env := mapEnv{"tests": &TestSummary{}}
+	runtimeErrors := map[string]string{
+		`tests.failures > 0`: `TestSummary has no field "failures"`,
+		`"a" < 1`:            "cannot compare",
+		`1 / 0`:              "division by zero",
+	}
+	for expr, want := range runtimeErrors {
+		x, err := CompileExpression(expr)
+		if err != nil {
+			t.Fatalf("CompileExpression(%q) failed: %v", expr, err)
+		}
+		if _, err := x.Eval(env); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Eval(%q): expected error containing %q, got %v", expr, want, err)
+		}
+	}
+}
+
+func TestWorkflowExpressions(t *testing.T) {
+	t.Run("ConditionsAndStepStatus", func(t *testing.T) {
+		engine := NewWorkflowEngine(nil)
+		engine.RegisterHandler("mock_test", func(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+			return &TestSummary{TotalTests: 4, FailedTests: 1}, nil
+		})
+		var ran []string
+		engine.RegisterHandler("record", func(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+			ran = append(ran, params["id"].(string))
+			return nil, nil
+		})
+
+		workflow, err := engine.ParseWorkflow([]byte(`
+name: expressions
+variables:
+  env: DEV
+steps:
+  - id: tests
+    action: mock_test
+    saveAs: tests
+  - action: record
+    parameters: {id: notify}
+    condition: tests.failedTests > 0 && env == "DEV"
+  - action: record
+    parameters: {id: release}
+    condition: steps.tests.status == "success" && tests.failedTests == 0
+  - action: record
+    parameters: {id: legacy}
+    condition: "exists:tests"
+`))
+		if err != nil {
+			t.Fatalf("ParseWorkflow failed: %v", err)
+		}
+
+		result, _ := engine.Execute(context.Background(), workflow)
+		if !result.Success {
+			t.Fatalf("expected success, got: %s", result.Error)
+		}
+		if got := strings.Join(ran, ","); got != "notify,legacy" {
+			t.Errorf("expected notify,legacy to run, got %s", got)
+		}
+	})
+
+	t.Run("FailIfAndForeach", func(t *testing.T) {
+		engine := NewWorkflowEngine(nil)
+		engine.RegisterHandler("mock_search", func(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+			return []ObjectRef{{Type: "CLAS", Name: "ZCL_A"}, {Type: "PROG", Name: "ZPROG"}, {Type: "CLAS", Name: "ZCL_B"}}, nil
+		})
+
+		workflow, err := engine.ParseWorkflow([]byte(`
+name: filter
+steps:
+  - action: mock_search
+    saveAs: objects
+  - action: foreach
+    parameters:
+      collection: objects
+      where: item.type == "CLAS"
+    saveAs: classes
+  - name: gate
+    action: fail_if
+    parameters:
+      condition: len(classes) != ${EXPECTED}
+      message: unexpected class count
+`))
+		if err != nil {
+			t.Fatalf("ParseWorkflow failed: %v", err)
+		}
+
+		result, _ := engine.Execute(context.Background(), workflow, WithVariables(map[string]string{"EXPECTED": "2"}))
+		if !result.Success {
+			t.Fatalf("expected success, got: %s", result.Error)
+		}
+		classes := result.Variables["classes"].([]interface{})
+		if len(classes) != 2 || classes[1].(ObjectRef).Name != "ZCL_B" {
+			t.Errorf("unexpected foreach result: %v", classes)
+		}
+
+		result, _ = engine.Execute(context.Background(), workflow, WithVariables(map[string]string{"EXPECTED": "3"}))
+		if result.Success || result.Error != "step 'gate' failed: unexpected class count" {
+			t.Errorf("expected fail_if to fail, got success=%v error=%s", result.Success, result.Error)
+		}
+	})
+
+	t.Run("VariableReferences", func(t *testing.T) {
+		engine := NewWorkflowEngine(nil)
+		var ran []string
+		engine.RegisterHandler("record", func(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+			ran = append(ran, params["id"].(string))
+			return nil, nil
+		})
+
+		workflow, err := engine.ParseWorkflow([]byte(`
+name: refs
+steps:
+  - action: record
+    parameters: {id: tmp}
+    condition: ${PACKAGE} == '$ZA'
+  - action: fail_if
+    parameters:
+      condition: ${TITLE} != "a' || true || 'b"
+      message: title mismatch
+  - name: compare
+    action: record
+    parameters: {id: never}
+    condition: ${PACKAGE} > 1
+`))
+		if err != nil {
+			t.Fatalf("ParseWorkflow failed: %v", err)
+		}
+
+		result, _ := engine.Execute(context.Background(), workflow,
+			WithVariables(map[string]string{"PACKAGE": "$ZA", "TITLE": "a' || true || 'b"}))
+		if got := strings.Join(ran, ","); got != "tmp" {
+			t.Errorf("expected tmp to run, got %s", got)
+		}
+		if result.Success || !strings.Contains(result.Error, "step 'compare' failed: condition:") {
+			t.Errorf("expected the condition error to fail the step, got success=%v error=%s", result.Success, result.Error)
+		}
+	})
+
+	t.Run("SyntaxErrorsAtParseTime", func(t *testing.T) {
+		engine := NewWorkflowEngine(nil)
+		for _, yamlContent := range []string{
+			"name: x\nsteps:\n  - action: print\n    condition: tests.failed >\n",
+			"name: x\nsteps:\n  - action: fail_if\n    parameters: {condition: 'size(x) > 0'}\n",
+			"name: x\nsteps:\n  - action: foreach\n    parameters: {collection: objects, where: 'item.type =='}\n",
+		} {
+			if _, err := engine.ParseWorkflow([]byte(yamlContent)); err == nil {
+				t.Errorf("expected parse error for %q", yamlContent)
+			}
+		}
+	})
+}
@@ -0,0 +1,340 @@
+package dsl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+// HistorySourceFolder is the folder (relative to the repository) replayed sources are written to.
+const HistorySourceFolder = "src"
+
+// HistoryTransport is one released transport in the history of a package,
+// with the source versions it wrote.
+type HistoryTransport struct {
+	Number      string        `json:"number"`
+	Owner       string        `json:"owner"`
+	Description string        `json:"description"`
+	Date        time.Time     `json:"date"` // Date of the newest version in the transport
+	Files       []HistoryFile `json:"files"`
+}
+
+// HistoryFile is one source version written by a transport.
+type HistoryFile struct {
+	Path    string            `json:"path"` // abapGit path, relative to the repository
+	Type    string            `json:"type"` // CLAS, INTF, PROG, DDLS, BDEF or SRVD
+	Name    string            `json:"name"`
+	Include string            `json:"include,omitempty"` // Class include
+	Version adt.ObjectVersion `json:"version"`
+
+	sourceType  string // GetVersions type (INCL for include programs)
+	description string
+}
+
+// HistoryResult is the result of collecting or replaying a package's history.
+type HistoryResult struct {
+	Package    string             `json:"package"`
+	Objects    int                `json:"objects"`
+	Transports []HistoryTransport `json:"transports"` // Oldest first
+	Skipped    int                `json:"skipped"`    // Versions without a released transport
+	Warnings   []string           `json:"warnings,omitempty"`
+}
+
+// HistoryBuilder reads the version history of a package's sources and
+// groups it by transport, oldest first.
+type HistoryBuilder struct {
+	client      *adt.Client
+	pkg         string
+	subpackages bool
+
+	// Callbacks
+	onObject    func(typ, name string)
+	onTransport func(t HistoryTransport)
+}
+
+// History creates a history builder for a package.
+func History(client *adt.Client, packageName string) *HistoryBuilder {
+	return &HistoryBuilder{
+		client: client,
+		pkg:    strings.ToUpper(packageName),
+	}
+}
+
+// Subpackages includes the objects of subpackages.
+func (b *HistoryBuilder) Subpackages() *HistoryBuilder {
+	b.subpackages = true
+	return b
+}
+
+// OnObject sets a callback for each object whose versions are read.
+func (b *HistoryBuilder) OnObject(fn func(typ, name string)) *HistoryBuilder {
+	b.onObject = fn
+	return b
+}
+
+// OnTransport sets a callback for each transport replayed.
+func (b *HistoryBuilder) OnTransport(fn func(t HistoryTransport)) *HistoryBuilder {
+	b.onTransport = fn
+	return b
+}
+
+// Collect lists the released transports that wrote the package's sources.
+//
+// Workflow: GetPackage → GetVersions per source → GetTransport per transport
+//
+// Versions without a transport, and versions in transports that are not
+// released, are skipped. Status, owner and description come from
+// GetTransport, so transports must be enabled; a transport that can't be
+// read is an error. Only objects that still exist in the package have a
+// history.
+func (b *HistoryBuilder) Collect(ctx context.Context) (*HistoryResult, error) {
+	result := &HistoryResult{Package: b.pkg}
+	transports := make(map[string]*HistoryTransport)
+
+	packages := []string{b.pkg}
+	for i := 0; i < len(packages); i++ {
+		content, err := b.client.GetPackage(ctx, packages[i])
+		if err != nil {
+			return nil, fmt.Errorf("reading package %s: %w", packages[i], err)
+		}
+		if b.subpackages {
+			packages = append(packages, content.SubPackages...)
+		}
+
+		for _, obj := range content.Objects {
+			sourceType := ""
+			switch obj.Type {
+			case "CLAS/OC", "INTF/OI", "PROG/P", "DDLS/DF", "BDEF/BDO", "SRVD/SRV":
+				sourceType = syncType(obj.Type)
+			case "PROG/I":
+				sourceType = "INCL"
+			default:
+				continue // No source file in the abapGit layout
+			}
+			typ := syncType(obj.Type)
+			name := strings.ToUpper(obj.Name)
+
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if b.onObject != nil {
+				b.onObject(typ, name)
+			}
+			result.Objects++
+
+			includes := []adt.ClassIncludeType{""}
+			if typ == "CLAS" {
+				includes = append(includes, syncClassIncludes...)
+			}
+			for _, include := range includes {
+				versions, err := b.client.GetVersions(ctx, sourceType, name, &adt.GetSourceOptions{Include: string(include)})
+				if err != nil {
+					if adt.IsNotFoundError(err) {
+						continue
+					}
+					return nil, fmt.Errorf("reading versions of %s %s: %w", typ, name, err)
+				}
+				file := HistoryFile{
+					Type:        typ,
+					Name:        name,
+					Include:     string(include),
+					sourceType:  sourceType,
+					description: obj.Description,
+				}
+				file.Path = filepath.ToSlash(filepath.Join(HistorySourceFolder, syncFilename(&SyncEntry{Type: typ, Name: name, Include: file.Include})))
+				result.Skipped += addHistoryVersions(transports, file, versions)
+			}
+		}
+	}
+
+	for _, t := range transports {
+		details, err := b.client.GetTransport(ctx, t.Number)
+		if err != nil {
+			return nil, fmt.Errorf("reading transport %s: %w", t.Number, err)
+		}
+		if details.Status != "R" { // Not released (modifiable, locked, ...)
+			result.Skipped += len(t.Files)
+			delete(transports, t.Number)
+			continue
+		}
+		if details.Owner != "" {
+			t.Owner = details.Owner
+		}
+		if details.Description != "" {
+			t.Description = details.Description
+		}
+	}
+
+	for _, t := range transports {
+		sort.Slice(t.Files, func(i, j int) bool { return t.Files[i].Path < t.Files[j].Path })
+		result.Transports = append(result.Transports, *t)
+	}
+	sort.Slice(result.Transports, func(i, j int) bool {
+		ti, tj := result.Transports[i], result.Transports[j]
+		if !ti.Date.Equal(tj.Date) {
+			return ti.Date.Before(tj.Date)
+		}
+		return ti.Number < tj.Number
+	})
+	return result, nil
+}
+
+// addHistoryVersions adds the versions of one source file to their transports.
+// If a transport wrote the file more than once, its newest version is kept.
+// Returns the number of versions without a transport.
+func addHistoryVersions(transports map[string]*HistoryTransport, file HistoryFile, versions []adt.ObjectVersion) int {
+	skipped := 0
+	for _, v := range versions {
+		if v.Transport == "" {
+			skipped++
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, v.Date)
+
+		t := transports[v.Transport]
+		if t == nil {
+			t = &HistoryTransport{Number: v.Transport, Owner: v.Author, Description: v.TransportTitle}
+			if t.Description == "" {
+				t.Description = v.Description
+			}
+			transports[v.Transport] = t
+		}
+		if date.After(t.Date) {
+			t.Date = date
+		}
+
+		f := file
+		f.Version = v
+		replaced := false
+		for i := range t.Files {
+			if t.Files[i].Path != f.Path {
+				continue
+			}
+			replaced = true
+			if old, _ := time.Parse(time.RFC3339, t.Files[i].Version.Date); date.After(old) {
+				t.Files[i] = f
+			}
+		}
+		if !replaced {
+			t.Files = append(t.Files, f)
+		}
+	}
+	return skipped
+}
+
+// Replay collects the history and writes it to dir, one transport at a time,
+// oldest first. After writing a transport's files, commit is called with the
+// paths written (relative to dir, slash-separated), e.g. to make a git commit
+// of just those files. Sources are written to dir/src in abapGit layout with
+// a .abapgit.xml in dir; the .xml metadata of an object is its current one,
+// written with the object's first version.
+func (b *HistoryBuilder) Replay(ctx context.Context, dir string, commit func(t HistoryTransport, paths []string) error) (*HistoryResult, error) {
+	result, err := b.Collect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, HistorySourceFolder), 0755); err != nil {
+		return nil, fmt.Errorf("creating %s: %w", HistorySourceFolder, err)
+	}
+
+	seen := make(map[string]bool)
+	repoFile := filepath.Join(dir, ".abapgit.xml")
+	_, statErr := os.Stat(repoFile)
+	writeRepoFile := os.IsNotExist(statErr)
+
+	for _, t := range result.Transports {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		var paths []string
+		for _, f := range t.Files {
+			source, err := b.client.GetVersionContent(ctx, f.Version)
+			if err != nil {
+				return result, fmt.Errorf("transport %s: %w", t.Number, err)
+			}
+			if f.Include != "" && onlyComments(source) {
+				continue // Generated local include without code
+			}
+			if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(f.Path)), []byte(source), 0644); err != nil {
+				return result, err
+			}
+			paths = append(paths, f.Path)
+
+			key := f.Type + ":" + f.Name
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			metaPath, language, err := b.writeHistoryMetadata(ctx, dir, f)
+			if err != nil {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("%s %s: metadata not written: %v", f.Type, f.Name, err))
+			}
+			if metaPath != "" {
+				paths = append(paths, metaPath)
+			}
+			if writeRepoFile && language != "" {
+				if err := os.WriteFile(repoFile, []byte(adt.BuildAbapGitRepoXML(language, "/"+HistorySourceFolder+"/")), 0644); err != nil {
+					return result, err
+				}
+				writeRepoFile = false
+				paths = append(paths, ".abapgit.xml")
+			}
+		}
+
+		if writeRepoFile { // No metadata read yet: fall back to English
+			if err := os.WriteFile(repoFile, []byte(adt.BuildAbapGitRepoXML("", "/"+HistorySourceFolder+"/")), 0644); err != nil {
+				return result, err
+			}
+			writeRepoFile = false
+			paths = append(paths, ".abapgit.xml")
+		}
+
+		if b.onTransport != nil {
+			b.onTransport(t)
+		}
+		if commit != nil {
+			if err := commit(t, paths); err != nil {
+				return result, fmt.Errorf("transport %s: %w", t.Number, err)
+			}
+		}
+	}
+	return result, nil
+}
+
+// writeHistoryMetadata writes the abapGit .xml file of f's object.
+// Returns the file's path relative to dir (empty if none is needed) and the
+// object's original language if it was read.
+func (b *HistoryBuilder) writeHistoryMetadata(ctx context.Context, dir string, f HistoryFile) (string, string, error) {
+	var meta *adt.ObjectMetadata
+	switch {
+	case f.sourceType == "INCL":
+		meta = &adt.ObjectMetadata{Type: "PROG", Name: f.Name, Description: f.description, ProgramType: "I"}
+	case adt.HasAbapGitMetadata(syncObjectType(f.Type)):
+		var err error
+		meta, err = b.client.GetObjectMetadata(ctx, syncObjectType(f.Type), f.Name)
+		if err != nil {
+			return "", "", err
+		}
+	default:
+		return "", "", nil // No .xml file needed
+	}
+
+	content, err := adt.BuildAbapGitXML(meta)
+	if err != nil {
+		return "", "", err
+	}
+	name := strings.ToLower(strings.ReplaceAll(f.Name, "/", "#"))
+	path := HistorySourceFolder + "/" + name + "." + strings.ToLower(meta.Type) + ".xml"
+	if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(path)), []byte(content), 0644); err != nil {
+		return "", "", err
+	}
+	return path, meta.Language, nil
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
 
 	"github.com/oisee/vibing-steampunk/pkg/adt"
@@ -18,6 +17,7 @@ type ImportFile struct {
 	ObjectName  string               `json:"objectName"`
 	IncludeType adt.ClassIncludeType `json:"includeType,omitempty"` // For class includes
 	Priority    int                  `json:"priority"`              // Lower = import first
+	DependsOn   []string             `json:"dependsOn,omitempty"`   // Objects in the batch it refers to
 }
 
 // ImportResult represents the result of importing a single file.
@@ -36,6 +36,7 @@ type BatchImportResult struct {
 	FailureCount  int            `json:"failureCount"`
 	SkippedCount  int            `json:"skippedCount"`
 	Results       []ImportResult `json:"results"`
+	Cycles        [][]string     `json:"cycles,omitempty"` // Objects activated together
 }
 
 // ExportResult represents the result of exporting a single object.
@@ -47,6 +48,8 @@ type ExportResult struct {
 	Success     bool   `json:"success"`
 	LineCount   int    `json:"lineCount"`
 	Message     string `json:"message"`
+
+	MetadataPath string `json:"metadataPath,omitempty"` // abapGit .xml file
 }
 
 // BatchExportResult represents the result of a batch export.
@@ -55,6 +58,7 @@ type BatchExportResult struct {
 	SuccessCount int            `json:"successCount"`
 	FailureCount int            `json:"failureCount"`
 	Results      []ExportResult `json:"results"`
+	RepoFile     string         `json:"repoFile,omitempty"` // .abapgit.xml written by the export
 }
 
 // ImportBuilder provides a fluent interface for batch imports.
@@ -224,53 +228,82 @@ func (b *ImportBuilder) OnError(fn func(file ImportFile, err error)) *ImportBuil
 }
 
 // Execute runs the batch import.
+//
+// Files are imported after the objects they refer to (see Groups). Objects
+// that refer to each other in a cycle are created inactive first and then
+// activated together with a single ActivateObjects request.
 func (b *ImportBuilder) Execute(ctx context.Context) (*BatchImportResult, error) {
 	result := &BatchImportResult{
 		TotalFiles: len(b.files),
 		Results:    make([]ImportResult, 0, len(b.files)),
 	}
 
-	// Sort files by priority (classes before includes, etc.)
-	sortedFiles := make([]ImportFile, len(b.files))
-	copy(sortedFiles, b.files)
-	sort.Slice(sortedFiles, func(i, j int) bool {
-		return sortedFiles[i].Priority < sortedFiles[j].Priority
-	})
-
-	for _, file := range sortedFiles {
+	for _, group := range b.Groups() {
 		select {
 		case <-ctx.Done():
 			return result, ctx.Err()
 		default:
 		}
 
-		if b.onStart != nil {
-			b.onStart(file)
+		var results []ImportResult
+		if group.Cycle {
+			var names []string
+			seen := make(map[string]bool)
+			for _, file := range group.Files {
+				name := strings.ToUpper(file.ObjectName)
+				if !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
+			result.Cycles = append(result.Cycles, names)
+			results = b.importCycle(ctx, group.Files)
+		} else {
+			file := group.Files[0]
+			if b.onStart != nil {
+				b.onStart(file)
+			}
+			results = []ImportResult{b.importFile(ctx, file)}
 		}
 
-		importResult := b.importFile(ctx, file)
-		result.Results = append(result.Results, importResult)
+		for _, importResult := range results {
+			file := importResult.File
+			result.Results = append(result.Results, importResult)
 
-		if importResult.Success {
-			result.SuccessCount++
-		} else {
-			result.FailureCount++
-			if b.onError != nil {
-				b.onError(file, fmt.Errorf(importResult.Message))
-			}
-			if b.stopOnError {
-				return result, fmt.Errorf("import failed for %s: %s", file.Path, importResult.Message)
+			if importResult.Success {
+				result.SuccessCount++
+			} else {
+				result.FailureCount++
+				if b.onError != nil {
+					b.onError(file, fmt.Errorf(importResult.Message))
+				}
+				if b.stopOnError {
+					return result, fmt.Errorf("import failed for %s: %s", file.Path, importResult.Message)
+				}
 			}
-		}
 
-		if b.onComplete != nil {
-			b.onComplete(importResult)
+			if b.onComplete != nil {
+				b.onComplete(importResult)
+			}
 		}
 	}
 
 	return result, nil
 }
 
+// Groups returns the files in import order. Each file comes after the files
+// of the objects it refers to: interfaces it implements, its superclass,
+// classes and interfaces it has TYPE REF TO, the views and tables a CDS view
+// selects from, the entities of a behavior or service definition. Class
+// includes come after their class. Otherwise the priority decides
+// (RAPOrder, DDLSFirst, ...). Files that refer to each other in a cycle form
+// one group.
+func (b *ImportBuilder) Groups() []ImportGroup {
+	files := make([]ImportFile, len(b.files))
+	copy(files, b.files)
+	return orderImportFiles(files)
+}
+
 // importFile imports a single file.
 func (b *ImportBuilder) importFile(ctx context.Context, file ImportFile) ImportResult {
 	result := ImportResult{
@@ -297,6 +330,77 @@ func (b *ImportBuilder) importFile(ctx context.Context, file ImportFile) ImportR
 	return result
 }
 
+// importCycle imports files that refer to each other: each one is created or
+// written as an inactive version, then all are activated in one request.
+func (b *ImportBuilder) importCycle(ctx context.Context, files []ImportFile) []ImportResult {
+	results := make([]ImportResult, 0, len(files))
+	for _, file := range files {
+		if b.onStart != nil {
+			b.onStart(file)
+		}
+		result := ImportResult{File: file}
+		if b.dryRun {
+			result.Success = true
+			result.Message = "dry run - would import (dependency cycle, activated together)"
+			results = append(results, result)
+			continue
+		}
+
+		deployResult, err := b.client.DeployInactiveFromFile(ctx, file.Path, b.packageName, b.transport)
+		if err != nil {
+			result.Message = fmt.Sprintf("deploy error: %v", err)
+		} else {
+			result.Success = deployResult.Success
+			result.Created = deployResult.Created
+			result.Message = deployResult.Message
+			result.ObjectURL = deployResult.ObjectURL
+		}
+		results = append(results, result)
+	}
+	if b.dryRun {
+		return results
+	}
+
+	var refs []adt.ObjectReference
+	seen := make(map[string]bool)
+	for _, r := range results {
+		if !r.Success || r.ObjectURL == "" || seen[r.ObjectURL] {
+			continue
+		}
+		seen[r.ObjectURL] = true
+		refs = append(refs, adt.ObjectReference{URI: r.ObjectURL, Name: strings.ToUpper(r.File.ObjectName)})
+	}
+	if len(refs) == 0 {
+		return results
+	}
+
+	activation, err := b.client.ActivateObjects(ctx, refs)
+	failure := ""
+	if err != nil {
+		failure = fmt.Sprintf("activation failed: %v", err)
+	} else if !activation.Success {
+		var errors []string
+		for _, msg := range activation.Messages {
+			if msg.Type == "E" || msg.Type == "A" {
+				errors = append(errors, msg.ShortText)
+			}
+		}
+		failure = "activation failed: " + strings.Join(errors, "; ")
+	}
+	for i := range results {
+		if !results[i].Success {
+			continue
+		}
+		if failure != "" {
+			results[i].Success = false
+			results[i].Message = failure
+		} else {
+			results[i].Message = fmt.Sprintf("%s; activated with %d object(s) of a dependency cycle", results[i].Message, len(refs))
+		}
+	}
+	return results
+}
+
 // Files returns the list of files to import.
 func (b *ImportBuilder) Files() []ImportFile {
 	return b.files
@@ -434,6 +538,10 @@ func (b *ExportBuilder) OnError(fn func(obj ExportObject, err error)) *ExportBui
 }
 
 // Execute runs the batch export.
+//
+// Classes, interfaces and programs are written with their abapGit .xml
+// metadata files. If the output directory has no .abapgit.xml yet, one is
+// written (starting folder /), so the directory can be pulled with abapGit.
 func (b *ExportBuilder) Execute(ctx context.Context) (*BatchExportResult, error) {
 	result := &BatchExportResult{
 		TotalObjects: len(b.objects),
@@ -447,6 +555,7 @@ func (b *ExportBuilder) Execute(ctx context.Context) (*BatchExportResult, error)
 		}
 	}
 
+	language := ""
 	for _, obj := range b.objects {
 		select {
 		case <-ctx.Done():
@@ -458,7 +567,10 @@ func (b *ExportBuilder) Execute(ctx context.Context) (*BatchExportResult, error)
 			b.onStart(obj)
 		}
 
-		exportResult := b.exportObject(ctx, obj)
+		exportResult, objLanguage := b.exportObject(ctx, obj)
+		if language == "" {
+			language = objLanguage
+		}
 
 		// Skip non-existent includes (not an error)
 		if !exportResult.Success && strings.Contains(exportResult.Message, "404") {
@@ -481,11 +593,36 @@ func (b *ExportBuilder) Execute(ctx context.Context) (*BatchExportResult, error)
 		}
 	}
 
+	if language != "" {
+		repoFile, err := writeAbapGitRepoFile(b.outputDir, language)
+		if err != nil {
+			return result, err
+		}
+		result.RepoFile = repoFile
+	}
+
 	return result, nil
 }
 
-// exportObject exports a single object.
-func (b *ExportBuilder) exportObject(ctx context.Context, obj ExportObject) ExportResult {
+// writeAbapGitRepoFile writes dir/.abapgit.xml unless the file already exists.
+// Returns the path of the written file, or "" if it was kept.
+func writeAbapGitRepoFile(dir, language string) (string, error) {
+	if dir == "" {
+		dir = "."
+	}
+	repoFile := filepath.Join(dir, ".abapgit.xml")
+	if _, err := os.Stat(repoFile); err == nil {
+		return "", nil
+	}
+	if err := os.WriteFile(repoFile, []byte(adt.BuildAbapGitRepoXML(language, "/")), 0644); err != nil {
+		return "", fmt.Errorf("writing .abapgit.xml: %w", err)
+	}
+	return repoFile, nil
+}
+
+// exportObject exports a single object. The returned language is the object's
+// original language if its abapGit metadata was written.
+func (b *ExportBuilder) exportObject(ctx context.Context, obj ExportObject) (ExportResult, string) {
 	result := ExportResult{
 		ObjectType:  string(obj.Type),
 		ObjectName:  obj.Name,
@@ -504,15 +641,16 @@ func (b *ExportBuilder) exportObject(ctx context.Context, obj ExportObject) Expo
 
 	if err != nil {
 		result.Message = fmt.Sprintf("export error: %v", err)
-		return result
+		return result, ""
 	}
 
 	result.Success = saveResult.Success
 	result.FilePath = saveResult.FilePath
 	result.LineCount = saveResult.LineCount
 	result.Message = saveResult.Message
+	result.MetadataPath = saveResult.MetadataPath
 
-	return result
+	return result, saveResult.Language
 }
 
 // --- Helper Functions ---
@@ -530,7 +668,7 @@ func ScanDirectory(dir string) ([]ImportFile, error) {
 		}
 
 		// Check for supported extensions
-		if !isABAPSourceFile(path) {
+		if !isABAPSourceFile(path) && !isDDICFile(path) {
 			return nil
 		}
 
@@ -586,10 +724,37 @@ func isABAPSourceFile(path string) bool {
 	return false
 }
 
+// isDDICFile checks if a file is an abapGit XML file of a DDIC object or message class.
+func isDDICFile(path string) bool {
+	lower := strings.ToLower(path)
+	extensions := []string{
+		".tabl.xml",
+		".dtel.xml",
+		".doma.xml",
+		".ttyp.xml",
+		".msag.xml",
+	}
+	for _, ext := range extensions {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
 // getPriority returns import priority (lower = first).
-// Order: Interfaces → Classes (main) → Programs → Class includes → DDLS → BDEF → SRVD
+// Order: Domains → Data elements → Tables/Structures → Table types/Message classes →
+// Interfaces → Classes (main) → Programs → Class includes → DDLS → BDEF → SRVD
 func getPriority(objType adt.CreatableObjectType, includeType adt.ClassIncludeType) int {
 	switch objType {
+	case adt.ObjectTypeDomain:
+		return 1
+	case adt.ObjectTypeDataElement:
+		return 2
+	case adt.ObjectTypeTable, adt.ObjectTypeStructure:
+		return 3
+	case adt.ObjectTypeTableType, adt.ObjectTypeMessageClass:
+		return 4
 	case adt.ObjectTypeInterface:
 		return 10
 	case adt.ObjectTypeClass:
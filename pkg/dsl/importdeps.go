@@ -0,0 +1,270 @@
+package dsl
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+// ImportGroup is a set of files imported together. Files of a dependency
+// cycle are created inactive first and then activated in a single request.
+type ImportGroup struct {
+	Files []ImportFile `json:"files"`
+	Cycle bool         `json:"cycle,omitempty"`
+}
+
+// referencePattern finds references to other objects in a source.
+type referencePattern struct {
+	re      *regexp.Regexp
+	targets []adt.CreatableObjectType // Types the referenced name may have
+	list    bool                      // Match is a list of names (INTERFACES: a, b.)
+}
+
+var (
+	abapReferencePatterns = []referencePattern{
+		{re: regexp.MustCompile(`(?i)\bINTERFACES\b\s*:?\s*([^.]+)`), targets: []adt.CreatableObjectType{adt.ObjectTypeInterface}, list: true},
+		{re: regexp.MustCompile(`(?i)\bINHERITING\s+FROM\s+([/\w]+)`), targets: []adt.CreatableObjectType{adt.ObjectTypeClass}},
+		{re: regexp.MustCompile(`(?i)\bTYPE\s+REF\s+TO\s+([/\w]+)`), targets: []adt.CreatableObjectType{adt.ObjectTypeClass, adt.ObjectTypeInterface}},
+	}
+	cdsReferencePatterns = []referencePattern{
+		{re: regexp.MustCompile(`(?i)\bselect\s+(?:distinct\s+)?from\s+([/\w]+)`), targets: []adt.CreatableObjectType{adt.ObjectTypeDDLS, adt.ObjectTypeTable}},
+		{re: regexp.MustCompile(`(?i)\bjoin\s+([/\w]+)`), targets: []adt.CreatableObjectType{adt.ObjectTypeDDLS, adt.ObjectTypeTable}},
+		{re: regexp.MustCompile(`(?i)\bprojection\s+on\s+([/\w]+)`), targets: []adt.CreatableObjectType{adt.ObjectTypeDDLS}},
+	}
+	bdefReferencePatterns = []referencePattern{
+		{re: regexp.MustCompile(`(?i)\bdefine\s+behavior\s+for\s+([/\w]+)`), targets: []adt.CreatableObjectType{adt.ObjectTypeDDLS}},
+	}
+	srvdReferencePatterns = []referencePattern{
+		{re: regexp.MustCompile(`(?i)\bexpose\s+([/\w]+)`), targets: []adt.CreatableObjectType{adt.ObjectTypeDDLS}},
+	}
+
+	cdsComments = regexp.MustCompile(`(?s)/\*.*?\*/|//[^\n]*`)
+)
+
+// objectReference is a name referenced by a source and the types it may have.
+type objectReference struct {
+	name    string
+	targets []adt.CreatableObjectType
+}
+
+// scanReferences returns the objects a source refers to: INTERFACES,
+// INHERITING FROM and TYPE REF TO in ABAP; select from, joins and projections
+// in CDS; the entities of a behavior definition; exposed entities in a
+// service definition. Associations are not followed.
+func scanReferences(objType adt.CreatableObjectType, source string) []objectReference {
+	var patterns []referencePattern
+	switch objType {
+	case adt.ObjectTypeClass, adt.ObjectTypeInterface, adt.ObjectTypeProgram, adt.ObjectTypeInclude, adt.ObjectTypeFunctionMod:
+		patterns = abapReferencePatterns
+		source = stripABAPComments(source)
+	case adt.ObjectTypeDDLS:
+		patterns = cdsReferencePatterns
+		source = cdsComments.ReplaceAllString(source, "")
+	case adt.ObjectTypeBDEF:
+		patterns = bdefReferencePatterns
+		source = cdsComments.ReplaceAllString(source, "")
+	case adt.ObjectTypeSRVD:
+		patterns = srvdReferencePatterns
+		source = cdsComments.ReplaceAllString(source, "")
+	default:
+		return nil
+	}
+
+	var refs []objectReference
+	for _, p := range patterns {
+		for _, m := range p.re.FindAllStringSubmatch(source, -1) {
+			names := []string{m[1]}
+			if p.list {
+				names = strings.FieldsFunc(m[1], func(r rune) bool {
+					return r == ',' || r == ':' || r == ' ' || r == '\t' || r == '\r' || r == '\n'
+				})
+			}
+			for _, name := range names {
+				refs = append(refs, objectReference{name: strings.ToUpper(name), targets: p.targets})
+			}
+		}
+	}
+	return refs
+}
+
+// stripABAPComments removes full-line (*) and end-of-line (") comments.
+func stripABAPComments(source string) string {
+	lines := strings.Split(source, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "*") {
+			lines[i] = ""
+			continue
+		}
+		if idx := strings.Index(line, `"`); idx >= 0 {
+			lines[i] = line[:idx]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// orderImportFiles sorts files so that every file comes after the files of
+// the objects it refers to. Files that refer to each other (directly or
+// through others) form one cycle group. Between independent files, the
+// priority decides, as before.
+func orderImportFiles(files []ImportFile) []ImportGroup {
+	// The file that creates each object: the main source (DDIC objects and
+	// other types have only one file).
+	objects := make(map[string]int)
+	for i, f := range files {
+		if f.ObjectType == adt.ObjectTypeClass && f.IncludeType != "" && f.IncludeType != adt.ClassIncludeMain {
+			continue
+		}
+		objects[string(f.ObjectType)+":"+strings.ToUpper(f.ObjectName)] = i
+	}
+
+	// deps[i] lists the files that must be imported before file i
+	deps := make([][]int, len(files))
+	for i := range files {
+		f := &files[i]
+		f.DependsOn = nil
+		add := func(j int) {
+			for _, d := range deps[i] {
+				if d == j {
+					return
+				}
+			}
+			deps[i] = append(deps[i], j)
+			if files[j].ObjectType != f.ObjectType || !strings.EqualFold(files[j].ObjectName, f.ObjectName) {
+				f.DependsOn = append(f.DependsOn, strings.ToUpper(files[j].ObjectName))
+			}
+		}
+
+		if f.ObjectType == adt.ObjectTypeClass && f.IncludeType != "" && f.IncludeType != adt.ClassIncludeMain {
+			if j, ok := objects[string(adt.ObjectTypeClass)+":"+strings.ToUpper(f.ObjectName)]; ok {
+				add(j) // Class includes need their class
+			}
+		}
+
+		source, err := os.ReadFile(f.Path)
+		if err != nil {
+			continue // Reported by the import itself
+		}
+		for _, ref := range scanReferences(f.ObjectType, string(source)) {
+			for _, typ := range ref.targets {
+				j, ok := objects[string(typ)+":"+ref.name]
+				if ok && j != i {
+					add(j)
+					break
+				}
+			}
+		}
+	}
+
+	components := stronglyConnected(deps)
+
+	// Order the components topologically; among the ready ones, the lowest
+	// priority (then path) goes first.
+	component := make([]int, len(files))
+	for c, members := range components {
+		sort.Slice(members, func(a, b int) bool { return importLess(files[members[a]], files[members[b]]) })
+		for _, i := range members {
+			component[i] = c
+		}
+	}
+	pending := make([]int, len(components)) // Unimported components each one depends on
+	dependents := make([][]int, len(components))
+	for i, ds := range deps {
+		seen := make(map[int]bool)
+		for _, j := range ds {
+			ci, cj := component[i], component[j]
+			if ci == cj || seen[cj] {
+				continue
+			}
+			seen[cj] = true
+			pending[ci]++
+			dependents[cj] = append(dependents[cj], ci)
+		}
+	}
+
+	var groups []ImportGroup
+	done := make([]bool, len(components))
+	for len(groups) < len(components) {
+		next := -1
+		for c := range components {
+			if done[c] || pending[c] > 0 {
+				continue
+			}
+			if next < 0 || importLess(files[components[c][0]], files[components[next][0]]) {
+				next = c
+			}
+		}
+		done[next] = true
+		for _, d := range dependents[next] {
+			pending[d]--
+		}
+
+		group := ImportGroup{Cycle: len(components[next]) > 1}
+		for _, i := range components[next] {
+			group.Files = append(group.Files, files[i])
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// importLess orders files by priority, then path.
+func importLess(a, b ImportFile) bool {
+	if a.Priority != b.Priority {
+		return a.Priority < b.Priority
+	}
+	return a.Path < b.Path
+}
+
+// stronglyConnected returns the strongly connected components of a
+// dependency graph (Tarjan's algorithm).
+func stronglyConnected(deps [][]int) [][]int {
+	index := make([]int, len(deps))
+	low := make([]int, len(deps))
+	onStack := make([]bool, len(deps))
+	for i := range index {
+		index[i] = -1
+	}
+	var stack []int
+	var components [][]int
+	counter := 0
+
+	var visit func(v int)
+	visit = func(v int) {
+		index[v], low[v] = counter, counter
+		counter++
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range deps[v] {
+			if index[w] < 0 {
+				visit(w)
+				low[v] = min(low[v], low[w])
+			} else if onStack[w] {
+				low[v] = min(low[v], index[w])
+			}
+		}
+
+		if low[v] == index[v] {
+			var component []int
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				component = append(component, w)
+				if w == v {
+					break
+				}
+			}
+			components = append(components, component)
+		}
+	}
+	for v := range deps {
+		if index[v] < 0 {
+			visit(v)
+		}
+	}
+	return components
+}
@@ -0,0 +1,171 @@
+package dsl
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MatrixSystem is the matrix axis that selects the SAP system of a run.
+const MatrixSystem = "system"
+
+// matrixAxisName matches matrix axis names, which become variables.
+var matrixAxisName = regexp.MustCompile(`^\w+$`)
+
+// SystemOptions returns the options that connect a matrix run to a system,
+// e.g. WithClient and WithToolCaller for the system's connection.
+type SystemOptions func(system string) ([]ExecuteOption, error)
+
+// MatrixResult is the combined report of a matrix run.
+type MatrixResult struct {
+	Name      string        `json:"name"`
+	Success   bool          `json:"success"`
+	Total     int           `json:"total"`
+	Passed    int           `json:"passed"`
+	Failed    int           `json:"failed"`
+	TotalTime time.Duration `json:"totalTime"`
+	Runs      []MatrixRun   `json:"runs"`
+}
+
+// MatrixRun is the result of the workflow for one matrix combination.
+type MatrixRun struct {
+	Combination   map[string]string `json:"combination"`
+	Success       bool              `json:"success"`
+	Error         string            `json:"error,omitempty"`
+	ExecutionTime time.Duration     `json:"executionTime"`
+	Result        *WorkflowResult   `json:"result,omitempty"`
+}
+
+// Label returns the combination as "key=value" pairs in axis order.
+func (r MatrixRun) Label() string {
+	axes := make([]string, 0, len(r.Combination))
+	for axis := range r.Combination {
+		axes = append(axes, axis)
+	}
+	parts := make([]string, 0, len(axes))
+	for _, axis := range sortAxes(axes) {
+		parts = append(parts, axis+"="+r.Combination[axis])
+	}
+	return strings.Join(parts, " ")
+}
+
+// matrixAxes returns the axis names of a matrix in expansion order.
+func matrixAxes(matrix map[string][]string) []string {
+	axes := make([]string, 0, len(matrix))
+	for axis := range matrix {
+		axes = append(axes, axis)
+	}
+	return sortAxes(axes)
+}
+
+// sortAxes sorts axis names: system first, then alphabetically.
+func sortAxes(axes []string) []string {
+	sort.Slice(axes, func(i, j int) bool {
+		if (axes[i] == MatrixSystem) != (axes[j] == MatrixSystem) {
+			return axes[i] == MatrixSystem
+		}
+		return axes[i] < axes[j]
+	})
+	return axes
+}
+
+// Combinations expands the matrix into one variable set per combination.
+// The first axis (system, if present) changes slowest.
+func (w *Workflow) Combinations() []map[string]string {
+	if len(w.Matrix) == 0 {
+		return nil
+	}
+	combos := []map[string]string{{}}
+	for _, axis := range matrixAxes(w.Matrix) {
+		var next []map[string]string
+		for _, combo := range combos {
+			for _, value := range w.Matrix[axis] {
+				c := make(map[string]string, len(combo)+1)
+				for k, v := range combo {
+					c[k] = v
+				}
+				c[axis] = value
+				next = append(next, c)
+			}
+		}
+		combos = next
+	}
+	return combos
+}
+
+// validateMatrix checks that every matrix axis has a name usable as a
+// variable and at least one value.
+func validateMatrix(matrix map[string][]string) error {
+	for _, axis := range matrixAxes(matrix) {
+		if !matrixAxisName.MatchString(axis) {
+			return fmt.Errorf("invalid matrix axis %q", axis)
+		}
+		if len(matrix[axis]) == 0 {
+			return fmt.Errorf("matrix axis %q has no values", axis)
+		}
+	}
+	return nil
+}
+
+// ExecuteMatrix runs the workflow once per matrix combination, one after the
+// other. The axis values are set as variables (overriding opts); for the
+// system axis, systems provides the options that connect the run to that
+// system. A failing run does not stop the others.
+func (e *WorkflowEngine) ExecuteMatrix(ctx context.Context, workflow *Workflow, systems SystemOptions, opts ...ExecuteOption) (*MatrixResult, error) {
+	start := time.Now()
+	result := &MatrixResult{Name: workflow.Name, Success: true}
+	if err := validateMatrix(workflow.Matrix); err != nil {
+		return nil, fmt.Errorf("invalid workflow: %w", err)
+	}
+	if len(workflow.Matrix) == 0 {
+		return nil, fmt.Errorf("workflow %s has no matrix", workflow.Name)
+	}
+
+	for _, combo := range workflow.Combinations() {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		runStart := time.Now()
+		run := MatrixRun{Combination: combo}
+
+		runOpts := append([]ExecuteOption{}, opts...)
+		var err error
+		if system, ok := combo[MatrixSystem]; ok {
+			var systemOpts []ExecuteOption
+			if systems == nil {
+				err = fmt.Errorf("no systems available for %s", system)
+			} else if systemOpts, err = systems(system); err != nil {
+				err = fmt.Errorf("system %s: %w", system, err)
+			}
+			runOpts = append(runOpts, systemOpts...)
+		}
+		if err == nil {
+			runOpts = append(runOpts, WithVariables(combo))
+			run.Result, err = e.Execute(ctx, workflow, runOpts...)
+		}
+
+		switch {
+		case err != nil:
+			run.Error = err.Error()
+		case !run.Result.Success:
+			run.Error = run.Result.Error
+		default:
+			run.Success = true
+		}
+		run.ExecutionTime = time.Since(runStart)
+
+		result.Runs = append(result.Runs, run)
+		result.Total++
+		if run.Success {
+			result.Passed++
+		} else {
+			result.Failed++
+			result.Success = false
+		}
+	}
+	result.TotalTime = time.Since(start)
+	return result, nil
+}
@@ -0,0 +1,81 @@
+package dsl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+func TestExecuteMatrix(t *testing.T) {
+	engine := NewWorkflowEngine(nil)
+	workflow, err := engine.ParseWorkflow([]byte(`
+name: nightly
+matrix:
+  package: [$ZA, $ZB]
+  system: [DEV, QAS, PRD]
+steps:
+  - action: check
+    parameters:
+      package: ${package}
+      system: ${system}
+`))
+	if err != nil {
+		t.Fatalf("ParseWorkflow failed: %v", err)
+	}
+
+	clients := map[string]*adt.Client{"DEV": adt.NewClient("http://dev", "u", "p"), "QAS": adt.NewClient("http://qas", "u", "p")}
+	systems := func(system string) ([]ExecuteOption, error) {
+		client, ok := clients[system]
+		if !ok {
+			return nil, fmt.Errorf("not configured")
+		}
+		return []ExecuteOption{WithClient(client)}, nil
+	}
+
+	var mu sync.Mutex
+	var ran []string
+	engine.RegisterHandler("check", func(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		system := params["system"].(string)
+		if ctx.Client() != clients[system] {
+			return nil, fmt.Errorf("run for %s uses the wrong client", system)
+		}
+		ran = append(ran, fmt.Sprintf("%s/%s", system, params["package"]))
+		if system == "QAS" && params["package"] == "$ZB" {
+			return nil, fmt.Errorf("tests failed")
+		}
+		return nil, nil
+	})
+
+	result, err := engine.ExecuteMatrix(context.Background(), workflow, systems)
+	if err != nil {
+		t.Fatalf("ExecuteMatrix failed: %v", err)
+	}
+	if got := strings.Join(ran, ","); got != "DEV/$ZA,DEV/$ZB,QAS/$ZA,QAS/$ZB" {
+		t.Errorf("unexpected runs: %s", got)
+	}
+	if result.Success || result.Total != 6 || result.Passed != 3 || result.Failed != 3 {
+		t.Errorf("unexpected totals: success=%v total=%d passed=%d failed=%d", result.Success, result.Total, result.Passed, result.Failed)
+	}
+
+	want := map[string]string{
+		"system=QAS package=$ZB": "tests failed",
+		"system=PRD package=$ZA": "system PRD: not configured",
+		"system=PRD package=$ZB": "system PRD: not configured",
+	}
+	for _, run := range result.Runs {
+		if msg, failed := want[run.Label()]; failed != !run.Success || !strings.Contains(run.Error, msg) {
+			t.Errorf("%s: unexpected result success=%v error=%q", run.Label(), run.Success, run.Error)
+		}
+	}
+
+	// Matrix axes are validated with the workflow
+	if _, err := engine.ParseWorkflow([]byte("name: x\nmatrix:\n  system: []\nsteps:\n  - action: print\n")); err == nil {
+		t.Error("expected error for empty matrix axis")
+	}
+}
@@ -0,0 +1,504 @@
+package dsl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+// PipelineResult represents the result of a pipeline run.
+type PipelineResult struct {
+	Name      string                 `json:"name"`
+	Success   bool                   `json:"success"`
+	DryRun    bool                   `json:"dryRun,omitempty"`
+	Stages    []StageResult          `json:"stages"`
+	Variables map[string]interface{} `json:"variables"`
+	Error     string                 `json:"error,omitempty"`
+}
+
+// StageResult represents the result of a single pipeline stage.
+type StageResult struct {
+	Name       string       `json:"name"`
+	Status     string       `json:"status"` // success, failed, skipped
+	Steps      []StepResult `json:"steps,omitempty"`
+	Error      string       `json:"error,omitempty"`
+	SkipReason string       `json:"skipReason,omitempty"`
+}
+
+// Stage statuses reported in StageResult.
+const (
+	StageSuccess = "success"
+	StageFailed  = "failed"
+	StageSkipped = "skipped"
+)
+
+// Run executes the pipeline with the client it was built with.
+// See WorkflowEngine.RunPipeline.
+func (p *Pipeline) Run(ctx context.Context, opts ...ExecuteOption) (*PipelineResult, error) {
+	return NewWorkflowEngine(p.client).RunPipeline(ctx, p, opts...)
+}
+
+// Validate checks stage names and dependencies: names must be unique,
+// dependsOn must refer to existing stages and there must be no cycles.
+func (p *Pipeline) Validate() error {
+	_, err := p.order()
+	return err
+}
+
+// order returns the stage indexes in execution order: declaration order,
+// except that a stage always comes after the stages it depends on.
+func (p *Pipeline) order() ([]int, error) {
+	index := make(map[string]int, len(p.Stages))
+	for i, stage := range p.Stages {
+		if stage.Name == "" {
+			return nil, fmt.Errorf("stage %d has no name", i+1)
+		}
+		if _, dup := index[stage.Name]; dup {
+			return nil, fmt.Errorf("duplicate stage %q", stage.Name)
+		}
+		index[stage.Name] = i
+	}
+
+	deps := make([][]int, len(p.Stages))
+	for i, stage := range p.Stages {
+		for _, ref := range stage.DependsOn {
+			j, ok := index[ref]
+			if !ok {
+				return nil, fmt.Errorf("stage %q depends on unknown stage %q", stage.Name, ref)
+			}
+			deps[i] = append(deps[i], j)
+		}
+	}
+	if cycle := findCycle(deps); cycle != nil {
+		names := make([]string, len(cycle))
+		for k, i := range cycle {
+			names[k] = p.Stages[i].Name
+		}
+		return nil, fmt.Errorf("dependency cycle: %s", strings.Join(names, " -> "))
+	}
+
+	order := make([]int, 0, len(p.Stages))
+	placed := make([]bool, len(p.Stages))
+	for len(order) < len(p.Stages) {
+		for i := range p.Stages {
+			if placed[i] {
+				continue
+			}
+			ready := true
+			for _, j := range deps[i] {
+				if !placed[j] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				placed[i] = true
+				order = append(order, i)
+				break
+			}
+		}
+	}
+	return order, nil
+}
+
+// RunPipeline executes a pipeline stage by stage.
+//
+// Stages run one at a time in dependency order and share one execution
+// context, so variables saved in one stage are visible to later stages.
+// A stage fails on its first failing step (including fail_if steps such as
+// FailIfTestsFailed); stages that depend on it are skipped. Independent
+// stages still run unless the failed stage has FailFast set. A stage whose
+// condition is not met is skipped, and counts as done for its dependents.
+func (e *WorkflowEngine) RunPipeline(ctx context.Context, p *Pipeline, opts ...ExecuteOption) (*PipelineResult, error) {
+	execCtx := NewExecutionContext(ctx, e.client)
+	for k, v := range p.Variables {
+		execCtx.SetVariable(k, v)
+	}
+	for _, opt := range opts {
+		opt(execCtx)
+	}
+
+	result := &PipelineResult{
+		Name:      p.Name,
+		Success:   true,
+		DryRun:    execCtx.IsDryRun(),
+		Stages:    make([]StageResult, 0, len(p.Stages)),
+		Variables: make(map[string]interface{}),
+	}
+
+	order, err := p.order()
+	if err != nil {
+		result.Success = false
+		result.Error = fmt.Sprintf("invalid pipeline: %s", err)
+		return result, nil
+	}
+
+	index := make(map[string]int, len(p.Stages))
+	for i, stage := range p.Stages {
+		index[stage.Name] = i
+	}
+
+	results := make([]*StageResult, len(p.Stages))
+	stopped := "" // Set when a FailFast stage failed
+	for _, i := range order {
+		stage := p.Stages[i]
+		stageResult := &StageResult{Name: stage.Name}
+		results[i] = stageResult
+
+		if stopped != "" {
+			stageResult.Status = StageSkipped
+			stageResult.SkipReason = fmt.Sprintf("pipeline stopped after stage '%s' failed", stopped)
+			continue
+		}
+		if blocker := blockedBy(stage, index, results); blocker != "" {
+			stageResult.Status = StageSkipped
+			stageResult.SkipReason = fmt.Sprintf("dependency '%s' did not succeed", blocker)
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			stageResult.Status = StageFailed
+			stageResult.Error = fmt.Sprintf("pipeline cancelled: %s", err)
+			if result.Success {
+				result.Success = false
+				result.Error = stageResult.Error
+			}
+			stopped = stage.Name
+			continue
+		}
+
+		e.runStage(execCtx, stage, stageResult, result.Variables)
+		if stageResult.Status == StageFailed {
+			if result.Success {
+				result.Success = false
+				result.Error = fmt.Sprintf("stage '%s' failed: %s", stage.Name, stageResult.Error)
+			}
+			if stage.FailFast {
+				stopped = stage.Name
+			}
+		}
+	}
+
+	// Report stages in declaration order
+	for _, r := range results {
+		result.Stages = append(result.Stages, *r)
+	}
+
+	return result, nil
+}
+
+// blockedBy returns the first dependency of stage that failed or was skipped
+// because of an earlier failure, or "" if the stage can run.
+func blockedBy(stage Stage, index map[string]int, results []*StageResult) string {
+	for _, dep := range stage.DependsOn {
+		r := results[index[dep]]
+		if r.Status == StageFailed || (r.Status == StageSkipped && r.SkipReason != "condition not met") {
+			return dep
+		}
+	}
+	return ""
+}
+
+// runStage runs the steps of a stage in order, stopping at the first failure.
+// Saved outputs are also recorded in vars.
+func (e *WorkflowEngine) runStage(execCtx *ExecutionContext, stage Stage, stageResult *StageResult, vars map[string]interface{}) {
+	stageResult.Status = StageSuccess
+
+	if stage.Condition != "" {
+		met, err := e.checkCondition(execCtx, stage.Condition)
+		if err != nil {
+			stageResult.Status = StageFailed
+			stageResult.Error = fmt.Sprintf("condition: %s", err)
+			return
+		}
+		if !met {
+			stageResult.Status = StageSkipped
+			stageResult.SkipReason = "condition not met"
+			return
+		}
+	}
+
+	for i, step := range stage.Steps {
+		ws := WorkflowStep{
+			Name:       step.Name,
+			Action:     step.Action,
+			Parameters: step.Parameters,
+			SaveAs:     step.SaveAs,
+			Condition:  step.Condition,
+		}
+		outcome := e.executeStep(execCtx, i, ws)
+		stageResult.Steps = append(stageResult.Steps, outcome.result)
+		if step.Name != "" {
+			execCtx.setStepResult([]string{step.Name}, outcome.result)
+		}
+		if outcome.saveAs != "" {
+			vars[outcome.saveAs] = outcome.result.Output
+		}
+		if outcome.fatal != "" {
+			stageResult.Status = StageFailed
+			stageResult.Error = outcome.fatal
+			return
+		}
+	}
+}
+
+// --- Pipeline Action Handlers ---
+//
+// These back the StageBuilder steps that have no workflow equivalent.
+// Actions that change the system only report what they would do in dry-run mode.
+
+func dryRunOutput(action string, params map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"dryRun": true, "action": action, "parameters": params}
+}
+
+func handleImport(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+	dir, _ := params["directory"].(string)
+	if dir == "" {
+		return nil, fmt.Errorf("import requires 'directory' parameter")
+	}
+	builder, err := Import(ctx.Client()).FromDirectory(dir)
+	if err != nil {
+		return nil, err
+	}
+	return runImport(ctx, builder, params)
+}
+
+func handleImportFiles(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+	files := stringList(params["files"])
+	if len(files) == 0 {
+		return nil, fmt.Errorf("import_files requires 'files' parameter")
+	}
+	builder, err := Import(ctx.Client()).FromFiles(files...)
+	if err != nil {
+		return nil, err
+	}
+	return runImport(ctx, builder, params)
+}
+
+func runImport(ctx *ExecutionContext, builder *ImportBuilder, params map[string]interface{}) (interface{}, error) {
+	pkg, _ := params["package"].(string)
+	if pkg == "" {
+		return nil, fmt.Errorf("import requires 'package' parameter")
+	}
+	builder.ToPackage(pkg)
+	if transport, ok := params["transport"].(string); ok {
+		builder.WithTransport(transport)
+	}
+	if ctx.IsDryRun() {
+		builder.DryRun()
+	}
+	result, err := builder.Execute(ctx.Context())
+	if err != nil {
+		return result, err
+	}
+	if result.FailureCount > 0 {
+		return result, fmt.Errorf("%d of %d files failed to import", result.FailureCount, result.TotalFiles)
+	}
+	return result, nil
+}
+
+func handleExport(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+	objectsVar, _ := params["objects"].(string)
+	if objectsVar == "" {
+		return nil, fmt.Errorf("export requires 'objects' parameter")
+	}
+	val, exists := ctx.Get(objectsVar)
+	if !exists {
+		return nil, fmt.Errorf("variable '%s' not found", objectsVar)
+	}
+	objects, ok := val.([]ObjectRef)
+	if !ok {
+		return nil, fmt.Errorf("variable '%s' is not a list of objects", objectsVar)
+	}
+	if ctx.IsDryRun() {
+		return dryRunOutput("export", params), nil
+	}
+
+	builder := Export(ctx.Client())
+	if dir, ok := params["outputDir"].(string); ok {
+		builder.ToDirectory(dir)
+	}
+	for _, obj := range objects {
+		switch obj.Type {
+		case TypeClass, "CLAS/OC":
+			builder.Classes(obj.Name)
+		case TypeProgram, "PROG/P":
+			builder.Programs(obj.Name)
+		case TypeInterface, "INTF/OI":
+			builder.Interfaces(obj.Name)
+		case TypeDDLS, "DDLS/DF":
+			builder.DDLSources(obj.Name)
+		}
+	}
+	return runExport(ctx, builder)
+}
+
+func handleExportClasses(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+	classes := stringList(params["classes"])
+	if len(classes) == 0 {
+		return nil, fmt.Errorf("export_classes requires 'classes' parameter")
+	}
+	if ctx.IsDryRun() {
+		return dryRunOutput("export_classes", params), nil
+	}
+	builder := Export(ctx.Client()).Classes(classes...)
+	if dir, ok := params["outputDir"].(string); ok {
+		builder.ToDirectory(dir)
+	}
+	return runExport(ctx, builder)
+}
+
+func runExport(ctx *ExecutionContext, builder *ExportBuilder) (interface{}, error) {
+	result, err := builder.Execute(ctx.Context())
+	if err != nil {
+		return result, err
+	}
+	if result.FailureCount > 0 {
+		return result, fmt.Errorf("%d of %d objects failed to export", result.FailureCount, result.TotalObjects)
+	}
+	return result, nil
+}
+
+func handleCreate(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+	if ctx.IsDryRun() {
+		return dryRunOutput("create", params), nil
+	}
+	objectType, _ := params["type"].(string)
+	name, _ := params["name"].(string)
+	pkg, _ := params["package"].(string)
+	description, _ := params["description"].(string)
+	if objectType == "" || name == "" || pkg == "" {
+		return nil, fmt.Errorf("create requires 'type', 'name' and 'package' parameters")
+	}
+	err := ctx.Client().CreateObject(ctx.Context(), adt.CreateObjectOptions{
+		ObjectType:  adt.CreatableObjectType(objectType),
+		Name:        name,
+		PackageName: pkg,
+		Description: description,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"object": name, "created": true}, nil
+}
+
+func handleWriteSource(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+	objectType, _ := params["type"].(string)
+	name, _ := params["name"].(string)
+	sourceVar, _ := params["source"].(string)
+	if objectType == "" || name == "" || sourceVar == "" {
+		return nil, fmt.Errorf("write_source requires 'type', 'name' and 'source' parameters")
+	}
+	val, exists := ctx.Get(sourceVar)
+	if !exists {
+		return nil, fmt.Errorf("variable '%s' not found", sourceVar)
+	}
+	source, ok := val.(string)
+	if !ok {
+		return nil, fmt.Errorf("variable '%s' is not a string", sourceVar)
+	}
+	if ctx.IsDryRun() {
+		return dryRunOutput("write_source", params), nil
+	}
+	result, err := ctx.Client().WriteSource(ctx.Context(), objectType, name, source, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Success {
+		return result, fmt.Errorf("write_source failed for %s: %s", name, result.Message)
+	}
+	return result, nil
+}
+
+func handleActivateObject(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+	if ctx.IsDryRun() {
+		return dryRunOutput("activate_object", params), nil
+	}
+	objectType, _ := params["type"].(string)
+	name, _ := params["name"].(string)
+	objectURL := buildObjectURL(ObjectRef{Type: objectType, Name: name})
+	if objectURL == "" {
+		objectURL = adt.GetObjectURL(adt.CreatableObjectType(objectType), name, "")
+	}
+	result, err := ctx.Client().Activate(ctx.Context(), objectURL, name)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Success {
+		return result, &adt.ActivationError{Object: name, Messages: result.Messages}
+	}
+	return result, nil
+}
+
+func handlePublish(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+	return publishBinding(ctx, "publish", params)
+}
+
+func handleUnpublish(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+	return publishBinding(ctx, "unpublish", params)
+}
+
+func publishBinding(ctx *ExecutionContext, action string, params map[string]interface{}) (interface{}, error) {
+	binding, _ := params["binding"].(string)
+	if binding == "" {
+		return nil, fmt.Errorf("%s requires 'binding' parameter", action)
+	}
+	version, _ := params["version"].(string)
+	if version == "" {
+		version = "0001"
+	}
+	if ctx.IsDryRun() {
+		return dryRunOutput(action, params), nil
+	}
+	if action == "unpublish" {
+		return ctx.Client().UnpublishServiceBinding(ctx.Context(), binding, version)
+	}
+	return ctx.Client().PublishServiceBinding(ctx.Context(), binding, version)
+}
+
+func handleQuery(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+	sql, _ := params["sql"].(string)
+	if sql == "" {
+		return nil, fmt.Errorf("query requires 'sql' parameter")
+	}
+	maxRows := 100
+	if mr, ok := params["maxRows"].(int); ok {
+		maxRows = mr
+	}
+	return ctx.Client().RunQuery(ctx.Context(), sql, maxRows)
+}
+
+func handleSetVar(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+	name, _ := params["name"].(string)
+	if name == "" {
+		return nil, fmt.Errorf("set_var requires 'name' parameter")
+	}
+	value := ""
+	if v, ok := params["value"]; ok && v != nil {
+		value = fmt.Sprintf("%v", v)
+	}
+	ctx.SetVariable(name, value)
+	return value, nil
+}
+
+// stringList converts a []string or YAML list parameter to []string.
+func stringList(v interface{}) []string {
+	switch val := v.(type) {
+	case []string:
+		return val
+	case []interface{}:
+		list := make([]string, 0, len(val))
+		for _, item := range val {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	case string:
+		if val != "" {
+			return []string{val}
+		}
+	}
+	return nil
+}
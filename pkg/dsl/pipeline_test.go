@@ -0,0 +1,181 @@
+package dsl
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// mockPipelineEngine returns an engine whose search, syntax_check and test
+// actions return canned results. failedTests sets the failures of the test run.
+func mockPipelineEngine(failedTests int, ran *[]string) *WorkflowEngine {
+	engine := NewWorkflowEngine(nil)
+	engine.RegisterHandler("search", func(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+		*ran = append(*ran, "search:"+params["query"].(string))
+		return []ObjectRef{{Type: "CLAS", Name: "ZCL_A"}, {Type: "PROG", Name: "ZPROG"}}, nil
+	})
+	engine.RegisterHandler("syntax_check", func(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+		val, _ := ctx.Get(params["objects"].(string))
+		objects := val.([]ObjectRef)
+		*ran = append(*ran, "syntax")
+		results := []map[string]interface{}{}
+		for _, obj := range objects {
+			results = append(results, map[string]interface{}{"object": obj.Name, "success": true})
+		}
+		return results, nil
+	})
+	engine.RegisterHandler("test", func(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+		*ran = append(*ran, "test")
+		return &TestSummary{TotalTests: 5, FailedTests: failedTests, PassedTests: 5 - failedTests}, nil
+	})
+	engine.RegisterHandler("print", func(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	return engine
+}
+
+func TestPipelineRun(t *testing.T) {
+	t.Run("CIPipelineSucceeds", func(t *testing.T) {
+		var ran []string
+		result, err := mockPipelineEngine(0, &ran).RunPipeline(context.Background(), CIPipeline(nil, "$ZTEST"))
+		if err != nil {
+			t.Fatalf("RunPipeline failed: %v", err)
+		}
+		if !result.Success {
+			t.Fatalf("expected success, got: %s", result.Error)
+		}
+		if got := strings.Join(ran, ","); got != "search:$ZTEST,syntax,test" {
+			t.Errorf("unexpected execution order: %s", got)
+		}
+		if len(result.Stages) != 3 {
+			t.Fatalf("expected 3 stage results, got %d", len(result.Stages))
+		}
+		for _, stage := range result.Stages {
+			if stage.Status != StageSuccess {
+				t.Errorf("stage %s: expected success, got %s (%s)", stage.Name, stage.Status, stage.Error)
+			}
+		}
+		if _, ok := result.Variables["testResults"].(*TestSummary); !ok {
+			t.Errorf("expected testResults variable, got %v", result.Variables["testResults"])
+		}
+	})
+
+	t.Run("FailIfTestsFailed", func(t *testing.T) {
+		var ran []string
+		pipeline := NewPipeline(nil, "gate").
+			Stage("discover").
+			Search("$ZTEST", "objects").
+			Then().
+			Stage("test").
+			DependsOn("discover").
+			Test("objects", "testResults").
+			FailIfTestsFailed("testResults").
+			Then().
+			Stage("deploy").
+			DependsOn("test").
+			Print("never").
+			Then().
+			Stage("report").
+			DependsOn("discover").
+			Print("independent").
+			Then().
+			Build()
+
+		result, _ := mockPipelineEngine(2, &ran).RunPipeline(context.Background(), pipeline)
+		if result.Success {
+			t.Fatal("expected pipeline to fail")
+		}
+		if !strings.Contains(result.Error, "stage 'test' failed") || !strings.Contains(result.Error, "2 tests failed") {
+			t.Errorf("unexpected error: %s", result.Error)
+		}
+		want := map[string]string{"discover": StageSuccess, "test": StageFailed, "deploy": StageSkipped, "report": StageSuccess}
+		for _, stage := range result.Stages {
+			if stage.Status != want[stage.Name] {
+				t.Errorf("stage %s: expected %s, got %s", stage.Name, want[stage.Name], stage.Status)
+			}
+		}
+		if result.Stages[2].SkipReason != "dependency 'test' did not succeed" {
+			t.Errorf("unexpected skip reason: %s", result.Stages[2].SkipReason)
+		}
+	})
+
+	t.Run("FailFastStopsPipeline", func(t *testing.T) {
+		var ran []string
+		pipeline := &Pipeline{
+			Name: "failfast",
+			Stages: []Stage{
+				{Name: "gate", FailFast: true, Steps: []Step{{Action: "fail_if", Parameters: map[string]interface{}{"condition": "true", "message": "blocked"}}}},
+				{Name: "other", Steps: []Step{{Action: "search", Parameters: map[string]interface{}{"query": "Z*"}}}},
+			},
+		}
+		result, _ := mockPipelineEngine(0, &ran).RunPipeline(context.Background(), pipeline)
+		if result.Success || len(ran) != 0 {
+			t.Errorf("expected pipeline to stop, success=%v ran=%v", result.Success, ran)
+		}
+		if result.Stages[1].Status != StageSkipped {
+			t.Errorf("expected 'other' to be skipped, got %s", result.Stages[1].Status)
+		}
+	})
+
+	t.Run("DependencyOrderAndConditions", func(t *testing.T) {
+		var ran []string
+		pipeline := &Pipeline{
+			Name:      "order",
+			Variables: map[string]string{"env": "DEV"},
+			Stages: []Stage{
+				{Name: "test", DependsOn: []string{"discover"}, Steps: []Step{{Action: "test"}}},
+				{Name: "prod-only", Condition: `env == "PRD"`, Steps: []Step{{Action: "search", Parameters: map[string]interface{}{"query": "PRD"}}}},
+				{Name: "discover", Steps: []Step{{Action: "search", Parameters: map[string]interface{}{"query": "Z*"}, SaveAs: "objects"}}},
+				{Name: "after-skip", DependsOn: []string{"prod-only"}, Steps: []Step{{Action: "set_var", Parameters: map[string]interface{}{"name": "done", "value": "yes"}}}},
+			},
+		}
+		result, _ := mockPipelineEngine(0, &ran).RunPipeline(context.Background(), pipeline)
+		if !result.Success {
+			t.Fatalf("expected success, got: %s", result.Error)
+		}
+		if got := strings.Join(ran, ","); got != "search:Z*,test" {
+			t.Errorf("unexpected execution order: %s", got)
+		}
+		if result.Stages[1].Status != StageSkipped || result.Stages[3].Status != StageSuccess {
+			t.Errorf("expected prod-only skipped and after-skip run, got %s and %s", result.Stages[1].Status, result.Stages[3].Status)
+		}
+	})
+
+	t.Run("DryRun", func(t *testing.T) {
+		var ran []string
+		pipeline := NewPipeline(nil, "dry").
+			Stage("publish").
+			Publish("ZUI_TRAVEL_O4", "0001").
+			ActivateObject("CLAS", "ZCL_A").
+			Then().
+			Build()
+		result, _ := mockPipelineEngine(0, &ran).RunPipeline(context.Background(), pipeline, WithDryRun(true))
+		if !result.Success || !result.DryRun {
+			t.Fatalf("expected dry run to succeed without a client, got: %s", result.Error)
+		}
+		output, _ := result.Stages[0].Steps[0].Output.(map[string]interface{})
+		if output["dryRun"] != true || output["action"] != "publish" {
+			t.Errorf("unexpected dry-run output: %v", result.Stages[0].Steps[0].Output)
+		}
+	})
+
+	t.Run("InvalidPipeline", func(t *testing.T) {
+		for _, p := range []*Pipeline{
+			{Name: "dup", Stages: []Stage{{Name: "a"}, {Name: "a"}}},
+			{Name: "unknown", Stages: []Stage{{Name: "a", DependsOn: []string{"b"}}}},
+			{Name: "cycle", Stages: []Stage{{Name: "a", DependsOn: []string{"b"}}, {Name: "b", DependsOn: []string{"a"}}}},
+		} {
+			if err := p.Validate(); err == nil {
+				t.Errorf("%s: expected validation error", p.Name)
+			}
+			result, _ := NewWorkflowEngine(nil).RunPipeline(context.Background(), p)
+			if result.Success || !strings.HasPrefix(result.Error, "invalid pipeline:") {
+				t.Errorf("%s: expected invalid pipeline error, got %q", p.Name, result.Error)
+			}
+		}
+		err := (&Pipeline{Stages: []Stage{{Name: "a", DependsOn: []string{"b"}}, {Name: "b", DependsOn: []string{"a"}}}}).Validate()
+		if err == nil || err.Error() != "dependency cycle: a -> b -> a" {
+			t.Errorf("unexpected cycle error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,719 @@
+package dsl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+// SyncStateFile is the sync state file, relative to the synced directory.
+const SyncStateFile = ".vsp/sync-state.json"
+
+// SyncStatus describes how a file differs between the local directory and the system.
+type SyncStatus string
+
+const (
+	SyncUnchanged     SyncStatus = "unchanged"
+	SyncLocalAdded    SyncStatus = "local-added"
+	SyncLocalChanged  SyncStatus = "local-changed"
+	SyncLocalDeleted  SyncStatus = "local-deleted"
+	SyncRemoteAdded   SyncStatus = "remote-added"
+	SyncRemoteChanged SyncStatus = "remote-changed"
+	SyncRemoteDeleted SyncStatus = "remote-deleted"
+	SyncConflict      SyncStatus = "conflict"
+)
+
+// SyncEntry is one source file (object main source or class include) in a sync.
+type SyncEntry struct {
+	Key     string     `json:"key"` // TYPE:NAME or TYPE:NAME:include
+	Type    string     `json:"type"`
+	Name    string     `json:"name"`
+	Include string     `json:"include,omitempty"` // Class include (testclasses, definitions, ...)
+	Path    string     `json:"path,omitempty"`    // Relative to the synced directory
+	Status  SyncStatus `json:"status"`
+	Reason  string     `json:"reason,omitempty"` // Why an entry is a conflict
+	Action  string     `json:"action,omitempty"` // pushed, pulled, deleted
+	Error   string     `json:"error,omitempty"`
+
+	local      *ImportFile
+	localSrc   string
+	remote     string
+	sourceType string // GetSource type of the remote object (INCL for program includes)
+	group      string // Function group of a function module
+	hasLocal   bool
+	hasRemote  bool
+	base       string
+}
+
+// SyncResult is the result of a sync.
+type SyncResult struct {
+	Dir      string      `json:"dir"`
+	Package  string      `json:"package"`
+	Entries  []SyncEntry `json:"entries"`
+	Pushed   int         `json:"pushed"`
+	Pulled   int         `json:"pulled"`
+	Deleted  int         `json:"deleted"`
+	Failed   int         `json:"failed"`
+	Warnings []string    `json:"warnings,omitempty"`
+}
+
+// Count returns the number of entries with status.
+func (r *SyncResult) Count(status SyncStatus) int {
+	n := 0
+	for _, e := range r.Entries {
+		if e.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// SyncState records the base hash of each synced file, i.e. its content at the
+// last sync. Comparing both sides against the base tells which side changed.
+type SyncState struct {
+	Package   string                    `json:"package"`
+	UpdatedAt time.Time                 `json:"updatedAt"`
+	Objects   map[string]SyncStateEntry `json:"objects"`
+}
+
+// SyncStateEntry is the recorded base of one file.
+type SyncStateEntry struct {
+	Path string `json:"path"`
+	Hash string `json:"hash"`
+}
+
+// LoadSyncState reads the sync state of dir. A missing file yields an empty state.
+func LoadSyncState(dir string) (*SyncState, error) {
+	state := &SyncState{Objects: make(map[string]SyncStateEntry)}
+	data, err := os.ReadFile(filepath.Join(dir, SyncStateFile))
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading sync state: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("parsing sync state: %w", err)
+	}
+	if state.Objects == nil {
+		state.Objects = make(map[string]SyncStateEntry)
+	}
+	return state, nil
+}
+
+// Save writes the sync state of dir.
+func (s *SyncState) Save(dir string) error {
+	path := filepath.Join(dir, SyncStateFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating state directory: %w", err)
+	}
+	s.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// syncClassIncludes are the class includes compared besides the main source.
+var syncClassIncludes = []adt.ClassIncludeType{
+	adt.ClassIncludeDefinitions,
+	adt.ClassIncludeImplementations,
+	adt.ClassIncludeMacros,
+	adt.ClassIncludeTestClasses,
+}
+
+// SyncBuilder compares a local abapGit-layout directory with a package and
+// pushes or pulls the differences.
+type SyncBuilder struct {
+	client      *adt.Client
+	dir         string
+	pkg         string
+	subpackages bool
+	push        bool
+	pull        bool
+	force       bool
+	deleteLocal bool
+	dryRun      bool
+	transport   string
+	only        map[string]bool
+
+	// Callbacks
+	onApply func(entry SyncEntry)
+}
+
+// Sync creates a sync builder for a directory and a package.
+func Sync(client *adt.Client, dir, packageName string) *SyncBuilder {
+	return &SyncBuilder{
+		client: client,
+		dir:    dir,
+		pkg:    strings.ToUpper(packageName),
+	}
+}
+
+// Subpackages includes the objects of subpackages.
+func (b *SyncBuilder) Subpackages() *SyncBuilder {
+	b.subpackages = true
+	return b
+}
+
+// Push writes local additions and changes to the system.
+func (b *SyncBuilder) Push() *SyncBuilder {
+	b.push = true
+	return b
+}
+
+// Pull writes remote additions and changes to the directory.
+func (b *SyncBuilder) Pull() *SyncBuilder {
+	b.pull = true
+	return b
+}
+
+// Force resolves conflicts in favor of the single chosen direction (Push or Pull).
+func (b *SyncBuilder) Force() *SyncBuilder {
+	b.force = true
+	return b
+}
+
+// DeleteLocal removes local files of objects deleted on the system when pulling.
+// Objects deleted locally are only reported, never deleted on the system.
+func (b *SyncBuilder) DeleteLocal() *SyncBuilder {
+	b.deleteLocal = true
+	return b
+}
+
+// DryRun reports what would be pushed or pulled without changing anything.
+func (b *SyncBuilder) DryRun() *SyncBuilder {
+	b.dryRun = true
+	return b
+}
+
+// WithTransport sets the transport request for pushed objects.
+func (b *SyncBuilder) WithTransport(transport string) *SyncBuilder {
+	b.transport = transport
+	return b
+}
+
+// Only restricts push and pull to the given objects (NAME or TYPE:NAME).
+func (b *SyncBuilder) Only(objects ...string) *SyncBuilder {
+	if b.only == nil {
+		b.only = make(map[string]bool)
+	}
+	for _, o := range objects {
+		b.only[strings.ToUpper(o)] = true
+	}
+	return b
+}
+
+// OnApply sets a callback invoked after each push, pull or delete.
+func (b *SyncBuilder) OnApply(fn func(entry SyncEntry)) *SyncBuilder {
+	b.onApply = fn
+	return b
+}
+
+// Execute compares both sides, applies the selected directions and updates
+// the sync state. Without Push or Pull it only reports the differences (and
+// records the base of files that are already in sync).
+func (b *SyncBuilder) Execute(ctx context.Context) (*SyncResult, error) {
+	if b.force && b.push == b.pull {
+		return nil, fmt.Errorf("force requires exactly one direction (push or pull)")
+	}
+
+	state, err := LoadSyncState(b.dir)
+	if err != nil {
+		return nil, err
+	}
+	if state.Package != "" && state.Package != b.pkg {
+		return nil, fmt.Errorf("%s is synced with package %s, not %s", b.dir, state.Package, b.pkg)
+	}
+
+	result := &SyncResult{Dir: b.dir, Package: b.pkg}
+	entries := make(map[string]*SyncEntry)
+	entry := func(typ, name, include string) *SyncEntry {
+		key := syncKey(typ, name, include)
+		if e, ok := entries[key]; ok {
+			return e
+		}
+		e := &SyncEntry{Key: key, Type: typ, Name: name, Include: include}
+		entries[key] = e
+		return e
+	}
+
+	if err := b.scanLocal(entry, result); err != nil {
+		return nil, err
+	}
+	if err := b.scanRemote(ctx, entry); err != nil {
+		return nil, err
+	}
+	for key, base := range state.Objects {
+		e := entries[key]
+		if e == nil {
+			typ, name, include := splitSyncKey(key)
+			e = entry(typ, name, include)
+		}
+		e.base = base.Hash
+		if e.Path == "" {
+			e.Path = base.Path
+		}
+	}
+
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		pi, pj := syncPriority(entries[keys[i]]), syncPriority(entries[keys[j]])
+		if pi != pj {
+			return pi < pj
+		}
+		return keys[i] < keys[j]
+	})
+
+	for _, key := range keys {
+		e := entries[key]
+		classifySync(e)
+		if e.Status == "" {
+			// Gone on both sides
+			delete(state.Objects, key)
+			continue
+		}
+		if e.Path == "" {
+			e.Path = syncFilename(e)
+			// Class includes go next to the main class file
+			if main := entries[syncKey(e.Type, e.Name, "")]; e.Include != "" && main != nil && main.Path != "" {
+				e.Path = filepath.ToSlash(filepath.Join(filepath.Dir(filepath.FromSlash(main.Path)), e.Path))
+			}
+		}
+		b.apply(ctx, e, result)
+		if e.Error != "" {
+			result.Failed++
+		}
+
+		switch {
+		case e.Action == "deleted":
+			delete(state.Objects, key)
+		case e.Action == "pushed" || e.Status == SyncUnchanged:
+			state.Objects[key] = SyncStateEntry{Path: e.Path, Hash: hashSource(e.localSrc)}
+		case e.Action == "pulled":
+			state.Objects[key] = SyncStateEntry{Path: e.Path, Hash: hashSource(e.remote)}
+		}
+		result.Entries = append(result.Entries, *e)
+	}
+
+	if !b.dryRun {
+		state.Package = b.pkg
+		if err := state.Save(b.dir); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// scanLocal reads the abapGit-layout files below the directory.
+func (b *SyncBuilder) scanLocal(entry func(typ, name, include string) *SyncEntry, result *SyncResult) error {
+	return filepath.Walk(b.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != b.dir && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir // .git, .vsp, ...
+			}
+			return nil
+		}
+		if !isABAPSourceFile(path) {
+			return nil
+		}
+
+		file, err := ParseImportFile(path)
+		if err != nil {
+			// Program includes have no REPORT statement; take the name from the filename
+			base := strings.ToLower(filepath.Base(path))
+			if !strings.HasSuffix(base, ".prog.abap") {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("skipping %s: %v", path, err))
+				return nil
+			}
+			name := strings.ToUpper(strings.ReplaceAll(strings.TrimSuffix(base, ".prog.abap"), "#", "/"))
+			file = &ImportFile{Path: path, ObjectType: adt.ObjectTypeProgram, ObjectName: name, Priority: getPriority(adt.ObjectTypeProgram, "")}
+		}
+
+		rel, _ := filepath.Rel(b.dir, path)
+		typ := syncType(string(file.ObjectType))
+		if file.ObjectType == adt.ObjectTypeFunctionMod {
+			typ = "FUNC"
+		}
+		if !syncedTypes[typ] {
+			// scanRemote can't read it, so it would look added locally on every run
+			result.Warnings = append(result.Warnings, fmt.Sprintf("skipping %s: %s sources are not synced", filepath.ToSlash(rel), typ))
+			return nil
+		}
+
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		include := string(file.IncludeType)
+		if include == string(adt.ClassIncludeMain) {
+			include = ""
+		}
+		if include != "" && onlyComments(string(source)) {
+			return nil // Absent on the system side too (see scanRemote)
+		}
+		e := entry(typ, strings.ToUpper(file.ObjectName), include)
+		if e.hasLocal {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s and %s both contain %s; using the first", e.Path, filepath.ToSlash(rel), e.Key))
+			return nil
+		}
+		e.local = file
+		e.localSrc = string(source)
+		e.hasLocal = true
+		e.Path = filepath.ToSlash(rel)
+		if typ == "FUNC" {
+			e.group = syncFunctionGroup(filepath.Base(path))
+		}
+		return nil
+	})
+}
+
+// scanRemote reads the sources of the package objects.
+func (b *SyncBuilder) scanRemote(ctx context.Context, entry func(typ, name, include string) *SyncEntry) error {
+	packages := []string{b.pkg}
+	for i := 0; i < len(packages); i++ {
+		content, err := b.client.GetPackage(ctx, packages[i])
+		if err != nil {
+			return fmt.Errorf("reading package %s: %w", packages[i], err)
+		}
+		if b.subpackages {
+			packages = append(packages, content.SubPackages...)
+		}
+
+		for _, obj := range content.Objects {
+			sourceType := ""
+			switch obj.Type {
+			case "CLAS/OC", "INTF/OI", "PROG/P", "DDLS/DF", "BDEF/BDO", "SRVD/SRV":
+				sourceType = syncType(obj.Type)
+			case "PROG/I":
+				sourceType = "INCL"
+			case "FUGR/F":
+				if err := b.scanRemoteFunctions(ctx, strings.ToUpper(obj.Name), entry); err != nil {
+					return err
+				}
+				continue
+			default:
+				continue // No source file in the abapGit layout we sync
+			}
+			typ := syncType(obj.Type)
+			name := strings.ToUpper(obj.Name)
+
+			source, err := b.client.GetSource(ctx, sourceType, name, nil)
+			if err != nil {
+				if adt.IsNotFoundError(err) {
+					continue
+				}
+				return fmt.Errorf("reading %s %s: %w", typ, name, err)
+			}
+			e := entry(typ, name, "")
+			e.remote, e.hasRemote, e.sourceType = source, true, sourceType
+
+			if typ != "CLAS" {
+				continue
+			}
+			for _, include := range syncClassIncludes {
+				source, err := b.client.GetClassInclude(ctx, name, include)
+				if err != nil {
+					if adt.IsNotFoundError(err) {
+						continue
+					}
+					return fmt.Errorf("reading %s %s include %s: %w", typ, name, include, err)
+				}
+				if onlyComments(source) {
+					continue // Every class has local includes, usually just the generated comments
+				}
+				e := entry(typ, name, string(include))
+				e.remote, e.hasRemote = source, true
+			}
+		}
+	}
+	return nil
+}
+
+// scanRemoteFunctions reads the function modules of a function group.
+func (b *SyncBuilder) scanRemoteFunctions(ctx context.Context, group string, entry func(typ, name, include string) *SyncEntry) error {
+	fg, err := b.client.GetFunctionGroup(ctx, group)
+	if err != nil {
+		if adt.IsNotFoundError(err) {
+			return nil
+		}
+		return fmt.Errorf("reading FUGR %s: %w", group, err)
+	}
+	for _, fm := range fg.Functions {
+		name := strings.ToUpper(fm.Name)
+		source, err := b.client.GetFunction(ctx, name, group)
+		if err != nil {
+			if adt.IsNotFoundError(err) {
+				continue
+			}
+			return fmt.Errorf("reading FUNC %s: %w", name, err)
+		}
+		e := entry("FUNC", name, "")
+		e.remote, e.hasRemote, e.sourceType, e.group = source, true, "FUNC", group
+	}
+	return nil
+}
+
+// classifySync sets the status of e from its local, remote and base hashes.
+func classifySync(e *SyncEntry) {
+	local, remote := hashSource(e.localSrc), hashSource(e.remote)
+	switch {
+	case !e.hasLocal && !e.hasRemote:
+		e.Status = ""
+	case e.hasLocal && e.hasRemote && local == remote:
+		e.Status = SyncUnchanged
+	case e.base == "":
+		switch {
+		case !e.hasRemote:
+			e.Status = SyncLocalAdded
+		case !e.hasLocal:
+			e.Status = SyncRemoteAdded
+		default:
+			e.Status, e.Reason = SyncConflict, "added on both sides with different content"
+		}
+	case !e.hasLocal:
+		if remote == e.base {
+			e.Status = SyncLocalDeleted
+		} else {
+			e.Status, e.Reason = SyncConflict, "deleted locally, changed on the system"
+		}
+	case !e.hasRemote:
+		if local == e.base {
+			e.Status = SyncRemoteDeleted
+		} else {
+			e.Status, e.Reason = SyncConflict, "changed locally, deleted on the system"
+		}
+	case local == e.base:
+		e.Status = SyncRemoteChanged
+	case remote == e.base:
+		e.Status = SyncLocalChanged
+	default:
+		e.Status, e.Reason = SyncConflict, "changed on both sides"
+	}
+}
+
+// apply pushes, pulls or deletes e as selected.
+func (b *SyncBuilder) apply(ctx context.Context, e *SyncEntry, result *SyncResult) {
+	if b.only != nil && !b.only[e.Name] && !b.only[e.Type+":"+e.Name] {
+		return
+	}
+
+	push := b.push && (e.Status == SyncLocalAdded || e.Status == SyncLocalChanged ||
+		(b.force && e.Status == SyncConflict && e.hasLocal))
+	pull := b.pull && (e.Status == SyncRemoteAdded || e.Status == SyncRemoteChanged ||
+		(b.force && e.Status == SyncConflict && e.hasRemote))
+	remove := b.pull && b.deleteLocal && (e.Status == SyncRemoteDeleted ||
+		(b.force && e.Status == SyncConflict && !e.hasRemote))
+
+	switch {
+	case push:
+		if !b.dryRun {
+			if err := b.checkRemoteUnchanged(ctx, e); err != nil {
+				e.Error = err.Error()
+				return
+			}
+			deployResult, err := b.client.DeployFromFile(ctx, e.local.Path, b.pkg, b.transport)
+			if err != nil {
+				e.Error = err.Error()
+				return
+			}
+			if !deployResult.Success {
+				e.Error = deployResult.Message
+				return
+			}
+		}
+		e.Action = "pushed"
+		result.Pushed++
+	case pull:
+		if !b.dryRun {
+			path := filepath.Join(b.dir, filepath.FromSlash(e.Path))
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				e.Error = err.Error()
+				return
+			}
+			if err := os.WriteFile(path, []byte(e.remote), 0644); err != nil {
+				e.Error = err.Error()
+				return
+			}
+		}
+		e.Action = "pulled"
+		result.Pulled++
+	case remove:
+		if !b.dryRun {
+			if err := os.Remove(filepath.Join(b.dir, filepath.FromSlash(e.Path))); err != nil {
+				e.Error = err.Error()
+				return
+			}
+		}
+		e.Action = "deleted"
+		result.Deleted++
+	default:
+		return
+	}
+	if b.onApply != nil {
+		b.onApply(*e)
+	}
+}
+
+// checkRemoteUnchanged reads e from the system again right before it is
+// pushed and fails if it changed since the scan, so that an edit made on the
+// system in the meantime is not overwritten.
+func (b *SyncBuilder) checkRemoteUnchanged(ctx context.Context, e *SyncEntry) error {
+	source, exists, err := b.remoteSource(ctx, e)
+	if err != nil {
+		return fmt.Errorf("re-reading %s before push: %w", e.Key, err)
+	}
+	if exists != e.hasRemote || (exists && hashSource(source) != hashSource(e.remote)) {
+		return fmt.Errorf("%s changed on the system during the sync; not pushed, run the sync again", e.Key)
+	}
+	return nil
+}
+
+// remoteSource reads the current source of e on the system. Like scanRemote,
+// it treats missing objects and class includes with only comments as absent.
+func (b *SyncBuilder) remoteSource(ctx context.Context, e *SyncEntry) (string, bool, error) {
+	var source string
+	var err error
+	if e.Include != "" {
+		source, err = b.client.GetClassInclude(ctx, e.Name, adt.ClassIncludeType(e.Include))
+	} else {
+		sourceType := e.sourceType
+		if sourceType == "" {
+			sourceType = e.Type
+		}
+		source, err = b.client.GetSource(ctx, sourceType, e.Name, &adt.GetSourceOptions{Parent: e.group})
+	}
+	if err != nil {
+		if adt.IsNotFoundError(err) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	if e.Include != "" && onlyComments(source) {
+		return "", false, nil
+	}
+	return source, true, nil
+}
+
+// syncedTypes are the types whose sources scanRemote reads.
+var syncedTypes = map[string]bool{
+	"CLAS": true, "INTF": true, "PROG": true, "FUNC": true, "DDLS": true, "BDEF": true, "SRVD": true,
+}
+
+// syncType returns the short type (CLAS, PROG, ...) of an ADT type like CLAS/OC.
+func syncType(adtType string) string {
+	typ, _, _ := strings.Cut(adtType, "/")
+	return strings.ToUpper(typ)
+}
+
+// syncFunctionGroup returns the function group of an abapGit function module
+// file (<group>.fugr.<function>.func.abap).
+func syncFunctionGroup(filename string) string {
+	group, _, _ := strings.Cut(strings.ToLower(filename), ".fugr.")
+	return strings.ToUpper(strings.ReplaceAll(group, "#", "/"))
+}
+
+func syncKey(typ, name, include string) string {
+	if include == "" {
+		return typ + ":" + name
+	}
+	return typ + ":" + name + ":" + include
+}
+
+func splitSyncKey(key string) (typ, name, include string) {
+	parts := strings.SplitN(key, ":", 3)
+	for len(parts) < 3 {
+		parts = append(parts, "")
+	}
+	return parts[0], parts[1], parts[2]
+}
+
+// syncPriority orders entries like imports: interfaces, classes, class includes, programs, CDS.
+func syncPriority(e *SyncEntry) int {
+	return getPriority(syncObjectType(e.Type), adt.ClassIncludeType(e.Include))
+}
+
+func syncObjectType(typ string) adt.CreatableObjectType {
+	switch typ {
+	case "CLAS":
+		return adt.ObjectTypeClass
+	case "INTF":
+		return adt.ObjectTypeInterface
+	case "PROG":
+		return adt.ObjectTypeProgram
+	case "FUNC":
+		return adt.ObjectTypeFunctionMod
+	case "DDLS":
+		return adt.ObjectTypeDDLS
+	case "BDEF":
+		return adt.ObjectTypeBDEF
+	case "SRVD":
+		return adt.ObjectTypeSRVD
+	}
+	return adt.CreatableObjectType(typ)
+}
+
+// syncFilename returns the abapGit filename for a remote-only entry.
+func syncFilename(e *SyncEntry) string {
+	name := strings.ToLower(strings.ReplaceAll(e.Name, "/", "#"))
+	switch e.Type {
+	case "DDLS":
+		return name + ".ddls.asddls"
+	case "BDEF":
+		return name + ".bdef.asbdef"
+	case "SRVD":
+		return name + ".srvd.srvdsrv"
+	case "FUNC":
+		group := strings.ToLower(strings.ReplaceAll(e.group, "/", "#"))
+		return group + ".fugr." + name + ".func.abap"
+	}
+	switch adt.ClassIncludeType(e.Include) {
+	case adt.ClassIncludeDefinitions:
+		return name + ".clas.locals_def.abap"
+	case adt.ClassIncludeImplementations:
+		return name + ".clas.locals_imp.abap"
+	case adt.ClassIncludeMacros:
+		return name + ".clas.macros.abap"
+	case adt.ClassIncludeTestClasses:
+		return name + ".clas.testclasses.abap"
+	}
+	return name + "." + strings.ToLower(e.Type) + ".abap"
+}
+
+// onlyComments reports whether source has no code besides comments.
+func onlyComments(source string) bool {
+	for _, line := range strings.Split(source, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "*") && !strings.HasPrefix(line, "\"") {
+			return false
+		}
+	}
+	return true
+}
+
+// hashSource hashes source code ignoring line ending and trailing whitespace differences.
+func hashSource(source string) string {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+	lines := strings.Split(strings.TrimRight(source, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:])
+}
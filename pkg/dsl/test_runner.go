@@ -2,7 +2,10 @@ package dsl
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -89,12 +92,33 @@ func (t *TestRunner) StopOnFirstFailure() *TestRunner {
 	return t
 }
 
+// WithCoverage measures coverage and attaches it to each TestResult.
+func (t *TestRunner) WithCoverage() *TestRunner {
+	t.config.Coverage = true
+	return t
+}
+
 // Parallel sets the number of parallel test executions.
 func (t *TestRunner) Parallel(n int) *TestRunner {
 	t.config.Parallel = n
 	return t
 }
 
+// RerunFailures runs the tests of a failed object up to n more times. The
+// last attempt is the result; earlier ones are kept in TestResult.Retries.
+func (t *TestRunner) RerunFailures(n int) *TestRunner {
+	t.config.RerunFailures = n
+	return t
+}
+
+// HashSources records a hash of each object's source in TestResult.SourceHash,
+// so results can be compared across runs of the same code. Without it, the
+// object's change timestamp is recorded instead (one metadata read).
+func (t *TestRunner) HashSources() *TestRunner {
+	t.config.HashSources = true
+	return t
+}
+
 // Timeout sets the timeout for each test run.
 func (t *TestRunner) Timeout(d time.Duration) *TestRunner {
 	t.config.Timeout = d
@@ -156,7 +180,7 @@ func (t *TestRunner) runSequential(ctx context.Context, objects []ObjectRef, sum
 		default:
 		}
 
-		result := t.runSingleTest(ctx, obj)
+		result := t.runObject(ctx, obj)
 		t.aggregateResult(summary, result)
 
 		if t.config.StopOnFirstFailure && !result.Success {
@@ -187,7 +211,7 @@ func (t *TestRunner) runParallel(ctx context.Context, objects []ObjectRef, summa
 			defer wg.Done()
 			defer func() { <-semaphore }()
 
-			result := t.runSingleTest(ctx, obj)
+			result := t.runObject(ctx, obj)
 
 			mu.Lock()
 			t.aggregateResult(summary, result)
@@ -210,16 +234,89 @@ func (t *TestRunner) runParallel(ctx context.Context, objects []ObjectRef, summa
 	return nil
 }
 
+// runObject runs the tests of an object, rerunning them after a failure
+// when configured.
+func (t *TestRunner) runObject(ctx context.Context, obj ObjectRef) TestResult {
+	if t.onStart != nil {
+		t.onStart(obj)
+	}
+
+	var hash string
+	if t.config.HashSources {
+		hash = t.sourceHash(ctx, obj)
+	} else {
+		hash = t.changeMarker(ctx, obj)
+	}
+
+	result := t.runSingleTest(ctx, obj)
+	result.SourceHash = hash
+	for i := 0; i < t.config.RerunFailures && !result.Success; i++ {
+		if ctx.Err() != nil {
+			break
+		}
+		retries := append(result.Retries, result)
+		retries[len(retries)-1].Retries = nil
+		result = t.runSingleTest(ctx, obj)
+		result.SourceHash = hash
+		result.Retries = retries
+	}
+
+	if t.onComplete != nil {
+		t.onComplete(obj, result)
+	}
+	return result
+}
+
+// sourceHash returns a SHA256 hex digest of the object's source, including
+// the local includes of a class. It returns "" if the source cannot be read.
+func (t *TestRunner) sourceHash(ctx context.Context, obj ObjectRef) string {
+	objectType, _, _ := strings.Cut(strings.ToUpper(obj.Type), "/")
+	h := sha256.New()
+	switch objectType {
+	case TypeClass:
+		main, err := t.client.GetClassSource(ctx, obj.Name)
+		if err != nil {
+			return ""
+		}
+		h.Write([]byte(main))
+		// Local includes may not exist; they hash as empty
+		for _, include := range []adt.ClassIncludeType{
+			adt.ClassIncludeDefinitions, adt.ClassIncludeImplementations,
+			adt.ClassIncludeMacros, adt.ClassIncludeTestClasses,
+		} {
+			source, _ := t.client.GetClassInclude(ctx, obj.Name, include)
+			fmt.Fprintf(h, "\n%s\n%s", include, source)
+		}
+	default:
+		source, err := t.client.GetSource(ctx, objectType, obj.Name, nil)
+		if err != nil {
+			return ""
+		}
+		h.Write([]byte(source))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// changeMarker returns "changed:" and the object's change timestamp, a cheap
+// stand-in for a source hash. It returns "" if the timestamp cannot be read.
+func (t *TestRunner) changeMarker(ctx context.Context, obj ObjectRef) string {
+	objectURL := t.buildObjectURL(obj)
+	if objectURL == "" {
+		return ""
+	}
+	changedAt, err := t.client.GetObjectChangedAt(ctx, objectURL)
+	if err != nil || changedAt.IsZero() {
+		return ""
+	}
+	return "changed:" + changedAt.UTC().Format(time.RFC3339Nano)
+}
+
 // runSingleTest runs tests for a single object.
 func (t *TestRunner) runSingleTest(ctx context.Context, obj ObjectRef) TestResult {
 	result := TestResult{
 		Object: obj,
 	}
 
-	if t.onStart != nil {
-		t.onStart(obj)
-	}
-
 	startTime := time.Now()
 
 	// Build object URL
@@ -240,6 +337,7 @@ func (t *TestRunner) runSingleTest(ctx context.Context, obj ObjectRef) TestResul
 		Short:     t.config.Short,
 		Medium:    t.config.Medium,
 		Long:      t.config.Long,
+		Coverage:  t.config.Coverage,
 	}
 
 	// Run the tests
@@ -253,6 +351,8 @@ func (t *TestRunner) runSingleTest(ctx context.Context, obj ObjectRef) TestResul
 	}
 
 	result.ExecutionTime = time.Since(startTime)
+	result.Coverage = testResult.Coverage
+	result.CoverageError = testResult.CoverageError
 
 	// Parse results
 	result.Success = true
@@ -266,7 +366,7 @@ func (t *TestRunner) runSingleTest(ctx context.Context, obj ObjectRef) TestResul
 			methodResult := TestMethodResult{
 				Name:          method.Name,
 				Success:       len(method.Alerts) == 0,
-				ExecutionTime: time.Duration(method.ExecutionTime) * time.Microsecond,
+				ExecutionTime: time.Duration(method.ExecutionTime * float64(time.Second)),
 			}
 
 			result.TotalTests++
@@ -287,10 +387,6 @@ func (t *TestRunner) runSingleTest(ctx context.Context, obj ObjectRef) TestResul
 		result.Classes = append(result.Classes, classResult)
 	}
 
-	if t.onComplete != nil {
-		t.onComplete(obj, result)
-	}
-
 	return result
 }
 
@@ -333,6 +429,8 @@ func (t *TestRunner) buildObjectURL(obj ObjectRef) string {
 		return fmt.Sprintf("/sap/bc/adt/programs/programs/%s", name)
 	case TypeInterface, "INTF/OI":
 		return fmt.Sprintf("/sap/bc/adt/oo/interfaces/%s", name)
+	case TypeFuncGroup, "FUGR/F":
+		return fmt.Sprintf("/sap/bc/adt/functions/groups/%s", name)
 	case TypeFunction:
 		return fmt.Sprintf("/sap/bc/adt/functions/groups/%s/fmodules/%s", obj.Package, name)
 	default:
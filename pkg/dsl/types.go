@@ -4,6 +4,9 @@ package dsl
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/oisee/vibing-steampunk/pkg/adt"
@@ -62,6 +65,9 @@ type TestConfig struct {
 	StopOnFirstFailure bool `json:"stopOnFirstFailure" yaml:"stopOnFirstFailure"`
 	Parallel           int  `json:"parallel" yaml:"parallel"` // Number of parallel executions
 	Timeout            time.Duration `json:"timeout" yaml:"timeout"`
+	Coverage           bool          `json:"coverage" yaml:"coverage"` // Measure coverage
+	RerunFailures      int           `json:"rerunFailures" yaml:"rerunFailures"` // Extra attempts for failed objects
+	HashSources        bool          `json:"hashSources" yaml:"hashSources"`     // Record a hash of the tested sources
 }
 
 // DefaultTestConfig returns sensible defaults for test execution.
@@ -83,12 +89,16 @@ func DefaultTestConfig() TestConfig {
 type TestResult struct {
 	Object        ObjectRef        `json:"object"`
 	Success       bool             `json:"success"`
-	TotalTests    int              `json:"totalTests"`
-	PassedTests   int              `json:"passedTests"`
-	FailedTests   int              `json:"failedTests"`
-	SkippedTests  int              `json:"skippedTests"`
+	TotalTests    int              `json:"totalTests" expr:"total"`
+	PassedTests   int              `json:"passedTests" expr:"passed"`
+	FailedTests   int              `json:"failedTests" expr:"failed"`
+	SkippedTests  int              `json:"skippedTests" expr:"skipped"`
 	ExecutionTime time.Duration    `json:"executionTime"`
 	Classes       []TestClassResult `json:"classes,omitempty"`
+	Coverage      *adt.CoverageResult `json:"coverage,omitempty"` // Set when run with coverage
+	CoverageError string           `json:"coverageError,omitempty"` // Why coverage is missing from a coverage run
+	SourceHash    string           `json:"sourceHash,omitempty"` // Source hash, or "changed:<timestamp>" without HashSources
+	Retries       []TestResult     `json:"retries,omitempty"`    // Earlier failed attempts, oldest first
 	Error         string           `json:"error,omitempty"`
 }
 
@@ -113,10 +123,10 @@ type TestSummary struct {
 	TestedObjects  int           `json:"testedObjects"`
 	PassedObjects  int           `json:"passedObjects"`
 	FailedObjects  int           `json:"failedObjects"`
-	TotalTests     int           `json:"totalTests"`
-	PassedTests    int           `json:"passedTests"`
-	FailedTests    int           `json:"failedTests"`
-	SkippedTests   int           `json:"skippedTests"`
+	TotalTests     int           `json:"totalTests" expr:"total"`
+	PassedTests    int           `json:"passedTests" expr:"passed"`
+	FailedTests    int           `json:"failedTests" expr:"failed"`
+	SkippedTests   int           `json:"skippedTests" expr:"skipped"`
 	TotalTime      time.Duration `json:"totalTime"`
 	Results        []TestResult  `json:"results"`
 }
@@ -153,6 +163,8 @@ type Pipeline struct {
 	Description string            `json:"description,omitempty" yaml:"description,omitempty"`
 	Stages      []Stage           `json:"stages" yaml:"stages"`
 	Variables   map[string]string `json:"variables,omitempty" yaml:"variables,omitempty"`
+
+	client *adt.Client // Set by PipelineBuilder.Build, used by Run
 }
 
 // Stage represents a pipeline stage.
@@ -174,13 +186,22 @@ type Step struct {
 }
 
 // ExecutionContext holds state during workflow execution.
+// It is shared by steps running in parallel.
 type ExecutionContext struct {
-	ctx       context.Context
-	client    *adt.Client
-	variables map[string]interface{}
-	results   map[string]interface{}
-	dryRun    bool
-	verbose   bool
+	ctx         context.Context
+	client      *adt.Client
+	mu          *sync.RWMutex // Shared with step contexts
+	variables   map[string]interface{}
+	results     map[string]interface{}
+	steps       map[string]interface{} // Step status by id and name, for expressions
+	dryRun      bool
+	verbose     bool
+	maxParallel int
+	statePath   string       // Workflow state file, written after every step
+	resume      *resumePoint // Run to continue, set by WithResume
+	tools       ToolCaller   // Tools for the "tool" action
+	baseDir     string       // Directory of the workflow file, for relative paths
+	callStack   []string     // Workflow files being run, outermost first
 }
 
 // NewExecutionContext creates a new execution context.
@@ -188,29 +209,58 @@ func NewExecutionContext(ctx context.Context, client *adt.Client) *ExecutionCont
 	return &ExecutionContext{
 		ctx:       ctx,
 		client:    client,
+		mu:        &sync.RWMutex{},
 		variables: make(map[string]interface{}),
 		results:   make(map[string]interface{}),
+		steps:     make(map[string]interface{}),
+	}
+}
+
+// WithContext returns a copy of the execution context that uses ctx
+// (e.g. a step timeout) and shares variables and results with ec.
+func (ec *ExecutionContext) WithContext(ctx context.Context) *ExecutionContext {
+	return &ExecutionContext{
+		ctx:         ctx,
+		client:      ec.client,
+		mu:          ec.mu,
+		variables:   ec.variables,
+		results:     ec.results,
+		steps:       ec.steps,
+		dryRun:      ec.dryRun,
+		verbose:     ec.verbose,
+		maxParallel: ec.maxParallel,
+		tools:       ec.tools,
+		baseDir:     ec.baseDir,
+		callStack:   ec.callStack,
 	}
 }
 
 // Set stores a value in the context.
 func (ec *ExecutionContext) Set(key string, value interface{}) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
 	ec.results[key] = value
 }
 
 // Get retrieves a value from the context.
 func (ec *ExecutionContext) Get(key string) (interface{}, bool) {
+	ec.mu.RLock()
+	defer ec.mu.RUnlock()
 	v, ok := ec.results[key]
 	return v, ok
 }
 
 // SetVariable sets a variable.
 func (ec *ExecutionContext) SetVariable(key, value string) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
 	ec.variables[key] = value
 }
 
 // GetVariable gets a variable.
 func (ec *ExecutionContext) GetVariable(key string) string {
+	ec.mu.RLock()
+	defer ec.mu.RUnlock()
 	if v, ok := ec.variables[key]; ok {
 		return v.(string)
 	}
@@ -227,6 +277,15 @@ func (ec *ExecutionContext) Client() *adt.Client {
 	return ec.client
 }
 
+// resolvePath resolves a relative path against the directory of the
+// workflow file being run.
+func (ec *ExecutionContext) resolvePath(path string) string {
+	if filepath.IsAbs(path) || ec.baseDir == "" {
+		return path
+	}
+	return filepath.Join(ec.baseDir, path)
+}
+
 // SetDryRun enables dry-run mode.
 func (ec *ExecutionContext) SetDryRun(dryRun bool) {
 	ec.dryRun = dryRun
@@ -246,3 +305,78 @@ func (ec *ExecutionContext) SetVerbose(verbose bool) {
 func (ec *ExecutionContext) IsVerbose() bool {
 	return ec.verbose
 }
+
+// SetMaxParallel limits how many workflow steps run at the same time.
+func (ec *ExecutionContext) SetMaxParallel(n int) {
+	ec.maxParallel = n
+}
+
+// MaxParallel returns the parallel step limit (0 = workflow default).
+func (ec *ExecutionContext) MaxParallel() int {
+	return ec.maxParallel
+}
+
+// Lookup resolves a name in expressions: saved step outputs first, then
+// workflow variables. "steps" holds the status of finished steps, and
+// ${NAME} also falls back to the environment, like parameter expansion.
+func (ec *ExecutionContext) Lookup(name string) (interface{}, bool) {
+	ec.mu.RLock()
+	defer ec.mu.RUnlock()
+	if ref := templateRef.FindStringSubmatch(name); ref != nil && ref[0] == name {
+		if v, ok := ec.results[ref[1]]; ok {
+			return v, true
+		}
+		if v, ok := ec.variables[ref[1]]; ok && v != "" {
+			return v, true
+		}
+		return os.LookupEnv(ref[1])
+	}
+	if name == "steps" {
+		steps := make(map[string]interface{}, len(ec.steps))
+		for k, v := range ec.steps {
+			steps[k] = v
+		}
+		return steps, true
+	}
+	if v, ok := ec.results[name]; ok {
+		return v, true
+	}
+	v, ok := ec.variables[name]
+	return v, ok
+}
+
+// Evaluate compiles and evaluates a workflow expression in this context.
+func (ec *ExecutionContext) Evaluate(expr string) (interface{}, error) {
+	x, err := CompileExpression(expr)
+	if err != nil {
+		return nil, err
+	}
+	return x.Eval(ec)
+}
+
+// setStepResult records the outcome of a step for the "steps" expression variable.
+func (ec *ExecutionContext) setStepResult(keys []string, r StepResult) {
+	status := "success"
+	switch {
+	case r.Skipped:
+		status = "skipped"
+	case !r.Success:
+		status = "failed"
+	}
+	entry := map[string]interface{}{
+		"status":   status,
+		"success":  r.Success,
+		"skipped":  r.Skipped,
+		"error":    r.Error,
+		"output":   r.Output,
+		"attempts": r.Attempts,
+	}
+
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	for _, key := range keys {
+		if key != "" {
+			ec.steps[key] = entry
+		}
+	}
+}
@@ -0,0 +1,532 @@
+package dsl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+// DefaultWatchDebounce is how long the watcher waits after the last save before
+// processing a batch.
+const DefaultWatchDebounce = 300 * time.Millisecond
+
+// WatchMessage is a syntax, activation or test message located in a local file.
+type WatchMessage struct {
+	Path     string `json:"path"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Severity string `json:"severity"` // E=Error, W=Warning, I=Info
+	Text     string `json:"text"`
+}
+
+// String formats the message as path:line:column: severity: text.
+func (m WatchMessage) String() string {
+	loc := m.Path
+	if m.Line > 0 {
+		loc += ":" + strconv.Itoa(m.Line)
+		if m.Column > 0 {
+			loc += ":" + strconv.Itoa(m.Column)
+		}
+	}
+	return fmt.Sprintf("%s: %s: %s", loc, severityName(m.Severity), m.Text)
+}
+
+// WatchFileResult is the outcome of writing one saved file.
+type WatchFileResult struct {
+	Path       string         `json:"path"`
+	ObjectType string         `json:"objectType,omitempty"`
+	ObjectName string         `json:"objectName,omitempty"`
+	Include    string         `json:"include,omitempty"`
+	ObjectURL  string         `json:"objectUrl,omitempty"`
+	Action     string         `json:"action"` // created, written, failed
+	Messages   []WatchMessage `json:"messages,omitempty"`
+	Error      string         `json:"error,omitempty"`
+
+	file   *ImportFile
+	source string
+}
+
+// WatchBatch is the result of processing the files saved within one debounce window.
+type WatchBatch struct {
+	Files     []WatchFileResult `json:"files"`
+	Activated bool              `json:"activated"`
+	Tests     []TestResult      `json:"tests,omitempty"`
+	Errors    int               `json:"errors"`
+	Duration  time.Duration     `json:"duration"`
+}
+
+// Messages returns the messages of all files in the batch.
+func (b *WatchBatch) Messages() []WatchMessage {
+	var msgs []WatchMessage
+	for _, f := range b.Files {
+		msgs = append(msgs, f.Messages...)
+	}
+	return msgs
+}
+
+// WatchBuilder watches a local folder in abapGit layout and writes saved files
+// to the system.
+type WatchBuilder struct {
+	client      *adt.Client
+	dir         string
+	packageName string
+	transport   string
+	activate    bool
+	runTests    bool
+	debounce    time.Duration
+
+	// Objects known to exist, so new ones are created instead of written
+	exists map[string]bool
+
+	onChange func(paths []string)
+	onBatch  func(batch *WatchBatch)
+	onError  func(err error)
+}
+
+// Watch creates a watcher for a local folder. New objects are created in packageName.
+func Watch(client *adt.Client, dir, packageName string) *WatchBuilder {
+	return &WatchBuilder{
+		client:      client,
+		dir:         dir,
+		packageName: strings.ToUpper(packageName),
+		debounce:    DefaultWatchDebounce,
+		exists:      make(map[string]bool),
+	}
+}
+
+// WithTransport sets the transport request for writes.
+func (w *WatchBuilder) WithTransport(transport string) *WatchBuilder {
+	w.transport = transport
+	return w
+}
+
+// Activate activates the saved objects after a clean syntax check.
+func (w *WatchBuilder) Activate() *WatchBuilder {
+	w.activate = true
+	return w
+}
+
+// RunTests runs the unit tests of the saved classes and programs after activation.
+// Implies Activate.
+func (w *WatchBuilder) RunTests() *WatchBuilder {
+	w.activate = true
+	w.runTests = true
+	return w
+}
+
+// Debounce sets how long to wait after the last save before processing.
+func (w *WatchBuilder) Debounce(d time.Duration) *WatchBuilder {
+	w.debounce = d
+	return w
+}
+
+// OnChange sets a callback invoked when a batch of saved files is about to be processed.
+func (w *WatchBuilder) OnChange(fn func(paths []string)) *WatchBuilder {
+	w.onChange = fn
+	return w
+}
+
+// OnBatch sets a callback invoked after each processed batch.
+func (w *WatchBuilder) OnBatch(fn func(batch *WatchBatch)) *WatchBuilder {
+	w.onBatch = fn
+	return w
+}
+
+// OnError sets a callback for watcher errors.
+func (w *WatchBuilder) OnError(fn func(err error)) *WatchBuilder {
+	w.onError = fn
+	return w
+}
+
+// Run watches the folder until ctx is cancelled. Saves that happen within the
+// debounce window are processed together.
+func (w *WatchBuilder) Run(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("creating watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	if err := w.addDirs(watcher, w.dir); err != nil {
+		return err
+	}
+
+	pending := make(map[string]bool)
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := w.addDirs(watcher, event.Name); err != nil {
+						w.reportError(err)
+					}
+					continue
+				}
+			}
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+				continue
+			}
+			if !isABAPSourceFile(event.Name) {
+				continue
+			}
+			pending[event.Name] = true
+			timer.Reset(w.debounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			w.reportError(err)
+
+		case <-timer.C:
+			paths := make([]string, 0, len(pending))
+			for path := range pending {
+				paths = append(paths, path)
+			}
+			pending = make(map[string]bool)
+
+			if w.onChange != nil {
+				w.onChange(paths)
+			}
+			batch := w.Process(ctx, paths)
+			if w.onBatch != nil {
+				w.onBatch(batch)
+			}
+		}
+	}
+}
+
+// addDirs watches dir and its subdirectories, skipping hidden ones.
+func (w *WatchBuilder) addDirs(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if path != w.dir && strings.HasPrefix(info.Name(), ".") {
+			return filepath.SkipDir // .git, .vsp, ...
+		}
+		if err := watcher.Add(path); err != nil {
+			return fmt.Errorf("watching %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+func (w *WatchBuilder) reportError(err error) {
+	if w.onError != nil {
+		w.onError(err)
+	}
+}
+
+// Process writes a batch of saved files in dependency order, syntax checks them
+// and, if enabled, activates them together and runs their unit tests.
+func (w *WatchBuilder) Process(ctx context.Context, paths []string) *WatchBatch {
+	start := time.Now()
+	batch := &WatchBatch{}
+
+	for _, path := range paths {
+		rel, err := filepath.Rel(w.dir, path)
+		if err != nil {
+			rel = path
+		}
+		f := WatchFileResult{Path: filepath.ToSlash(rel)}
+
+		source, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue // deleted or renamed after saving
+			}
+			f.Action, f.Error = "failed", err.Error()
+			batch.Files = append(batch.Files, f)
+			continue
+		}
+		f.source = string(source)
+
+		file, err := ParseImportFile(path)
+		if err != nil {
+			f.Action, f.Error = "failed", err.Error()
+			batch.Files = append(batch.Files, f)
+			continue
+		}
+		f.file = file
+		f.ObjectType = syncType(string(file.ObjectType))
+		f.ObjectName = strings.ToUpper(file.ObjectName)
+		if file.IncludeType != adt.ClassIncludeMain {
+			f.Include = string(file.IncludeType)
+		}
+		batch.Files = append(batch.Files, f)
+	}
+	sortWatchFiles(batch.Files)
+
+	for i := range batch.Files {
+		if batch.Files[i].file != nil {
+			w.write(ctx, &batch.Files[i])
+		}
+	}
+	for i := range batch.Files {
+		if batch.Files[i].Action != "failed" {
+			w.syntaxCheck(ctx, batch, &batch.Files[i])
+		}
+	}
+
+	batch.Errors = countWatchErrors(batch)
+	if w.activate && batch.Errors == 0 {
+		w.activateBatch(ctx, batch)
+		batch.Errors = countWatchErrors(batch)
+	}
+	if w.runTests && batch.Activated {
+		w.testBatch(ctx, batch)
+	}
+
+	batch.Duration = time.Since(start)
+	return batch
+}
+
+// sortWatchFiles orders files for writing: interfaces before classes, main
+// sources before their includes, then by path.
+func sortWatchFiles(files []WatchFileResult) {
+	sort.SliceStable(files, func(i, j int) bool {
+		pi, pj := 1000, 1000
+		if files[i].file != nil {
+			pi = files[i].file.Priority
+		}
+		if files[j].file != nil {
+			pj = files[j].file.Priority
+		}
+		if pi != pj {
+			return pi < pj
+		}
+		return files[i].Path < files[j].Path
+	})
+}
+
+// write creates the object if it does not exist yet, otherwise writes an
+// inactive version.
+func (w *WatchBuilder) write(ctx context.Context, f *WatchFileResult) {
+	var result *adt.DeployResult
+	var err error
+	if w.objectExists(ctx, f) {
+		result, err = w.client.WriteFromFile(ctx, f.file.Path, w.transport)
+		f.Action = "written"
+	} else {
+		result, err = w.client.DeployFromFile(ctx, f.file.Path, w.packageName, w.transport)
+		f.Action = "created"
+	}
+	if err != nil {
+		f.Action, f.Error = "failed", err.Error()
+		return
+	}
+	f.ObjectURL = result.ObjectURL
+	if !result.Success {
+		f.Action, f.Error = "failed", result.Message
+		for _, text := range result.SyntaxErrors {
+			f.Messages = append(f.Messages, WatchMessage{Path: f.Path, Severity: "E", Text: text})
+		}
+		return
+	}
+	if result.Created {
+		w.exists[syncKey(f.ObjectType, f.ObjectName, "")] = true
+	}
+}
+
+// objectExists reports whether the object of a file exists on the system.
+// Objects found once are not looked up again. If the lookup fails for another
+// reason, the object is assumed to exist for this save only, so the write
+// reports the actual error and the next save looks it up again.
+func (w *WatchBuilder) objectExists(ctx context.Context, f *WatchFileResult) bool {
+	key := syncKey(f.ObjectType, f.ObjectName, "")
+	if exists, ok := w.exists[key]; ok {
+		return exists
+	}
+	_, err := w.client.GetSource(ctx, f.ObjectType, f.ObjectName, nil)
+	switch {
+	case err == nil:
+		w.exists[key] = true
+		return true
+	case adt.IsNotFoundError(err):
+		return false // not cached: DeployFromFile marks it once created
+	default:
+		return true
+	}
+}
+
+// syntaxCheck checks the saved source and records its messages.
+func (w *WatchBuilder) syntaxCheck(ctx context.Context, batch *WatchBatch, f *WatchFileResult) {
+	checkURL := f.ObjectURL
+	if f.Include != "" {
+		checkURL += "/includes/" + f.Include
+	}
+	results, err := w.client.SyntaxCheck(ctx, checkURL, f.source)
+	if err != nil {
+		f.Error = err.Error()
+		return
+	}
+	for _, r := range results {
+		path := batch.locate(r.URI, f.Path)
+		f.Messages = append(f.Messages, WatchMessage{
+			Path:     path,
+			Line:     r.Line,
+			Column:   r.Offset,
+			Severity: r.Severity,
+			Text:     r.Text,
+		})
+	}
+}
+
+// activateBatch activates all written objects in one request, so objects that
+// depend on each other are activated together.
+func (w *WatchBuilder) activateBatch(ctx context.Context, batch *WatchBatch) {
+	var refs []adt.ObjectReference
+	seen := make(map[string]bool)
+	for _, f := range batch.Files {
+		if f.Action != "written" || seen[f.ObjectURL] {
+			continue // created objects are already active
+		}
+		seen[f.ObjectURL] = true
+		refs = append(refs, adt.ObjectReference{URI: f.ObjectURL, Name: f.ObjectName})
+	}
+	if len(refs) == 0 {
+		batch.Activated = true
+		return
+	}
+
+	result, err := w.client.ActivateObjects(ctx, refs)
+	if err != nil {
+		for i := range batch.Files {
+			if batch.Files[i].Action == "written" {
+				batch.Files[i].Error = fmt.Sprintf("activation failed: %v", err)
+			}
+		}
+		return
+	}
+	batch.Activated = result.Success
+
+	for _, msg := range result.Messages {
+		m := WatchMessage{Line: msg.Line, Severity: msg.Type, Text: msg.ShortText}
+		if loc := watchHrefLocation.FindStringSubmatch(msg.Href); loc != nil {
+			m.Line, _ = strconv.Atoi(loc[1])
+			m.Column, _ = strconv.Atoi(loc[2])
+		}
+		idx := -1
+		m.Path = batch.locate(msg.Href, "")
+		for i, f := range batch.Files {
+			if (m.Path == "" && f.Action == "written") || (m.Path != "" && f.Path == m.Path) {
+				idx = i
+				break
+			}
+		}
+		m.Path = batch.Files[idx].Path
+		batch.Files[idx].Messages = append(batch.Files[idx].Messages, m)
+	}
+}
+
+// testBatch runs the unit tests of the classes and programs in the batch.
+func (w *WatchBuilder) testBatch(ctx context.Context, batch *WatchBatch) {
+	runner := Test(w.client)
+	seen := make(map[string]bool)
+	for _, f := range batch.Files {
+		if f.ObjectType != TypeClass && f.ObjectType != TypeProgram {
+			continue
+		}
+		key := syncKey(f.ObjectType, f.ObjectName, "")
+		if f.Action == "failed" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		runner.Object(f.ObjectType, f.ObjectName)
+	}
+	if len(seen) == 0 {
+		return
+	}
+
+	summary, err := runner.Run(ctx)
+	if err != nil {
+		batch.Errors++
+		return
+	}
+	batch.Tests = summary.Results
+	for _, r := range summary.Results {
+		if !r.Success {
+			batch.Errors++
+		}
+	}
+}
+
+var (
+	watchHrefLocation    = regexp.MustCompile(`#start=(\d+),(\d+)`)
+	watchIncludeFragment = regexp.MustCompile(`/includes/([a-z_]+)`)
+)
+
+// locate returns the local file for an ADT source URI, or fallback if it is
+// not part of the batch.
+func (b *WatchBatch) locate(uri, fallback string) string {
+	uri = strings.ToLower(uri)
+	uri, _, _ = strings.Cut(uri, "#")
+	include := ""
+	if m := watchIncludeFragment.FindStringSubmatch(uri); m != nil {
+		include = m[1]
+		uri = uri[:strings.Index(uri, "/includes/")]
+	}
+	uri = strings.TrimSuffix(uri, "/source/main")
+
+	for _, f := range b.Files {
+		if f.ObjectURL == "" || strings.ToLower(f.ObjectURL) != uri {
+			continue
+		}
+		if f.Include == include {
+			return f.Path
+		}
+	}
+	return fallback
+}
+
+func countWatchErrors(batch *WatchBatch) int {
+	count := 0
+	for _, f := range batch.Files {
+		if f.Error != "" {
+			count++
+			continue
+		}
+		for _, m := range f.Messages {
+			if m.Severity == "E" {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func severityName(severity string) string {
+	switch severity {
+	case "E":
+		return "error"
+	case "W":
+		return "warning"
+	case "I":
+		return "info"
+	default:
+		return strings.ToLower(severity)
+	}
+}
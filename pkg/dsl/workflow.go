@@ -2,9 +2,12 @@ package dsl
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -17,17 +20,35 @@ type Workflow struct {
 	Name        string            `yaml:"name"`
 	Description string            `yaml:"description,omitempty"`
 	Variables   map[string]string `yaml:"variables,omitempty"`
+	MaxParallel int               `yaml:"maxParallel,omitempty"` // Steps run at the same time (default: 4)
+	Matrix      map[string][]string `yaml:"matrix,omitempty"`    // Run once per combination (see ExecuteMatrix)
 	Steps       []WorkflowStep    `yaml:"steps"`
+
+	path string // Absolute path when loaded with LoadWorkflow, recorded in state files
 }
 
 // WorkflowStep represents a single step in a workflow.
 type WorkflowStep struct {
+	ID         string                 `yaml:"id,omitempty"`        // Referenced by dependsOn
 	Name       string                 `yaml:"name,omitempty"`
+	DependsOn  []string               `yaml:"dependsOn,omitempty"` // Step ids (or names) to wait for
+	Parallel   bool                   `yaml:"parallel,omitempty"`  // Run alongside adjacent parallel steps
 	Action     string                 `yaml:"action"`
 	Parameters map[string]interface{} `yaml:"parameters,omitempty"`
+	Tool       string                 `yaml:"tool,omitempty"`      // Tool called by action: tool
+	Arguments  map[string]interface{} `yaml:"arguments,omitempty"` // Tool arguments
 	SaveAs     string                 `yaml:"saveAs,omitempty"`
 	Condition  string                 `yaml:"condition,omitempty"`
 	OnFailure  string                 `yaml:"onFailure,omitempty"` // continue, fail, skip
+	Timeout    string                 `yaml:"timeout,omitempty"`   // Per-attempt limit, e.g. "5m"
+	Retry      *RetryPolicy           `yaml:"retry,omitempty"`
+}
+
+// RetryPolicy re-runs a failed step. OnFailure applies once all attempts failed.
+type RetryPolicy struct {
+	Attempts int      `yaml:"attempts,omitempty"` // Total attempts including the first (default: 3)
+	Backoff  string   `yaml:"backoff,omitempty"`  // Delay before the first retry, doubled each time (default: 2s)
+	On       []string `yaml:"on,omitempty"`       // Error classes to retry: timeout, lock_conflict, 5xx (default: any error)
 }
 
 // WorkflowResult represents the result of a workflow execution.
@@ -48,12 +69,15 @@ type StepResult struct {
 	Error      string      `json:"error,omitempty"`
 	Skipped    bool        `json:"skipped,omitempty"`
 	SkipReason string      `json:"skipReason,omitempty"`
+	Attempts   int         `json:"attempts,omitempty"` // Set when the step has a retry policy
+	Resumed    bool        `json:"resumed,omitempty"`  // Result carried over from a resumed run
 }
 
 // WorkflowEngine executes YAML-defined workflows.
 type WorkflowEngine struct {
 	client   *adt.Client
 	handlers map[string]ActionHandler
+	specs    map[string]ActionSpec // Parameters of the actions, for CheckWorkflow
 }
 
 // ActionHandler is a function that handles a workflow action.
@@ -64,6 +88,10 @@ func NewWorkflowEngine(client *adt.Client) *WorkflowEngine {
 	engine := &WorkflowEngine{
 		client:   client,
 		handlers: make(map[string]ActionHandler),
+		specs:    make(map[string]ActionSpec, len(builtinActionSpecs)),
+	}
+	for action, spec := range builtinActionSpecs {
+		engine.specs[action] = spec
 	}
 
 	// Register built-in handlers
@@ -76,6 +104,23 @@ func NewWorkflowEngine(client *adt.Client) *WorkflowEngine {
 	engine.RegisterHandler("print", handlePrint)
 	engine.RegisterHandler("fail_if", handleFailIf)
 	engine.RegisterHandler("foreach", handleForEach)
+	engine.RegisterHandler("tool", handleTool)
+	engine.RegisterHandler("lua", handleLua)
+	engine.RegisterHandler("call", engine.handleCall)
+	engine.RegisterHandler("include", engine.handleCall)
+
+	// Pipeline stage actions
+	engine.RegisterHandler("import", handleImport)
+	engine.RegisterHandler("import_files", handleImportFiles)
+	engine.RegisterHandler("export", handleExport)
+	engine.RegisterHandler("export_classes", handleExportClasses)
+	engine.RegisterHandler("create", handleCreate)
+	engine.RegisterHandler("write_source", handleWriteSource)
+	engine.RegisterHandler("activate_object", handleActivateObject)
+	engine.RegisterHandler("publish", handlePublish)
+	engine.RegisterHandler("unpublish", handleUnpublish)
+	engine.RegisterHandler("query", handleQuery)
+	engine.RegisterHandler("set_var", handleSetVar)
 
 	return engine
 }
@@ -92,7 +137,14 @@ func (e *WorkflowEngine) LoadWorkflow(path string) (*Workflow, error) {
 		return nil, fmt.Errorf("reading workflow file: %w", err)
 	}
 
-	return e.ParseWorkflow(data)
+	workflow, err := e.ParseWorkflow(data)
+	if err != nil {
+		return nil, err
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		workflow.path = abs
+	}
+	return workflow, nil
 }
 
 // ParseWorkflow parses a workflow from YAML data.
@@ -101,6 +153,9 @@ func (e *WorkflowEngine) ParseWorkflow(data []byte) (*Workflow, error) {
 	if err := yaml.Unmarshal(data, &workflow); err != nil {
 		return nil, fmt.Errorf("parsing workflow: %w", err)
 	}
+	if err := workflow.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid workflow: %w", err)
+	}
 
 	return &workflow, nil
 }
@@ -118,6 +173,10 @@ func (e *WorkflowEngine) Execute(ctx context.Context, workflow *Workflow, opts .
 	for _, opt := range opts {
 		opt(execCtx)
 	}
+	if workflow.path != "" {
+		execCtx.baseDir = filepath.Dir(workflow.path)
+		execCtx.callStack = append(execCtx.callStack[:len(execCtx.callStack):len(execCtx.callStack)], workflow.path)
+	}
 
 	result := &WorkflowResult{
 		Name:        workflow.Name,
@@ -126,80 +185,207 @@ func (e *WorkflowEngine) Execute(ctx context.Context, workflow *Workflow, opts .
 		Variables:   make(map[string]interface{}),
 	}
 
-	// Execute steps
-	for i, step := range workflow.Steps {
-		stepName := step.Name
-		if stepName == "" {
-			stepName = fmt.Sprintf("step_%d_%s", i+1, step.Action)
+	if err := workflow.Validate(); err != nil {
+		result.Success = false
+		result.Error = fmt.Sprintf("invalid workflow: %s", err)
+		return result, nil
+	}
+	deps, _ := workflow.plan()
+
+	limit := execCtx.MaxParallel()
+	if limit <= 0 {
+		limit = workflow.MaxParallel
+	}
+	if limit <= 0 {
+		limit = DefaultMaxParallel
+	}
+
+	// Steps finished in the run being resumed are not run again
+	results := make([]*StepResult, len(workflow.Steps))
+	if execCtx.resume != nil {
+		restored, err := e.restoreState(execCtx, workflow, result)
+		if err != nil {
+			result.Success = false
+			result.Error = fmt.Sprintf("resume: %s", err)
+			return result, nil
+		}
+		for i, r := range restored {
+			r := r
+			results[i] = &r
 		}
+	}
 
-		stepResult := StepResult{
-			Name:   stepName,
-			Action: step.Action,
+	// Schedule steps as their dependencies complete. Once a step fails the
+	// workflow, no new steps are started; running ones are allowed to finish.
+	waiting := make([]int, len(workflow.Steps))
+	dependents := make([][]int, len(workflow.Steps))
+	var ready []int
+	for i := range workflow.Steps {
+		for _, j := range deps[i] {
+			dependents[j] = append(dependents[j], i)
+			if results[j] == nil {
+				waiting[i]++
+			}
+		}
+		if waiting[i] == 0 && results[i] == nil {
+			ready = append(ready, i)
 		}
+	}
 
-		// Check condition
-		if step.Condition != "" {
-			if !e.evaluateCondition(execCtx, step.Condition) {
-				stepResult.Skipped = true
-				stepResult.SkipReason = "condition not met"
-				stepResult.Success = true
-				result.StepResults = append(result.StepResults, stepResult)
-				continue
+	done := make(chan stepOutcome)
+	running := 0
+	for {
+		for result.Success && len(ready) > 0 && running < limit {
+			if err := ctx.Err(); err != nil {
+				result.Success = false
+				result.Error = fmt.Sprintf("workflow cancelled: %s", err)
+				break
 			}
+			i := ready[0]
+			ready = ready[1:]
+			running++
+			go func(i int) {
+				done <- e.executeStep(execCtx, i, workflow.Steps[i])
+			}(i)
+		}
+		if running == 0 {
+			break
 		}
 
-		// Get handler
-		handler, ok := e.handlers[step.Action]
-		if !ok {
-			stepResult.Success = false
-			stepResult.Error = fmt.Sprintf("unknown action: %s", step.Action)
-			result.StepResults = append(result.StepResults, stepResult)
+		outcome := <-done
+		running--
+		results[outcome.index] = &outcome.result
+		step := workflow.Steps[outcome.index]
+		execCtx.setStepResult([]string{step.ID, step.Name, outcome.result.Name}, outcome.result)
+		if outcome.fatal != "" && result.Success {
 			result.Success = false
-			result.Error = stepResult.Error
-			return result, nil
+			result.Error = outcome.fatal
+		}
+		if outcome.saveAs != "" {
+			result.Variables[outcome.saveAs] = outcome.result.Output
+		}
+		for _, j := range dependents[outcome.index] {
+			if waiting[j]--; waiting[j] == 0 {
+				ready = insertSorted(ready, j)
+			}
+		}
+		if execCtx.statePath != "" {
+			if err := e.saveState(execCtx, workflow, results, result); err != nil && result.Success {
+				result.Success = false
+				result.Error = fmt.Sprintf("saving workflow state: %s", err)
+			}
+		}
+	}
+
+	// Record the final status (e.g. cancellation) and resumed-only runs
+	if execCtx.statePath != "" {
+		if err := e.saveState(execCtx, workflow, results, result); err != nil && result.Success {
+			result.Success = false
+			result.Error = fmt.Sprintf("saving workflow state: %s", err)
+		}
+	}
+
+	// Report steps in workflow order
+	for _, r := range results {
+		if r != nil {
+			result.StepResults = append(result.StepResults, *r)
 		}
+	}
 
-		// Expand variables in parameters
-		params := e.expandParams(execCtx, step.Parameters)
+	return result, nil
+}
 
-		// Execute handler
-		output, err := handler(execCtx, params)
+// stepOutcome is the result of a step run by the scheduler.
+type stepOutcome struct {
+	index  int
+	result StepResult
+	saveAs string // Variable the output was saved to
+	fatal  string // Set when the failure stops the workflow
+}
+
+// executeStep runs a single step: condition, handler, failure mode and saveAs.
+func (e *WorkflowEngine) executeStep(execCtx *ExecutionContext, i int, step WorkflowStep) stepOutcome {
+	name := stepName(i, step)
+	outcome := stepOutcome{
+		index:  i,
+		result: StepResult{Name: name, Action: step.Action},
+	}
+	stepResult := &outcome.result
+
+	// Check condition
+	if step.Condition != "" {
+		met, err := e.checkCondition(execCtx, step.Condition)
 		if err != nil {
 			stepResult.Success = false
-			stepResult.Error = err.Error()
-
-			// Handle failure mode
-			switch step.OnFailure {
-			case "continue":
-				result.StepResults = append(result.StepResults, stepResult)
-				continue
-			case "skip":
-				stepResult.Skipped = true
-				stepResult.SkipReason = "skipped due to error"
-				result.StepResults = append(result.StepResults, stepResult)
-				continue
-			default: // "fail" or empty
-				result.StepResults = append(result.StepResults, stepResult)
-				result.Success = false
-				result.Error = fmt.Sprintf("step '%s' failed: %s", stepName, err)
-				return result, nil
-			}
+			stepResult.Error = fmt.Sprintf("condition: %s", err)
+			outcome.fatal = fmt.Sprintf("step '%s' failed: %s", name, stepResult.Error)
+			return outcome
 		}
+		if !met {
+			stepResult.Skipped = true
+			stepResult.SkipReason = "condition not met"
+			stepResult.Success = true
+			return outcome
+		}
+	}
+
+	// Get handler
+	handler, ok := e.handlers[step.Action]
+	if !ok {
+		stepResult.Success = false
+		stepResult.Error = fmt.Sprintf("unknown action: %s", step.Action)
+		outcome.fatal = stepResult.Error
+		return outcome
+	}
 
-		stepResult.Success = true
-		stepResult.Output = output
+	// Expand variables in parameters
+	params := e.expandParams(execCtx, toolParams(step))
+	for _, name := range rawParams[step.Action] {
+		if v, ok := step.Parameters[name]; ok {
+			params[name] = v
+		}
+	}
 
-		// Save result if requested
-		if step.SaveAs != "" {
-			execCtx.Set(step.SaveAs, output)
-			result.Variables[step.SaveAs] = output
+	// Execute handler (with timeout and retries)
+	output, attempts, err := e.runWithRetry(execCtx, step, handler, params)
+	if step.Retry != nil {
+		stepResult.Attempts = attempts
+	}
+	if err != nil {
+		stepResult.Success = false
+		stepResult.Error = err.Error()
+
+		// Handle failure mode
+		switch step.OnFailure {
+		case "continue":
+		case "skip":
+			stepResult.Skipped = true
+			stepResult.SkipReason = "skipped due to error"
+		default: // "fail" or empty
+			outcome.fatal = fmt.Sprintf("step '%s' failed: %s", name, err)
 		}
+		return outcome
+	}
 
-		result.StepResults = append(result.StepResults, stepResult)
+	stepResult.Success = true
+	stepResult.Output = output
+
+	// Save result if requested
+	if step.SaveAs != "" {
+		execCtx.Set(step.SaveAs, output)
+		outcome.saveAs = step.SaveAs
 	}
 
-	return result, nil
+	return outcome
+}
+
+// insertSorted inserts i into the sorted slice s, so ready steps start in workflow order.
+func insertSorted(s []int, i int) []int {
+	k := sort.SearchInts(s, i)
+	s = append(s, 0)
+	copy(s[k+1:], s[k:])
+	s[k] = i
+	return s
 }
 
 // ExecuteOption configures workflow execution.
@@ -219,6 +405,21 @@ func WithVerbose(verbose bool) ExecuteOption {
 	}
 }
 
+// WithMaxParallel limits how many steps run at the same time,
+// overriding the workflow's maxParallel.
+func WithMaxParallel(n int) ExecuteOption {
+	return func(ctx *ExecutionContext) {
+		ctx.SetMaxParallel(n)
+	}
+}
+
+// WithClient runs the workflow against client instead of the engine's client.
+func WithClient(client *adt.Client) ExecuteOption {
+	return func(ctx *ExecutionContext) {
+		ctx.client = client
+	}
+}
+
 // WithVariables sets additional variables.
 func WithVariables(vars map[string]string) ExecuteOption {
 	return func(ctx *ExecutionContext) {
@@ -281,11 +482,46 @@ func (e *WorkflowEngine) expandValue(ctx *ExecutionContext, v interface{}) inter
 	}
 }
 
-// evaluateCondition evaluates a simple condition.
-func (e *WorkflowEngine) evaluateCondition(ctx *ExecutionContext, condition string) bool {
-	// Simple conditions: "exists:varName", "empty:varName", "true", "false"
+// legacyCondition matches the prefix conditions that predate expressions
+// ("exists:var", "tests_failed:var", ...).
+var legacyCondition = regexp.MustCompile(`^(exists|empty|not_empty|tests_failed|syntax_errors):\S+$`)
+
+// templateRef matches ${VAR} references: expanded as text in parameters,
+// read as values in expressions.
+var templateRef = regexp.MustCompile(`\$\{(\w+)\}`)
+
+// rawParams are the parameters whose ${VAR} references are never expanded as
+// text: expressions read them as values, and Lua scripts read vars.NAME.
+var rawParams = map[string][]string{
+	"fail_if": {"condition"},
+	"foreach": {"collection", "where"},
+	"lua":     {"script"},
+}
+
+// compileCondition compiles a condition or fail_if expression. Legacy prefix
+// conditions and empty strings compile to nil.
+func compileCondition(condition string) (*Expression, error) {
 	condition = strings.TrimSpace(condition)
+	if condition == "" || legacyCondition.MatchString(condition) {
+		return nil, nil
+	}
+	return CompileExpression(condition)
+}
 
+// checkCondition evaluates a step condition: a legacy prefix condition or an expression.
+func (e *WorkflowEngine) checkCondition(ctx *ExecutionContext, condition string) (bool, error) {
+	x, err := compileCondition(condition)
+	if err != nil {
+		return false, err
+	}
+	if x == nil {
+		return e.evaluateLegacyCondition(ctx, strings.TrimSpace(condition)), nil
+	}
+	return x.EvalBool(ctx)
+}
+
+// evaluateLegacyCondition evaluates "exists:varName", "empty:varName" and "not_empty:varName".
+func (e *WorkflowEngine) evaluateLegacyCondition(ctx *ExecutionContext, condition string) bool {
 	if strings.HasPrefix(condition, "exists:") {
 		varName := strings.TrimPrefix(condition, "exists:")
 		_, ok := ctx.Get(varName)
@@ -328,7 +564,7 @@ func (e *WorkflowEngine) evaluateCondition(ctx *ExecutionContext, condition stri
 		}
 	}
 
-	return condition == "true"
+	return false
 }
 
 // --- Built-in Action Handlers ---
@@ -423,6 +659,10 @@ func handleSyntaxCheck(ctx *ExecutionContext, params map[string]interface{}) (in
 
 	var results []map[string]interface{}
 	for _, obj := range objects {
+		if err := ctx.Context().Err(); err != nil {
+			return results, err
+		}
+
 		// Get source
 		var source string
 		var err error
@@ -510,6 +750,9 @@ func handleActivate(ctx *ExecutionContext, params map[string]interface{}) (inter
 
 	var results []map[string]interface{}
 	for _, obj := range objects {
+		if err := ctx.Context().Err(); err != nil {
+			return results, err
+		}
 		objectURL := buildObjectURL(obj)
 		result, err := ctx.Client().Activate(ctx.Context(), objectURL, obj.Name)
 		if err != nil {
@@ -520,6 +763,9 @@ func handleActivate(ctx *ExecutionContext, params map[string]interface{}) (inter
 			"success": result.Success,
 			"messages": result.Messages,
 		})
+		if !result.Success {
+			return results, &adt.ActivationError{Object: obj.Name, Messages: result.Messages}
+		}
 	}
 
 	return results, nil
@@ -568,24 +814,58 @@ func handleFailIf(ctx *ExecutionContext, params map[string]interface{}) (interfa
 		}
 	}
 
+	if condition != "" && !legacyCondition.MatchString(condition) {
+		failed, err := ctx.Evaluate(condition)
+		if err != nil {
+			return nil, err
+		}
+		if truthy(failed) {
+			if message == "" {
+				message = fmt.Sprintf("condition met: %s", condition)
+			}
+			return nil, errors.New(message)
+		}
+	}
+
 	return nil, nil
 }
 
 func handleForEach(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
-	// ForEach iterates over a collection
-	collectionVar, _ := params["collection"].(string)
-	if collectionVar == "" {
+	// ForEach evaluates a collection expression and optionally filters it with
+	// a "where" expression, which sees the current element as item (and index).
+	collection, _ := params["collection"].(string)
+	if collection == "" {
 		return nil, fmt.Errorf("foreach requires 'collection' parameter")
 	}
 
-	val, exists := ctx.Get(collectionVar)
-	if !exists {
-		return nil, fmt.Errorf("variable '%s' not found", collectionVar)
+	val, err := ctx.Evaluate(collection)
+	if err != nil {
+		return nil, err
+	}
+	if val == nil {
+		return nil, fmt.Errorf("collection '%s' not found", collection)
 	}
 
-	// The actual iteration would be handled by workflow expansion
-	// This is a placeholder for the concept
-	return val, nil
+	where, _ := params["where"].(string)
+	if where == "" {
+		return val, nil
+	}
+	filter, err := CompileExpression(where)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []interface{}{}
+	for i, item := range toList(val) {
+		keep, err := filter.EvalBool(&scopeEnv{parent: ctx, names: map[string]interface{}{"item": item, "index": float64(i)}})
+		if err != nil {
+			return nil, err
+		}
+		if keep {
+			items = append(items, item)
+		}
+	}
+	return items, nil
 }
 
 // buildObjectURL constructs the ADT URL for an object.
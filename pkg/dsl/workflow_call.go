@@ -0,0 +1,84 @@
+package dsl
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// MaxCallDepth limits how deeply workflows can call other workflows.
+const MaxCallDepth = 10
+
+// validateCallStep checks that a call or include step names its workflow.
+func validateCallStep(step WorkflowStep) error {
+	if step.Action != "call" && step.Action != "include" {
+		return nil
+	}
+	if file, _ := step.Parameters["workflow"].(string); file == "" {
+		return fmt.Errorf("%s action requires a workflow file", step.Action)
+	}
+	if vars, ok := step.Parameters["variables"]; ok {
+		if _, ok := vars.(map[string]interface{}); !ok {
+			return fmt.Errorf("%s variables must be a map", step.Action)
+		}
+	}
+	return nil
+}
+
+// handleCall runs another workflow file. The called workflow starts from its
+// own variables, overridden by the "variables" parameter; it inherits the
+// client, dry-run, verbose, parallelism and tools from the caller. Its saved
+// outputs are the step output.
+func (e *WorkflowEngine) handleCall(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+	file, _ := params["workflow"].(string)
+	if file == "" {
+		return nil, fmt.Errorf("call action requires 'workflow' parameter")
+	}
+	vars := make(map[string]string)
+	if v, ok := params["variables"].(map[string]interface{}); ok {
+		for k, val := range v {
+			vars[k] = toString(val)
+		}
+	} else if params["variables"] != nil {
+		return nil, fmt.Errorf("workflow variables must be a map")
+	}
+
+	path, err := filepath.Abs(ctx.resolvePath(file))
+	if err != nil {
+		return nil, err
+	}
+	for i, caller := range ctx.callStack {
+		if caller == path {
+			chain := make([]string, 0, len(ctx.callStack)-i+1)
+			for _, p := range append(ctx.callStack[i:], path) {
+				chain = append(chain, filepath.Base(p))
+			}
+			return nil, fmt.Errorf("workflow call cycle: %s", strings.Join(chain, " -> "))
+		}
+	}
+	if len(ctx.callStack) >= MaxCallDepth {
+		return nil, fmt.Errorf("workflow calls nested deeper than %d", MaxCallDepth)
+	}
+
+	workflow, err := e.LoadWorkflow(path)
+	if err != nil {
+		return nil, err
+	}
+
+	inherit := func(sub *ExecutionContext) {
+		sub.client = ctx.client
+		sub.dryRun = ctx.dryRun
+		sub.verbose = ctx.verbose
+		sub.maxParallel = ctx.maxParallel
+		sub.tools = ctx.tools
+		sub.callStack = ctx.callStack
+	}
+	result, err := e.Execute(ctx.Context(), workflow, inherit, WithVariables(vars))
+	if err != nil {
+		return nil, err
+	}
+	if !result.Success {
+		return result.Variables, fmt.Errorf("workflow '%s' failed: %s", workflow.Name, result.Error)
+	}
+	return result.Variables, nil
+}
@@ -0,0 +1,553 @@
+package dsl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ActionSpec describes the parameters of an action. It is used by
+// CheckWorkflow and for the workflow JSON Schema.
+type ActionSpec struct {
+	Description string
+	Params      map[string]ParamSpec
+}
+
+// ParamSpec describes an action parameter.
+type ParamSpec struct {
+	Type        string // JSON Schema type: string, integer, boolean, array or object; empty for any
+	Required    bool
+	Ref         bool // Names the saved output (saveAs) of an earlier step
+	Description string
+}
+
+// builtinActionSpecs describes the parameters of the built-in actions.
+var builtinActionSpecs = map[string]ActionSpec{
+	"search": {Description: "Find ABAP objects", Params: map[string]ParamSpec{
+		"query":      {Type: "string", Description: "Object name pattern (default: *)"},
+		"package":    {Type: "string", Description: "Only objects in this package"},
+		"packages":   {Type: "array", Description: "Only objects in these packages"},
+		"types":      {Type: "array", Description: "Object types, e.g. CLAS, PROG"},
+		"maxResults": {Type: "integer", Description: "Maximum results (default: 100)"},
+	}},
+	"test": {Description: "Run unit tests", Params: map[string]ParamSpec{
+		"objects":            {Type: "string", Ref: true, Description: "Saved list of objects to test"},
+		"class":              {Type: "string", Description: "Class to test"},
+		"package":            {Type: "string", Description: "Package to test"},
+		"dangerous":          {Type: "boolean", Description: "Include dangerous tests"},
+		"long":               {Type: "boolean", Description: "Include long tests"},
+		"stopOnFirstFailure": {Type: "boolean", Description: "Stop at the first failure"},
+	}},
+	"syntax_check": {Description: "Check the syntax of objects", Params: map[string]ParamSpec{
+		"objects": {Type: "string", Required: true, Ref: true, Description: "Saved list of objects to check"},
+	}},
+	"activate": {Description: "Activate objects", Params: map[string]ParamSpec{
+		"objects": {Type: "string", Required: true, Ref: true, Description: "Saved list of objects to activate"},
+	}},
+	"transform": {Description: "Transform sources (not implemented)", Params: map[string]ParamSpec{}},
+	"save":      {Description: "Save sources (not implemented)", Params: map[string]ParamSpec{}},
+	"print": {Description: "Print a message", Params: map[string]ParamSpec{
+		"message": {Type: "string"},
+	}},
+	"fail_if": {Description: "Fail the step when a condition is true", Params: map[string]ParamSpec{
+		"condition": {Type: "string", Required: true, Description: "Expression or legacy condition"},
+		"message":   {Type: "string", Description: "Error message"},
+	}},
+	"foreach": {Description: "Filter a collection", Params: map[string]ParamSpec{
+		"collection": {Type: "string", Required: true, Description: "Expression returning a list"},
+		"where":      {Type: "string", Description: "Filter expression over item and index"},
+	}},
+	"tool": {Description: "Call an MCP tool", Params: map[string]ParamSpec{
+		"tool":      {Type: "string", Description: "Tool name (or set tool on the step)"},
+		"arguments": {Type: "object", Description: "Tool arguments (or set arguments on the step)"},
+	}},
+	"lua": {Description: "Run a Lua script", Params: map[string]ParamSpec{
+		"script": {Type: "string", Description: "Inline script"},
+		"file":   {Type: "string", Description: "Script file, relative to the workflow"},
+	}},
+	"call":    {Description: "Run another workflow", Params: callParams},
+	"include": {Description: "Run another workflow (same as call)", Params: callParams},
+	"import": {Description: "Import a source directory", Params: map[string]ParamSpec{
+		"directory": {Type: "string", Required: true},
+		"package":   {Type: "string", Required: true},
+		"transport": {Type: "string"},
+	}},
+	"import_files": {Description: "Import source files", Params: map[string]ParamSpec{
+		"files":     {Type: "array", Required: true},
+		"package":   {Type: "string", Required: true},
+		"transport": {Type: "string"},
+	}},
+	"export": {Description: "Export objects to files", Params: map[string]ParamSpec{
+		"objects":   {Type: "string", Required: true, Ref: true, Description: "Saved list of objects to export"},
+		"outputDir": {Type: "string"},
+	}},
+	"export_classes": {Description: "Export classes to files", Params: map[string]ParamSpec{
+		"classes":   {Type: "array", Required: true},
+		"outputDir": {Type: "string"},
+	}},
+	"create": {Description: "Create an object", Params: map[string]ParamSpec{
+		"type":        {Type: "string", Required: true},
+		"name":        {Type: "string", Required: true},
+		"package":     {Type: "string", Required: true},
+		"description": {Type: "string"},
+	}},
+	"write_source": {Description: "Write the source of an object", Params: map[string]ParamSpec{
+		"type":   {Type: "string", Required: true},
+		"name":   {Type: "string", Required: true},
+		"source": {Type: "string", Required: true, Ref: true, Description: "Saved output holding the source"},
+	}},
+	"activate_object": {Description: "Activate an object", Params: map[string]ParamSpec{
+		"type": {Type: "string", Required: true},
+		"name": {Type: "string", Required: true},
+	}},
+	"publish":   {Description: "Publish a service binding", Params: bindingParams},
+	"unpublish": {Description: "Unpublish a service binding", Params: bindingParams},
+	"query": {Description: "Run a SQL query", Params: map[string]ParamSpec{
+		"sql":     {Type: "string", Required: true},
+		"maxRows": {Type: "integer", Description: "Maximum rows (default: 100)"},
+	}},
+	"set_var": {Description: "Set a workflow variable", Params: map[string]ParamSpec{
+		"name":  {Type: "string", Required: true},
+		"value": {},
+	}},
+}
+
+var callParams = map[string]ParamSpec{
+	"workflow":  {Type: "string", Required: true, Description: "Workflow file, relative to the calling workflow"},
+	"variables": {Type: "object", Description: "Variables passed to the workflow"},
+}
+
+var bindingParams = map[string]ParamSpec{
+	"binding": {Type: "string", Required: true},
+	"version": {Type: "string", Description: "Service version (default: 0001)"},
+}
+
+// DescribeAction sets the parameter spec of an action, so that CheckWorkflow
+// can check the parameters of custom actions too.
+func (e *WorkflowEngine) DescribeAction(action string, spec ActionSpec) {
+	e.specs[action] = spec
+}
+
+// ValidationIssue is a problem found by CheckWorkflow.
+type ValidationIssue struct {
+	Step    string `json:"step,omitempty"`
+	Message string `json:"message"`
+	Warning bool   `json:"warning,omitempty"`
+}
+
+func (i ValidationIssue) String() string {
+	level := "error"
+	if i.Warning {
+		level = "warning"
+	}
+	if i.Step == "" {
+		return fmt.Sprintf("%s: %s", level, i.Message)
+	}
+	return fmt.Sprintf("%s: step %q: %s", level, i.Step, i.Message)
+}
+
+// HasErrors reports whether issues contains an error (not only warnings).
+func HasErrors(issues []ValidationIssue) bool {
+	for _, issue := range issues {
+		if !issue.Warning {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckWorkflowFile reads a workflow file and checks it with CheckWorkflow.
+// Unknown fields are reported as issues; only unreadable files and invalid
+// YAML are returned as error.
+func (e *WorkflowEngine) CheckWorkflowFile(path string, vars []string) ([]ValidationIssue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading workflow file: %w", err)
+	}
+	var workflow Workflow
+	if err := yaml.Unmarshal(data, &workflow); err != nil {
+		return nil, fmt.Errorf("parsing workflow: %w", err)
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		workflow.path = abs
+	}
+
+	var issues []ValidationIssue
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&Workflow{}); err != nil {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			for _, msg := range typeErr.Errors {
+				issues = append(issues, ValidationIssue{Message: msg})
+			}
+		} else {
+			issues = append(issues, ValidationIssue{Message: err.Error()})
+		}
+	}
+	return append(issues, e.CheckWorkflow(&workflow, vars)...), nil
+}
+
+// CheckWorkflow checks a workflow without running it: everything Validate
+// checks, plus action names against the registered handlers, required and
+// unknown parameters, ${VAR} references against the variables (and matrix
+// axes) defined before each step, references to saved outputs, saveAs collisions and
+// files used by lua and call steps. vars names the variables the workflow
+// is run with (e.g. --var); environment variables are defined too.
+func (e *WorkflowEngine) CheckWorkflow(w *Workflow, vars []string) []ValidationIssue {
+	var issues []ValidationIssue
+	var order []int // Step index of each issue, -1 for the workflow
+	add := func(i int, warning bool, format string, args ...interface{}) {
+		issue := ValidationIssue{Message: fmt.Sprintf(format, args...), Warning: warning}
+		if i >= 0 {
+			issue.Step = stepName(i, w.Steps[i])
+		}
+		issues = append(issues, issue)
+		order = append(order, i)
+	}
+
+	if w.Name == "" {
+		add(-1, true, "workflow has no name")
+	}
+	if len(w.Steps) == 0 {
+		add(-1, false, "workflow has no steps")
+	}
+	if err := validateMatrix(w.Matrix); err != nil {
+		add(-1, false, "%s", err)
+	}
+
+	baseDir := ""
+	if w.path != "" {
+		baseDir = filepath.Dir(w.path)
+	}
+
+	savedBy := make(map[string]int)
+	for i, step := range w.Steps {
+		structural := false
+		for _, check := range []func(WorkflowStep) error{validateStepPolicy, validateStepExpressions, validateToolStep, validateLuaStep, validateCallStep} {
+			if err := check(step); err != nil {
+				add(i, false, "%s", err)
+				structural = true
+			}
+		}
+		switch step.OnFailure {
+		case "", "continue", "fail", "skip":
+		default:
+			add(i, false, "unknown onFailure %q (use continue, fail or skip)", step.OnFailure)
+		}
+
+		if _, ok := e.handlers[step.Action]; !ok {
+			if step.Action == "" {
+				add(i, false, "missing action")
+			} else if s := suggest(step.Action, e.actions()); s != "" {
+				add(i, false, "unknown action %q (did you mean %q?)", step.Action, s)
+			} else {
+				add(i, false, "unknown action %q", step.Action)
+			}
+		} else if spec, ok := e.specs[step.Action]; ok {
+			e.checkParams(step, spec, !structural, func(format string, args ...interface{}) {
+				add(i, false, format, args...)
+			})
+		}
+
+		for _, file := range stepFiles(step) {
+			path := file
+			if !filepath.IsAbs(path) && baseDir != "" {
+				path = filepath.Join(baseDir, path)
+			}
+			if _, err := os.Stat(path); err != nil {
+				add(i, false, "file %s not found", file)
+			}
+		}
+
+		if step.SaveAs != "" {
+			if j, dup := savedBy[step.SaveAs]; dup {
+				add(i, false, "saveAs %q is also used by step %q", step.SaveAs, stepName(j, w.Steps[j]))
+			} else {
+				savedBy[step.SaveAs] = i
+			}
+			if _, ok := w.Variables[step.SaveAs]; ok {
+				add(i, true, "saveAs %q hides the workflow variable of the same name", step.SaveAs)
+			}
+		}
+	}
+
+	deps, err := w.plan()
+	if err != nil {
+		add(-1, false, "%s", err)
+		return sortIssues(issues, order)
+	}
+
+	// Names defined before each step: variables, and what the steps it
+	// (transitively) waits for save or set
+	defined := make(map[string]bool)
+	for k := range w.Variables {
+		defined[k] = true
+	}
+	for _, k := range vars {
+		defined[k] = true
+	}
+	for k := range w.Matrix {
+		defined[k] = true
+	}
+	for i, step := range w.Steps {
+		known := make(map[string]bool)
+		for _, j := range ancestors(deps, i) {
+			for _, name := range definedBy(w.Steps[j]) {
+				known[name] = true
+			}
+		}
+		isDefined := func(name string) bool {
+			if defined[name] || known[name] {
+				return true
+			}
+			_, ok := os.LookupEnv(name)
+			return ok
+		}
+
+		reported := make(map[string]bool)
+		for _, name := range templateRefs(step) {
+			if !isDefined(name) && !reported[name] {
+				reported[name] = true
+				add(i, false, "${%s} is not defined by the workflow variables or an earlier step", name)
+			}
+		}
+
+		if spec, ok := e.specs[step.Action]; ok {
+			for _, param := range sortedParams(spec) {
+				ref, _ := toolParams(step)[param].(string)
+				if !spec.Params[param].Ref || ref == "" || templateRef.MatchString(ref) {
+					continue
+				}
+				if !known[ref] {
+					if j, ok := savedBy[ref]; ok && j != i {
+						add(i, false, "%s refers to %q, which is saved by step %q that does not run before it", param, ref, stepName(j, w.Steps[j]))
+					} else {
+						add(i, false, "%s refers to %q, which no earlier step saves", param, ref)
+					}
+				}
+			}
+		}
+	}
+	return sortIssues(issues, order)
+}
+
+// sortIssues orders issues by the step they belong to (order), keeping
+// workflow issues first and the order of the checks within a step.
+func sortIssues(issues []ValidationIssue, order []int) []ValidationIssue {
+	idx := make([]int, len(issues))
+	for k := range idx {
+		idx[k] = k
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return order[idx[a]] < order[idx[b]] })
+	sorted := make([]ValidationIssue, len(issues))
+	for k, i := range idx {
+		sorted[k] = issues[i]
+	}
+	return sorted
+}
+
+// checkParams reports unknown, mistyped and (if required is set) missing parameters.
+func (e *WorkflowEngine) checkParams(step WorkflowStep, spec ActionSpec, required bool, report func(string, ...interface{})) {
+	params := toolParams(step)
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	known := sortedParams(spec)
+
+	for _, name := range names {
+		p, ok := spec.Params[name]
+		if !ok {
+			if s := suggest(name, known); s != "" {
+				report("unknown parameter %q for action %s (did you mean %q?)", name, step.Action, s)
+			} else {
+				report("unknown parameter %q for action %s", name, step.Action)
+			}
+			continue
+		}
+		if !matchesType(params[name], p.Type) {
+			report("parameter %q must be of type %s", name, p.Type)
+		}
+	}
+	if !required {
+		return
+	}
+	for _, name := range known {
+		if _, ok := params[name]; spec.Params[name].Required && !ok {
+			report("missing required parameter %q for action %s", name, step.Action)
+		}
+	}
+}
+
+// matchesType checks a parameter value against a JSON Schema type. Strings
+// containing ${VAR} references are not checked.
+func matchesType(v interface{}, typ string) bool {
+	if s, ok := v.(string); ok && templateRef.MatchString(s) {
+		return true
+	}
+	switch typ {
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "integer":
+		_, ok := v.(int)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	}
+	return true
+}
+
+// templateRefs returns the names of the ${VAR} references of a step.
+func templateRefs(step WorkflowStep) []string {
+	var refs []string
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch val := v.(type) {
+		case string:
+			for _, m := range templateRef.FindAllStringSubmatch(val, -1) {
+				refs = append(refs, m[1])
+			}
+		case []interface{}:
+			for _, item := range val {
+				walk(item)
+			}
+		case map[string]interface{}:
+			keys := make([]string, 0, len(val))
+			for k := range val {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				walk(val[k])
+			}
+		}
+	}
+	walk(step.Condition)
+	params := toolParams(step)
+	if _, ok := params["script"]; ok && step.Action == "lua" {
+		// Scripts read vars.NAME; ${...} in a script is left alone
+		params = make(map[string]interface{}, len(params))
+		for k, v := range toolParams(step) {
+			if k != "script" {
+				params[k] = v
+			}
+		}
+	}
+	walk(params)
+	return refs
+}
+
+// definedBy returns the names a step defines: its saveAs and set_var variable.
+func definedBy(step WorkflowStep) []string {
+	var names []string
+	if step.SaveAs != "" {
+		names = append(names, step.SaveAs)
+	}
+	if step.Action == "set_var" {
+		if name, _ := step.Parameters["name"].(string); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// stepFiles returns the literal file paths a step reads.
+func stepFiles(step WorkflowStep) []string {
+	var param string
+	switch step.Action {
+	case "lua":
+		param = "file"
+	case "call", "include":
+		param = "workflow"
+	default:
+		return nil
+	}
+	if file, _ := step.Parameters[param].(string); file != "" && !templateRef.MatchString(file) {
+		return []string{file}
+	}
+	return nil
+}
+
+// ancestors returns the steps that step i waits for, directly or indirectly.
+func ancestors(deps [][]int, i int) []int {
+	seen := make(map[int]bool)
+	var result []int
+	stack := append([]int(nil), deps[i]...)
+	for len(stack) > 0 {
+		j := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[j] {
+			continue
+		}
+		seen[j] = true
+		result = append(result, j)
+		stack = append(stack, deps[j]...)
+	}
+	return result
+}
+
+func sortedParams(spec ActionSpec) []string {
+	names := make([]string, 0, len(spec.Params))
+	for name := range spec.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// actions returns the registered action names, sorted.
+func (e *WorkflowEngine) actions() []string {
+	names := make([]string, 0, len(e.handlers))
+	for name := range e.handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// suggest returns the candidate closest to name, if it is a likely typo.
+func suggest(name string, candidates []string) string {
+	best, bestDist := "", 3
+	for _, c := range candidates {
+		if d := editDistance(strings.ToLower(name), strings.ToLower(c)); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,192 @@
+package dsl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultMaxParallel is the number of workflow steps run at the same time
+// when neither the workflow nor the caller sets a limit.
+const DefaultMaxParallel = 4
+
+// stepName returns the display name of a step.
+func stepName(i int, step WorkflowStep) string {
+	if step.Name != "" {
+		return step.Name
+	}
+	if step.ID != "" {
+		return step.ID
+	}
+	return fmt.Sprintf("step_%d_%s", i+1, step.Action)
+}
+
+// Validate checks the step dependency graph: ids must be unique, dependsOn must
+// refer to existing steps and the graph must not contain cycles.
+// Step timeouts, retry policies, expressions, tool steps and the matrix are
+// checked too.
+func (w *Workflow) Validate() error {
+	for i, step := range w.Steps {
+		if err := validateStepPolicy(step); err != nil {
+			return fmt.Errorf("step %q: %w", stepName(i, step), err)
+		}
+		if err := validateStepExpressions(step); err != nil {
+			return fmt.Errorf("step %q: %w", stepName(i, step), err)
+		}
+		if err := validateToolStep(step); err != nil {
+			return fmt.Errorf("step %q: %w", stepName(i, step), err)
+		}
+		if err := validateLuaStep(step); err != nil {
+			return fmt.Errorf("step %q: %w", stepName(i, step), err)
+		}
+		if err := validateCallStep(step); err != nil {
+			return fmt.Errorf("step %q: %w", stepName(i, step), err)
+		}
+	}
+	if err := validateMatrix(w.Matrix); err != nil {
+		return err
+	}
+	_, err := w.plan()
+	return err
+}
+
+// plan returns for each step the indexes of the steps it waits for.
+//
+// A step with dependsOn waits only for those steps. Consecutive steps marked
+// parallel run alongside each other and wait for everything before the group.
+// Any other step waits for all steps before it, so workflows without ids run
+// strictly in order.
+func (w *Workflow) plan() ([][]int, error) {
+	keys := make(map[string]int, len(w.Steps))
+	for i, step := range w.Steps {
+		if step.ID == "" {
+			continue
+		}
+		if _, dup := keys[step.ID]; dup {
+			return nil, fmt.Errorf("duplicate step id %q", step.ID)
+		}
+		keys[step.ID] = i
+	}
+	// Names can be referenced too; a name used by several steps is ambiguous (-1)
+	names := make(map[string]int)
+	for i, step := range w.Steps {
+		if step.Name == "" {
+			continue
+		}
+		if _, taken := keys[step.Name]; taken {
+			continue
+		}
+		if _, dup := names[step.Name]; dup {
+			names[step.Name] = -1
+		} else {
+			names[step.Name] = i
+		}
+	}
+	for name, i := range names {
+		keys[name] = i
+	}
+
+	deps := make([][]int, len(w.Steps))
+	groupStart := 0
+	for i, step := range w.Steps {
+		implicitParallel := step.Parallel && len(step.DependsOn) == 0
+		switch {
+		case len(step.DependsOn) > 0:
+			for _, ref := range step.DependsOn {
+				j, ok := keys[ref]
+				if !ok {
+					return nil, fmt.Errorf("step %q depends on unknown step %q", stepName(i, step), ref)
+				}
+				if j < 0 {
+					return nil, fmt.Errorf("step %q depends on %q, which names several steps: give them ids", stepName(i, step), ref)
+				}
+				deps[i] = append(deps[i], j)
+			}
+		case implicitParallel:
+			if i == 0 || !w.Steps[i-1].Parallel || len(w.Steps[i-1].DependsOn) > 0 {
+				groupStart = i
+			}
+			for j := 0; j < groupStart; j++ {
+				deps[i] = append(deps[i], j)
+			}
+		default:
+			for j := 0; j < i; j++ {
+				deps[i] = append(deps[i], j)
+			}
+		}
+	}
+
+	if cycle := findCycle(deps); cycle != nil {
+		names := make([]string, len(cycle))
+		for k, i := range cycle {
+			names[k] = stepName(i, w.Steps[i])
+		}
+		return nil, fmt.Errorf("dependency cycle: %s", strings.Join(names, " -> "))
+	}
+	return deps, nil
+}
+
+// findCycle returns the steps of a dependency cycle (first step repeated at
+// the end), or nil if the graph is acyclic.
+func findCycle(deps [][]int) []int {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make([]int, len(deps))
+	var stack []int
+
+	var visit func(i int) []int
+	visit = func(i int) []int {
+		state[i] = visiting
+		stack = append(stack, i)
+		for _, j := range deps[i] {
+			switch state[j] {
+			case visiting:
+				for k, s := range stack {
+					if s == j {
+						cycle := append([]int(nil), stack[k:]...)
+						return append(cycle, j)
+					}
+				}
+			case unvisited:
+				if cycle := visit(j); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[i] = done
+		return nil
+	}
+
+	for i := range deps {
+		if state[i] == unvisited {
+			if cycle := visit(i); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// validateStepExpressions compiles the expressions of a step: its condition,
+// a fail_if condition and foreach's collection and where.
+func validateStepExpressions(step WorkflowStep) error {
+	exprs := []string{step.Condition}
+	switch step.Action {
+	case "fail_if":
+		c, _ := step.Parameters["condition"].(string)
+		exprs = append(exprs, c)
+	case "foreach":
+		c, _ := step.Parameters["collection"].(string)
+		w, _ := step.Parameters["where"].(string)
+		exprs = append(exprs, c, w)
+	}
+	for _, expr := range exprs {
+		if _, err := compileCondition(expr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package dsl
+
+import (
+	"fmt"
+
+	"github.com/oisee/vibing-steampunk/pkg/scripting"
+)
+
+// validateLuaStep checks that a lua step has either an inline script or a file.
+func validateLuaStep(step WorkflowStep) error {
+	if step.Action != "lua" {
+		return nil
+	}
+	script, _ := step.Parameters["script"].(string)
+	file, _ := step.Parameters["file"].(string)
+	if (script == "") == (file == "") {
+		return fmt.Errorf("lua action requires either a script or a file")
+	}
+	return nil
+}
+
+// handleLua runs a Lua script with the workflow variables and saved outputs
+// in the global table "vars". The value the script returns is the step output.
+func handleLua(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+	script, _ := params["script"].(string)
+	file, _ := params["file"].(string)
+	if (script == "") == (file == "") {
+		return nil, fmt.Errorf("lua action requires either 'script' or 'file' parameter")
+	}
+	if file != "" {
+		file = ctx.resolvePath(file)
+	}
+
+	// Scripts can change the system through the ADT bindings; dry runs only report them
+	if ctx.IsDryRun() {
+		output := map[string]interface{}{"dryRun": true, "action": "lua"}
+		if file != "" {
+			output["file"] = file
+		}
+		return output, nil
+	}
+
+	engine := scripting.NewLuaEngine(ctx.Client())
+	defer engine.Close()
+	engine.SetContext(ctx.Context())
+	engine.L.SetContext(ctx.Context()) // Stops the script on step timeout or cancellation
+	engine.SetGlobal("vars", luaVars(ctx))
+
+	if file != "" {
+		return engine.EvalFile(file)
+	}
+	return engine.Eval(script)
+}
+
+// luaVars returns the names visible to expressions: workflow variables,
+// overridden by saved step outputs.
+func luaVars(ctx *ExecutionContext) map[string]interface{} {
+	ctx.mu.RLock()
+	defer ctx.mu.RUnlock()
+	vars := make(map[string]interface{}, len(ctx.variables)+len(ctx.results))
+	for k, v := range ctx.variables {
+		vars[k] = v
+	}
+	for k, v := range ctx.results {
+		vars[k] = v
+	}
+	return vars
+}
@@ -0,0 +1,162 @@
+package dsl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+const (
+	defaultRetryAttempts = 3
+	defaultRetryBackoff  = 2 * time.Second
+
+	// handlerCancelGrace is how long a timed-out handler is given to return
+	// after its context is cancelled before it is abandoned.
+	handlerCancelGrace = 200 * time.Millisecond
+)
+
+// Retryable error classes for RetryPolicy.On
+const (
+	RetryOnTimeout      = "timeout"
+	RetryOnLockConflict = "lock_conflict"
+	RetryOn5xx          = "5xx"
+)
+
+var retryClasses = map[string]func(error) bool{
+	RetryOnTimeout:      isTimeoutError,
+	RetryOnLockConflict: adt.IsLockConflictError,
+	RetryOn5xx:          adt.IsServerError,
+}
+
+// validateStepPolicy checks the timeout and retry settings of a step.
+func validateStepPolicy(step WorkflowStep) error {
+	if step.Timeout != "" {
+		if d, err := time.ParseDuration(step.Timeout); err != nil || d <= 0 {
+			return fmt.Errorf("invalid timeout %q", step.Timeout)
+		}
+	}
+	if step.Retry == nil {
+		return nil
+	}
+	if step.Retry.Attempts < 0 {
+		return fmt.Errorf("retry attempts must not be negative")
+	}
+	if step.Retry.Backoff != "" {
+		if d, err := time.ParseDuration(step.Retry.Backoff); err != nil || d < 0 {
+			return fmt.Errorf("invalid retry backoff %q", step.Retry.Backoff)
+		}
+	}
+	for _, class := range step.Retry.On {
+		if _, ok := retryClasses[class]; !ok {
+			return fmt.Errorf("unknown retry condition %q (use timeout, lock_conflict or 5xx)", class)
+		}
+	}
+	return nil
+}
+
+// runWithRetry runs a step handler, applying the step timeout to each attempt
+// and retrying according to the step's retry policy. It returns the number of
+// attempts made.
+func (e *WorkflowEngine) runWithRetry(execCtx *ExecutionContext, step WorkflowStep, handler ActionHandler, params map[string]interface{}) (interface{}, int, error) {
+	attempts := 1
+	backoff := defaultRetryBackoff
+	if step.Retry != nil {
+		attempts = defaultRetryAttempts
+		if step.Retry.Attempts > 0 {
+			attempts = step.Retry.Attempts
+		}
+		if step.Retry.Backoff != "" {
+			backoff, _ = time.ParseDuration(step.Retry.Backoff)
+		}
+	}
+	var timeout time.Duration
+	if step.Timeout != "" {
+		timeout, _ = time.ParseDuration(step.Timeout)
+	}
+
+	for attempt := 1; ; attempt++ {
+		output, err := runAttempt(execCtx, handler, params, timeout)
+		if err == nil || attempt >= attempts || !shouldRetry(step.Retry, err) {
+			return output, attempt, err
+		}
+
+		// Wait before retrying, unless the workflow itself is cancelled
+		select {
+		case <-time.After(backoff):
+		case <-execCtx.Context().Done():
+			return nil, attempt, err
+		}
+		backoff *= 2
+	}
+}
+
+// runAttempt runs the handler once with an optional timeout. When the timeout
+// expires, the handler's context is cancelled and runAttempt waits for it to
+// return, so that it doesn't keep running into the next attempt or step.
+// Built-in handlers pass their context to every client call and check it
+// between objects, so they return promptly. A handler that ignores its
+// context is abandoned after handlerCancelGrace.
+func runAttempt(execCtx *ExecutionContext, handler ActionHandler, params map[string]interface{}, timeout time.Duration) (interface{}, error) {
+	if timeout <= 0 {
+		return handler(execCtx, params)
+	}
+
+	ctx, cancel := context.WithTimeout(execCtx.Context(), timeout)
+	defer cancel()
+
+	type attemptResult struct {
+		output interface{}
+		err    error
+	}
+	done := make(chan attemptResult, 1)
+	go func() {
+		output, err := handler(execCtx.WithContext(ctx), params)
+		done <- attemptResult{output, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil && ctx.Err() == context.DeadlineExceeded {
+			return r.output, fmt.Errorf("timed out after %s: %w", timeout, r.err)
+		}
+		return r.output, r.err
+	case <-ctx.Done():
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(handlerCancelGrace):
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("timed out after %s: %w", timeout, ctx.Err())
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// shouldRetry reports whether err matches the retry policy.
+func shouldRetry(policy *RetryPolicy, err error) bool {
+	if policy == nil {
+		return false
+	}
+	if len(policy.On) == 0 {
+		return !errors.Is(err, context.Canceled)
+	}
+	for _, class := range policy.On {
+		if retryClasses[class](err) {
+			return true
+		}
+	}
+	return false
+}
+
+func isTimeoutError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
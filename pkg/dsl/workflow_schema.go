@@ -0,0 +1,117 @@
+package dsl
+
+// JSONSchema returns a JSON Schema (draft-07) for workflow files, for editor
+// validation and autocompletion. Parameters are described for the actions
+// that have an ActionSpec.
+func (e *WorkflowEngine) JSONSchema() map[string]interface{} {
+	actions := e.actions()
+	enum := make([]interface{}, len(actions))
+	for i, a := range actions {
+		enum[i] = a
+	}
+
+	definitions := map[string]interface{}{}
+	var conditionals []interface{}
+	for _, action := range actions {
+		spec, ok := e.specs[action]
+		if !ok {
+			continue
+		}
+		definitions["params_"+action] = paramsSchema(spec)
+		conditionals = append(conditionals, map[string]interface{}{
+			"if": map[string]interface{}{
+				"properties": map[string]interface{}{"action": map[string]interface{}{"const": action}},
+				"required":   []string{"action"},
+			},
+			"then": map[string]interface{}{
+				"properties": map[string]interface{}{
+					"parameters": map[string]interface{}{"$ref": "#/definitions/params_" + action},
+				},
+			},
+		})
+	}
+
+	str := map[string]interface{}{"type": "string"}
+	definitions["step"] = map[string]interface{}{
+		"type":                 "object",
+		"required":             []string{"action"},
+		"additionalProperties": false,
+		"properties": map[string]interface{}{
+			"id":         map[string]interface{}{"type": "string", "description": "Step id, referenced by dependsOn"},
+			"name":       str,
+			"dependsOn":  map[string]interface{}{"type": "array", "items": str, "description": "Step ids (or names) to wait for"},
+			"parallel":   map[string]interface{}{"type": "boolean", "description": "Run alongside adjacent parallel steps"},
+			"action":     map[string]interface{}{"enum": enum},
+			"parameters": map[string]interface{}{"type": "object"},
+			"tool":       map[string]interface{}{"type": "string", "description": "Tool called by action: tool"},
+			"arguments":  map[string]interface{}{"type": "object", "description": "Tool arguments"},
+			"saveAs":     map[string]interface{}{"type": "string", "description": "Save the step output under this name"},
+			"condition":  map[string]interface{}{"type": "string", "description": "Run the step only if this expression is true"},
+			"onFailure":  map[string]interface{}{"enum": []interface{}{"continue", "fail", "skip"}},
+			"timeout":    map[string]interface{}{"type": "string", "description": "Per-attempt limit, e.g. 5m"},
+			"retry": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": false,
+				"properties": map[string]interface{}{
+					"attempts": map[string]interface{}{"type": "integer", "minimum": 0},
+					"backoff":  str,
+					"on":       map[string]interface{}{"type": "array", "items": map[string]interface{}{"enum": []interface{}{"timeout", "lock_conflict", "5xx"}}},
+				},
+			},
+		},
+		"allOf": conditionals,
+	}
+
+	return map[string]interface{}{
+		"$schema":              "http://json-schema.org/draft-07/schema#",
+		"title":                "vsp workflow",
+		"type":                 "object",
+		"required":             []string{"name", "steps"},
+		"additionalProperties": false,
+		"properties": map[string]interface{}{
+			"name":        str,
+			"description": str,
+			"variables":   map[string]interface{}{"type": "object", "additionalProperties": str},
+			"maxParallel": map[string]interface{}{"type": "integer", "minimum": 1},
+			"matrix": map[string]interface{}{
+				"type":                 "object",
+				"description":          "Run once per combination; the system axis selects the SAP system",
+				"additionalProperties": map[string]interface{}{"type": "array", "items": str, "minItems": 1},
+			},
+			"steps": map[string]interface{}{"type": "array", "items": map[string]interface{}{"$ref": "#/definitions/step"}},
+		},
+		"definitions": definitions,
+	}
+}
+
+// paramsSchema returns the schema of the parameters of an action.
+func paramsSchema(spec ActionSpec) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+	for _, name := range sortedParams(spec) {
+		p := spec.Params[name]
+		prop := map[string]interface{}{}
+		if p.Type != "" {
+			prop["type"] = p.Type
+		}
+		if p.Description != "" {
+			prop["description"] = p.Description
+		}
+		properties[name] = prop
+		if p.Required {
+			required = append(required, name)
+		}
+	}
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties":           properties,
+	}
+	if spec.Description != "" {
+		schema["description"] = spec.Description
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
@@ -0,0 +1,304 @@
+package dsl
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+)
+
+// WorkflowStateVersion is the format version of workflow state files.
+const WorkflowStateVersion = 1
+
+// WorkflowState is the persisted progress of a workflow run: variables,
+// saved step outputs and the results of the steps that finished.
+// It is written after every step when a state file is set (WithStateFile)
+// and used by WithResume to continue a failed run.
+type WorkflowState struct {
+	Version   int                   `json:"version"`
+	Workflow  string                `json:"workflow,omitempty"` // Absolute path of the workflow file
+	Name      string                `json:"name"`
+	UpdatedAt time.Time             `json:"updatedAt"`
+	DryRun    bool                  `json:"dryRun,omitempty"`
+	Success   bool                  `json:"success"`
+	Error     string                `json:"error,omitempty"`
+	Variables map[string]string     `json:"variables,omitempty"`
+	Results   map[string]StateValue `json:"results,omitempty"` // saveAs outputs
+	Steps     []StepResult          `json:"steps"`
+}
+
+// StateValue is a saved output with the name of its Go type, so that values
+// such as []ObjectRef or *TestSummary keep their type when a run is resumed.
+type StateValue struct {
+	Type  string          `json:"type,omitempty"` // Registered type name; empty for plain JSON
+	Value json.RawMessage `json:"value"`
+}
+
+// listStateType marks a []interface{} whose elements are StateValues.
+const listStateType = "list"
+
+// stateTypes maps type names to the Go types that saved outputs are decoded into.
+var stateTypes = map[string]reflect.Type{}
+
+// RegisterStateType registers the type of sample so that saved outputs of
+// that type are restored with it. Custom action handlers whose outputs are
+// read back with a type assertion should register their output types.
+func RegisterStateType(name string, sample interface{}) {
+	stateTypes[name] = reflect.TypeOf(sample)
+}
+
+func init() {
+	RegisterStateType("objects", []ObjectRef{})
+	RegisterStateType("object", ObjectRef{})
+	RegisterStateType("test_summary", &TestSummary{})
+	RegisterStateType("syntax_results", []map[string]interface{}{})
+	RegisterStateType("batch_result", &BatchResult{})
+	RegisterStateType("import_result", &BatchImportResult{})
+	RegisterStateType("export_result", &BatchExportResult{})
+}
+
+// encodeStateValue encodes a saved output. Elements of []interface{} (e.g.
+// foreach results) are encoded one by one so they keep their types too.
+func encodeStateValue(v interface{}) (StateValue, error) {
+	if list, ok := v.([]interface{}); ok {
+		items := make([]StateValue, len(list))
+		for i, item := range list {
+			sv, err := encodeStateValue(item)
+			if err != nil {
+				return StateValue{}, err
+			}
+			items[i] = sv
+		}
+		data, err := json.Marshal(items)
+		return StateValue{Type: listStateType, Value: data}, err
+	}
+
+	var typeName string
+	if v != nil {
+		t := reflect.TypeOf(v)
+		for name, st := range stateTypes {
+			if st == t {
+				typeName = name
+				break
+			}
+		}
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return StateValue{}, err
+	}
+	return StateValue{Type: typeName, Value: data}, nil
+}
+
+// decodeStateValue decodes a saved output into its registered type.
+func decodeStateValue(sv StateValue) (interface{}, error) {
+	if sv.Type == listStateType {
+		var items []StateValue
+		if err := json.Unmarshal(sv.Value, &items); err != nil {
+			return nil, err
+		}
+		list := make([]interface{}, len(items))
+		for i, item := range items {
+			v, err := decodeStateValue(item)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = v
+		}
+		return list, nil
+	}
+
+	t, ok := stateTypes[sv.Type]
+	if !ok {
+		if sv.Type != "" {
+			return nil, fmt.Errorf("unknown state type %q", sv.Type)
+		}
+		var v interface{}
+		err := json.Unmarshal(sv.Value, &v)
+		return v, err
+	}
+	if t.Kind() == reflect.Ptr {
+		ptr := reflect.New(t.Elem())
+		if err := json.Unmarshal(sv.Value, ptr.Interface()); err != nil {
+			return nil, err
+		}
+		return ptr.Interface(), nil
+	}
+	ptr := reflect.New(t)
+	if err := json.Unmarshal(sv.Value, ptr.Interface()); err != nil {
+		return nil, err
+	}
+	return ptr.Elem().Interface(), nil
+}
+
+// LoadWorkflowState reads a workflow state file.
+func LoadWorkflowState(path string) (*WorkflowState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading state file: %w", err)
+	}
+	var state WorkflowState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parsing state file: %w", err)
+	}
+	if state.Version != WorkflowStateVersion {
+		return nil, fmt.Errorf("unsupported state file version %d", state.Version)
+	}
+	return &state, nil
+}
+
+// Save writes the state to path atomically.
+func (s *WorkflowState) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// resumePoint is the state a run continues from (see WithResume).
+type resumePoint struct {
+	state *WorkflowState
+	from  string
+}
+
+// WithStateFile saves the workflow state to path after every step.
+func WithStateFile(path string) ExecuteOption {
+	return func(ctx *ExecutionContext) {
+		ctx.statePath = path
+	}
+}
+
+// WithResume continues the run recorded in state. Steps that succeeded (or
+// were skipped) before the first failed or missing step keep their results
+// and are not run again. from names the step (id or name) to restart at
+// instead; steps before it that have a recorded result are not run again.
+//
+// The saved variables are restored when the option is applied, so a
+// WithVariables option after it overrides them.
+func WithResume(state *WorkflowState, from string) ExecuteOption {
+	return func(ctx *ExecutionContext) {
+		ctx.resume = &resumePoint{state: state, from: from}
+		for k, v := range state.Variables {
+			ctx.SetVariable(k, v)
+		}
+	}
+}
+
+// restoreState loads the saved outputs from the resume state and returns
+// the results of the steps that do not need to run again.
+func (e *WorkflowEngine) restoreState(execCtx *ExecutionContext, workflow *Workflow, result *WorkflowResult) (map[int]StepResult, error) {
+	state := execCtx.resume.state
+
+	for k, sv := range state.Results {
+		v, err := decodeStateValue(sv)
+		if err != nil {
+			return nil, fmt.Errorf("restoring %q: %w", k, err)
+		}
+		execCtx.Set(k, v)
+		result.Variables[k] = v
+	}
+
+	recorded := make(map[string]StepResult, len(state.Steps))
+	for _, r := range state.Steps {
+		recorded[r.Name] = r
+	}
+
+	start := -1
+	if from := execCtx.resume.from; from != "" {
+		for i, step := range workflow.Steps {
+			if step.ID == from || step.Name == from || stepName(i, step) == from {
+				start = i
+				break
+			}
+		}
+		if start < 0 {
+			return nil, fmt.Errorf("unknown step %q", from)
+		}
+	} else {
+		start = len(workflow.Steps)
+		for i, step := range workflow.Steps {
+			if r, ok := recorded[stepName(i, step)]; !ok || !r.Success {
+				start = i
+				break
+			}
+		}
+	}
+
+	done := make(map[int]StepResult)
+	for i := 0; i < start; i++ {
+		step := workflow.Steps[i]
+		r, ok := recorded[stepName(i, step)]
+		if !ok {
+			continue
+		}
+		r.Resumed = true
+		done[i] = r
+		execCtx.setStepResult([]string{step.ID, step.Name, r.Name}, r)
+	}
+	return done, nil
+}
+
+// saveState writes the current progress to the state file.
+func (e *WorkflowEngine) saveState(execCtx *ExecutionContext, workflow *Workflow, results []*StepResult, result *WorkflowResult) error {
+	state := &WorkflowState{
+		Version:   WorkflowStateVersion,
+		Workflow:  workflow.path,
+		Name:      workflow.Name,
+		UpdatedAt: time.Now(),
+		DryRun:    execCtx.IsDryRun(),
+		Success:   result.Success,
+		Error:     result.Error,
+		Variables: make(map[string]string),
+		Results:   make(map[string]StateValue),
+		Steps:     []StepResult{},
+	}
+	if state.Workflow == "" && execCtx.resume != nil {
+		state.Workflow = execCtx.resume.state.Workflow
+	}
+
+	execCtx.mu.RLock()
+	for k, v := range execCtx.variables {
+		if s, ok := v.(string); ok {
+			state.Variables[k] = s
+		}
+	}
+	saved := make(map[string]interface{}, len(execCtx.results))
+	for k, v := range execCtx.results {
+		saved[k] = v
+	}
+	execCtx.mu.RUnlock()
+
+	for k, v := range saved {
+		sv, err := encodeStateValue(v)
+		if err != nil {
+			return fmt.Errorf("saved output %q is not serializable: %w", k, err)
+		}
+		state.Results[k] = sv
+	}
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+		step := *r
+		step.Resumed = false
+		// Step outputs are kept for reference only; saveAs outputs are restored from Results
+		if _, err := json.Marshal(step.Output); err != nil {
+			step.Output = fmt.Sprintf("%v", step.Output)
+		}
+		state.Steps = append(state.Steps, step)
+	}
+
+	return state.Save(execCtx.statePath)
+}
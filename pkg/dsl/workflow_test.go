@@ -2,8 +2,19 @@ package dsl
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
 )
 
 func TestWorkflowExecution(t *testing.T) {
@@ -655,3 +666,919 @@ steps:
 		t.Errorf("unexpected error: %s", result.Error)
 	}
 }
+
+func TestWorkflowParallelExecution(t *testing.T) {
+	yamlContent := `
+name: ci-packages
+steps:
+  - id: discover
+    action: mock_step
+  - id: syntax_a
+    action: mock_step
+    dependsOn: [discover]
+  - id: syntax_b
+    action: mock_step
+    dependsOn: [discover]
+  - id: tests_a
+    action: mock_step
+    dependsOn: [syntax_a]
+  - id: tests_b
+    action: mock_step
+    dependsOn: [syntax_b]
+  - id: report
+    action: mock_step
+    dependsOn: [tests_a, tests_b]
+`
+
+	t.Run("IndependentStepsOverlap", func(t *testing.T) {
+		engine := NewWorkflowEngine(nil)
+
+		var mu sync.Mutex
+		active, maxActive := 0, 0
+		engine.RegisterHandler("mock_step", func(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+			mu.Lock()
+			active++
+			if active > maxActive {
+				maxActive = active
+			}
+			mu.Unlock()
+			time.Sleep(20 * time.Millisecond)
+			mu.Lock()
+			active--
+			mu.Unlock()
+			return nil, nil
+		})
+		workflow, err := engine.ParseWorkflow([]byte(yamlContent))
+		if err != nil {
+			t.Fatalf("ParseWorkflow failed: %v", err)
+		}
+
+		result, err := engine.Execute(context.Background(), workflow)
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if !result.Success {
+			t.Fatalf("expected success, got: %s", result.Error)
+		}
+		if maxActive != 2 {
+			t.Errorf("expected 2 steps running at once, got %d", maxActive)
+		}
+
+		// Results keep workflow order
+		expected := []string{"discover", "syntax_a", "syntax_b", "tests_a", "tests_b", "report"}
+		for i, name := range expected {
+			if result.StepResults[i].Name != name {
+				t.Errorf("step %d: expected %s, got %s", i, name, result.StepResults[i].Name)
+			}
+		}
+	})
+
+	t.Run("MaxParallelLimit", func(t *testing.T) {
+		engine := NewWorkflowEngine(nil)
+
+		var mu sync.Mutex
+		active, maxActive := 0, 0
+		engine.RegisterHandler("mock_step", func(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+			mu.Lock()
+			active++
+			if active > maxActive {
+				maxActive = active
+			}
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			active--
+			mu.Unlock()
+			return nil, nil
+		})
+
+		workflow := &Workflow{Name: "fan-out"}
+		for i := 0; i < 6; i++ {
+			workflow.Steps = append(workflow.Steps, WorkflowStep{Action: "mock_step", Parallel: true})
+		}
+
+		result, err := engine.Execute(context.Background(), workflow, WithMaxParallel(3))
+		if err != nil || !result.Success {
+			t.Fatalf("Execute failed: %v %s", err, result.Error)
+		}
+		if maxActive != 3 {
+			t.Errorf("expected at most 3 steps at once, got %d", maxActive)
+		}
+	})
+
+	t.Run("ParallelGroupWaitsForPreviousSteps", func(t *testing.T) {
+		engine := NewWorkflowEngine(nil)
+
+		var mu sync.Mutex
+		var order []string
+		engine.RegisterHandler("record", func(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+			if d, ok := params["sleep"].(int); ok {
+				time.Sleep(time.Duration(d) * time.Millisecond)
+			}
+			mu.Lock()
+			order = append(order, params["id"].(string))
+			mu.Unlock()
+			return nil, nil
+		})
+
+		workflow, err := engine.ParseWorkflow([]byte(`
+name: groups
+steps:
+  - action: record
+    parameters: {id: first, sleep: 10}
+  - action: record
+    parallel: true
+    parameters: {id: slow, sleep: 30}
+  - action: record
+    parallel: true
+    parameters: {id: fast}
+  - action: record
+    parameters: {id: last}
+`))
+		if err != nil {
+			t.Fatalf("ParseWorkflow failed: %v", err)
+		}
+
+		if _, err := engine.Execute(context.Background(), workflow); err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		expected := "first,fast,slow,last"
+		if got := strings.Join(order, ","); got != expected {
+			t.Errorf("expected order %s, got %s", expected, got)
+		}
+	})
+
+	t.Run("FailureStopsNewSteps", func(t *testing.T) {
+		engine := NewWorkflowEngine(nil)
+		engine.RegisterHandler("mock_step", func(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+			if params["fail"] == true {
+				return nil, fmt.Errorf("boom")
+			}
+			return nil, nil
+		})
+
+		workflow := &Workflow{
+			Name: "failing",
+			Steps: []WorkflowStep{
+				{ID: "a", Action: "mock_step", Parameters: map[string]interface{}{"fail": true}},
+				{ID: "b", Action: "mock_step", DependsOn: []string{"a"}},
+			},
+		}
+
+		result, _ := engine.Execute(context.Background(), workflow)
+		if result.Success {
+			t.Fatal("expected workflow to fail")
+		}
+		if result.Error != "step 'a' failed: boom" {
+			t.Errorf("unexpected error: %s", result.Error)
+		}
+		if len(result.StepResults) != 1 {
+			t.Errorf("expected dependent step not to run, got %d results", len(result.StepResults))
+		}
+	})
+}
+
+func TestWorkflowValidate(t *testing.T) {
+	engine := NewWorkflowEngine(nil)
+
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{
+			name: "Cycle",
+			yaml: `
+name: cyclic
+steps:
+  - {id: a, action: print, dependsOn: [c]}
+  - {id: b, action: print, dependsOn: [a]}
+  - {id: c, action: print, dependsOn: [b]}
+`,
+			wantErr: "dependency cycle: a -> c -> b -> a",
+		},
+		{
+			name: "UnknownDependency",
+			yaml: `
+name: unknown
+steps:
+  - {id: a, action: print, dependsOn: [missing]}
+`,
+			wantErr: `step "a" depends on unknown step "missing"`,
+		},
+		{
+			name: "DuplicateID",
+			yaml: `
+name: dup
+steps:
+  - {id: a, action: print}
+  - {id: a, action: print}
+`,
+			wantErr: `duplicate step id "a"`,
+		},
+		{
+			name: "DependsOnName",
+			yaml: `
+name: by-name
+steps:
+  - {name: build, action: print}
+  - {name: check, action: print, dependsOn: [build]}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := engine.ParseWorkflow([]byte(tt.yaml))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestWorkflowRetryAndTimeout(t *testing.T) {
+	t.Run("RetriesLockConflict", func(t *testing.T) {
+		engine := NewWorkflowEngine(nil)
+
+		calls := 0
+		engine.RegisterHandler("mock_activate", func(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+			calls++
+			if calls < 3 {
+				return nil, fmt.Errorf("activation failed: %w", &adt.APIError{StatusCode: 403, Message: "Object is locked by user DEV"})
+			}
+			return "activated", nil
+		})
+
+		workflow, err := engine.ParseWorkflow([]byte(`
+name: retry
+steps:
+  - name: activate
+    action: mock_activate
+    retry: {attempts: 3, backoff: 1ms, on: [lock_conflict]}
+`))
+		if err != nil {
+			t.Fatalf("ParseWorkflow failed: %v", err)
+		}
+
+		result, _ := engine.Execute(context.Background(), workflow)
+		if !result.Success {
+			t.Fatalf("expected success after retries, got: %s", result.Error)
+		}
+		if calls != 3 || result.StepResults[0].Attempts != 3 {
+			t.Errorf("expected 3 attempts, got %d calls / %d attempts", calls, result.StepResults[0].Attempts)
+		}
+	})
+
+	t.Run("DoesNotRetryOtherErrors", func(t *testing.T) {
+		engine := NewWorkflowEngine(nil)
+
+		calls := 0
+		engine.RegisterHandler("mock_activate", func(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+			calls++
+			return nil, &adt.APIError{StatusCode: 400, Message: "Syntax error"}
+		})
+
+		workflow := &Workflow{
+			Name: "no-retry",
+			Steps: []WorkflowStep{{
+				Action: "mock_activate",
+				Retry:  &RetryPolicy{Attempts: 5, Backoff: "1ms", On: []string{RetryOnLockConflict, RetryOn5xx}},
+			}},
+		}
+
+		result, _ := engine.Execute(context.Background(), workflow)
+		if result.Success || calls != 1 {
+			t.Errorf("expected a single failed attempt, got success=%v calls=%d", result.Success, calls)
+		}
+	})
+
+	t.Run("TimeoutThenRetry", func(t *testing.T) {
+		engine := NewWorkflowEngine(nil)
+
+		var calls int32
+		engine.RegisterHandler("mock_test", func(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				<-ctx.Context().Done() // hang until the step timeout
+				return nil, ctx.Context().Err()
+			}
+			return "ok", nil
+		})
+
+		workflow := &Workflow{
+			Name: "timeout",
+			Steps: []WorkflowStep{{
+				Action:  "mock_test",
+				Timeout: "20ms",
+				Retry:   &RetryPolicy{Attempts: 2, Backoff: "1ms", On: []string{RetryOnTimeout}},
+			}},
+		}
+
+		result, _ := engine.Execute(context.Background(), workflow)
+		if n := atomic.LoadInt32(&calls); !result.Success || n != 2 {
+			t.Errorf("expected success on second attempt, got success=%v calls=%d err=%s", result.Success, n, result.Error)
+		}
+	})
+
+	t.Run("TimeoutFailsStep", func(t *testing.T) {
+		engine := NewWorkflowEngine(nil)
+		engine.RegisterHandler("mock_hang", func(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+			time.Sleep(time.Second) // ignores its context
+			return nil, nil
+		})
+
+		workflow := &Workflow{
+			Name:  "hang",
+			Steps: []WorkflowStep{{Name: "tests", Action: "mock_hang", Timeout: "20ms"}},
+		}
+
+		start := time.Now()
+		result, _ := engine.Execute(context.Background(), workflow)
+		if result.Success {
+			t.Fatal("expected timeout failure")
+		}
+		if !strings.Contains(result.Error, "timed out after 20ms") {
+			t.Errorf("unexpected error: %s", result.Error)
+		}
+		if time.Since(start) > 500*time.Millisecond {
+			t.Errorf("step was not abandoned at its timeout")
+		}
+	})
+
+	t.Run("HandlerReturnsBeforeNextAttempt", func(t *testing.T) {
+		engine := NewWorkflowEngine(nil)
+
+		var running, maxRunning int32
+		engine.RegisterHandler("mock_test", func(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+			n := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			if n > atomic.LoadInt32(&maxRunning) {
+				atomic.StoreInt32(&maxRunning, n)
+			}
+			<-ctx.Context().Done()
+			time.Sleep(10 * time.Millisecond) // cleanup after cancellation
+			return nil, ctx.Context().Err()
+		})
+
+		workflow := &Workflow{
+			Name: "cancel",
+			Steps: []WorkflowStep{{
+				Action:  "mock_test",
+				Timeout: "10ms",
+				Retry:   &RetryPolicy{Attempts: 3, Backoff: "1ms", On: []string{RetryOnTimeout}},
+			}},
+		}
+
+		result, _ := engine.Execute(context.Background(), workflow)
+		if result.Success {
+			t.Fatal("expected timeout failure")
+		}
+		if n := atomic.LoadInt32(&running); n != 0 {
+			t.Errorf("%d handler(s) still running after the step ended", n)
+		}
+		if n := atomic.LoadInt32(&maxRunning); n != 1 {
+			t.Errorf("expected attempts not to overlap, got %d at once", n)
+		}
+	})
+
+	t.Run("InvalidPolicy", func(t *testing.T) {
+		engine := NewWorkflowEngine(nil)
+		for _, yamlContent := range []string{
+			"name: x\nsteps:\n  - {action: print, timeout: soon}\n",
+			"name: x\nsteps:\n  - {action: print, retry: {on: [network]}}\n",
+			"name: x\nsteps:\n  - {action: print, retry: {backoff: often}}\n",
+		} {
+			if _, err := engine.ParseWorkflow([]byte(yamlContent)); err == nil {
+				t.Errorf("expected error for %q", yamlContent)
+			}
+		}
+	})
+}
+
+func TestWorkflowResume(t *testing.T) {
+	var calls []string
+	failDeploy := true
+	engine := NewWorkflowEngine(nil)
+	engine.RegisterHandler("mock_search", func(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+		calls = append(calls, "search")
+		return []ObjectRef{{Type: "CLAS", Name: "ZCL_A"}}, nil
+	})
+	engine.RegisterHandler("mock_test", func(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+		calls = append(calls, "test")
+		return &TestSummary{TotalTests: 3, PassedTests: 3}, nil
+	})
+	engine.RegisterHandler("mock_deploy", func(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+		calls = append(calls, "deploy")
+		// Saved outputs come back with their Go types
+		objects, ok := ctx.Get("objects")
+		if _, typed := objects.([]ObjectRef); !ok || !typed {
+			return nil, fmt.Errorf("objects not restored: %T", objects)
+		}
+		if _, ok := ctx.Get("tests"); !ok {
+			return nil, fmt.Errorf("tests not restored")
+		}
+		if failDeploy {
+			return nil, fmt.Errorf("transport locked")
+		}
+		return "deployed to " + ctx.GetVariable("TARGET"), nil
+	})
+
+	workflow := &Workflow{
+		Name:      "deploy",
+		Variables: map[string]string{"TARGET": "QAS"},
+		Steps: []WorkflowStep{
+			{ID: "discover", Action: "mock_search", SaveAs: "objects"},
+			{ID: "tests", Action: "mock_test", SaveAs: "tests"},
+			{ID: "deploy", Action: "mock_deploy", SaveAs: "deployment"},
+		},
+	}
+	statePath := t.TempDir() + "/deploy.state.json"
+
+	result, _ := engine.Execute(context.Background(), workflow, WithStateFile(statePath))
+	if result.Success {
+		t.Fatal("expected first run to fail")
+	}
+
+	state, err := LoadWorkflowState(statePath)
+	if err != nil {
+		t.Fatalf("LoadWorkflowState failed: %v", err)
+	}
+	if state.Success || len(state.Steps) != 3 || state.Steps[2].Success {
+		t.Fatalf("unexpected state: success=%v steps=%v", state.Success, state.Steps)
+	}
+	if state.Results["objects"].Type != "objects" || state.Results["tests"].Type != "test_summary" {
+		t.Errorf("unexpected saved output types: %v", state.Results)
+	}
+
+	// Resume starts at the failed step
+	calls = nil
+	failDeploy = false
+	result, _ = engine.Execute(context.Background(), workflow, WithResume(state, ""), WithVariables(map[string]string{"TARGET": "PRD"}), WithStateFile(statePath))
+	if !result.Success {
+		t.Fatalf("expected resumed run to succeed, got: %s", result.Error)
+	}
+	if got := strings.Join(calls, ","); got != "deploy" {
+		t.Errorf("expected only deploy to run, got %s", got)
+	}
+	if len(result.StepResults) != 3 || !result.StepResults[0].Resumed || result.StepResults[2].Resumed {
+		t.Errorf("unexpected step results: %+v", result.StepResults)
+	}
+	if result.Variables["deployment"] != "deployed to PRD" {
+		t.Errorf("expected variable override, got %v", result.Variables["deployment"])
+	}
+	if state, _ = LoadWorkflowState(statePath); !state.Success {
+		t.Errorf("expected state file to record success")
+	}
+
+	// --from restarts at the given step
+	calls = nil
+	result, _ = engine.Execute(context.Background(), workflow, WithResume(state, "tests"))
+	if got := strings.Join(calls, ","); !result.Success || got != "test,deploy" {
+		t.Errorf("expected test,deploy to run, got %s (%s)", got, result.Error)
+	}
+
+	result, _ = engine.Execute(context.Background(), workflow, WithResume(state, "publish"))
+	if result.Success || result.Error != `resume: unknown step "publish"` {
+		t.Errorf("unexpected error: %s", result.Error)
+	}
+
+	t.Run("NotSerializable", func(t *testing.T) {
+		engine := NewWorkflowEngine(nil)
+		engine.RegisterHandler("mock_chan", func(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+			return make(chan int), nil
+		})
+		workflow := &Workflow{Name: "x", Steps: []WorkflowStep{{Action: "mock_chan", SaveAs: "ch"}}}
+		result, _ := engine.Execute(context.Background(), workflow, WithStateFile(t.TempDir()+"/x.json"))
+		if result.Success || !strings.Contains(result.Error, `saved output "ch" is not serializable`) {
+			t.Errorf("unexpected result: success=%v error=%s", result.Success, result.Error)
+		}
+	})
+}
+
+func TestWorkflowToolAction(t *testing.T) {
+	var called []string
+	caller := func(ctx context.Context, name string, args map[string]interface{}) (interface{}, error) {
+		called = append(called, fmt.Sprintf("%s(%v)", name, args["object_name"]))
+		if name == "RunATCCheck" {
+			return map[string]interface{}{"findings": []interface{}{map[string]interface{}{"priority": float64(1)}}}, nil
+		}
+		return nil, fmt.Errorf("%s: not allowed", name)
+	}
+
+	engine := NewWorkflowEngine(nil)
+	workflow, err := engine.ParseWorkflow([]byte(`
+name: atc
+steps:
+  - action: tool
+    tool: RunATCCheck
+    arguments:
+      object_name: ${CLASS}
+    saveAs: atc
+  - action: fail_if
+    parameters:
+      condition: len(atc.findings) > 0
+      message: ATC findings
+`))
+	if err != nil {
+		t.Fatalf("ParseWorkflow failed: %v", err)
+	}
+
+	result, _ := engine.Execute(context.Background(), workflow, WithToolCaller(caller), WithVariables(map[string]string{"CLASS": "ZCL_ORDER"}))
+	if result.Success || result.Error != "step 'step_2_fail_if' failed: ATC findings" {
+		t.Errorf("expected fail_if on ATC result, got success=%v error=%s", result.Success, result.Error)
+	}
+	if len(called) != 1 || called[0] != "RunATCCheck(ZCL_ORDER)" {
+		t.Errorf("unexpected calls: %v", called)
+	}
+
+	// Tool errors (e.g. blocked by safety settings) fail the step
+	workflow.Steps = []WorkflowStep{{Action: "tool", Parameters: map[string]interface{}{"tool": "WriteSource"}}}
+	result, _ = engine.Execute(context.Background(), workflow, WithToolCaller(caller))
+	if result.Success || !strings.Contains(result.Error, "WriteSource: not allowed") {
+		t.Errorf("expected tool error, got: %s", result.Error)
+	}
+
+	// Dry runs report the call; without a caller the step fails
+	called = nil
+	result, _ = engine.Execute(context.Background(), workflow, WithToolCaller(caller), WithDryRun(true))
+	if !result.Success || len(called) != 0 {
+		t.Errorf("expected dry run without calls, got success=%v calls=%v", result.Success, called)
+	}
+	result, _ = engine.Execute(context.Background(), workflow)
+	if result.Success || !strings.Contains(result.Error, "no tools available") {
+		t.Errorf("expected missing caller error, got: %s", result.Error)
+	}
+
+	for _, yamlContent := range []string{
+		"name: x\nsteps:\n  - action: tool\n    arguments: {a: 1}\n",
+		"name: x\nsteps:\n  - action: print\n    tool: GetSource\n",
+	} {
+		if _, err := engine.ParseWorkflow([]byte(yamlContent)); err == nil {
+			t.Errorf("expected parse error for %q", yamlContent)
+		}
+	}
+}
+
+func TestWorkflowLuaAction(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "label.lua"), []byte(`return vars.PACKAGE .. ":" .. vars.count`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "lua.yaml"), []byte(`
+name: lua
+variables:
+  PACKAGE: $ZTEST
+steps:
+  - action: search
+    saveAs: objects
+  - action: lua
+    parameters:
+      script: |
+        local n = 0
+        for _, obj in ipairs(vars.objects) do
+          if obj.type == "CLAS" then n = n + 1 end
+        end
+        return n
+    saveAs: count
+  - action: fail_if
+    parameters:
+      condition: count != 2
+      message: wrong count
+  - action: lua
+    parameters:
+      file: label.lua
+    saveAs: label
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	engine := NewWorkflowEngine(nil)
+	engine.RegisterHandler("search", func(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+		return []ObjectRef{{Type: "CLAS", Name: "ZCL_A"}, {Type: "PROG", Name: "ZPROG"}, {Type: "CLAS", Name: "ZCL_B"}}, nil
+	})
+	workflow, err := engine.LoadWorkflow(filepath.Join(dir, "lua.yaml"))
+	if err != nil {
+		t.Fatalf("LoadWorkflow failed: %v", err)
+	}
+
+	result, _ := engine.Execute(context.Background(), workflow)
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Error)
+	}
+	if result.Variables["count"] != int64(2) || result.Variables["label"] != "$ZTEST:2" {
+		t.Errorf("unexpected outputs: count=%#v label=%#v", result.Variables["count"], result.Variables["label"])
+	}
+
+	// ${VAR} is not expanded in scripts: a value with quotes stays data
+	quoted := &Workflow{Name: "quoted", Steps: []WorkflowStep{{
+		Action:     "lua",
+		Parameters: map[string]interface{}{"script": `return "${TITLE}" .. "|" .. vars.TITLE`},
+		SaveAs:     "out",
+	}}}
+	result, _ = engine.Execute(context.Background(), quoted, WithVariables(map[string]string{"TITLE": `a" .. os.exit() .. "`}))
+	if !result.Success || result.Variables["out"] != `${TITLE}|a" .. os.exit() .. "` {
+		t.Errorf("unexpected output: %#v (%s)", result.Variables["out"], result.Error)
+	}
+
+	// Script errors fail the step; dry runs do not run scripts
+	workflow.Steps = []WorkflowStep{{Action: "lua", Parameters: map[string]interface{}{"script": "error('bad input')"}}}
+	result, _ = engine.Execute(context.Background(), workflow)
+	if result.Success || !strings.Contains(result.Error, "bad input") {
+		t.Errorf("expected script error, got: %s", result.Error)
+	}
+	result, _ = engine.Execute(context.Background(), workflow, WithDryRun(true))
+	if !result.Success {
+		t.Errorf("expected dry run to skip the script, got: %s", result.Error)
+	}
+
+	for _, yamlContent := range []string{
+		"name: x\nsteps:\n  - action: lua\n",
+		"name: x\nsteps:\n  - action: lua\n    parameters: {script: 'return 1', file: a.lua}\n",
+	} {
+		if _, err := engine.ParseWorkflow([]byte(yamlContent)); err == nil {
+			t.Errorf("expected parse error for %q", yamlContent)
+		}
+	}
+}
+
+func TestWorkflowCall(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("verify.yaml", `
+name: verify
+variables:
+  CLASS: ZCL_DEFAULT
+  MODE: strict
+steps:
+  - action: check
+    parameters:
+      object: ${CLASS}
+      mode: ${MODE}
+    saveAs: checked
+`)
+	write("main.yaml", `
+name: main
+steps:
+  - action: call
+    parameters:
+      workflow: verify.yaml
+      variables:
+        CLASS: ${TARGET}
+    saveAs: verify
+  - action: fail_if
+    parameters:
+      condition: verify.checked != "ZCL_ORDER/strict"
+      message: wrong object
+  - action: include
+    parameters:
+      workflow: verify.yaml
+`)
+	write("loop.yaml", `
+name: loop
+steps:
+  - action: call
+    parameters:
+      workflow: loop.yaml
+`)
+
+	var checked []string
+	engine := NewWorkflowEngine(nil)
+	engine.RegisterHandler("check", func(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+		object := fmt.Sprintf("%v/%v", params["object"], params["mode"])
+		checked = append(checked, object)
+		if params["object"] == "ZCL_BROKEN" {
+			return nil, fmt.Errorf("%s is broken", params["object"])
+		}
+		return object, nil
+	})
+
+	workflow, err := engine.LoadWorkflow(filepath.Join(dir, "main.yaml"))
+	if err != nil {
+		t.Fatalf("LoadWorkflow failed: %v", err)
+	}
+	result, _ := engine.Execute(context.Background(), workflow, WithVariables(map[string]string{"TARGET": "ZCL_ORDER"}))
+	if !result.Success {
+		t.Fatalf("expected success, got: %s", result.Error)
+	}
+	if got := strings.Join(checked, ","); got != "ZCL_ORDER/strict,ZCL_DEFAULT/strict" {
+		t.Errorf("unexpected checks: %s", got)
+	}
+
+	// A failing called workflow fails the calling step
+	result, _ = engine.Execute(context.Background(), workflow, WithVariables(map[string]string{"TARGET": "ZCL_BROKEN"}))
+	if result.Success || !strings.Contains(result.Error, "workflow 'verify' failed") || !strings.Contains(result.Error, "ZCL_BROKEN is broken") {
+		t.Errorf("expected called workflow failure, got: %s", result.Error)
+	}
+
+	// Dry run is inherited by the called workflow
+	engine.RegisterHandler("check", func(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+		if !ctx.IsDryRun() {
+			return nil, fmt.Errorf("expected dry run")
+		}
+		return fmt.Sprintf("%v/%v", params["object"], params["mode"]), nil
+	})
+	result, _ = engine.Execute(context.Background(), workflow, WithDryRun(true), WithVariables(map[string]string{"TARGET": "ZCL_ORDER"}))
+	if !result.Success {
+		t.Errorf("expected dry run to succeed, got: %s", result.Error)
+	}
+
+	loop, err := engine.LoadWorkflow(filepath.Join(dir, "loop.yaml"))
+	if err != nil {
+		t.Fatalf("LoadWorkflow failed: %v", err)
+	}
+	result, _ = engine.Execute(context.Background(), loop)
+	if result.Success || !strings.Contains(result.Error, "workflow call cycle: loop.yaml -> loop.yaml") {
+		t.Errorf("expected call cycle error, got: %s", result.Error)
+	}
+
+	if _, err := engine.ParseWorkflow([]byte("name: x\nsteps:\n  - action: include\n")); err == nil {
+		t.Error("expected parse error for include without workflow")
+	}
+}
+
+func TestCheckWorkflow(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "deploy.yaml")
+	if err := os.WriteFile(path, []byte(`
+name: deploy
+variables:
+  PACKAGE: $ZTEST
+steps:
+  - id: discover
+    action: serach
+    parameters:
+      query: ${PACKAGE}
+  - id: find
+    action: search
+    parameters:
+      qeury: ZCL_*
+      maxResults: many
+    saveAs: objects
+  - id: check
+    action: syntax_check
+    dependsOn: [discover]
+    parameters:
+      objects: objects
+  - id: test
+    action: test
+    dependsOn: [find]
+    parameters:
+      objects: objects
+      package: ${TARGET}
+    saveAs: objects
+    condition: len(objects) >
+  - id: sub
+    action: call
+    parameters:
+      workflow: missing.yaml
+  - action: print
+    saveas: x
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	engine := NewWorkflowEngine(nil)
+	issues, err := engine.CheckWorkflowFile(path, nil)
+	if err != nil {
+		t.Fatalf("CheckWorkflowFile failed: %v", err)
+	}
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.String())
+	}
+	want := []string{
+		`error: line 34: field saveas not found in type dsl.WorkflowStep`,
+		`error: step "discover": unknown action "serach" (did you mean "search"?)`,
+		`error: step "find": parameter "maxResults" must be of type integer`,
+		`error: step "find": unknown parameter "qeury" for action search (did you mean "query"?)`,
+		`error: step "check": objects refers to "objects", which is saved by step "find" that does not run before it`,
+		`error: step "test": expression "len(objects) >": unexpected "end of expression" at offset 14`,
+		`error: step "test": saveAs "objects" is also used by step "find"`,
+		`error: step "test": ${TARGET} is not defined by the workflow variables or an earlier step`,
+		`error: step "sub": file missing.yaml not found`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected issues:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Variables given at run time and set_var define names for later steps
+	workflow, err := engine.ParseWorkflow([]byte(`
+name: ok
+steps:
+  - action: set_var
+    parameters: {name: MODE, value: strict}
+  - action: print
+    parameters: {message: "${MODE} ${TARGET}"}
+  - action: search
+    saveAs: objects
+  - action: export
+    parameters: {objects: objects, outputDir: ./out}
+`))
+	if err != nil {
+		t.Fatalf("ParseWorkflow failed: %v", err)
+	}
+	if issues := engine.CheckWorkflow(workflow, []string{"TARGET"}); len(issues) != 0 {
+		t.Errorf("expected no issues, got %v", issues)
+	}
+	issues = engine.CheckWorkflow(workflow, nil)
+	if !HasErrors(issues) || len(issues) != 1 || !strings.Contains(issues[0].Message, "${TARGET}") {
+		t.Errorf("expected undefined TARGET, got %v", issues)
+	}
+
+	// Custom actions are checked once described
+	engine.RegisterHandler("notify", func(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) { return nil, nil })
+	workflow.Steps = []WorkflowStep{{Action: "notify", Parameters: map[string]interface{}{"chanel": "#ci"}}}
+	if issues := engine.CheckWorkflow(workflow, nil); len(issues) != 0 {
+		t.Errorf("expected undescribed action to be accepted, got %v", issues)
+	}
+	engine.DescribeAction("notify", ActionSpec{Params: map[string]ParamSpec{"channel": {Type: "string", Required: true}}})
+	issues = engine.CheckWorkflow(workflow, nil)
+	if len(issues) != 2 || !strings.Contains(issues[0].Message, `did you mean "channel"`) || !strings.Contains(issues[1].Message, `missing required parameter "channel"`) {
+		t.Errorf("unexpected issues for described action: %v", issues)
+	}
+}
+
+func TestWorkflowJSONSchema(t *testing.T) {
+	schema := NewWorkflowEngine(nil).JSONSchema()
+	data, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("schema is not serializable: %v", err)
+	}
+	definitions := schema["definitions"].(map[string]interface{})
+	step := definitions["step"].(map[string]interface{})
+	action := step["properties"].(map[string]interface{})["action"].(map[string]interface{})
+	if !strings.Contains(fmt.Sprint(action["enum"]), "syntax_check") {
+		t.Errorf("expected actions in enum, got %v", action["enum"])
+	}
+	params, ok := definitions["params_fail_if"].(map[string]interface{})
+	if !ok || fmt.Sprint(params["required"]) != "[condition]" {
+		t.Errorf("unexpected fail_if parameters: %v", definitions["params_fail_if"])
+	}
+	if !strings.Contains(string(data), `"#/definitions/params_search"`) {
+		t.Error("expected step parameters to refer to the action definitions")
+	}
+}
+
+func TestHandleActivateLockConflict(t *testing.T) {
+	const locked = `<?xml version="1.0" encoding="utf-8"?>
+<chkl:messages xmlns:chkl="http://www.sap.com/abapxml/checklist">
+  <msg objDescr="Class ZCL_TEST" type="E" line="1">
+    <shortText><txt>Object ZCL_TEST is locked by user DEV</txt></shortText>
+  </msg>
+</chkl:messages>`
+
+	activations := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "discovery"):
+			w.Header().Set("X-CSRF-Token", "token")
+		case strings.Contains(r.URL.Path, "activation"):
+			activations++
+			w.Write([]byte(locked))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	engine := NewWorkflowEngine(adt.NewClient(server.URL, "u", "p"))
+	engine.RegisterHandler("find", func(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+		return []ObjectRef{{Type: TypeClass, Name: "ZCL_TEST"}}, nil
+	})
+	workflow, err := engine.ParseWorkflow([]byte(`
+name: activate
+steps:
+  - name: find
+    action: find
+    saveAs: objs
+  - name: activate
+    action: activate
+    parameters: {objects: objs}
+    retry: {attempts: 2, backoff: 1ms, on: [lock_conflict]}
+`))
+	if err != nil {
+		t.Fatalf("ParseWorkflow failed: %v", err)
+	}
+
+	result, _ := engine.Execute(context.Background(), workflow)
+	if result.Success {
+		t.Fatal("expected activation failure")
+	}
+	if activations != 2 || result.StepResults[1].Attempts != 2 {
+		t.Errorf("expected the lock conflict to be retried, got %d activations", activations)
+	}
+	if !strings.Contains(result.Error, "locked by user DEV") {
+		t.Errorf("unexpected error: %s", result.Error)
+	}
+}
@@ -0,0 +1,72 @@
+package dsl
+
+import (
+	"context"
+	"fmt"
+)
+
+// ToolCaller calls a tool by name, e.g. a tool of the MCP server, and returns
+// its decoded result.
+type ToolCaller func(ctx context.Context, name string, args map[string]interface{}) (interface{}, error)
+
+// WithToolCaller makes the tools of caller available to the "tool" action.
+func WithToolCaller(caller ToolCaller) ExecuteOption {
+	return func(ctx *ExecutionContext) {
+		ctx.tools = caller
+	}
+}
+
+// toolParams merges the step-level tool and arguments into the step
+// parameters, so they can be given either on the step or in parameters.
+func toolParams(step WorkflowStep) map[string]interface{} {
+	if step.Tool == "" && step.Arguments == nil {
+		return step.Parameters
+	}
+	params := make(map[string]interface{}, len(step.Parameters)+2)
+	for k, v := range step.Parameters {
+		params[k] = v
+	}
+	if step.Tool != "" {
+		params["tool"] = step.Tool
+	}
+	if step.Arguments != nil {
+		params["arguments"] = step.Arguments
+	}
+	return params
+}
+
+// validateToolStep checks that a tool step names its tool.
+func validateToolStep(step WorkflowStep) error {
+	if step.Action != "tool" {
+		if step.Tool != "" || step.Arguments != nil {
+			return fmt.Errorf("tool and arguments are only valid with action: tool")
+		}
+		return nil
+	}
+	if name, _ := toolParams(step)["tool"].(string); name == "" {
+		return fmt.Errorf("tool action requires a tool name")
+	}
+	return nil
+}
+
+func handleTool(ctx *ExecutionContext, params map[string]interface{}) (interface{}, error) {
+	name, _ := params["tool"].(string)
+	if name == "" {
+		return nil, fmt.Errorf("tool action requires 'tool' parameter")
+	}
+	args := map[string]interface{}{}
+	if a, ok := params["arguments"].(map[string]interface{}); ok {
+		args = a
+	} else if params["arguments"] != nil {
+		return nil, fmt.Errorf("tool arguments must be a map")
+	}
+
+	// Tools may change the system; dry runs only report the call
+	if ctx.IsDryRun() {
+		return map[string]interface{}{"dryRun": true, "action": "tool", "tool": name, "arguments": args}, nil
+	}
+	if ctx.tools == nil {
+		return nil, fmt.Errorf("no tools available to call %s", name)
+	}
+	return ctx.tools(ctx.Context(), name, args)
+}
@@ -0,0 +1,174 @@
+package graph
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Supported export formats.
+const (
+	FormatDOT     = "dot"
+	FormatGraphML = "graphml"
+	FormatMermaid = "mermaid"
+)
+
+// typeColors maps object type -> fill color used by DOT and Mermaid output.
+var typeColors = map[string]string{
+	"PROG":  "#4472C4",
+	"INCL":  "#6FA8DC",
+	"CLAS":  "#6AA84F",
+	"INTF":  "#93C47D",
+	"FUNC":  "#E69138",
+	"FUGR":  "#F6B26B",
+	"DDLS":  "#45818E",
+	"BDEF":  "#76A5AF",
+	"SRVD":  "#4A86A8",
+	"TABL":  "#B7B7B7",
+	"TABLE": "#B7B7B7",
+	"VIEW":  "#A4C2F4",
+	"DEVC":  "#FFD966",
+}
+
+// Export renders the graph in the given format.
+func Export(g *Graph, format string) (string, error) {
+	switch strings.ToLower(format) {
+	case FormatDOT, "gv":
+		return ToDOT(g), nil
+	case FormatGraphML, "xml":
+		return ToGraphML(g), nil
+	case FormatMermaid, "mmd":
+		return ToMermaid(g), nil
+	default:
+		return "", fmt.Errorf("unsupported graph format: %s (expected dot, graphml or mermaid)", format)
+	}
+}
+
+// ToDOT renders the graph as a Graphviz digraph.
+func ToDOT(g *Graph) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "digraph %s {\n", dotQuote(g.Name))
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [shape=box, style=\"rounded,filled\", fillcolor=\"#EEEEEE\", fontname=\"Helvetica\"];\n")
+	sb.WriteString("  edge [fontname=\"Helvetica\", fontsize=10];\n\n")
+
+	for _, n := range g.sortedNodes() {
+		attrs := []string{"label=" + dotQuote(nodeLabel(n))}
+		if color, ok := typeColors[n.Type]; ok {
+			attrs = append(attrs, "fillcolor="+dotQuote(color))
+		}
+		fmt.Fprintf(&sb, "  %s [%s];\n", dotQuote(n.ID), strings.Join(attrs, ", "))
+	}
+	if len(g.Edges) > 0 {
+		sb.WriteString("\n")
+	}
+	for _, e := range g.Edges {
+		if e.Label != "" {
+			fmt.Fprintf(&sb, "  %s -> %s [label=%s];\n", dotQuote(e.From), dotQuote(e.To), dotQuote(e.Label))
+		} else {
+			fmt.Fprintf(&sb, "  %s -> %s;\n", dotQuote(e.From), dotQuote(e.To))
+		}
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// ToGraphML renders the graph as GraphML with label, type, package and depth attributes.
+func ToGraphML(g *Graph) string {
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	sb.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="http://graphml.graphdrawing.org/xmlns http://graphml.graphdrawing.org/xmlns/1.0/graphml.xsd">` + "\n")
+	sb.WriteString(`  <key id="label" for="node" attr.name="label" attr.type="string"/>` + "\n")
+	sb.WriteString(`  <key id="type" for="node" attr.name="type" attr.type="string"/>` + "\n")
+	sb.WriteString(`  <key id="package" for="node" attr.name="package" attr.type="string"/>` + "\n")
+	sb.WriteString(`  <key id="depth" for="node" attr.name="depth" attr.type="int"/>` + "\n")
+	sb.WriteString(`  <key id="relation" for="edge" attr.name="relation" attr.type="string"/>` + "\n")
+	fmt.Fprintf(&sb, "  <graph id=\"%s\" edgedefault=\"directed\">\n", xmlEscape(g.Name))
+
+	for _, n := range g.sortedNodes() {
+		fmt.Fprintf(&sb, "    <node id=\"%s\">\n", xmlEscape(n.ID))
+		fmt.Fprintf(&sb, "      <data key=\"label\">%s</data>\n", xmlEscape(n.Name))
+		fmt.Fprintf(&sb, "      <data key=\"type\">%s</data>\n", xmlEscape(n.Type))
+		fmt.Fprintf(&sb, "      <data key=\"package\">%s</data>\n", xmlEscape(n.Package))
+		fmt.Fprintf(&sb, "      <data key=\"depth\">%d</data>\n", n.Depth)
+		sb.WriteString("    </node>\n")
+	}
+	for i, e := range g.Edges {
+		fmt.Fprintf(&sb, "    <edge id=\"e%d\" source=\"%s\" target=\"%s\">\n", i, xmlEscape(e.From), xmlEscape(e.To))
+		fmt.Fprintf(&sb, "      <data key=\"relation\">%s</data>\n", xmlEscape(e.Label))
+		sb.WriteString("    </edge>\n")
+	}
+
+	sb.WriteString("  </graph>\n")
+	sb.WriteString("</graphml>\n")
+	return sb.String()
+}
+
+var mermaidUnsafe = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// ToMermaid renders the graph as a Mermaid flowchart (without ``` fences).
+func ToMermaid(g *Graph) string {
+	ids := make(map[string]string)
+	mermaidID := func(id string) string {
+		if m, ok := ids[id]; ok {
+			return m
+		}
+		m := fmt.Sprintf("n%d_%s", len(ids), mermaidUnsafe.ReplaceAllString(id, "_"))
+		ids[id] = m
+		return m
+	}
+
+	var sb strings.Builder
+	sb.WriteString("flowchart LR\n")
+
+	nodes := g.sortedNodes()
+	usedTypes := make(map[string]bool)
+	for _, n := range nodes {
+		if _, ok := typeColors[n.Type]; ok {
+			usedTypes[n.Type] = true
+		}
+	}
+	for _, n := range nodes {
+		if usedTypes[n.Type] {
+			fmt.Fprintf(&sb, "    classDef %s fill:%s,stroke:#555\n", n.Type, typeColors[n.Type])
+			usedTypes[n.Type] = false
+		}
+	}
+
+	for _, n := range nodes {
+		label := strings.ReplaceAll(nodeLabel(n), `"`, "#quot;")
+		fmt.Fprintf(&sb, "    %s[\"%s\"]", mermaidID(n.ID), label)
+		if _, ok := typeColors[n.Type]; ok {
+			fmt.Fprintf(&sb, ":::%s", n.Type)
+		}
+		sb.WriteString("\n")
+	}
+	for _, e := range g.Edges {
+		if e.Label != "" {
+			fmt.Fprintf(&sb, "    %s -->|\"%s\"| %s\n", mermaidID(e.From), strings.ReplaceAll(e.Label, `"`, "#quot;"), mermaidID(e.To))
+		} else {
+			fmt.Fprintf(&sb, "    %s --> %s\n", mermaidID(e.From), mermaidID(e.To))
+		}
+	}
+	return sb.String()
+}
+
+func nodeLabel(n *Node) string {
+	if n.Type == "" || n.Type == "DEVC" {
+		return n.Name
+	}
+	return n.Type + ": " + n.Name
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+func xmlEscape(s string) string {
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
@@ -0,0 +1,341 @@
+// Package graph provides a format-neutral object graph model with exporters
+// for DOT (Graphviz), GraphML (Gephi, yEd) and Mermaid flowcharts.
+package graph
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+	"github.com/oisee/vibing-steampunk/pkg/cache"
+)
+
+// Node is a vertex of an object graph.
+type Node struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`              // CLAS, PROG, FUNC, DDLS, TABLE, DEVC, ...
+	Package string `json:"package,omitempty"` // Empty if unknown
+	Depth   int    `json:"depth"`             // Hops from the root (or seed package)
+}
+
+// Edge is a directed relationship between two nodes.
+type Edge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Label string `json:"label,omitempty"` // calls, FROM, ASSOCIATION, USES, ...
+}
+
+// Graph is a directed object graph.
+type Graph struct {
+	Name  string  `json:"name"`
+	Nodes []*Node `json:"nodes"`
+	Edges []Edge  `json:"edges"`
+
+	index map[string]*Node
+	edges map[string]bool
+}
+
+// New creates an empty graph.
+func New(name string) *Graph {
+	return &Graph{
+		Name:  name,
+		index: make(map[string]*Node),
+		edges: make(map[string]bool),
+	}
+}
+
+// AddNode adds a node. If a node with the same ID exists, the smaller depth wins
+// and missing package information is filled in.
+func (g *Graph) AddNode(n *Node) *Node {
+	if existing, ok := g.index[n.ID]; ok {
+		if n.Depth < existing.Depth {
+			existing.Depth = n.Depth
+		}
+		if existing.Package == "" {
+			existing.Package = n.Package
+		}
+		return existing
+	}
+	g.index[n.ID] = n
+	g.Nodes = append(g.Nodes, n)
+	return n
+}
+
+// AddEdge adds a directed edge, ignoring duplicates.
+func (g *Graph) AddEdge(from, to, label string) {
+	key := from + "\x00" + to + "\x00" + label
+	if g.edges[key] {
+		return
+	}
+	g.edges[key] = true
+	g.Edges = append(g.Edges, Edge{From: from, To: to, Label: label})
+}
+
+// Node returns the node with the given ID.
+func (g *Graph) Node(id string) (*Node, bool) {
+	n, ok := g.index[id]
+	return n, ok
+}
+
+// --- Builders ---
+
+// FromCallGraph converts an ADT call hierarchy into a graph.
+// direction is the direction used to fetch it: for "callers" edges point from child
+// to parent (child calls parent), otherwise from parent to child.
+func FromCallGraph(root *adt.CallGraphNode, direction string) *Graph {
+	g := New("callgraph")
+	if root == nil {
+		return g
+	}
+	g.Name = root.Name
+
+	id := func(n *adt.CallGraphNode) string {
+		if n.URI != "" {
+			return n.URI
+		}
+		return n.Type + ":" + n.Name
+	}
+
+	var walk func(n *adt.CallGraphNode, depth int)
+	walk = func(n *adt.CallGraphNode, depth int) {
+		g.AddNode(&Node{ID: id(n), Name: n.Name, Type: baseType(n.Type), Depth: depth})
+		for i := range n.Children {
+			child := &n.Children[i]
+			walk(child, depth+1)
+			if direction == "callers" {
+				g.AddEdge(id(child), id(n), "calls")
+			} else {
+				g.AddEdge(id(n), id(child), "calls")
+			}
+		}
+	}
+	walk(root, 0)
+	return g
+}
+
+// FromCDSDependencies converts a CDS dependency tree into a graph.
+// Edges point from a view to the entities it selects from or associates.
+func FromCDSDependencies(root *adt.CDSDependencyNode) *Graph {
+	g := New("cds")
+	if root == nil {
+		return g
+	}
+	g.Name = root.Name
+
+	var walk func(n *adt.CDSDependencyNode, depth int)
+	walk = func(n *adt.CDSDependencyNode, depth int) {
+		g.AddNode(&Node{ID: n.Name, Name: n.Name, Type: n.Type, Depth: depth})
+		for i := range n.Children {
+			child := &n.Children[i]
+			walk(child, depth+1)
+			label := child.Relation
+			if label == "" {
+				label = "uses"
+			}
+			g.AddEdge(n.Name, child.Name, label)
+		}
+	}
+	walk(root, 0)
+	return g
+}
+
+// FromCache builds a package graph from the object graph cache.
+// All cached nodes of the given packages are seeds (depth 0); outgoing edges are
+// followed up to maxDepth hops, so dependencies outside the packages are included.
+// Member nodes (methods) are folded into their enclosing object.
+func FromCache(ctx context.Context, c cache.Cache, packages []string, maxDepth int) (*Graph, error) {
+	g := New(strings.Join(packages, ","))
+
+	objectOf := func(n *cache.Node) *Node {
+		if n.EnclosingName != "" {
+			return &Node{ID: n.EnclosingType + ":" + n.EnclosingName, Name: n.EnclosingName, Type: n.EnclosingType, Package: n.Package}
+		}
+		return &Node{ID: n.ObjectType + ":" + n.ObjectName, Name: n.ObjectName, Type: n.ObjectType, Package: n.Package}
+	}
+	resolve := func(id string) *Node {
+		if n, err := c.GetNode(ctx, id); err == nil {
+			return objectOf(n)
+		}
+		return &Node{ID: id, Name: id}
+	}
+
+	type queued struct {
+		id    string
+		depth int
+	}
+	visited := make(map[string]bool)
+	var queue []queued
+
+	for _, pkg := range packages {
+		nodes, err := c.GetNodesByPackage(ctx, pkg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get nodes of %s: %w", pkg, err)
+		}
+		for _, n := range nodes {
+			if visited[n.ID] {
+				continue
+			}
+			visited[n.ID] = true
+			g.AddNode(objectOf(n))
+			queue = append(queue, queued{id: n.ID})
+		}
+	}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if current.depth >= maxDepth {
+			continue
+		}
+
+		edges, err := c.GetEdgesFrom(ctx, current.id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get edges from %s: %w", current.id, err)
+		}
+
+		from := resolve(current.id)
+		for _, e := range edges {
+			to := resolve(e.ToID)
+			to.Depth = current.depth + 1
+			to = g.AddNode(to)
+			if from.ID != to.ID {
+				g.AddEdge(from.ID, to.ID, e.EdgeType)
+			}
+			if !visited[e.ToID] {
+				visited[e.ToID] = true
+				queue = append(queue, queued{id: e.ToID, depth: current.depth + 1})
+			}
+		}
+	}
+
+	return g, nil
+}
+
+// --- Filtering ---
+
+// FilterOptions restricts which nodes end up in an exported graph.
+type FilterOptions struct {
+	MaxDepth int      // Drop nodes deeper than this (0 = no limit)
+	Packages []string // Keep only nodes in these packages (wildcards allowed, e.g. Z*)
+	Types    []string // Keep only these object types (e.g. CLAS, PROG)
+	Collapse bool     // Collapse nodes into one node per package
+}
+
+// Filter returns a new graph with the options applied. Root nodes (depth 0)
+// are always kept so that the graph stays anchored.
+func (g *Graph) Filter(opts FilterOptions) *Graph {
+	out := New(g.Name)
+	for _, n := range g.Nodes {
+		if n.Depth > 0 {
+			if opts.MaxDepth > 0 && n.Depth > opts.MaxDepth {
+				continue
+			}
+			if len(opts.Packages) > 0 && !matchAny(opts.Packages, n.Package) {
+				continue
+			}
+			if len(opts.Types) > 0 && !matchType(opts.Types, n.Type) {
+				continue
+			}
+		}
+		copied := *n
+		out.AddNode(&copied)
+	}
+	for _, e := range g.Edges {
+		_, fromOK := out.index[e.From]
+		_, toOK := out.index[e.To]
+		if fromOK && toOK {
+			out.AddEdge(e.From, e.To, e.Label)
+		}
+	}
+
+	if opts.Collapse {
+		return out.CollapsePackages()
+	}
+	return out
+}
+
+// CollapsePackages returns a graph with one node per package. Edges between
+// packages are merged and labeled with the number of underlying edges;
+// edges inside a package are dropped.
+func (g *Graph) CollapsePackages() *Graph {
+	out := New(g.Name)
+	pkgID := func(n *Node) string {
+		if n.Package == "" {
+			return "DEVC:(unknown)"
+		}
+		return "DEVC:" + n.Package
+	}
+
+	for _, n := range g.Nodes {
+		name := n.Package
+		if name == "" {
+			name = "(unknown)"
+		}
+		out.AddNode(&Node{ID: pkgID(n), Name: name, Type: "DEVC", Package: n.Package, Depth: n.Depth})
+	}
+
+	counts := make(map[[2]string]int)
+	var order [][2]string
+	for _, e := range g.Edges {
+		from, to := pkgID(g.index[e.From]), pkgID(g.index[e.To])
+		if from == to {
+			continue
+		}
+		key := [2]string{from, to}
+		if counts[key] == 0 {
+			order = append(order, key)
+		}
+		counts[key]++
+	}
+	for _, key := range order {
+		out.AddEdge(key[0], key[1], fmt.Sprintf("%d", counts[key]))
+	}
+	return out
+}
+
+// sortedNodes returns nodes ordered by depth, then type and name.
+func (g *Graph) sortedNodes() []*Node {
+	nodes := make([]*Node, len(g.Nodes))
+	copy(nodes, g.Nodes)
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if nodes[i].Depth != nodes[j].Depth {
+			return nodes[i].Depth < nodes[j].Depth
+		}
+		if nodes[i].Type != nodes[j].Type {
+			return nodes[i].Type < nodes[j].Type
+		}
+		return nodes[i].Name < nodes[j].Name
+	})
+	return nodes
+}
+
+// baseType strips the ADT subtype (e.g. "PROG/P" -> "PROG").
+func baseType(t string) string {
+	if idx := strings.Index(t, "/"); idx > 0 {
+		return t[:idx]
+	}
+	return t
+}
+
+func matchAny(patterns []string, value string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(strings.ToUpper(p), strings.ToUpper(value)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func matchType(types []string, t string) bool {
+	for _, want := range types {
+		if strings.EqualFold(want, t) || strings.EqualFold(want, baseType(t)) {
+			return true
+		}
+	}
+	return false
+}
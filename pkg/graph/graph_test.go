@@ -0,0 +1,177 @@
+package graph
+
+import (
+	"context"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+	"github.com/oisee/vibing-steampunk/pkg/cache"
+)
+
+func sampleCallGraph() *adt.CallGraphNode {
+	return &adt.CallGraphNode{
+		URI: "/sap/bc/adt/oo/classes/ZCL_ORDER", Name: "ZCL_ORDER", Type: "CLAS/OC",
+		Children: []adt.CallGraphNode{
+			{URI: "/sap/bc/adt/oo/classes/ZCL_UTIL", Name: "ZCL_UTIL", Type: "CLAS/OC",
+				Children: []adt.CallGraphNode{
+					{URI: "/sap/bc/adt/functions/groups/ZFG/fmodules/Z_LOG", Name: "Z_LOG", Type: "FUGR/FF"},
+				},
+			},
+			{URI: "/sap/bc/adt/programs/programs/ZREPORT", Name: "ZREPORT", Type: "PROG/P"},
+		},
+	}
+}
+
+func TestFromCallGraph(t *testing.T) {
+	g := FromCallGraph(sampleCallGraph(), "callees")
+
+	if len(g.Nodes) != 4 {
+		t.Fatalf("expected 4 nodes, got %d", len(g.Nodes))
+	}
+	if len(g.Edges) != 3 {
+		t.Fatalf("expected 3 edges, got %d", len(g.Edges))
+	}
+	if g.Edges[0].From != "/sap/bc/adt/oo/classes/ZCL_UTIL" {
+		t.Errorf("callees: expected edge from ZCL_UTIL first, got %+v", g.Edges[0])
+	}
+	n, _ := g.Node("/sap/bc/adt/functions/groups/ZFG/fmodules/Z_LOG")
+	if n.Depth != 2 || n.Type != "FUGR" {
+		t.Errorf("Z_LOG: expected depth 2, type FUGR, got %+v", n)
+	}
+
+	callers := FromCallGraph(sampleCallGraph(), "callers")
+	if callers.Edges[0].To != "/sap/bc/adt/oo/classes/ZCL_UTIL" {
+		t.Errorf("callers: expected reversed edge, got %+v", callers.Edges[0])
+	}
+}
+
+func TestFromCDSDependencies(t *testing.T) {
+	root := &adt.CDSDependencyNode{
+		Name: "ZI_ORDER", Type: "CDS_VIEW",
+		Children: []adt.CDSDependencyNode{
+			{Name: "ZORDER", Type: "TABLE", Relation: "FROM"},
+			{Name: "ZI_CUSTOMER", Type: "CDS_VIEW", Relation: "ASSOCIATION"},
+		},
+	}
+
+	g := FromCDSDependencies(root)
+	if len(g.Nodes) != 3 || len(g.Edges) != 2 {
+		t.Fatalf("expected 3 nodes/2 edges, got %d/%d", len(g.Nodes), len(g.Edges))
+	}
+	if g.Edges[0].Label != "FROM" {
+		t.Errorf("expected FROM label, got %q", g.Edges[0].Label)
+	}
+}
+
+func TestFromCache(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewMemoryCache(cache.DefaultConfig())
+
+	c.PutNodes(ctx, []*cache.Node{
+		{ID: "CLAS:ZCL_A", ObjectType: "CLAS", ObjectName: "ZCL_A", Package: "$SD", Valid: true},
+		{ID: "ME:ZCL_A=>RUN", ObjectType: "METH", ObjectName: "RUN", EnclosingType: "CLAS", EnclosingName: "ZCL_A", Package: "$SD", Valid: true},
+		{ID: "CLAS:ZCL_B", ObjectType: "CLAS", ObjectName: "ZCL_B", Package: "$BASE", Valid: true},
+		{ID: "CLAS:ZCL_C", ObjectType: "CLAS", ObjectName: "ZCL_C", Package: "$BASE", Valid: true},
+	})
+	c.PutEdges(ctx, []*cache.Edge{
+		{FromID: "ME:ZCL_A=>RUN", ToID: "CLAS:ZCL_B", EdgeType: "CALLS", Valid: true},
+		{FromID: "CLAS:ZCL_B", ToID: "CLAS:ZCL_C", EdgeType: "USES", Valid: true},
+	})
+
+	g, err := FromCache(ctx, c, []string{"$SD"}, 1)
+	if err != nil {
+		t.Fatalf("FromCache failed: %v", err)
+	}
+	if len(g.Nodes) != 2 {
+		t.Fatalf("expected ZCL_A and ZCL_B, got %d nodes", len(g.Nodes))
+	}
+	if len(g.Edges) != 1 || g.Edges[0].From != "CLAS:ZCL_A" {
+		t.Errorf("expected method edge folded into ZCL_A, got %+v", g.Edges)
+	}
+
+	g, _ = FromCache(ctx, c, []string{"$SD"}, 2)
+	if len(g.Nodes) != 3 {
+		t.Errorf("expected 3 nodes with depth 2, got %d", len(g.Nodes))
+	}
+}
+
+func TestFilter(t *testing.T) {
+	g := FromCallGraph(sampleCallGraph(), "callees")
+
+	t.Run("depth", func(t *testing.T) {
+		f := g.Filter(FilterOptions{MaxDepth: 1})
+		if len(f.Nodes) != 3 || len(f.Edges) != 2 {
+			t.Errorf("expected 3 nodes/2 edges, got %d/%d", len(f.Nodes), len(f.Edges))
+		}
+	})
+
+	t.Run("type keeps root", func(t *testing.T) {
+		f := g.Filter(FilterOptions{Types: []string{"PROG"}})
+		if len(f.Nodes) != 2 {
+			t.Errorf("expected root + ZREPORT, got %d nodes", len(f.Nodes))
+		}
+	})
+
+	t.Run("collapse", func(t *testing.T) {
+		pg := New("pkgs")
+		pg.AddNode(&Node{ID: "A", Name: "ZCL_A", Type: "CLAS", Package: "$SD"})
+		pg.AddNode(&Node{ID: "A2", Name: "ZCL_A2", Type: "CLAS", Package: "$SD", Depth: 1})
+		pg.AddNode(&Node{ID: "B", Name: "ZCL_B", Type: "CLAS", Package: "$BASE", Depth: 1})
+		pg.AddEdge("A", "B", "CALLS")
+		pg.AddEdge("A2", "B", "USES")
+		pg.AddEdge("A", "A2", "CALLS")
+
+		f := pg.Filter(FilterOptions{Packages: []string{"$*"}, Collapse: true})
+		if len(f.Nodes) != 2 {
+			t.Fatalf("expected 2 package nodes, got %d", len(f.Nodes))
+		}
+		if len(f.Edges) != 1 || f.Edges[0].Label != "2" {
+			t.Errorf("expected one merged edge with count 2, got %+v", f.Edges)
+		}
+	})
+}
+
+func TestExportFormats(t *testing.T) {
+	g := FromCallGraph(sampleCallGraph(), "callees")
+
+	dot, err := Export(g, "dot")
+	if err != nil {
+		t.Fatalf("dot export failed: %v", err)
+	}
+	if !strings.HasPrefix(dot, `digraph "ZCL_ORDER" {`) ||
+		!strings.Contains(dot, `"/sap/bc/adt/oo/classes/ZCL_ORDER" -> "/sap/bc/adt/oo/classes/ZCL_UTIL" [label="calls"];`) {
+		t.Errorf("unexpected DOT output:\n%s", dot)
+	}
+
+	graphml, _ := Export(g, "graphml")
+	var parsed struct {
+		Graph struct {
+			Nodes []struct {
+				ID string `xml:"id,attr"`
+			} `xml:"node"`
+			Edges []struct {
+				Source string `xml:"source,attr"`
+			} `xml:"edge"`
+		} `xml:"graph"`
+	}
+	if err := xml.Unmarshal([]byte(graphml), &parsed); err != nil {
+		t.Fatalf("GraphML is not valid XML: %v", err)
+	}
+	if len(parsed.Graph.Nodes) != 4 || len(parsed.Graph.Edges) != 3 {
+		t.Errorf("GraphML: expected 4 nodes/3 edges, got %d/%d", len(parsed.Graph.Nodes), len(parsed.Graph.Edges))
+	}
+
+	mermaid, _ := Export(g, "mermaid")
+	if !strings.HasPrefix(mermaid, "flowchart LR\n") || !strings.Contains(mermaid, `["CLAS: ZCL_UTIL"]:::CLAS`) {
+		t.Errorf("unexpected Mermaid output:\n%s", mermaid)
+	}
+	if strings.Contains(mermaid, "/sap/") && !strings.Contains(mermaid, "_sap_") {
+		t.Errorf("Mermaid IDs must be sanitized:\n%s", mermaid)
+	}
+
+	if _, err := Export(g, "png"); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
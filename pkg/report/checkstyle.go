@@ -0,0 +1,61 @@
+package report
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+type checkstyleDoc struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Column   int    `xml:"column,attr,omitempty"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr,omitempty"`
+}
+
+// WriteCheckstyle writes the findings as Checkstyle XML, grouped by file.
+// Findings without a file are reported under the object name.
+func (r *Report) WriteCheckstyle(w io.Writer) error {
+	doc := checkstyleDoc{Version: "8.0"}
+	index := make(map[string]int)
+	for _, f := range r.Findings {
+		name := r.file(f.File)
+		if name == "" {
+			name = f.Object
+		}
+		i, ok := index[name]
+		if !ok {
+			i = len(doc.Files)
+			index[name] = i
+			doc.Files = append(doc.Files, checkstyleFile{Name: name})
+		}
+
+		source := f.Rule
+		if f.Tool != "" && source != "" {
+			source = f.Tool + "." + source
+		}
+		severity := f.Severity
+		if severity == "" {
+			severity = SeverityWarning
+		}
+		doc.Files[i].Errors = append(doc.Files[i].Errors, checkstyleError{
+			Line:     f.Line,
+			Column:   f.Column,
+			Severity: severity,
+			Message:  f.Message,
+			Source:   source,
+		})
+	}
+	return writeXML(w, doc)
+}
@@ -0,0 +1,129 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+type coberturaCoverage struct {
+	XMLName         xml.Name           `xml:"coverage"`
+	LineRate        string             `xml:"line-rate,attr"`
+	BranchRate      string             `xml:"branch-rate,attr"`
+	LinesCovered    int                `xml:"lines-covered,attr"`
+	LinesValid      int                `xml:"lines-valid,attr"`
+	BranchesCovered int                `xml:"branches-covered,attr"`
+	BranchesValid   int                `xml:"branches-valid,attr"`
+	Complexity      int                `xml:"complexity,attr"`
+	Version         string             `xml:"version,attr"`
+	Timestamp       string             `xml:"timestamp,attr"`
+	Sources         []string           `xml:"sources>source"`
+	Packages        []coberturaPackage `xml:"packages>package"`
+}
+
+type coberturaPackage struct {
+	Name       string           `xml:"name,attr"`
+	LineRate   string           `xml:"line-rate,attr"`
+	BranchRate string           `xml:"branch-rate,attr"`
+	Complexity int              `xml:"complexity,attr"`
+	Classes    []coberturaClass `xml:"classes>class"`
+}
+
+type coberturaClass struct {
+	Name       string            `xml:"name,attr"`
+	Filename   string            `xml:"filename,attr"`
+	LineRate   string            `xml:"line-rate,attr"`
+	BranchRate string            `xml:"branch-rate,attr"`
+	Complexity int               `xml:"complexity,attr"`
+	Methods    []coberturaMethod `xml:"methods>method"`
+	Lines      []coberturaLine   `xml:"lines>line"`
+}
+
+type coberturaMethod struct {
+	Name       string          `xml:"name,attr"`
+	Signature  string          `xml:"signature,attr"`
+	LineRate   string          `xml:"line-rate,attr"`
+	BranchRate string          `xml:"branch-rate,attr"`
+	Complexity int             `xml:"complexity,attr"`
+	Lines      []coberturaLine `xml:"lines>line"`
+}
+
+type coberturaLine struct {
+	Number int `xml:"number,attr"`
+	Hits   int `xml:"hits,attr"`
+}
+
+// WriteCobertura writes the coverage as Cobertura XML with one class per
+// source file. File names are relative to BaseDir, which becomes the source
+// directory.
+func (r *Report) WriteCobertura(w io.Writer) error {
+	source := r.BaseDir
+	if source == "" {
+		source = "."
+	}
+	doc := coberturaCoverage{
+		Version:   "vsp",
+		Timestamp: strconv.FormatInt(time.Now().UnixMilli(), 10),
+		Sources:   []string{source},
+	}
+	pkg := coberturaPackage{Name: r.Name}
+
+	var lines, branches adt.CoverageCounter
+	for _, f := range r.Coverage {
+		covered, total := f.LinesCovered()
+		class := coberturaClass{
+			Name:       f.Object,
+			Filename:   f.File,
+			LineRate:   rate(covered, total),
+			BranchRate: rate(f.Branches.Executed, f.Branches.Total),
+			Methods:    []coberturaMethod{},
+			Lines:      []coberturaLine{},
+		}
+		for _, fn := range f.Functions {
+			m := coberturaMethod{
+				Name:       fn.Name,
+				LineRate:   rate(fn.Statements.Executed, fn.Statements.Total),
+				BranchRate: rate(fn.Branches.Executed, fn.Branches.Total),
+				Lines:      []coberturaLine{},
+			}
+			if fn.Line > 0 {
+				hits := 0
+				if fn.Statements.Executed > 0 {
+					hits = 1
+				}
+				m.Lines = append(m.Lines, coberturaLine{Number: fn.Line, Hits: hits})
+			}
+			class.Methods = append(class.Methods, m)
+		}
+		for _, l := range f.Lines {
+			class.Lines = append(class.Lines, coberturaLine{Number: l.Line, Hits: l.Hits})
+		}
+		pkg.Classes = append(pkg.Classes, class)
+
+		lines.Executed += covered
+		lines.Total += total
+		branches.Executed += f.Branches.Executed
+		branches.Total += f.Branches.Total
+	}
+
+	pkg.LineRate = rate(lines.Executed, lines.Total)
+	pkg.BranchRate = rate(branches.Executed, branches.Total)
+	doc.Packages = []coberturaPackage{pkg}
+	doc.LineRate, doc.BranchRate = pkg.LineRate, pkg.BranchRate
+	doc.LinesCovered, doc.LinesValid = lines.Executed, lines.Total
+	doc.BranchesCovered, doc.BranchesValid = branches.Executed, branches.Total
+
+	return writeXML(w, doc)
+}
+
+// rate returns covered/total as a Cobertura rate (1 if there is nothing to cover).
+func rate(covered, total int) string {
+	if total == 0 {
+		return "1"
+	}
+	return fmt.Sprintf("%.4g", float64(covered)/float64(total))
+}
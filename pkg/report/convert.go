@@ -0,0 +1,200 @@
+package report
+
+import (
+	"strings"
+	"time"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+	"github.com/oisee/vibing-steampunk/pkg/dsl"
+)
+
+// AddUnitTestResult adds the test methods of an ABAP Unit run on objectURL.
+// Each method becomes a test case; its first alert is the failure.
+func (r *Report) AddUnitTestResult(objectURL string, result *adt.UnitTestResult) *Report {
+	if result == nil {
+		return r
+	}
+	suite := ObjectName(objectURL)
+	for _, class := range result.Classes {
+		// The navigation URI points at the include that holds the test class
+		file := FilePath(class.NavigationURI)
+		if file == "" {
+			file = testFile(objectURL)
+		}
+		className := class.Name
+		if suite != "" {
+			className = suite + "." + class.Name
+		}
+
+		if len(class.TestMethods) == 0 && len(class.Alerts) > 0 {
+			// Class-level alert without methods, e.g. setup failed
+			c := TestCase{Suite: suite, ClassName: className, Name: class.Name, File: file}
+			setAlert(&c, class.Alerts[0])
+			r.Tests = append(r.Tests, c)
+			continue
+		}
+		for _, m := range class.TestMethods {
+			c := TestCase{
+				Suite:     suite,
+				ClassName: className,
+				Name:      m.Name,
+				File:      file,
+				Time:      time.Duration(m.ExecutionTime * float64(time.Second)),
+			}
+			alerts := m.Alerts
+			if len(alerts) == 0 {
+				alerts = class.Alerts
+			}
+			if len(alerts) > 0 {
+				setAlert(&c, alerts[0])
+			}
+			r.Tests = append(r.Tests, c)
+		}
+	}
+	return r
+}
+
+// setAlert records a unit test alert as the failure of a test case.
+func setAlert(c *TestCase, alert adt.UnitTestAlert) {
+	c.Failure = alert.Title
+	if c.Failure == "" {
+		c.Failure = alert.Kind
+	}
+	c.Error = alert.Kind == "exception"
+	details := append([]string(nil), alert.Details...)
+	for _, entry := range alert.Stack {
+		details = append(details, "at "+entry.Description)
+	}
+	c.Details = strings.Join(details, "\n")
+}
+
+// testFile returns the file holding the tests of the object at uri.
+func testFile(uri string) string {
+	file := FilePath(uri)
+	if strings.HasSuffix(file, ".clas.abap") {
+		return strings.TrimSuffix(file, ".clas.abap") + classIncludeFiles["testclasses"]
+	}
+	return file
+}
+
+// AddTestSummary adds the results of a workflow or pipeline test run, and
+// their coverage if it was measured. Objects that could not be tested become
+// a single errored test case.
+func (r *Report) AddTestSummary(summary *dsl.TestSummary) *Report {
+	if summary == nil {
+		return r
+	}
+	for _, res := range summary.Results {
+		r.AddCoverage(res.Coverage)
+		obj := res.Object
+		file := TestFile(obj.Type, obj.Name)
+		if file == "" && obj.URL != "" {
+			file = testFile(obj.URL)
+		}
+		if res.Error != "" {
+			r.Tests = append(r.Tests, TestCase{
+				Suite:     obj.Name,
+				ClassName: obj.Name,
+				Name:      obj.Name,
+				File:      file,
+				Time:      res.ExecutionTime,
+				Failure:   res.Error,
+				Error:     true,
+			})
+			continue
+		}
+		for _, class := range res.Classes {
+			for _, m := range class.Methods {
+				c := TestCase{
+					Suite:     obj.Name,
+					ClassName: obj.Name + "." + class.Name,
+					Name:      m.Name,
+					File:      file,
+					Time:      m.ExecutionTime,
+				}
+				if !m.Success {
+					c.Failure = m.Message
+					if c.Failure == "" {
+						c.Failure = "test failed"
+					}
+				}
+				r.Tests = append(r.Tests, c)
+			}
+		}
+	}
+	return r
+}
+
+// AddATCWorklist adds the findings of an ATC worklist. Priority 1 findings
+// are errors, priority 2 warnings and the rest info.
+func (r *Report) AddATCWorklist(worklist *adt.ATCWorklist) *Report {
+	if worklist == nil {
+		return r
+	}
+	for _, obj := range worklist.Objects {
+		for _, f := range obj.Findings {
+			file := FilePath(f.Location)
+			if file == "" {
+				file = FilePath(obj.URI)
+			}
+			if file == "" {
+				file = ObjectFile(obj.Type, obj.Name)
+			}
+			line, column := f.Line, f.Column
+			if line == 0 {
+				line, column = Location(f.Location)
+			}
+			severity := SeverityInfo
+			switch f.Priority {
+			case 1:
+				severity = SeverityError
+			case 2:
+				severity = SeverityWarning
+			}
+			r.Findings = append(r.Findings, Finding{
+				Tool:      "ATC",
+				Object:    obj.Name,
+				File:      file,
+				Line:      line,
+				Column:    column,
+				Severity:  severity,
+				Rule:      f.CheckID,
+				RuleTitle: f.CheckTitle,
+				Message:   f.MessageTitle,
+			})
+		}
+	}
+	return r
+}
+
+// AddSyntaxCheck adds the messages of a syntax check of objectURL.
+func (r *Report) AddSyntaxCheck(objectURL string, results []adt.SyntaxCheckResult) *Report {
+	for _, res := range results {
+		uri := res.URI
+		if FilePath(uri) == "" {
+			uri = objectURL
+		}
+		severity := SeverityInfo
+		switch strings.ToUpper(res.Severity) {
+		case "E", "A", "X":
+			severity = SeverityError
+		case "W":
+			severity = SeverityWarning
+		}
+		column := 0
+		if res.Line > 0 {
+			column = res.Offset + 1
+		}
+		r.Findings = append(r.Findings, Finding{
+			Tool:     "syntax",
+			Object:   ObjectName(uri),
+			File:     FilePath(uri),
+			Line:     res.Line,
+			Column:   column,
+			Severity: severity,
+			Rule:     "syntax",
+			Message:  res.Text,
+		})
+	}
+	return r
+}
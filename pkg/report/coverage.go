@@ -0,0 +1,134 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+)
+
+// FileCoverage is the coverage of one source file.
+type FileCoverage struct {
+	File      string // Source file, e.g. zcl_foo.clas.abap
+	Object    string // Object name, e.g. ZCL_FOO
+	Functions []FunctionCoverage
+	Lines     []LineCoverage // Sorted by line
+	Branches  adt.CoverageCounter
+}
+
+// FunctionCoverage is the coverage of one method, form or function module.
+type FunctionCoverage struct {
+	Name       string
+	Line       int // First line; 0 if unknown
+	Statements adt.CoverageCounter
+	Branches   adt.CoverageCounter
+}
+
+// LineCoverage is the execution count of one source line.
+type LineCoverage struct {
+	Line int
+	Hits int
+}
+
+// LinesCovered returns the number of executed lines and the number of lines.
+func (f *FileCoverage) LinesCovered() (covered, total int) {
+	for _, l := range f.Lines {
+		if l.Hits > 0 {
+			covered++
+		}
+	}
+	return covered, len(f.Lines)
+}
+
+// AddCoverage adds the coverage of a unit test run. Lines are grouped by the
+// source include they belong to, so class methods end up in
+// zcl_foo.clas.abap and local class methods in zcl_foo.clas.locals_imp.abap.
+func (r *Report) AddCoverage(coverage *adt.CoverageResult) *Report {
+	if coverage == nil {
+		return r
+	}
+	for _, obj := range coverage.Objects {
+		files := make(map[string]*FileCoverage)
+		var order []string
+		get := func(uri string) *FileCoverage {
+			name := FilePath(uri)
+			if name == "" {
+				name = FilePath(obj.URI)
+			}
+			if name == "" {
+				name = ObjectFile(obj.Type, obj.Name)
+			}
+			f, ok := files[name]
+			if !ok {
+				f = &FileCoverage{File: name, Object: obj.Name}
+				files[name] = f
+				order = append(order, name)
+			}
+			return f
+		}
+
+		for _, m := range obj.Methods {
+			f := get(m.URI)
+			line, _ := Location(m.URI)
+			f.Functions = append(f.Functions, FunctionCoverage{
+				Name:       m.Name,
+				Line:       line,
+				Statements: m.Statements,
+				Branches:   m.Branches,
+			})
+			f.Branches.Total += m.Branches.Total
+			f.Branches.Executed += m.Branches.Executed
+			for _, l := range m.Lines {
+				lf := get(l.URI)
+				lf.Lines = append(lf.Lines, LineCoverage{Line: l.Line, Hits: l.Hits})
+			}
+		}
+		if len(obj.Methods) == 0 {
+			f := get(obj.URI)
+			f.Branches = obj.Branches
+		}
+
+		for _, name := range order {
+			f := files[name]
+			sort.SliceStable(f.Lines, func(i, j int) bool { return f.Lines[i].Line < f.Lines[j].Line })
+			r.Coverage = append(r.Coverage, *f)
+		}
+	}
+	return r
+}
+
+// WriteLCOV writes the coverage in the LCOV tracefile format read by genhtml
+// and most coverage services.
+func (r *Report) WriteLCOV(w io.Writer) error {
+	var sb strings.Builder
+	for _, f := range r.Coverage {
+		fmt.Fprintf(&sb, "TN:%s\n", r.Name)
+		fmt.Fprintf(&sb, "SF:%s\n", r.file(f.File))
+		hit := 0
+		for _, fn := range f.Functions {
+			fmt.Fprintf(&sb, "FN:%d,%s\n", fn.Line, fn.Name)
+		}
+		for _, fn := range f.Functions {
+			calls := 0
+			if fn.Statements.Executed > 0 {
+				calls = 1
+				hit++
+			}
+			fmt.Fprintf(&sb, "FNDA:%d,%s\n", calls, fn.Name)
+		}
+		fmt.Fprintf(&sb, "FNF:%d\nFNH:%d\n", len(f.Functions), hit)
+		for _, l := range f.Lines {
+			fmt.Fprintf(&sb, "DA:%d,%d\n", l.Line, l.Hits)
+		}
+		if f.Branches.Total > 0 {
+			fmt.Fprintf(&sb, "BRF:%d\nBRH:%d\n", f.Branches.Total, f.Branches.Executed)
+		}
+		covered, total := f.LinesCovered()
+		fmt.Fprintf(&sb, "LF:%d\nLH:%d\n", total, covered)
+		sb.WriteString("end_of_record\n")
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,112 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr,omitempty"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the test results as JUnit XML, one test suite per object.
+func (r *Report) WriteJUnit(w io.Writer) error {
+	doc := junitTestSuites{Name: r.Name}
+	var total time.Duration
+	index := make(map[string]int)
+	for _, c := range r.Tests {
+		i, ok := index[c.Suite]
+		if !ok {
+			i = len(doc.Suites)
+			index[c.Suite] = i
+			doc.Suites = append(doc.Suites, junitTestSuite{Name: c.Suite})
+		}
+		suite := &doc.Suites[i]
+
+		tc := junitTestCase{ClassName: c.ClassName, Name: c.Name, File: r.file(c.File), Time: seconds(c.Time)}
+		suite.Tests++
+		switch {
+		case c.Skipped:
+			tc.Skipped = &struct{}{}
+			suite.Skipped++
+		case c.Failed() && c.Error:
+			tc.Error = &junitProblem{Message: c.Failure, Type: "exception", Text: c.Details}
+			suite.Errors++
+		case c.Failed():
+			tc.Failure = &junitProblem{Message: c.Failure, Type: "failedAssertion", Text: c.Details}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, tc)
+		total += c.Time
+	}
+
+	for i := range doc.Suites {
+		suite := &doc.Suites[i]
+		var d time.Duration
+		for _, c := range r.Tests {
+			if c.Suite == suite.Name {
+				d += c.Time
+			}
+		}
+		suite.Time = seconds(d)
+		doc.Tests += suite.Tests
+		doc.Failures += suite.Failures
+		doc.Errors += suite.Errors
+		doc.Skipped += suite.Skipped
+	}
+	doc.Time = seconds(total)
+
+	return writeXML(w, doc)
+}
+
+func writeXML(w io.Writer, doc interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,144 @@
+package report
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// adtLocation matches the #start=line[,column] fragment of ADT URIs.
+var adtLocation = regexp.MustCompile(`#start=(\d+)(?:,(\d+))?`)
+
+// classIncludeFiles maps ADT class include names to abapGit file suffixes.
+var classIncludeFiles = map[string]string{
+	"main":            ".clas.abap",
+	"testclasses":     ".clas.testclasses.abap",
+	"definitions":     ".clas.locals_def.abap",
+	"implementations": ".clas.locals_imp.abap",
+	"macros":          ".clas.macros.abap",
+}
+
+// objectFiles maps object types to the file suffixes written by ExportToFile.
+var objectFiles = map[string]string{
+	"CLAS": ".clas.abap",
+	"INTF": ".intf.abap",
+	"PROG": ".prog.abap",
+	"FUGR": ".fugr.abap",
+	"INCL": ".abap",
+	"DDLS": ".ddls.asddls",
+	"BDEF": ".bdef.asbdef",
+	"SRVD": ".srvd.srvdsrv",
+}
+
+// ObjectFile returns the abapGit file name of an object's main source, e.g.
+// "zcl_foo.clas.abap" for CLAS ZCL_FOO. Types may carry an ADT subtype
+// ("CLAS/OC"). It returns "" for unknown types.
+func ObjectFile(objectType, name string) string {
+	objectType, _, _ = strings.Cut(strings.ToUpper(objectType), "/")
+	suffix, ok := objectFiles[objectType]
+	if !ok || name == "" {
+		return ""
+	}
+	return fileName(name) + suffix
+}
+
+// TestFile returns the file that holds an object's unit tests: the test
+// classes include for classes, the main source otherwise.
+func TestFile(objectType, name string) string {
+	if t, _, _ := strings.Cut(strings.ToUpper(objectType), "/"); t == "CLAS" && name != "" {
+		return fileName(name) + classIncludeFiles["testclasses"]
+	}
+	return ObjectFile(objectType, name)
+}
+
+// FilePath returns the abapGit file name for an ADT source URI, e.g.
+// /sap/bc/adt/oo/classes/zcl_foo/includes/testclasses → zcl_foo.clas.testclasses.abap.
+// It returns "" for URIs of unknown objects.
+func FilePath(uri string) string {
+	uri, _, _ = strings.Cut(uri, "#")
+	uri, _, _ = strings.Cut(uri, "?")
+	parts := strings.Split(strings.Trim(uri, "/"), "/")
+	// sap/bc/adt/<area>/<collection>/<name>/...
+	if len(parts) < 6 || parts[0] != "sap" || parts[1] != "bc" || parts[2] != "adt" {
+		return ""
+	}
+	name, rest := unescape(parts[5]), parts[6:]
+
+	switch parts[3] + "/" + parts[4] {
+	case "oo/classes":
+		if len(rest) >= 2 && rest[0] == "includes" {
+			if suffix, ok := classIncludeFiles[rest[1]]; ok {
+				return fileName(name) + suffix
+			}
+		}
+		return fileName(name) + ".clas.abap"
+	case "oo/interfaces":
+		return fileName(name) + ".intf.abap"
+	case "programs/programs":
+		return fileName(name) + ".prog.abap"
+	case "programs/includes":
+		return fileName(name) + ".abap"
+	case "functions/groups":
+		if len(rest) >= 2 && rest[0] == "fmodules" {
+			return fileName(name) + ".fugr." + fileName(unescape(rest[1])) + ".func.abap"
+		}
+		return fileName(name) + ".fugr.abap"
+	case "ddic/ddl", "ddic/srvd":
+		// /sap/bc/adt/ddic/ddl/sources/<name>
+		if name != "sources" || len(rest) == 0 {
+			return ""
+		}
+		if parts[4] == "ddl" {
+			return fileName(unescape(rest[0])) + ".ddls.asddls"
+		}
+		return fileName(unescape(rest[0])) + ".srvd.srvdsrv"
+	case "bo/behaviordefinitions":
+		return fileName(name) + ".bdef.asbdef"
+	}
+	return ""
+}
+
+// Location returns the line and column (both 1-based, 0 if absent) of the
+// #start=line,column fragment of an ADT URI. ADT columns are 0-based.
+func Location(uri string) (line, column int) {
+	m := adtLocation.FindStringSubmatch(uri)
+	if m == nil {
+		return 0, 0
+	}
+	line, _ = strconv.Atoi(m[1])
+	if m[2] != "" {
+		column, _ = strconv.Atoi(m[2])
+		column++
+	}
+	return line, column
+}
+
+// fileName converts an object name to the abapGit file name convention:
+// lower case, with namespace slashes replaced by #.
+func fileName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "/", "#")
+}
+
+// unescape decodes a URI path segment, e.g. %2fui5%2fcl_foo.
+func unescape(s string) string {
+	if u, err := url.PathUnescape(s); err == nil {
+		return u
+	}
+	return s
+}
+
+// ObjectName returns the upper-case object name of an ADT URI, e.g. ZCL_FOO
+// for /sap/bc/adt/oo/classes/zcl_foo/source/main, or "" if there is none.
+func ObjectName(uri string) string {
+	uri, _, _ = strings.Cut(uri, "#")
+	uri, _, _ = strings.Cut(uri, "?")
+	parts := strings.Split(strings.Trim(uri, "/"), "/")
+	if len(parts) < 6 || parts[0] != "sap" || parts[1] != "bc" || parts[2] != "adt" {
+		return ""
+	}
+	if parts[5] == "sources" && len(parts) > 6 {
+		return strings.ToUpper(unescape(parts[6]))
+	}
+	return strings.ToUpper(unescape(parts[5]))
+}
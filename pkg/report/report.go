@@ -0,0 +1,124 @@
+// Package report renders unit test results and check findings in formats
+// that CI systems understand: JUnit XML and TAP for tests, SARIF 2.1 and
+// Checkstyle XML for ATC and syntax check findings, LCOV and Cobertura XML
+// for coverage.
+//
+// File paths follow the abapGit layout used by ExportToFile, e.g.
+// zcl_foo.clas.abap or zcl_foo.clas.testclasses.abap, so findings line up
+// with exported sources.
+package report
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// Output formats.
+const (
+	FormatJSON       = "json"       // Tool-specific JSON (not written by this package)
+	FormatJUnit      = "junit"      // JUnit XML, for test results
+	FormatTAP        = "tap"        // Test Anything Protocol, for test results
+	FormatSARIF      = "sarif"      // SARIF 2.1.0, for findings
+	FormatCheckstyle = "checkstyle" // Checkstyle XML, for findings
+	FormatLCOV       = "lcov"       // LCOV tracefile, for coverage
+	FormatCobertura  = "cobertura"  // Cobertura XML, for coverage
+)
+
+// Finding severities.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// TestCase is the result of one test method.
+type TestCase struct {
+	Suite     string        // Object under test, e.g. ZCL_FOO
+	ClassName string        // Test class, e.g. ZCL_FOO.LTCL_TEST
+	Name      string        // Test method
+	File      string        // Source file of the test class
+	Time      time.Duration // Execution time
+	Failure   string        // Failure message; empty if the test passed
+	Details   string        // Failure details, e.g. stack or assertion values
+	Error     bool          // Failed with an exception rather than an assertion
+	Skipped   bool
+}
+
+// Failed reports whether the test failed or errored.
+func (c TestCase) Failed() bool {
+	return c.Failure != ""
+}
+
+// Finding is a check finding at a source location.
+type Finding struct {
+	Tool      string // Check that reported it, e.g. "ATC" or "syntax"
+	Object    string // Object name, e.g. ZCL_FOO
+	File      string // Source file
+	Line      int    // 1-based; 0 if unknown
+	Column    int    // 1-based; 0 if unknown
+	Severity  string // error, warning or info
+	Rule      string // Check or message id
+	RuleTitle string // Human-readable rule name
+	Message   string
+}
+
+// Report collects test results, findings and coverage for one output file.
+type Report struct {
+	Name     string // Name of the test run, e.g. the package
+	BaseDir  string // Prefix for file paths, e.g. "src" when sources are exported there
+	Tests    []TestCase
+	Findings []Finding
+	Coverage []FileCoverage
+}
+
+// New returns an empty report.
+func New(name string) *Report {
+	return &Report{Name: name}
+}
+
+// WithBaseDir sets the directory prefix of the file paths in the report.
+func (r *Report) WithBaseDir(dir string) *Report {
+	r.BaseDir = dir
+	return r
+}
+
+// file returns the report path of a source file.
+func (r *Report) file(name string) string {
+	if r.BaseDir == "" || name == "" {
+		return name
+	}
+	return path.Join(strings.ReplaceAll(r.BaseDir, "\\", "/"), name)
+}
+
+// Write renders the report in format: junit or tap for test results, sarif
+// or checkstyle for findings, lcov or cobertura for coverage.
+func (r *Report) Write(w io.Writer, format string) error {
+	switch strings.ToLower(format) {
+	case FormatJUnit:
+		return r.WriteJUnit(w)
+	case FormatTAP:
+		return r.WriteTAP(w)
+	case FormatSARIF:
+		return r.WriteSARIF(w)
+	case FormatCheckstyle:
+		return r.WriteCheckstyle(w)
+	case FormatLCOV:
+		return r.WriteLCOV(w)
+	case FormatCobertura:
+		return r.WriteCobertura(w)
+	default:
+		return fmt.Errorf("unknown report format %q (use junit, tap, sarif, checkstyle, lcov or cobertura)", format)
+	}
+}
+
+// Render returns the report rendered in format, like Write.
+func (r *Report) Render(format string) (string, error) {
+	var sb strings.Builder
+	if err := r.Write(&sb, format); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,260 @@
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oisee/vibing-steampunk/pkg/adt"
+	"github.com/oisee/vibing-steampunk/pkg/dsl"
+)
+
+func TestFilePath(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want string
+	}{
+		{"/sap/bc/adt/oo/classes/zcl_foo/source/main", "zcl_foo.clas.abap"},
+		{"/sap/bc/adt/oo/classes/ZCL_FOO/includes/testclasses#start=12,4", "zcl_foo.clas.testclasses.abap"},
+		{"/sap/bc/adt/oo/classes/zcl_foo/includes/implementations", "zcl_foo.clas.locals_imp.abap"},
+		{"/sap/bc/adt/oo/interfaces/zif_foo/source/main", "zif_foo.intf.abap"},
+		{"/sap/bc/adt/programs/programs/ztest/source/main", "ztest.prog.abap"},
+		{"/sap/bc/adt/programs/includes/ztest_top", "ztest_top.abap"},
+		{"/sap/bc/adt/functions/groups/zfg/fmodules/z_do_it/source/main", "zfg.fugr.z_do_it.func.abap"},
+		{"/sap/bc/adt/ddic/ddl/sources/zi_travel/source/main", "zi_travel.ddls.asddls"},
+		{"/sap/bc/adt/oo/classes/%2fui5%2fcl_foo/source/main", "#ui5#cl_foo.clas.abap"},
+		{"/sap/bc/adt/unknown/things/x", ""},
+		{"ZCL_FOO", ""},
+	}
+	for _, tt := range tests {
+		if got := FilePath(tt.uri); got != tt.want {
+			t.Errorf("FilePath(%q) = %q, want %q", tt.uri, got, tt.want)
+		}
+	}
+
+	if got := TestFile("CLAS/OC", "ZCL_FOO"); got != "zcl_foo.clas.testclasses.abap" {
+		t.Errorf("TestFile = %q", got)
+	}
+	if got := ObjectFile("PROG", "ZTEST"); got != "ztest.prog.abap" {
+		t.Errorf("ObjectFile = %q", got)
+	}
+	if line, col := Location("/sap/bc/adt/oo/classes/zcl_foo/source/main#start=7,0"); line != 7 || col != 1 {
+		t.Errorf("Location = %d,%d, want 7,1", line, col)
+	}
+}
+
+func unitTestResult() *adt.UnitTestResult {
+	return &adt.UnitTestResult{Classes: []adt.UnitTestClass{{
+		URI:           "/sap/bc/adt/oo/classes/zcl_foo",
+		Name:          "LTCL_TEST",
+		NavigationURI: "/sap/bc/adt/oo/classes/zcl_foo/includes/testclasses#start=3,1",
+		TestMethods: []adt.UnitTestMethod{
+			{Name: "OK", ExecutionTime: 0.25},
+			{Name: "FAILS", ExecutionTime: 0.5, Alerts: []adt.UnitTestAlert{{
+				Kind:    "failedAssertion",
+				Title:   "Expected <1> but was <2>",
+				Details: []string{"Test 'FAILS' failed"},
+				Stack:   []adt.UnitTestStackEntry{{Description: "LTCL_TEST->FAILS line 12"}},
+			}}},
+			{Name: "DUMPS", Alerts: []adt.UnitTestAlert{{Kind: "exception", Title: "CX_SY_ZERODIVIDE"}}},
+		},
+	}}}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	r := New("ZPKG").AddUnitTestResult("/sap/bc/adt/oo/classes/ZCL_FOO", unitTestResult())
+	out, err := r.Render(FormatJUnit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc junitTestSuites
+	if err := xml.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, out)
+	}
+	if doc.Tests != 3 || doc.Failures != 1 || doc.Errors != 1 || doc.Time != "0.750" {
+		t.Errorf("totals = %+v", doc)
+	}
+	if len(doc.Suites) != 1 || doc.Suites[0].Name != "ZCL_FOO" {
+		t.Fatalf("suites = %+v", doc.Suites)
+	}
+	cases := doc.Suites[0].Cases
+	if cases[0].ClassName != "ZCL_FOO.LTCL_TEST" || cases[0].File != "zcl_foo.clas.testclasses.abap" {
+		t.Errorf("case = %+v", cases[0])
+	}
+	if cases[1].Failure == nil || cases[1].Failure.Message != "Expected <1> but was <2>" ||
+		!strings.Contains(cases[1].Failure.Text, "at LTCL_TEST->FAILS line 12") {
+		t.Errorf("failure = %+v", cases[1].Failure)
+	}
+	if cases[2].Error == nil || cases[2].Failure != nil {
+		t.Errorf("error case = %+v", cases[2])
+	}
+}
+
+func TestWriteTAP(t *testing.T) {
+	summary := &dsl.TestSummary{Results: []dsl.TestResult{
+		{
+			Object: dsl.ObjectRef{Type: "CLAS", Name: "ZCL_FOO"},
+			Classes: []dsl.TestClassResult{{Name: "LTCL_TEST", Methods: []dsl.TestMethodResult{
+				{Name: "OK", Success: true, ExecutionTime: time.Second},
+				{Name: "FAILS", Message: "expected \"a\""},
+			}}},
+		},
+		{Object: dsl.ObjectRef{Type: "PROG", Name: "ZTEST"}, Error: "no tests"},
+	}}
+	out, err := New("run").WithBaseDir("src").AddTestSummary(summary).Render(FormatTAP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"TAP version 13\n1..3\n",
+		"ok 1 - ZCL_FOO.LTCL_TEST.OK\n",
+		"not ok 2 - ZCL_FOO.LTCL_TEST.FAILS\n  ---\n  message: \"expected \\\"a\\\"\"\n  severity: fail\n  file: \"src/zcl_foo.clas.testclasses.abap\"\n  ...\n",
+		"not ok 3 - ZTEST.ZTEST\n",
+		"severity: error\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("TAP output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func atcWorklist() *adt.ATCWorklist {
+	return &adt.ATCWorklist{Objects: []adt.ATCObject{{
+		URI:  "/sap/bc/adt/oo/classes/zcl_foo",
+		Type: "CLAS/OC",
+		Name: "ZCL_FOO",
+		Findings: []adt.ATCFinding{
+			{Location: "/sap/bc/adt/oo/classes/zcl_foo/source/main#start=10,2", Priority: 1, CheckID: "CL_CI_TEST_SELECT", CheckTitle: "Select checks", MessageTitle: "SELECT * used"},
+			{Location: "/sap/bc/adt/oo/classes/zcl_foo/includes/testclasses#start=4,0", Priority: 3, CheckID: "CL_CI_TEST_SELECT", MessageTitle: "Hint"},
+		},
+	}}}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	r := New("ZPKG").AddATCWorklist(atcWorklist()).
+		AddSyntaxCheck("/sap/bc/adt/programs/programs/ZTEST", []adt.SyntaxCheckResult{
+			{URI: "/sap/bc/adt/programs/programs/ztest/source/main", Line: 5, Offset: 3, Severity: "E", Text: "Unknown field"},
+		})
+	out, err := r.Render(FormatSARIF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc sarifLog
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if doc.Version != "2.1.0" || len(doc.Runs) != 2 {
+		t.Fatalf("doc = %+v", doc)
+	}
+	atc := doc.Runs[0]
+	if atc.Tool.Driver.Name != "ATC" || len(atc.Tool.Driver.Rules) != 1 || len(atc.Results) != 2 {
+		t.Fatalf("ATC run = %+v", atc)
+	}
+	first := atc.Results[0]
+	loc := first.Locations[0].PhysicalLocation
+	if first.Level != "error" || *first.RuleIndex != 0 || loc.ArtifactLocation.URI != "zcl_foo.clas.abap" ||
+		loc.Region.StartLine != 10 || loc.Region.StartColumn != 3 {
+		t.Errorf("first result = %+v %+v", first, loc)
+	}
+	if atc.Results[1].Level != "note" || atc.Results[1].Locations[0].PhysicalLocation.ArtifactLocation.URI != "zcl_foo.clas.testclasses.abap" {
+		t.Errorf("second result = %+v", atc.Results[1])
+	}
+	syntax := doc.Runs[1].Results[0]
+	if syntax.Level != "error" || syntax.Locations[0].PhysicalLocation.Region.StartColumn != 4 {
+		t.Errorf("syntax result = %+v", syntax)
+	}
+}
+
+func TestWriteCheckstyle(t *testing.T) {
+	out, err := New("ZPKG").AddATCWorklist(atcWorklist()).Render(FormatCheckstyle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc checkstyleDoc
+	if err := xml.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, out)
+	}
+	if len(doc.Files) != 2 || doc.Files[0].Name != "zcl_foo.clas.abap" {
+		t.Fatalf("files = %+v", doc.Files)
+	}
+	e := doc.Files[0].Errors[0]
+	if e.Line != 10 || e.Column != 3 || e.Severity != "error" || e.Source != "ATC.CL_CI_TEST_SELECT" {
+		t.Errorf("error = %+v", e)
+	}
+	if doc.Files[1].Errors[0].Severity != "info" {
+		t.Errorf("second severity = %q", doc.Files[1].Errors[0].Severity)
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	if _, err := New("x").Render("html"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func coverageResult() *adt.CoverageResult {
+	main := "/sap/bc/adt/oo/classes/zcl_foo/source/main"
+	imp := "/sap/bc/adt/oo/classes/zcl_foo/includes/implementations"
+	return &adt.CoverageResult{Objects: []adt.CoverageObject{{
+		URI:  "/sap/bc/adt/oo/classes/zcl_foo",
+		Type: "CLAS/OC",
+		Name: "ZCL_FOO",
+		Methods: []adt.CoverageMethod{
+			{
+				Name:       "CALCULATE",
+				URI:        main + "#start=10,2",
+				Statements: adt.CoverageCounter{Total: 3, Executed: 2},
+				Branches:   adt.CoverageCounter{Total: 2, Executed: 1},
+				Lines:      []adt.CoverageLine{{URI: main, Line: 12, Hits: 3}, {URI: main, Line: 11, Hits: 1}, {URI: main, Line: 14}},
+			},
+			{
+				Name:       "LCL_HELPER=>RUN",
+				URI:        imp + "#start=5,2",
+				Statements: adt.CoverageCounter{Total: 1},
+				Lines:      []adt.CoverageLine{{URI: imp, Line: 6}},
+			},
+		},
+	}}}
+}
+
+func TestWriteLCOV(t *testing.T) {
+	r := New("ZPKG").WithBaseDir("src").AddCoverage(coverageResult())
+	if len(r.Coverage) != 2 || r.Coverage[1].File != "zcl_foo.clas.locals_imp.abap" {
+		t.Fatalf("coverage = %+v", r.Coverage)
+	}
+	out, err := r.Render(FormatLCOV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "TN:ZPKG\nSF:src/zcl_foo.clas.abap\nFN:10,CALCULATE\nFNDA:1,CALCULATE\nFNF:1\nFNH:1\n" +
+		"DA:11,1\nDA:12,3\nDA:14,0\nBRF:2\nBRH:1\nLF:3\nLH:2\nend_of_record\n" +
+		"TN:ZPKG\nSF:src/zcl_foo.clas.locals_imp.abap\nFN:5,LCL_HELPER=>RUN\nFNDA:0,LCL_HELPER=>RUN\nFNF:1\nFNH:0\n" +
+		"DA:6,0\nLF:1\nLH:0\nend_of_record\n"
+	if out != want {
+		t.Errorf("LCOV =\n%s\nwant\n%s", out, want)
+	}
+}
+
+func TestWriteCobertura(t *testing.T) {
+	out, err := New("ZPKG").WithBaseDir("src").AddCoverage(coverageResult()).Render(FormatCobertura)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc coberturaCoverage
+	if err := xml.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, out)
+	}
+	if doc.LinesCovered != 2 || doc.LinesValid != 4 || doc.LineRate != "0.5" || doc.BranchRate != "0.5" ||
+		len(doc.Sources) != 1 || doc.Sources[0] != "src" {
+		t.Errorf("totals = %+v", doc)
+	}
+	classes := doc.Packages[0].Classes
+	if len(classes) != 2 || classes[0].Filename != "zcl_foo.clas.abap" || classes[0].LineRate != "0.6667" ||
+		len(classes[0].Lines) != 3 || classes[0].Methods[0].Name != "CALCULATE" {
+		t.Errorf("classes = %+v", classes)
+	}
+}
@@ -0,0 +1,130 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// SARIF 2.1.0 document structure (the subset written by WriteSARIF).
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []sarifRule `json:"rules,omitempty"`
+}
+
+type sarifRule struct {
+	ID               string        `json:"id"`
+	Name             string        `json:"name,omitempty"`
+	ShortDescription *sarifMessage `json:"shortDescription,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId,omitempty"`
+	RuleIndex *int            `json:"ruleIndex,omitempty"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           *sarifRegion  `json:"region,omitempty"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// sarifLevels maps finding severities to SARIF result levels.
+var sarifLevels = map[string]string{
+	SeverityError:   "error",
+	SeverityWarning: "warning",
+	SeverityInfo:    "note",
+}
+
+// WriteSARIF writes the findings as a SARIF 2.1.0 log with one run per tool.
+func (r *Report) WriteSARIF(w io.Writer) error {
+	doc := sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarifRun{},
+	}
+
+	runs := make(map[string]int)
+	rules := make(map[string]map[string]int)
+	for _, f := range r.Findings {
+		tool := f.Tool
+		if tool == "" {
+			tool = "vsp"
+		}
+		i, ok := runs[tool]
+		if !ok {
+			i = len(doc.Runs)
+			runs[tool] = i
+			rules[tool] = make(map[string]int)
+			doc.Runs = append(doc.Runs, sarifRun{
+				Tool:    sarifTool{Driver: sarifDriver{Name: tool, InformationURI: "https://github.com/oisee/vibing-steampunk"}},
+				Results: []sarifResult{},
+			})
+		}
+		run := &doc.Runs[i]
+
+		result := sarifResult{RuleID: f.Rule, Level: sarifLevels[f.Severity], Message: sarifMessage{Text: f.Message}}
+		if result.Level == "" {
+			result.Level = "warning"
+		}
+		if f.Rule != "" {
+			k, ok := rules[tool][f.Rule]
+			if !ok {
+				k = len(run.Tool.Driver.Rules)
+				rules[tool][f.Rule] = k
+				rule := sarifRule{ID: f.Rule, Name: f.RuleTitle}
+				if f.RuleTitle != "" {
+					rule.ShortDescription = &sarifMessage{Text: f.RuleTitle}
+				}
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+			}
+			result.RuleIndex = &k
+		}
+		if file := r.file(f.File); file != "" {
+			loc := sarifLocation{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifact{URI: file}}}
+			if f.Line > 0 {
+				loc.PhysicalLocation.Region = &sarifRegion{StartLine: f.Line, StartColumn: f.Column}
+			}
+			result.Locations = []sarifLocation{loc}
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
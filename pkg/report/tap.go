@@ -0,0 +1,52 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteTAP writes the test results in TAP version 13. Failures carry a YAML
+// diagnostic block with the message, details and file.
+func (r *Report) WriteTAP(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("TAP version 13\n")
+	fmt.Fprintf(&sb, "1..%d\n", len(r.Tests))
+	for i, c := range r.Tests {
+		name := c.Name
+		if c.ClassName != "" {
+			name = c.ClassName + "." + c.Name
+		}
+		switch {
+		case c.Skipped:
+			fmt.Fprintf(&sb, "ok %d - %s # SKIP\n", i+1, name)
+		case c.Failed():
+			fmt.Fprintf(&sb, "not ok %d - %s\n", i+1, name)
+			sb.WriteString("  ---\n")
+			fmt.Fprintf(&sb, "  message: %s\n", yamlString(c.Failure))
+			severity := "fail"
+			if c.Error {
+				severity = "error"
+			}
+			fmt.Fprintf(&sb, "  severity: %s\n", severity)
+			if c.Details != "" {
+				fmt.Fprintf(&sb, "  details: %s\n", yamlString(c.Details))
+			}
+			if file := r.file(c.File); file != "" {
+				fmt.Fprintf(&sb, "  file: %s\n", yamlString(file))
+			}
+			sb.WriteString("  ...\n")
+		default:
+			fmt.Fprintf(&sb, "ok %d - %s\n", i+1, name)
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// yamlString quotes s as a YAML double-quoted scalar (JSON strings are valid YAML).
+func yamlString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
@@ -79,6 +79,46 @@ func (e *LuaEngine) ExecuteFile(path string) error {
 	return e.L.DoFile(path)
 }
 
+// Eval runs a Lua script string and returns its first return value as a Go
+// value (nil if the script returns nothing). Tables become maps or slices.
+func (e *LuaEngine) Eval(script string) (interface{}, error) {
+	fn, err := e.L.LoadString(script)
+	if err != nil {
+		return nil, err
+	}
+	return e.call(fn)
+}
+
+// EvalFile runs a Lua script file and returns its first return value like Eval.
+func (e *LuaEngine) EvalFile(path string) (interface{}, error) {
+	fn, err := e.L.LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return e.call(fn)
+}
+
+func (e *LuaEngine) call(fn *lua.LFunction) (interface{}, error) {
+	e.L.Push(fn)
+	if err := e.L.PCall(0, 1, nil); err != nil {
+		return nil, err
+	}
+	ret := e.L.Get(-1)
+	e.L.Pop(1)
+	return luaToGo(ret), nil
+}
+
+// SetGlobal sets a Lua global to a Go value. Maps, slices and structs
+// become tables.
+func (e *LuaEngine) SetGlobal(name string, value interface{}) {
+	e.L.SetGlobal(name, goToLua(e.L, value))
+}
+
+// Global returns the value of a Lua global as a Go value.
+func (e *LuaEngine) Global(name string) interface{} {
+	return luaToGo(e.L.GetGlobal(name))
+}
+
 // REPL runs an interactive Lua Read-Eval-Print Loop.
 func (e *LuaEngine) REPL() {
 	reader := bufio.NewReader(os.Stdin)
@@ -54,6 +54,38 @@ func TestExecuteSyntaxError(t *testing.T) {
 	}
 }
 
+func TestEvalAndGlobals(t *testing.T) {
+	engine := NewLuaEngine(nil)
+	defer engine.Close()
+
+	engine.SetGlobal("vars", map[string]interface{}{
+		"PACKAGE": "$ZTEST",
+		"objects": []interface{}{map[string]interface{}{"name": "ZCL_A"}, map[string]interface{}{"name": "ZCL_B"}},
+	})
+
+	result, err := engine.Eval(`
+		count = #vars.objects
+		return { package = vars.PACKAGE, count = count }
+	`)
+	if err != nil {
+		t.Fatalf("Eval failed: %v", err)
+	}
+	m, ok := result.(map[string]interface{})
+	if !ok || m["package"] != "$ZTEST" || m["count"] != int64(2) {
+		t.Errorf("unexpected result: %#v", result)
+	}
+	if got := engine.Global("count"); got != int64(2) {
+		t.Errorf("expected global count = 2, got %#v", got)
+	}
+
+	if result, err := engine.Eval("x = 1"); err != nil || result != nil {
+		t.Errorf("expected nil result without return, got %v (%v)", result, err)
+	}
+	if _, err := engine.Eval("error('boom')"); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("expected script error, got %v", err)
+	}
+}
+
 func TestSetContext(t *testing.T) {
 	engine := NewLuaEngine(nil)
 	defer engine.Close()